@@ -1,8 +1,12 @@
 package rest
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/app"
@@ -18,73 +22,670 @@ var (
 	validate *validator.Validate
 )
 
+type productPriceResponse struct {
+	Country  string `json:"country,omitempty"`
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+}
+
+type priceVersionResponse struct {
+	ID            string                 `json:"id"`
+	EffectiveFrom time.Time              `json:"effective_from"`
+	Price         string                 `json:"price"`
+	Currency      string                 `json:"currency"`
+	Prices        []productPriceResponse `json:"prices,omitempty"`
+}
+
 type getProductByIdResponse struct {
-	ID                 string  `json:"id"`
-	Name               string  `json:"name"`
-	SubscriptionPeriod uint    `json:"subscription_period"`
-	Price              float64 `json:"price"`
-	TaxPercentage      float64 `json:"tax_percentage"`
+	ID                 string                 `json:"id"`
+	Name               string                 `json:"name"`
+	SubscriptionPeriod uint                   `json:"subscription_period"`
+	TrialDays          uint                   `json:"trial_days,omitempty"`
+	MaxPauseDays       uint                   `json:"max_pause_days,omitempty"`
+	MaxPausesPerPeriod uint                   `json:"max_pauses_per_period,omitempty"`
+	Price              string                 `json:"price"`
+	Currency           string                 `json:"currency"`
+	Prices             []productPriceResponse `json:"prices,omitempty"`
+	PriceVersionID     string                 `json:"price_version_id,omitempty"`
+	PriceVersions      []priceVersionResponse `json:"price_versions,omitempty"`
+	TaxCategory        string                 `json:"tax_category"`
+	TaxInclusive       bool                   `json:"tax_inclusive"`
+	ArchivedAt         *time.Time             `json:"archived_at,omitempty"`
 }
 
 type getAllProductsResponse struct {
-	Products []getProductByIdResponse `json:"products"`
+	Products   []getProductByIdResponse `json:"products"`
+	TotalCount int64                    `json:"total_count"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+type productPriceRequest struct {
+	Country  string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	Price    string `json:"price" validate:"required"`
+	Currency string `json:"currency" validate:"required,iso4217"`
+}
+
+type productRequest struct {
+	Name               string                `json:"name" validate:"required"`
+	SubscriptionPeriod uint                  `json:"subscription_period" validate:"required"`
+	TrialDays          uint                  `json:"trial_days,omitempty"`
+	MaxPauseDays       uint                  `json:"max_pause_days,omitempty"`
+	MaxPausesPerPeriod uint                  `json:"max_pauses_per_period,omitempty"`
+	Price              string                `json:"price" validate:"required"`
+	Currency           string                `json:"currency" validate:"required,iso4217"`
+	Prices             []productPriceRequest `json:"prices,omitempty" validate:"dive"`
+	TaxCategory        string                `json:"tax_category" validate:"required"`
+	TaxInclusive       bool                  `json:"tax_inclusive"`
+}
+
+type priceVersionRequest struct {
+	EffectiveFrom string                `json:"effective_from,omitempty"`
+	Price         string                `json:"price" validate:"required"`
+	Currency      string                `json:"currency" validate:"required,iso4217"`
+	Prices        []productPriceRequest `json:"prices,omitempty" validate:"dive"`
 }
 
 type buySubscriptionRequest struct {
-	ProductID string `json:"product_id" validate:"required"`
-	EmailID   string `json:"email_id" validate:"email,required"`
+	ProductID    string `json:"product_id" validate:"required"`
+	EmailID      string `json:"email_id" validate:"email,required"`
+	Currency     string `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	Country      string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	PaymentToken string `json:"payment_token" validate:"required"`
 }
 
 type buySubscriptionResponse struct {
-	ID          string    `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	Email       string    `json:"email"`
-	ProductName string    `json:"product_name"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	Price       float64   `json:"price"`
-	Tax         float64   `json:"tax"`
-	Status      string    `json:"status"`
+	ID             string            `json:"id"`
+	Version        int64             `json:"version"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Email          string            `json:"email"`
+	Country        string            `json:"country,omitempty"`
+	ProductID      string            `json:"product_id"`
+	ProductName    string            `json:"product_name"`
+	StartDate      time.Time         `json:"start_date"`
+	EndDate        time.Time         `json:"end_date"`
+	Price          string            `json:"price"`
+	NetPrice       string            `json:"net_price"`
+	Tax            string            `json:"tax"`
+	TaxRate        float64           `json:"tax_rate"`
+	Currency       string            `json:"currency"`
+	PriceVersionID string            `json:"price_version_id,omitempty"`
+	Status         string            `json:"status"`
+	AutoRenew      bool              `json:"auto_renew"`
+	TrialEndDate   *time.Time        `json:"trial_end_date,omitempty"`
+	Payments       []paymentResponse `json:"payments,omitempty"`
+	PendingPayment *paymentResponse  `json:"pending_payment,omitempty"`
 }
 
 type getSubscriptionByIDResponse struct {
-	ID             string     `json:"id"`
-	CreatedAt      time.Time  `json:"created_at"`
-	Email          string     `json:"email"`
-	ProductName    string     `json:"product_name"`
-	StartDate      time.Time  `json:"start_date"`
-	EndDate        time.Time  `json:"end_date"`
-	Price          float64    `json:"price"`
-	Tax            float64    `json:"tax"`
-	Status         string     `json:"status"`
-	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
-	PauseStartDate *time.Time `json:"pause_start_date,omitempty"`
+	ID                string                   `json:"id"`
+	Version           int64                    `json:"version"`
+	CreatedAt         time.Time                `json:"created_at"`
+	Email             string                   `json:"email"`
+	Country           string                   `json:"country,omitempty"`
+	ProductID         string                   `json:"product_id"`
+	ProductName       string                   `json:"product_name"`
+	StartDate         time.Time                `json:"start_date"`
+	EndDate           time.Time                `json:"end_date"`
+	Price             string                   `json:"price"`
+	NetPrice          string                   `json:"net_price"`
+	Tax               string                   `json:"tax"`
+	TaxRate           float64                  `json:"tax_rate"`
+	Currency          string                   `json:"currency"`
+	PriceVersionID    string                   `json:"price_version_id,omitempty"`
+	Status            string                   `json:"status"`
+	UpdatedAt         *time.Time               `json:"updated_at,omitempty"`
+	PauseStartDate    *time.Time               `json:"pause_start_date,omitempty"`
+	ResumeDate        *time.Time               `json:"resume_date,omitempty"`
+	Pauses            []pauseResponse          `json:"pauses,omitempty"`
+	AutoRenew         bool                     `json:"auto_renew"`
+	TrialEndDate      *time.Time               `json:"trial_end_date,omitempty"`
+	Renewals          []renewalResponse        `json:"renewals,omitempty"`
+	CancelAtPeriodEnd bool                     `json:"cancel_at_period_end"`
+	PendingPlanChange *planChangeResponse      `json:"pending_plan_change,omitempty"`
+	PlanChanges       []planChangeResponse     `json:"plan_changes,omitempty"`
+	Payments          []paymentResponse        `json:"payments,omitempty"`
+	PendingPayment    *paymentResponse         `json:"pending_payment,omitempty"`
+	NextPaymentRetry  *time.Time               `json:"next_payment_retry,omitempty"`
+	PaymentAttempts   []paymentAttemptResponse `json:"payment_attempts,omitempty"`
 }
 
 type updateSubscriptionByIDResponse struct {
-	ID             string     `json:"id"`
-	CreatedAt      time.Time  `json:"created_at"`
-	Email          string     `json:"email"`
-	ProductName    string     `json:"product_name"`
-	StartDate      time.Time  `json:"start_date"`
-	EndDate        time.Time  `json:"end_date"`
-	Price          float64    `json:"price"`
-	Tax            float64    `json:"tax"`
-	Status         string     `json:"status"`
-	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
-	PauseStartDate *time.Time `json:"pause_start_date,omitempty"`
+	ID                string                   `json:"id"`
+	Version           int64                    `json:"version"`
+	CreatedAt         time.Time                `json:"created_at"`
+	Email             string                   `json:"email"`
+	Country           string                   `json:"country,omitempty"`
+	ProductID         string                   `json:"product_id"`
+	ProductName       string                   `json:"product_name"`
+	StartDate         time.Time                `json:"start_date"`
+	EndDate           time.Time                `json:"end_date"`
+	Price             string                   `json:"price"`
+	NetPrice          string                   `json:"net_price"`
+	Tax               string                   `json:"tax"`
+	TaxRate           float64                  `json:"tax_rate"`
+	Currency          string                   `json:"currency"`
+	PriceVersionID    string                   `json:"price_version_id,omitempty"`
+	Status            string                   `json:"status"`
+	UpdatedAt         *time.Time               `json:"updated_at,omitempty"`
+	PauseStartDate    *time.Time               `json:"pause_start_date,omitempty"`
+	ResumeDate        *time.Time               `json:"resume_date,omitempty"`
+	Pauses            []pauseResponse          `json:"pauses,omitempty"`
+	AutoRenew         bool                     `json:"auto_renew"`
+	TrialEndDate      *time.Time               `json:"trial_end_date,omitempty"`
+	Renewals          []renewalResponse        `json:"renewals,omitempty"`
+	CancelAtPeriodEnd bool                     `json:"cancel_at_period_end"`
+	PendingPlanChange *planChangeResponse      `json:"pending_plan_change,omitempty"`
+	PlanChanges       []planChangeResponse     `json:"plan_changes,omitempty"`
+	Payments          []paymentResponse        `json:"payments,omitempty"`
+	PendingPayment    *paymentResponse         `json:"pending_payment,omitempty"`
+	NextPaymentRetry  *time.Time               `json:"next_payment_retry,omitempty"`
+	PaymentAttempts   []paymentAttemptResponse `json:"payment_attempts,omitempty"`
+}
+
+type renewalResponse struct {
+	RenewedAt      time.Time `json:"renewed_at"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	Price          string    `json:"price"`
+	Currency       string    `json:"currency"`
+	PriceVersionID string    `json:"price_version_id,omitempty"`
+}
+
+type paymentResponse struct {
+	AuthorizationID string    `json:"authorization_id"`
+	Type            string    `json:"type"`
+	Reason          string    `json:"reason"`
+	Amount          string    `json:"amount"`
+	Currency        string    `json:"currency"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type paymentAttemptResponse struct {
+	PeriodStart time.Time `json:"period_start"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	Succeeded   bool      `json:"succeeded"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type pauseResponse struct {
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Actor     string     `json:"actor,omitempty"`
+}
+
+type planChangeResponse struct {
+	RequestedAt   time.Time `json:"requested_at"`
+	EffectiveAt   time.Time `json:"effective_at"`
+	FromProductID string    `json:"from_product_id"`
+	ToProductID   string    `json:"to_product_id"`
+	Price         string    `json:"price,omitempty"`
+	Proration     string    `json:"proration,omitempty"`
+	Currency      string    `json:"currency,omitempty"`
+}
+
+type changePlanRequest struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Timing    string `json:"timing,omitempty" validate:"omitempty,oneof=immediate period_end"`
+}
+
+type confirmPaymentRequest struct {
+	AuthorizationID string `json:"authorization_id" validate:"required"`
+	Status          string `json:"status" validate:"required,oneof=succeeded failed"`
+}
+
+type auditEventResponse struct {
+	ID             string    `json:"id"`
+	Action         string    `json:"action"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	NewStatus      string    `json:"new_status"`
+	Actor          string    `json:"actor"`
+	RequestID      string    `json:"request_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type getSubscriptionHistoryResponse struct {
+	Events []auditEventResponse `json:"events"`
+}
+
+type invoiceLineResponse struct {
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Net         string    `json:"net"`
+	Tax         string    `json:"tax"`
+	Gross       string    `json:"gross"`
+	TaxRate     float64   `json:"tax_rate"`
+}
+
+type invoiceResponse struct {
+	ID              string                `json:"id"`
+	Type            string                `json:"type"`
+	Number          string                `json:"number"`
+	SubscriptionID  string                `json:"subscription_id"`
+	Email           string                `json:"email"`
+	Country         string                `json:"country,omitempty"`
+	AuthorizationID string                `json:"authorization_id,omitempty"`
+	Reason          string                `json:"reason,omitempty"`
+	Lines           []invoiceLineResponse `json:"lines"`
+	Net             string                `json:"net"`
+	Tax             string                `json:"tax"`
+	Total           string                `json:"total"`
+	Currency        string                `json:"currency"`
+	IssuedAt        time.Time             `json:"issued_at"`
+}
+
+type getSubscriptionInvoicesResponse struct {
+	Invoices []invoiceResponse `json:"invoices"`
+}
+
+type listSubscriptionsResponse struct {
+	Subscriptions []getSubscriptionByIDResponse `json:"subscriptions"`
+	NextCursor    string                        `json:"next_cursor,omitempty"`
 }
 
 type errorRespose struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
+type duplicateSubscriptionResponse struct {
+	ErrorMessage   string `json:"errorMessage"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
 func createErrorResponse(c *gin.Context, code int, message string) {
 	c.IndentedJSON(code, &errorRespose{
 		ErrorMessage: message,
 	})
 }
 
+// createProductResponse creates product response from domain product, prices are the prices of the price version effective now
+func createProductResponse(product *domain.Product) *getProductByIdResponse {
+	version := product.PriceVersionAt(time.Now().UTC())
+	resp := &getProductByIdResponse{
+		ID:                 product.ID,
+		Name:               product.Name,
+		SubscriptionPeriod: product.SubscriptionPeriod,
+		TrialDays:          product.TrialDays,
+		MaxPauseDays:       product.MaxPauseDays,
+		MaxPausesPerPeriod: product.MaxPausesPerPeriod,
+		Price:              version.Price.String(),
+		Currency:           version.Price.Currency,
+		TaxCategory:        product.TaxCategory,
+		TaxInclusive:       product.TaxInclusive,
+		ArchivedAt:         product.ArchivedAt,
+		Prices:             createProductPricesResponse(version.Prices),
+		PriceVersionID:     version.ID,
+	}
+
+	for _, v := range product.PriceVersions {
+		resp.PriceVersions = append(resp.PriceVersions, priceVersionResponse{
+			ID:            v.ID,
+			EffectiveFrom: v.EffectiveFrom,
+			Price:         v.Price.String(),
+			Currency:      v.Price.Currency,
+			Prices:        createProductPricesResponse(v.Prices),
+		})
+	}
+	return resp
+}
+
+// createProductPricesResponse creates price list response from domain price list
+func createProductPricesResponse(prices []domain.ProductPrice) []productPriceResponse {
+	var resp []productPriceResponse
+	for _, v := range prices {
+		resp = append(resp, productPriceResponse{
+			Country:  v.Country,
+			Price:    v.Price.String(),
+			Currency: v.Price.Currency,
+		})
+	}
+	return resp
+}
+
+// createRenewalsResponse creates renewals response from domain renewals
+func createRenewalsResponse(renewals []domain.Renewal) []renewalResponse {
+	resp := []renewalResponse{}
+	for _, v := range renewals {
+		resp = append(resp, renewalResponse{
+			RenewedAt:      v.RenewedAt,
+			PeriodStart:    v.PeriodStart,
+			PeriodEnd:      v.PeriodEnd,
+			Price:          v.Price.String(),
+			Currency:       v.Price.Currency,
+			PriceVersionID: v.PriceVersionID,
+		})
+	}
+	return resp
+}
+
+// createPaymentsResponse creates payments response from domain payments
+func createPaymentsResponse(payments []domain.Payment) []paymentResponse {
+	var resp []paymentResponse
+	for _, v := range payments {
+		resp = append(resp, paymentResponse{
+			AuthorizationID: v.AuthorizationID,
+			Type:            string(v.Type),
+			Reason:          string(v.Reason),
+			Amount:          v.Amount.String(),
+			Currency:        v.Amount.Currency,
+			CreatedAt:       v.CreatedAt,
+		})
+	}
+	return resp
+}
+
+// createPendingPaymentResponse creates pending payment response from domain payment, returns nil if there is no pending payment
+func createPendingPaymentResponse(payment *domain.Payment) *paymentResponse {
+	if payment == nil {
+		return nil
+	}
+	return &createPaymentsResponse([]domain.Payment{*payment})[0]
+}
+
+// createPaymentAttemptsResponse creates payment attempts response from domain payment attempts
+func createPaymentAttemptsResponse(attempts []domain.PaymentAttempt) []paymentAttemptResponse {
+	var resp []paymentAttemptResponse
+	for _, v := range attempts {
+		resp = append(resp, paymentAttemptResponse(v))
+	}
+	return resp
+}
+
+// createPausesResponse creates pauses response from domain pauses
+func createPausesResponse(pauses []domain.Pause) []pauseResponse {
+	var resp []pauseResponse
+	for _, v := range pauses {
+		resp = append(resp, pauseResponse(v))
+	}
+	return resp
+}
+
+// pauseStartDate returns start date of the open pause of the subscription if any
+func pauseStartDate(subscription *domain.UserSubscription) *time.Time {
+	pause := subscription.CurrentPause()
+	if pause == nil {
+		return nil
+	}
+	return &pause.StartDate
+}
+
+// createPlanChangeResponse creates plan change response from domain plan change
+func createPlanChangeResponse(planChange domain.PlanChange) planChangeResponse {
+	resp := planChangeResponse{
+		RequestedAt:   planChange.RequestedAt,
+		EffectiveAt:   planChange.EffectiveAt,
+		FromProductID: planChange.FromProductID,
+		ToProductID:   planChange.ToProductID,
+	}
+
+	if planChange.Price.Currency != "" {
+		resp.Price = planChange.Price.String()
+		resp.Proration = planChange.Proration.String()
+		resp.Currency = planChange.Price.Currency
+	}
+	return resp
+}
+
+// createPendingPlanChangeResponse creates plan change response for the pending plan change if any
+func createPendingPlanChangeResponse(planChange *domain.PlanChange) *planChangeResponse {
+	if planChange == nil {
+		return nil
+	}
+
+	resp := createPlanChangeResponse(*planChange)
+	return &resp
+}
+
+// createPlanChangesResponse creates plan changes response from domain plan changes
+func createPlanChangesResponse(planChanges []domain.PlanChange) []planChangeResponse {
+	var resp []planChangeResponse
+	for _, v := range planChanges {
+		resp = append(resp, createPlanChangeResponse(v))
+	}
+	return resp
+}
+
+// createSubscriptionResponse creates get subscription response from domain subscription
+func createSubscriptionResponse(subscription *domain.UserSubscription) *getSubscriptionByIDResponse {
+	return &getSubscriptionByIDResponse{
+		ID:                subscription.ID,
+		Version:           subscription.Version,
+		CreatedAt:         subscription.CreatedAt,
+		Email:             subscription.Email,
+		Country:           subscription.Country,
+		ProductID:         subscription.ProductID,
+		ProductName:       subscription.ProductName,
+		StartDate:         subscription.StartDate,
+		EndDate:           subscription.EndDate,
+		Price:             subscription.Price.String(),
+		NetPrice:          subscription.NetPrice.String(),
+		Tax:               subscription.Tax.String(),
+		TaxRate:           subscription.TaxRate,
+		Currency:          subscription.Price.Currency,
+		PriceVersionID:    subscription.PriceVersionID,
+		Status:            string(subscription.Status),
+		UpdatedAt:         subscription.UpdatedAt,
+		PauseStartDate:    pauseStartDate(subscription),
+		ResumeDate:        subscription.ResumeDate,
+		Pauses:            createPausesResponse(subscription.Pauses),
+		AutoRenew:         subscription.AutoRenew,
+		TrialEndDate:      subscription.TrialEndDate,
+		Renewals:          createRenewalsResponse(subscription.Renewals),
+		CancelAtPeriodEnd: subscription.CancelAtPeriodEnd,
+		PendingPlanChange: createPendingPlanChangeResponse(subscription.PendingPlanChange),
+		PlanChanges:       createPlanChangesResponse(subscription.PlanChanges),
+		Payments:          createPaymentsResponse(subscription.Payments),
+		PendingPayment:    createPendingPaymentResponse(subscription.PendingPayment),
+		NextPaymentRetry:  subscription.NextPaymentRetry,
+		PaymentAttempts:   createPaymentAttemptsResponse(subscription.PaymentAttempts),
+	}
+}
+
+// createListSubscriptionsResponse creates list subscriptions response from domain subscription page
+func createListSubscriptionsResponse(page *domain.SubscriptionPage) *listSubscriptionsResponse {
+	res := &listSubscriptionsResponse{
+		Subscriptions: []getSubscriptionByIDResponse{},
+		NextCursor:    page.NextCursor,
+	}
+	for i := range page.Subscriptions {
+		res.Subscriptions = append(res.Subscriptions, *createSubscriptionResponse(&page.Subscriptions[i]))
+	}
+	return res
+}
+
+// createDomainProduct creates domain product from product request, returns error if a price is not valid
+func createDomainProduct(req *productRequest) (*domain.Product, error) {
+	price, err := domain.ParseMoney(req.Price, req.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price value: %w", err)
+	}
+
+	product := &domain.Product{
+		Name:               req.Name,
+		SubscriptionPeriod: req.SubscriptionPeriod,
+		TrialDays:          req.TrialDays,
+		MaxPauseDays:       req.MaxPauseDays,
+		MaxPausesPerPeriod: req.MaxPausesPerPeriod,
+		Price:              price,
+		TaxCategory:        req.TaxCategory,
+		TaxInclusive:       req.TaxInclusive,
+	}
+
+	product.Prices, err = createDomainPrices(req.Prices)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// createDomainPriceVersion creates domain price version from price version request, returns error if a price or date is not valid
+func createDomainPriceVersion(req *priceVersionRequest) (*domain.PriceVersion, error) {
+	price, err := domain.ParseMoney(req.Price, req.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price value: %w", err)
+	}
+
+	version := &domain.PriceVersion{
+		Price: price,
+	}
+
+	if req.EffectiveFrom != "" {
+		version.EffectiveFrom, err = parseDate(req.EffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid effective_from value")
+		}
+	}
+
+	version.Prices, err = createDomainPrices(req.Prices)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// createDomainPrices creates domain price list from price list request, returns error if a price is not valid
+func createDomainPrices(req []productPriceRequest) ([]domain.ProductPrice, error) {
+	var prices []domain.ProductPrice
+	for _, v := range req {
+		price, err := domain.ParseMoney(v.Price, v.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price list value: %w", err)
+		}
+		prices = append(prices, domain.ProductPrice{
+			Country: v.Country,
+			Price:   price,
+		})
+	}
+	return prices, nil
+}
+
+// createUpdateSubscriptionResponse creates update subscription response from domain subscription
+func createUpdateSubscriptionResponse(subscription *domain.UserSubscription) *updateSubscriptionByIDResponse {
+	return &updateSubscriptionByIDResponse{
+		ID:                subscription.ID,
+		Version:           subscription.Version,
+		CreatedAt:         subscription.CreatedAt,
+		Email:             subscription.Email,
+		Country:           subscription.Country,
+		ProductID:         subscription.ProductID,
+		ProductName:       subscription.ProductName,
+		StartDate:         subscription.StartDate,
+		EndDate:           subscription.EndDate,
+		Price:             subscription.Price.String(),
+		NetPrice:          subscription.NetPrice.String(),
+		Tax:               subscription.Tax.String(),
+		TaxRate:           subscription.TaxRate,
+		Currency:          subscription.Price.Currency,
+		PriceVersionID:    subscription.PriceVersionID,
+		Status:            string(subscription.Status),
+		UpdatedAt:         subscription.UpdatedAt,
+		PauseStartDate:    pauseStartDate(subscription),
+		ResumeDate:        subscription.ResumeDate,
+		Pauses:            createPausesResponse(subscription.Pauses),
+		AutoRenew:         subscription.AutoRenew,
+		TrialEndDate:      subscription.TrialEndDate,
+		Renewals:          createRenewalsResponse(subscription.Renewals),
+		CancelAtPeriodEnd: subscription.CancelAtPeriodEnd,
+		PendingPlanChange: createPendingPlanChangeResponse(subscription.PendingPlanChange),
+		PlanChanges:       createPlanChangesResponse(subscription.PlanChanges),
+		Payments:          createPaymentsResponse(subscription.Payments),
+		PendingPayment:    createPendingPaymentResponse(subscription.PendingPayment),
+		NextPaymentRetry:  subscription.NextPaymentRetry,
+		PaymentAttempts:   createPaymentAttemptsResponse(subscription.PaymentAttempts),
+	}
+}
+
+func createSubscriptionHistoryResponse(events []domain.AuditEvent) *getSubscriptionHistoryResponse {
+	res := &getSubscriptionHistoryResponse{
+		Events: []auditEventResponse{},
+	}
+	for _, v := range events {
+		res.Events = append(res.Events, auditEventResponse{
+			ID:             v.ID,
+			Action:         string(v.Action),
+			PreviousStatus: string(v.PreviousStatus),
+			NewStatus:      string(v.NewStatus),
+			Actor:          v.Actor,
+			RequestID:      v.RequestID,
+			CreatedAt:      v.CreatedAt,
+		})
+	}
+	return res
+}
+
+// createInvoiceResponse creates invoice response from domain invoice
+func createInvoiceResponse(invoice *domain.Invoice) *invoiceResponse {
+	res := &invoiceResponse{
+		ID:              invoice.ID,
+		Type:            string(invoice.Type),
+		Number:          invoice.Number,
+		SubscriptionID:  invoice.SubscriptionID,
+		Email:           invoice.Email,
+		Country:         invoice.Country,
+		AuthorizationID: invoice.AuthorizationID,
+		Reason:          string(invoice.Reason),
+		Lines:           []invoiceLineResponse{},
+		Net:             invoice.Net.String(),
+		Tax:             invoice.Tax.String(),
+		Total:           invoice.Total.String(),
+		Currency:        invoice.Total.Currency,
+		IssuedAt:        invoice.IssuedAt,
+	}
+	for _, v := range invoice.Lines {
+		res.Lines = append(res.Lines, invoiceLineResponse{
+			ProductID:   v.ProductID,
+			ProductName: v.ProductName,
+			PeriodStart: v.PeriodStart,
+			PeriodEnd:   v.PeriodEnd,
+			Net:         v.Net.String(),
+			Tax:         v.Tax.String(),
+			Gross:       v.Gross.String(),
+			TaxRate:     v.TaxRate,
+		})
+	}
+	return res
+}
+
+func createSubscriptionInvoicesResponse(invoices []domain.Invoice) *getSubscriptionInvoicesResponse {
+	res := &getSubscriptionInvoicesResponse{
+		Invoices: []invoiceResponse{},
+	}
+	for i := range invoices {
+		res.Invoices = append(res.Invoices, *createInvoiceResponse(&invoices[i]))
+	}
+	return res
+}
+
+// parseDate parses RFC 3339 date time or date in YYYY-MM-DD format
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return date.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseDateQuery parses the optional date query parameter, date in YYYY-MM-DD format of the end of the range
+// is the end of that day so that the range includes the whole day
+func parseDateQuery(c *gin.Context, name string, rangeEnd bool) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := parseDate(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v value", name)
+	}
+
+	if rangeEnd && len(value) == len("2006-01-02") {
+		date = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &date, nil
+}
+
 func (api *apiDetails) setupRouter() *gin.Engine {
 	validate = validator.New()
 
@@ -92,13 +693,27 @@ func (api *apiDetails) setupRouter() *gin.Engine {
 	docs.SwaggerInfo.BasePath = apiV1
 
 	r := gin.Default()
+	r.ContextWithFallback = true
+	r.Use(api.actorMiddleware(), requestIDMiddleware())
 	v1group := r.Group(apiV1)
 	v1group.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	v1group.GET("/product/:id", api.getProductByID)
 	v1group.GET("/product", api.getAllProducts)
-	v1group.POST("/subscription", api.buySubscription)
+	adminGroup := v1group.Group("/admin", verifiedActorMiddleware())
+	adminGroup.POST("/product", api.createProduct)
+	adminGroup.PUT("/product/:id", api.updateProduct)
+	adminGroup.POST("/product/:id/price_version", api.schedulePriceVersion)
+	adminGroup.POST("/product/:id/archive", api.archiveProduct)
+	adminGroup.POST("/product/:id/restore", api.restoreProduct)
+	v1group.POST("/subscription", api.idempotencyMiddleware(), api.buySubscription)
+	v1group.GET("/subscription", api.listSubscriptions)
 	v1group.GET("/subscription/:id", api.getSubscriptionByID)
-	v1group.PATCH("/subscription/:id/changeStatus/:status", api.updateSubscriptionStatusByID)
+	v1group.GET("/subscription/:id/history", api.getSubscriptionHistory)
+	v1group.GET("/subscription/:id/invoices", api.getSubscriptionInvoices)
+	v1group.GET("/subscription/:id/invoices/:number", api.getSubscriptionInvoice)
+	v1group.PATCH("/subscription/:id/changeStatus/:status", api.idempotencyMiddleware(), ifMatchMiddleware(), api.updateSubscriptionStatusByID)
+	v1group.PATCH("/subscription/:id/changePlan", ifMatchMiddleware(), api.changePlan)
+	v1group.POST("/subscription/:id/payment", api.paymentSignatureMiddleware(), api.confirmPayment)
 
 	return r
 }
@@ -139,73 +754,94 @@ func (api *apiDetails) getProductByID(c *gin.Context) {
 		return
 	}
 
-	product := products[0]
-	c.IndentedJSON(http.StatusOK, &getProductByIdResponse{
-		ID:                 product.ID,
-		Name:               product.Name,
-		SubscriptionPeriod: product.SubscriptionPeriod,
-		Price:              product.Price,
-		TaxPercentage:      product.TaxPercentage,
-	})
+	c.IndentedJSON(http.StatusOK, createProductResponse(&products[0]))
 	c.Done()
 }
 
 // getAllProducts godoc
 // @Summary get all the products
-// @Description return feteched  products
+// @Description return a page of the products matching the filter with total count of matching products, next_cursor is set if there are more products
+// @Description price range is in the currency (default EUR) of the default product price and includes both min and max
 // @Tags product-api
 // @Accept  json
 // @Produce  json
+// @Param name query string false "part of the product name, case insensitive"
+// @Param subscription_period query int false "subscription period in months"
+// @Param min_price query string false "min default price effective now e.g. 10.00"
+// @Param max_price query string false "max default price effective now e.g. 20.00"
+// @Param currency query string false "currency of the price range (ISO 4217)"
+// @Param include_archived query bool false "list archived products too"
+// @Param sort query string false "sort field" Enums(name, price, subscription_period)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Param limit query int false "page size, default 20 and max 100"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {object} rest.getAllProductsResponse
+// @Failure 400 {object} rest.errorRespose
 // @Failure 500 {object} rest.errorRespose
 // @Router /product [get]
 func (api *apiDetails) getAllProducts(c *gin.Context) {
-	products, err := api.app.GetProduct(c, "")
-	if err != nil {
-		createErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return
+	query := domain.ProductQuery{
+		Name:   c.Query("name"),
+		SortBy: domain.ProductSortField(c.Query("sort")),
+		Cursor: c.Query("cursor"),
 	}
 
-	respProducts := getAllProductsResponse{}
-	for _, v := range products {
-		respProducts.Products = append(respProducts.Products, getProductByIdResponse{
-			ID:                 v.ID,
-			Name:               v.Name,
-			SubscriptionPeriod: v.SubscriptionPeriod,
-			Price:              v.Price,
-			TaxPercentage:      v.TaxPercentage,
-		})
+	if value := c.Query("subscription_period"); value != "" {
+		period, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || period == 0 {
+			createErrorResponse(c, http.StatusBadRequest, "invalid subscription_period value")
+			return
+		}
+		query.SubscriptionPeriod = uint(period)
 	}
-	c.IndentedJSON(http.StatusOK, &respProducts)
-	c.Done()
-}
 
-// buySubscription godoc
-// @Summary create a subscription for the user with given product
-// @Description return created subscription record
-// @Tags subscription-api
-// @Accept  json
-// @Produce  json
-// @Param buySubscriptionRequest body rest.buySubscriptionRequest true "create subscription request"
-// @Success 201 {object} rest.buySubscriptionResponse
-// @Failure 400 {object} rest.errorRespose
-// @Failure 500 {object} rest.errorRespose
-// @Router /subscription [post]
-func (api *apiDetails) buySubscription(c *gin.Context) {
-	req := &buySubscriptionRequest{}
-	err := c.BindJSON(req)
-	if err != nil {
-		createErrorResponse(c, http.StatusBadRequest, err.Error())
-		return
+	currency := c.DefaultQuery("currency", domain.DefaultCurrency)
+	if value := c.Query("min_price"); value != "" {
+		price, err := domain.ParseMoney(value, currency)
+		if err != nil {
+			createErrorResponse(c, http.StatusBadRequest, "invalid min_price value")
+			return
+		}
+		query.MinPrice = &price
 	}
 
-	err = validate.Struct(req)
-	if err != nil {
-		createErrorResponse(c, http.StatusBadRequest, err.Error())
+	if value := c.Query("max_price"); value != "" {
+		price, err := domain.ParseMoney(value, currency)
+		if err != nil {
+			createErrorResponse(c, http.StatusBadRequest, "invalid max_price value")
+			return
+		}
+		query.MaxPrice = &price
+	}
+
+	if value := c.Query("include_archived"); value != "" {
+		includeArchived, err := strconv.ParseBool(value)
+		if err != nil {
+			createErrorResponse(c, http.StatusBadRequest, "invalid include_archived value")
+			return
+		}
+		query.IncludeArchived = includeArchived
+	}
+
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		createErrorResponse(c, http.StatusBadRequest, "invalid order value")
 		return
 	}
 
-	subscriptionDetails, err := api.app.BuySubscription(c, req.ProductID, req.EmailID)
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 {
+			createErrorResponse(c, http.StatusBadRequest, "invalid limit value")
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := api.app.ListProducts(c, query)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, app.InvalidArgErr) {
@@ -215,136 +851,874 @@ func (api *apiDetails) buySubscription(c *gin.Context) {
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, &buySubscriptionResponse{
-		ID:          subscriptionDetails.ID,
-		CreatedAt:   subscriptionDetails.CreatedAt,
-		Email:       subscriptionDetails.Email,
-		ProductName: subscriptionDetails.ProductName,
-		StartDate:   subscriptionDetails.StartDate,
-		EndDate:     subscriptionDetails.EndDate,
-		Price:       subscriptionDetails.Price,
-		Tax:         subscriptionDetails.Tax,
-		Status:      string(subscriptionDetails.Status),
-	})
+	respProducts := getAllProductsResponse{
+		TotalCount: page.TotalCount,
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Products {
+		respProducts.Products = append(respProducts.Products, *createProductResponse(&page.Products[i]))
+	}
+	c.IndentedJSON(http.StatusOK, &respProducts)
 	c.Done()
 }
 
-// getSubscriptionByID godoc
-// @Summary get a subscription for given subscription id
-// @Description return feteched  subscription record for input id
-// @Tags subscription-api
+// createProduct godoc
+// @Summary create a product
+// @Description validate and create the product, subscription period must be positive, prices must not be negative and tax category must have tax rules
+// @Tags product-admin-api
 // @Accept  json
 // @Produce  json
-// @Param id path string true "subscription ID"
-// @Success 200 {object} rest.getSubscriptionByIDResponse
-// @Failure 404 {object} rest.errorRespose
+// @Param productRequest body rest.productRequest true "product, prices are decimal strings e.g. 10.00"
+// @Param X-Actor header string true "admin who makes the change"
+// @Param X-Actor-Signature header string true "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Success 201 {object} rest.getProductByIdResponse
 // @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
 // @Failure 500 {object} rest.errorRespose
-// @Router /subscription/{id} [get]
-func (api *apiDetails) getSubscriptionByID(c *gin.Context) {
-	subscriptionID := c.Params.ByName("id")
-	if subscriptionID == "" {
-		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+// @Router /admin/product [post]
+func (api *apiDetails) createProduct(c *gin.Context) {
+	product, ok := bindProductRequest(c)
+	if !ok {
 		return
 	}
 
-	subscriptionDetails, err := api.app.GetSubscriptionByID(c, subscriptionID)
+	created, err := api.app.CreateProduct(c, *product)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		switch {
-		case errors.Is(err, app.InvalidArgErr):
-			statusCode = http.StatusBadRequest
-		case errors.Is(err, app.NotFoundErr):
-			statusCode = http.StatusNotFound
-		}
-		createErrorResponse(c, statusCode, err.Error())
+		createProductErrorResponse(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, &getSubscriptionByIDResponse{
-		ID:             subscriptionDetails.ID,
-		CreatedAt:      subscriptionDetails.CreatedAt,
-		Email:          subscriptionDetails.Email,
-		ProductName:    subscriptionDetails.ProductName,
-		StartDate:      subscriptionDetails.StartDate,
-		EndDate:        subscriptionDetails.EndDate,
-		Price:          subscriptionDetails.Price,
-		Tax:            subscriptionDetails.Tax,
-		Status:         string(subscriptionDetails.Status),
-		UpdatedAt:      subscriptionDetails.UpdatedAt,
-		PauseStartDate: subscriptionDetails.PauseStartDate,
-	})
+	c.IndentedJSON(http.StatusCreated, createProductResponse(created))
 	c.Done()
 }
 
-// updateSubscriptionStatusByID godoc
-// @Summary update subscription with given status
-// @Description update subscription with given status and returns updated subscription
-// @Tags subscription-api
+// updateProduct godoc
+// @Summary update a product
+// @Description validate and replace the product for input id, subscriptions of the product keep the name and price they were bought with
+// @Tags product-admin-api
 // @Accept  json
 // @Produce  json
-// @Param id path string true "subscription ID"
-// @Param status path string true "status" Enums(active, cancel, pause)
-// @Success 200 {object} rest.updateSubscriptionByIDResponse
+// @Param id path string true "product ID"
+// @Param productRequest body rest.productRequest true "product, prices are decimal strings e.g. 10.00"
+// @Param X-Actor header string true "admin who makes the change"
+// @Param X-Actor-Signature header string true "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Success 200 {object} rest.getProductByIdResponse
 // @Failure 404 {object} rest.errorRespose
 // @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
 // @Failure 500 {object} rest.errorRespose
-// @Router /subscription/{id}/changeStatus/{status} [patch]
-func (api *apiDetails) updateSubscriptionStatusByID(c *gin.Context) {
-	subscriptionID := c.Params.ByName("id")
-	if subscriptionID == "" {
+// @Router /admin/product/{id} [put]
+func (api *apiDetails) updateProduct(c *gin.Context) {
+	productID := c.Params.ByName("id")
+	if productID == "" {
 		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
 		return
 	}
 
-	status := c.Params.ByName("status")
-	if status == "" {
-		createErrorResponse(c, http.StatusBadRequest, "status cannot be empty")
+	product, ok := bindProductRequest(c)
+	if !ok {
 		return
 	}
 
-	var subscriptionStatus domain.SubscriptionStatus
-	switch status {
-	case "active":
-		subscriptionStatus = domain.SubscriptionStatusActive
-	case "cancel":
-		subscriptionStatus = domain.SubscriptionStatusCancelled
-	case "pause":
-		subscriptionStatus = domain.SubscriptionStatusPaused
-	default:
-		createErrorResponse(c, http.StatusBadRequest, "invalid status value")
+	product.ID = productID
+	updated, err := api.app.UpdateProduct(c, *product)
+	if err != nil {
+		createProductErrorResponse(c, err)
 		return
 	}
 
-	subscriptionDetails, err := api.app.UpdateSubscriptionStatusByID(c, subscriptionID, subscriptionStatus)
-	if err != nil {
+	c.IndentedJSON(http.StatusOK, createProductResponse(updated))
+	c.Done()
+}
+
+// schedulePriceVersion godoc
+// @Summary schedule a price change of a product
+// @Description add a price version to the product for input id, the prices are effective from effective_from (now if not given) until the next price version
+// @Tags product-admin-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "product ID"
+// @Param priceVersionRequest body rest.priceVersionRequest true "price version, effective_from is RFC 3339 or YYYY-MM-DD and must be after the last price version"
+// @Param X-Actor header string true "admin who makes the change"
+// @Param X-Actor-Signature header string true "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Success 201 {object} rest.getProductByIdResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /admin/product/{id}/price_version [post]
+func (api *apiDetails) schedulePriceVersion(c *gin.Context) {
+	productID := c.Params.ByName("id")
+	if productID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	req := &priceVersionRequest{}
+	err := c.BindJSON(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := createDomainPriceVersion(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, err := api.app.SchedulePriceVersion(c, productID, *version)
+	if err != nil {
+		createProductErrorResponse(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, createProductResponse(product))
+	c.Done()
+}
+
+// archiveProduct godoc
+// @Summary archive a product
+// @Description archive the product for input id, archived product cannot be bought, existing subscriptions of the product are not changed
+// @Tags product-admin-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "product ID"
+// @Param X-Actor header string true "admin who makes the change"
+// @Param X-Actor-Signature header string true "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Success 200 {object} rest.getProductByIdResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /admin/product/{id}/archive [post]
+func (api *apiDetails) archiveProduct(c *gin.Context) {
+	api.changeProductArchived(c, api.app.ArchiveProduct)
+}
+
+// restoreProduct godoc
+// @Summary restore a product
+// @Description restore the archived product for input id, so it can be bought again
+// @Tags product-admin-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "product ID"
+// @Param X-Actor header string true "admin who makes the change"
+// @Param X-Actor-Signature header string true "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Success 200 {object} rest.getProductByIdResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /admin/product/{id}/restore [post]
+func (api *apiDetails) restoreProduct(c *gin.Context) {
+	api.changeProductArchived(c, api.app.RestoreProduct)
+}
+
+// changeProductArchived archives or restores the product of the id param using given app function
+func (api *apiDetails) changeProductArchived(c *gin.Context, change func(ctx context.Context, id string) (*domain.Product, error)) {
+	productID := c.Params.ByName("id")
+	if productID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	product, err := change(c, productID)
+	if err != nil {
+		createProductErrorResponse(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, createProductResponse(product))
+	c.Done()
+}
+
+// bindProductRequest binds and validates product request body, writes bad request response if it is not valid
+func bindProductRequest(c *gin.Context) (*domain.Product, bool) {
+	req := &productRequest{}
+	err := c.BindJSON(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	product, err := createDomainProduct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return product, true
+}
+
+// createProductErrorResponse writes error response of the product admin app error
+func createProductErrorResponse(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, app.InvalidArgErr):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, app.NotFoundErr):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, app.StatusUnchangedErr):
+		statusCode = http.StatusBadRequest
+	}
+	createErrorResponse(c, statusCode, err.Error())
+}
+
+// buySubscription godoc
+// @Summary create a subscription for the user with given product
+// @Description return created subscription record
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param buySubscriptionRequest body rest.buySubscriptionRequest true "create subscription request"
+// @Param Idempotency-Key header string false "unique key of the request, retries with the same key get the response of the first request"
+// @Success 201 {object} rest.buySubscriptionResponse
+// @Header 201 {string} ETag "subscription version"
+// @Header 201 {string} Idempotent-Replayed "true if the response of the earlier request with the same Idempotency-Key is replayed"
+// @Failure 400 {object} rest.errorRespose
+// @Failure 404 {object} rest.errorRespose
+// @Failure 402 {object} rest.errorRespose
+// @Failure 409 {object} rest.duplicateSubscriptionResponse
+// @Failure 422 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Failure 504 {object} rest.errorRespose
+// @Router /subscription [post]
+func (api *apiDetails) buySubscription(c *gin.Context) {
+	req := &buySubscriptionRequest{}
+	err := c.BindJSON(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subscriptionDetails, err := api.app.BuySubscription(c, domain.Purchase{
+		ProductID:    req.ProductID,
+		EmailID:      req.EmailID,
+		Currency:     req.Currency,
+		Country:      req.Country,
+		PaymentToken: req.PaymentToken,
+	})
+	if err != nil {
+		duplicateErr := &app.DuplicateSubscriptionError{}
+		if errors.As(err, &duplicateErr) {
+			c.IndentedJSON(http.StatusConflict, &duplicateSubscriptionResponse{
+				ErrorMessage:   err.Error(),
+				SubscriptionID: duplicateErr.SubscriptionID,
+			})
+			return
+		}
+
 		statusCode := http.StatusInternalServerError
 		switch {
 		case errors.Is(err, app.InvalidArgErr):
 			statusCode = http.StatusBadRequest
 		case errors.Is(err, app.NotFoundErr):
 			statusCode = http.StatusNotFound
-		case errors.Is(err, app.StatusUnchangedErr):
-			statusCode = http.StatusBadRequest
 		case errors.Is(err, app.NotAllowedArgErr):
 			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.PaymentDeclinedErr):
+			statusCode = http.StatusPaymentRequired
+		case errors.Is(err, app.PaymentTimeoutErr):
+			statusCode = http.StatusGatewayTimeout
 		}
 		createErrorResponse(c, statusCode, err.Error())
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, &updateSubscriptionByIDResponse{
+	c.Header(etagHeader, createETag(subscriptionDetails.Version))
+	c.IndentedJSON(http.StatusCreated, &buySubscriptionResponse{
 		ID:             subscriptionDetails.ID,
+		Version:        subscriptionDetails.Version,
 		CreatedAt:      subscriptionDetails.CreatedAt,
 		Email:          subscriptionDetails.Email,
+		Country:        subscriptionDetails.Country,
+		ProductID:      subscriptionDetails.ProductID,
 		ProductName:    subscriptionDetails.ProductName,
 		StartDate:      subscriptionDetails.StartDate,
 		EndDate:        subscriptionDetails.EndDate,
-		Price:          subscriptionDetails.Price,
-		Tax:            subscriptionDetails.Tax,
+		Price:          subscriptionDetails.Price.String(),
+		NetPrice:       subscriptionDetails.NetPrice.String(),
+		Tax:            subscriptionDetails.Tax.String(),
+		TaxRate:        subscriptionDetails.TaxRate,
+		Currency:       subscriptionDetails.Price.Currency,
+		PriceVersionID: subscriptionDetails.PriceVersionID,
 		Status:         string(subscriptionDetails.Status),
-		UpdatedAt:      subscriptionDetails.UpdatedAt,
-		PauseStartDate: subscriptionDetails.PauseStartDate,
+		AutoRenew:      subscriptionDetails.AutoRenew,
+		TrialEndDate:   subscriptionDetails.TrialEndDate,
+		Payments:       createPaymentsResponse(subscriptionDetails.Payments),
+		PendingPayment: createPendingPaymentResponse(subscriptionDetails.PendingPayment),
 	})
 	c.Done()
 }
+
+// listSubscriptions godoc
+// @Summary list subscriptions of the user
+// @Description return a page of the subscriptions for input email matching the filter, next_cursor is set if there are more subscriptions
+// @Description date ranges include both from and to, YYYY-MM-DD date of the to parameter includes the whole day
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param email query string true "email of the user"
+// @Param status query []string false "subscription statuses, comma separated or repeated" collectionFormat(multi)
+// @Param product_id query string false "product ID"
+// @Param created_from query string false "created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "created at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param end_date_from query string false "end date at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param end_date_to query string false "end date at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "sort field" Enums(created_at, end_date)
+// @Param order query string false "sort order" Enums(asc, desc)
+// @Param limit query int false "page size, default 20 and max 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} rest.listSubscriptionsResponse
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription [get]
+func (api *apiDetails) listSubscriptions(c *gin.Context) {
+	query := domain.SubscriptionQuery{
+		Email:     c.Query("email"),
+		ProductID: c.Query("product_id"),
+		SortBy:    domain.SubscriptionSortField(c.Query("sort")),
+		Cursor:    c.Query("cursor"),
+	}
+	if query.Email == "" {
+		createErrorResponse(c, http.StatusBadRequest, "email cannot be empty")
+		return
+	}
+
+	for _, v := range c.QueryArray("status") {
+		for _, status := range strings.Split(v, ",") {
+			if status != "" {
+				query.Statuses = append(query.Statuses, domain.SubscriptionStatus(status))
+			}
+		}
+	}
+
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		createErrorResponse(c, http.StatusBadRequest, "invalid order value")
+		return
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 {
+			createErrorResponse(c, http.StatusBadRequest, "invalid limit value")
+			return
+		}
+		query.Limit = limit
+	}
+
+	var err error
+	query.CreatedFrom, err = parseDateQuery(c, "created_from", false)
+	if err == nil {
+		query.CreatedTo, err = parseDateQuery(c, "created_to", true)
+	}
+	if err == nil {
+		query.EndDateFrom, err = parseDateQuery(c, "end_date_from", false)
+	}
+	if err == nil {
+		query.EndDateTo, err = parseDateQuery(c, "end_date_to", true)
+	}
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := api.app.ListSubscriptions(c, query)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, app.InvalidArgErr) {
+			statusCode = http.StatusBadRequest
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, createListSubscriptionsResponse(page))
+	c.Done()
+}
+
+// getSubscriptionByID godoc
+// @Summary get a subscription for given subscription id
+// @Description return feteched  subscription record for input id
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Success 200 {object} rest.getSubscriptionByIDResponse
+// @Header 200 {string} ETag "subscription version"
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id} [get]
+func (api *apiDetails) getSubscriptionByID(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	subscriptionDetails, err := api.app.GetSubscriptionByID(c, subscriptionID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.Header(etagHeader, createETag(subscriptionDetails.Version))
+	c.IndentedJSON(http.StatusOK, createSubscriptionResponse(subscriptionDetails))
+	c.Done()
+}
+
+// updateSubscriptionStatusByID godoc
+// @Summary update subscription with given status
+// @Description update subscription with given status and returns updated subscription
+// @Description cancel with mode period_end keeps the subscription active until its end date, active status undoes it
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Param status path string true "status" Enums(active, cancel, pause)
+// @Param mode query string false "cancel mode" Enums(immediate, period_end)
+// @Param resume_date query string false "resume date of the paused subscription (RFC 3339 or YYYY-MM-DD)"
+// @Param reason query string false "reason of the pause"
+// @Param X-Actor header string false "who makes the change, anonymous if not set or the actor signing key is not configured"
+// @Param X-Actor-Signature header string false "hex HMAC-SHA256 of X-Actor with the actor signing key"
+// @Param If-Match header string false "ETag of the subscription version the change is based on"
+// @Param Idempotency-Key header string false "unique key of the request, retries with the same key get the response of the first request"
+// @Success 200 {object} rest.updateSubscriptionByIDResponse
+// @Header 200 {string} ETag "subscription version"
+// @Header 200 {string} Idempotent-Replayed "true if the response of the earlier request with the same Idempotency-Key is replayed"
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
+// @Failure 409 {object} rest.errorRespose
+// @Failure 412 {object} rest.errorRespose
+// @Failure 422 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id}/changeStatus/{status} [patch]
+func (api *apiDetails) updateSubscriptionStatusByID(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	status := c.Params.ByName("status")
+	if status == "" {
+		createErrorResponse(c, http.StatusBadRequest, "status cannot be empty")
+		return
+	}
+
+	var subscriptionStatus domain.SubscriptionStatus
+	switch status {
+	case "active":
+		subscriptionStatus = domain.SubscriptionStatusActive
+	case "cancel":
+		subscriptionStatus = domain.SubscriptionStatusCancelled
+	case "pause":
+		subscriptionStatus = domain.SubscriptionStatusPaused
+	default:
+		createErrorResponse(c, http.StatusBadRequest, "invalid status value")
+		return
+	}
+
+	var resumeDate *time.Time
+	if value := c.Query("resume_date"); value != "" {
+		if subscriptionStatus != domain.SubscriptionStatusPaused {
+			createErrorResponse(c, http.StatusBadRequest, "resume_date is only allowed for pause status")
+			return
+		}
+
+		date, err := parseDate(value)
+		if err != nil {
+			createErrorResponse(c, http.StatusBadRequest, "invalid resume_date value")
+			return
+		}
+		resumeDate = &date
+	}
+
+	reason := c.Query("reason")
+	if reason != "" && subscriptionStatus != domain.SubscriptionStatusPaused {
+		createErrorResponse(c, http.StatusBadRequest, "reason is only allowed for pause status")
+		return
+	}
+
+	var subscriptionDetails *domain.UserSubscription
+	var err error
+	switch c.Query("mode") {
+	case "", "immediate":
+		if resumeDate != nil || reason != "" {
+			subscriptionDetails, err = api.app.PauseSubscriptionByID(c, subscriptionID, resumeDate, reason)
+			break
+		}
+		subscriptionDetails, err = api.app.UpdateSubscriptionStatusByID(c, subscriptionID, subscriptionStatus)
+	case "period_end":
+		if subscriptionStatus != domain.SubscriptionStatusCancelled {
+			createErrorResponse(c, http.StatusBadRequest, "mode period_end is only allowed for cancel status")
+			return
+		}
+		subscriptionDetails, err = api.app.CancelSubscriptionAtPeriodEnd(c, subscriptionID)
+	default:
+		createErrorResponse(c, http.StatusBadRequest, "invalid mode value")
+		return
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, app.StatusUnchangedErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotAllowedArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.ConflictErr):
+			statusCode = http.StatusConflict
+		case errors.Is(err, app.VersionMismatchErr):
+			statusCode = http.StatusPreconditionFailed
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.Header(etagHeader, createETag(subscriptionDetails.Version))
+	c.IndentedJSON(http.StatusOK, createUpdateSubscriptionResponse(subscriptionDetails))
+	c.Done()
+}
+
+// changePlan godoc
+// @Summary change product of the subscription
+// @Description change subscription product immediately with prorated charge/credit or at the end of the current period and returns updated subscription
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Param changePlanRequest body rest.changePlanRequest true "change plan request, timing is immediate (default) or period_end"
+// @Param If-Match header string false "ETag of the subscription version the change is based on"
+// @Success 200 {object} rest.updateSubscriptionByIDResponse
+// @Header 200 {string} ETag "subscription version"
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 402 {object} rest.errorRespose
+// @Failure 409 {object} rest.errorRespose
+// @Failure 412 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Failure 504 {object} rest.errorRespose
+// @Router /subscription/{id}/changePlan [patch]
+func (api *apiDetails) changePlan(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	req := &changePlanRequest{}
+	err := c.BindJSON(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	timing := domain.PlanChangeImmediate
+	if req.Timing != "" {
+		timing = domain.PlanChangeTiming(req.Timing)
+	}
+
+	subscriptionDetails, err := api.app.ChangePlan(c, subscriptionID, req.ProductID, timing)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, app.NotAllowedArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.ConflictErr):
+			statusCode = http.StatusConflict
+		case errors.Is(err, app.VersionMismatchErr):
+			statusCode = http.StatusPreconditionFailed
+		case errors.Is(err, app.PaymentDeclinedErr):
+			statusCode = http.StatusPaymentRequired
+		case errors.Is(err, app.PaymentTimeoutErr):
+			statusCode = http.StatusGatewayTimeout
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.Header(etagHeader, createETag(subscriptionDetails.Version))
+	c.IndentedJSON(http.StatusOK, createUpdateSubscriptionResponse(subscriptionDetails))
+	c.Done()
+}
+
+// confirmPayment godoc
+// @Summary confirm the purchase payment of the pending subscription
+// @Description payment provider callback which activates the pending subscription when the payment succeeded or marks it payment_failed otherwise, returns updated subscription
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Param confirmPaymentRequest body rest.confirmPaymentRequest true "confirm payment request, status is succeeded or failed"
+// @Param X-Payment-Signature header string true "hex HMAC-SHA256 of the request body with the payment callback key"
+// @Success 200 {object} rest.updateSubscriptionByIDResponse
+// @Header 200 {string} ETag "subscription version"
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 401 {object} rest.errorRespose
+// @Failure 409 {object} rest.errorRespose
+// @Failure 412 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Failure 504 {object} rest.errorRespose
+// @Router /subscription/{id}/payment [post]
+func (api *apiDetails) confirmPayment(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	req := &confirmPaymentRequest{}
+	err := c.BindJSON(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		createErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subscriptionDetails, err := api.app.ConfirmPayment(c, subscriptionID, req.AuthorizationID, req.Status == "succeeded")
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, app.NotAllowedArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.ConflictErr):
+			statusCode = http.StatusConflict
+		case errors.Is(err, app.VersionMismatchErr):
+			statusCode = http.StatusPreconditionFailed
+		case errors.Is(err, app.PaymentTimeoutErr):
+			statusCode = http.StatusGatewayTimeout
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.Header(etagHeader, createETag(subscriptionDetails.Version))
+	c.IndentedJSON(http.StatusOK, createUpdateSubscriptionResponse(subscriptionDetails))
+	c.Done()
+}
+
+// getSubscriptionHistory godoc
+// @Summary get audit log of the subscription
+// @Description return all the changes of the subscription for input id sorted by creation time
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Success 200 {object} rest.getSubscriptionHistoryResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id}/history [get]
+func (api *apiDetails) getSubscriptionHistory(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	events, err := api.app.GetSubscriptionHistory(c, subscriptionID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, createSubscriptionHistoryResponse(events))
+	c.Done()
+}
+
+// getSubscriptionInvoices godoc
+// @Summary get invoices of the subscription
+// @Description return all the invoices and credit notes of the subscription for input id sorted by issue time
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Success 200 {object} rest.getSubscriptionInvoicesResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id}/invoices [get]
+func (api *apiDetails) getSubscriptionInvoices(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	invoices, err := api.app.GetSubscriptionInvoices(c, subscriptionID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, createSubscriptionInvoicesResponse(invoices))
+	c.Done()
+}
+
+// getSubscriptionInvoice godoc
+// @Summary get invoice of the subscription
+// @Description return the invoice of the subscription for input id and invoice number e.g. 2022-000001
+// @Tags subscription-api
+// @Accept  json
+// @Produce  json
+// @Param id path string true "subscription ID"
+// @Param number path string true "invoice number"
+// @Success 200 {object} rest.invoiceResponse
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id}/invoices/{number} [get]
+func (api *apiDetails) getSubscriptionInvoice(c *gin.Context) {
+	// gin does not route a parameter with a static suffix, so the PDF of the invoice is matched here
+	if strings.HasSuffix(c.Params.ByName("number"), pdfSuffix) {
+		api.getSubscriptionInvoicePDF(c)
+		return
+	}
+
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	number := c.Params.ByName("number")
+	if number == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param number cannot be empty")
+		return
+	}
+
+	invoice, err := api.app.GetSubscriptionInvoice(c, subscriptionID, number)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, createInvoiceResponse(invoice))
+	c.Done()
+}
+
+// getSubscriptionInvoicePDF godoc
+// @Summary get invoice PDF of the subscription
+// @Description render the invoice of the subscription for input id and invoice number as PDF document
+// @Tags subscription-api
+// @Accept  json
+// @Produce  application/pdf
+// @Param id path string true "subscription ID"
+// @Param number path string true "invoice number"
+// @Success 200 {file} file
+// @Failure 404 {object} rest.errorRespose
+// @Failure 400 {object} rest.errorRespose
+// @Failure 500 {object} rest.errorRespose
+// @Router /subscription/{id}/invoices/{number}.pdf [get]
+func (api *apiDetails) getSubscriptionInvoicePDF(c *gin.Context) {
+	subscriptionID := c.Params.ByName("id")
+	if subscriptionID == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param id cannot be empty")
+		return
+	}
+
+	number := strings.TrimSuffix(c.Params.ByName("number"), pdfSuffix)
+	if number == "" {
+		createErrorResponse(c, http.StatusBadRequest, "param number cannot be empty")
+		return
+	}
+
+	invoice, err := api.app.GetSubscriptionInvoice(c, subscriptionID, number)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, app.InvalidArgErr):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, app.NotFoundErr):
+			statusCode = http.StatusNotFound
+		}
+		createErrorResponse(c, statusCode, err.Error())
+		return
+	}
+
+	document, err := renderInvoicePDF(api.invoiceTemplate, invoice)
+	if err != nil {
+		createErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="invoice-%v.pdf"`, invoice.Number))
+	c.Data(http.StatusOK, pdfContentType, document)
+	c.Done()
+}
@@ -1,15 +1,20 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/app"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/mocks"
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"gotest.tools/assert"
@@ -48,8 +53,23 @@ func (suite *HandlerTestSuite) TestGetProductByID() {
 		ID:                 productID,
 		Name:               "test name",
 		SubscriptionPeriod: 1,
-		Price:              10,
-		TaxPercentage:      10,
+		Price:              domain.NewMoney(1000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
+	}
+	repricedProductRecord := productRecord
+	repricedProductRecord.PriceVersions = []domain.PriceVersion{
+		{
+			ID:            "62bc589278b49cee00f01422",
+			EffectiveFrom: time.Now().AddDate(0, -1, 0).UTC(),
+			Price:         domain.NewMoney(1200, "EUR"),
+			Prices:        []domain.ProductPrice{{Country: "CH", Price: domain.NewMoney(1300, "CHF")}},
+		},
+		{
+			ID:            "62bc589278b49cee00f01423",
+			EffectiveFrom: time.Now().AddDate(0, 1, 0).UTC(),
+			Price:         domain.NewMoney(1500, "EUR"),
+		},
 	}
 
 	gomock.InOrder(
@@ -59,6 +79,9 @@ func (suite *HandlerTestSuite) TestGetProductByID() {
 		appInstance.EXPECT().GetProduct(gomock.Any(), "invalidid").Return(nil,
 			app.InvalidArgErr).Times(1),
 		appInstance.EXPECT().GetProduct(gomock.Any(), productIDNotPresent).Return([]domain.Product{}, nil).Times(1),
+		appInstance.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{
+			repricedProductRecord,
+		}, nil).Times(1),
 	)
 
 	api := &apiDetails{
@@ -79,8 +102,10 @@ func (suite *HandlerTestSuite) TestGetProductByID() {
 		ID:                 productRecord.ID,
 		Name:               productRecord.Name,
 		SubscriptionPeriod: productRecord.SubscriptionPeriod,
-		Price:              productRecord.Price,
-		TaxPercentage:      productRecord.TaxPercentage,
+		Price:              productRecord.Price.String(),
+		Currency:           productRecord.Price.Currency,
+		TaxCategory:        productRecord.TaxCategory,
+		TaxInclusive:       productRecord.TaxInclusive,
 	}, v)
 
 	// invalid id in the input
@@ -104,6 +129,19 @@ func (suite *HandlerTestSuite) TestGetProductByID() {
 	assert.DeepEqual(t, errorRespose{
 		ErrorMessage: "product not found for given id",
 	}, errorResp)
+
+	// prices of the price version effective now
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, getProdApiPath+productID, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	v = getProductByIdResponse{}
+	json.NewDecoder(w.Body).Decode(&v)
+	assert.Equal(t, v.Price, "12.00")
+	assert.Equal(t, v.PriceVersionID, "62bc589278b49cee00f01422")
+	assert.DeepEqual(t, v.Prices, []productPriceResponse{{Country: "CH", Price: "13.00", Currency: "CHF"}})
+	assert.Equal(t, len(v.PriceVersions), 2)
 }
 
 func (suite *HandlerTestSuite) TestGetAllProducts() {
@@ -115,15 +153,41 @@ func (suite *HandlerTestSuite) TestGetAllProducts() {
 		ID:                 productID,
 		Name:               "test name",
 		SubscriptionPeriod: 1,
-		Price:              10,
-		TaxPercentage:      10,
+		Price:              domain.NewMoney(1000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
 	}
 
+	minPrice := domain.NewMoney(500, "CHF")
+	maxPrice := domain.NewMoney(2050, "CHF")
+
 	gomock.InOrder(
-		appInstance.EXPECT().GetProduct(gomock.Any(), "").Return([]domain.Product{
-			productRecord,
+		appInstance.EXPECT().ListProducts(gomock.Any(), domain.ProductQuery{}).Return(&domain.ProductPage{
+			Products: []domain.Product{
+				productRecord,
+			},
+			TotalCount: 1,
+		}, nil).Times(1),
+		appInstance.EXPECT().ListProducts(gomock.Any(), domain.ProductQuery{}).Return(nil, app.NotFoundErr).Times(1),
+		appInstance.EXPECT().ListProducts(gomock.Any(), domain.ProductQuery{
+			Name:               "shape",
+			SubscriptionPeriod: 1,
+			MinPrice:           &minPrice,
+			MaxPrice:           &maxPrice,
+			SortBy:             domain.ProductSortByPrice,
+			SortDesc:           true,
+			Limit:              1,
+			Cursor:             "eyJzIjoicHJpY2UifQ",
+		}).Return(&domain.ProductPage{
+			Products: []domain.Product{
+				productRecord,
+			},
+			TotalCount: 2,
+			NextCursor: "eyJzIjoicHJpY2UifR",
 		}, nil).Times(1),
-		appInstance.EXPECT().GetProduct(gomock.Any(), "").Return(nil, app.NotFoundErr).Times(1),
+		appInstance.EXPECT().ListProducts(gomock.Any(), domain.ProductQuery{
+			SortBy: "trial_days",
+		}).Return(nil, app.InvalidArgErr).Times(1),
 	)
 
 	api := &apiDetails{
@@ -144,15 +208,272 @@ func (suite *HandlerTestSuite) TestGetAllProducts() {
 			ID:                 productRecord.ID,
 			Name:               productRecord.Name,
 			SubscriptionPeriod: productRecord.SubscriptionPeriod,
-			Price:              productRecord.Price,
-			TaxPercentage:      productRecord.TaxPercentage,
-		}}}, v)
+			Price:              productRecord.Price.String(),
+			Currency:           productRecord.Price.Currency,
+			TaxCategory:        productRecord.TaxCategory,
+			TaxInclusive:       productRecord.TaxInclusive,
+		}},
+		TotalCount: 1,
+	}, v)
 
 	// error while getting product
 	w = httptest.NewRecorder()
 	req, _ = http.NewRequest(http.MethodGet, "/api/v1/product", nil)
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// filtered and sorted page test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/product?name=shape&subscription_period=1&min_price=5&max_price=20.50"+
+		"&currency=CHF&sort=price&order=desc&limit=1&cursor=eyJzIjoicHJpY2UifQ", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	v = getAllProductsResponse{}
+	json.NewDecoder(w.Body).Decode(&v)
+	assert.Equal(t, v.TotalCount, int64(2))
+	assert.Equal(t, v.NextCursor, "eyJzIjoicHJpY2UifR")
+
+	// invalid query returned by app test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/product?sort=trial_days", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid query params tests
+	for _, query := range []string{
+		"?subscription_period=0",
+		"?min_price=ten",
+		"?max_price=10.001",
+		"?order=up",
+		"?limit=-1",
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/api/v1/product"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func (suite *HandlerTestSuite) TestProductAdmin() {
+	t := suite.T()
+
+	appInstance := suite.App
+	productID := "62bc589278b49cee00f01421"
+	productIDNotPresent := "62bc59417f1271f8e9c5e1c4"
+	archivedAt := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		Name:               "test name",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(1000, "EUR"),
+		Prices: []domain.ProductPrice{
+			{Country: "CH", Price: domain.NewMoney(1250, "CHF")},
+		},
+		TaxCategory: "digital_service",
+	}
+	created := product
+	created.ID = productID
+	archived := created
+	archived.ArchivedAt = &archivedAt
+	updated := product
+	updated.ID = productIDNotPresent
+
+	gomock.InOrder(
+		appInstance.EXPECT().CreateProduct(gomock.Any(), product).Return(&created, nil).Times(1),
+		appInstance.EXPECT().CreateProduct(gomock.Any(), product).Return(nil, app.InvalidArgErr).Times(1),
+		appInstance.EXPECT().UpdateProduct(gomock.Any(), updated).Return(nil, app.NotFoundErr).Times(1),
+		appInstance.EXPECT().ArchiveProduct(gomock.Any(), productID).Return(&archived, nil).Times(1),
+		appInstance.EXPECT().ArchiveProduct(gomock.Any(), productID).Return(nil, app.StatusUnchangedErr).Times(1),
+		appInstance.EXPECT().RestoreProduct(gomock.Any(), productID).Return(&created, nil).Times(1),
+	)
+
+	api := &apiDetails{
+		app:             appInstance,
+		actorSigningKey: "actor-key",
+	}
+	router := api.setupRouter()
+	productBody := `{
+		"name":"test name",
+		"subscription_period":6,
+		"price":"10.00",
+		"currency":"EUR",
+		"prices":[{"country":"CH","price":"12.50","currency":"CHF"}],
+		"tax_category":"digital_service"
+	}`
+
+	// success create test
+	w := httptest.NewRecorder()
+	req := newAdminRequest(http.MethodPost, "/api/v1/admin/product", strings.NewReader(productBody))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp getProductByIdResponse
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.ID, productID)
+	assert.Equal(t, resp.Price, "10.00")
+	assert.Equal(t, len(resp.Prices), 1)
+
+	// product rejected by the validation
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPost, "/api/v1/admin/product", strings.NewReader(productBody))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// update of the product not present
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPut, "/api/v1/admin/product/"+productIDNotPresent, strings.NewReader(productBody))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// archive test
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPost, "/api/v1/admin/product/"+productID+"/archive", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp = getProductByIdResponse{}
+	err = json.NewDecoder(w.Body).Decode(&resp)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.ArchivedAt.Equal(archivedAt), true)
+
+	// archive of already archived product
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPost, "/api/v1/admin/product/"+productID+"/archive", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// restore test
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPost, "/api/v1/admin/product/"+productID+"/restore", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// request without verified actor is rejected before calling the app
+	for _, header := range []map[string]string{
+		{},
+		{"X-Actor": "admin@test.com"},
+		{"X-Actor": "admin@test.com", "X-Actor-Signature": sign("other-key", "admin@test.com")},
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPost, "/api/v1/admin/product/"+productID+"/archive", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// admin API is closed if the actor signing key is not configured
+	api.actorSigningKey = ""
+	router = api.setupRouter()
+	w = httptest.NewRecorder()
+	req = newAdminRequest(http.MethodPost, "/api/v1/admin/product/"+productID+"/archive", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	api.actorSigningKey = "actor-key"
+	router = api.setupRouter()
+
+	// invalid request bodies are rejected before calling the app
+	for _, body := range []string{
+		`{"subscription_period":6,"price":"10.00","currency":"EUR","tax_category":"digital_service"}`,
+		`{"name":"test name","subscription_period":6,"price":"ten","currency":"EUR","tax_category":"digital_service"}`,
+		`{"name":"test name","subscription_period":6,"price":"10.00","currency":"XYZ","tax_category":"digital_service"}`,
+		`{"name":"test name","subscription_period":6,"price":"10.00","currency":"EUR","tax_category":"digital_service","prices":[{"country":"XX","price":"1.00","currency":"CHF"}]}`,
+	} {
+		w = httptest.NewRecorder()
+		req = newAdminRequest(http.MethodPost, "/api/v1/admin/product", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func (suite *HandlerTestSuite) TestSchedulePriceVersion() {
+	t := suite.T()
+
+	appInstance := suite.App
+	productID := "62bc589278b49cee00f01421"
+	effectiveFrom := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		ID:                 productID,
+		Name:               "test name",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(1000, "EUR"),
+		PriceVersions: []domain.PriceVersion{
+			{
+				ID:            "62bc589278b49cee00f01422",
+				EffectiveFrom: effectiveFrom,
+				Price:         domain.NewMoney(1200, "EUR"),
+				Prices:        []domain.ProductPrice{{Country: "CH", Price: domain.NewMoney(1300, "CHF")}},
+			},
+		},
+		TaxCategory: "digital_service",
+	}
+
+	gomock.InOrder(
+		appInstance.EXPECT().SchedulePriceVersion(gomock.Any(), productID, domain.PriceVersion{
+			EffectiveFrom: effectiveFrom,
+			Price:         domain.NewMoney(1200, "EUR"),
+			Prices:        []domain.ProductPrice{{Country: "CH", Price: domain.NewMoney(1300, "CHF")}},
+		}).Return(&product, nil).Times(1),
+		appInstance.EXPECT().SchedulePriceVersion(gomock.Any(), productID, domain.PriceVersion{
+			Price: domain.NewMoney(1200, "EUR"),
+		}).Return(nil, app.InvalidArgErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app:             appInstance,
+		actorSigningKey: "actor-key",
+	}
+	router := api.setupRouter()
+	path := "/api/v1/admin/product/" + productID + "/price_version"
+
+	// success test
+	w := httptest.NewRecorder()
+	body := strings.NewReader(`{
+		"effective_from":"2030-01-01",
+		"price":"12.00",
+		"currency":"EUR",
+		"prices":[{"country":"CH","price":"13.00","currency":"CHF"}]
+	}`)
+	req := newAdminRequest(http.MethodPost, path, body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp getProductByIdResponse
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.PriceVersions), 1)
+	assert.Equal(t, resp.PriceVersions[0].Price, "12.00")
+	assert.Equal(t, resp.PriceVersions[0].EffectiveFrom.Equal(effectiveFrom), true)
+	assert.Equal(t, resp.PriceVersions[0].Prices[0].Currency, "CHF")
+
+	// price version rejected by the app
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"price":"12.00","currency":"EUR"}`)
+	req = newAdminRequest(http.MethodPost, path, body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid request bodies are rejected before calling the app
+	for _, body := range []string{
+		`{"effective_from":"next month","price":"12.00","currency":"EUR"}`,
+		`{"price":"twelve","currency":"EUR"}`,
+		`{"price":"12.00"}`,
+	} {
+		w = httptest.NewRecorder()
+		req = newAdminRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+// newAdminRequest creates request of the admin signed with the actor signing key of the tests
+func newAdminRequest(method string, path string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Set("X-Actor", "admin@test.com")
+	req.Header.Set("X-Actor-Signature", sign("actor-key", "admin@test.com"))
+	return req
 }
 
 func (suite *HandlerTestSuite) TestBuySubscription() {
@@ -160,15 +481,58 @@ func (suite *HandlerTestSuite) TestBuySubscription() {
 
 	appInstance := suite.App
 	productID := "62bc589278b49cee00f01421"
+	productIDNotPresent := "62bc59417f1271f8e9c5e1c4"
 	subscriptionID := "62bc589278b49cee00f01421"
 	emailID := "test@test.com"
 
 	gomock.InOrder(
-		appInstance.EXPECT().BuySubscription(gomock.Any(), productID, emailID).Return(&domain.UserSubscription{
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productID,
+			EmailID:      emailID,
+			PaymentToken: "tok_visa",
+		}).Return(&domain.UserSubscription{
+			ID: subscriptionID,
+		}, nil).Times(1),
+
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productID,
+			EmailID:      emailID,
+			PaymentToken: "tok_visa",
+			Currency:     "CHF",
+			Country:      "CH",
+		}).Return(&domain.UserSubscription{
 			ID: subscriptionID,
 		}, nil).Times(1),
 
-		appInstance.EXPECT().BuySubscription(gomock.Any(), "invalidid", emailID).Return(nil, app.InvalidArgErr).Times(1),
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    "invalidid",
+			EmailID:      emailID,
+			PaymentToken: "tok_visa",
+		}).Return(nil, app.InvalidArgErr).Times(1),
+
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productIDNotPresent,
+			EmailID:      emailID,
+			PaymentToken: "tok_visa",
+		}).Return(nil, app.NotFoundErr).Times(1),
+
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productID,
+			EmailID:      emailID,
+			PaymentToken: "tok_visa",
+		}).Return(nil, &app.DuplicateSubscriptionError{SubscriptionID: subscriptionID}).Times(1),
+
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productID,
+			EmailID:      emailID,
+			PaymentToken: app.FakeTokenDecline,
+		}).Return(nil, app.PaymentDeclinedErr).Times(1),
+
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    productID,
+			EmailID:      emailID,
+			PaymentToken: app.FakeTokenTimeout,
+		}).Return(nil, app.PaymentTimeoutErr).Times(1),
 	)
 
 	api := &apiDetails{
@@ -180,12 +544,38 @@ func (suite *HandlerTestSuite) TestBuySubscription() {
 	w := httptest.NewRecorder()
 	body := strings.NewReader(`{
 		"product_id":"62bc589278b49cee00f01421",
-		"email_id":"test@test.com"
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa"
 	}`)
 	req, _ := http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusCreated, w.Code)
 
+	// success test with currency and country
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa",
+		"currency":"CHF",
+		"country":"CH"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// invalid currency
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa",
+		"currency":"XYZ"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
 	// invalid input email
 	w = httptest.NewRecorder()
 	body = strings.NewReader(`{
@@ -210,11 +600,71 @@ func (suite *HandlerTestSuite) TestBuySubscription() {
 	w = httptest.NewRecorder()
 	body = strings.NewReader(`{
 		"product_id":"invalidid",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// product not found
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc59417f1271f8e9c5e1c4",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// user already has live subscription
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	resp := &duplicateSubscriptionResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.SubscriptionID, subscriptionID)
+
+	// missing payment token
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
 		"email_id":"test@test.com"
 	}`)
 	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// payment declined
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_decline"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+
+	// payment provider timed out
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_timeout"
+	}`)
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
 }
 
 func (suite *HandlerTestSuite) TestGetSubscriptionByID() {
@@ -223,10 +673,38 @@ func (suite *HandlerTestSuite) TestGetSubscriptionByID() {
 	appInstance := suite.App
 	subscriptionID := "62bc589278b49cee00f01421"
 	notFoundSubscriptionID := "62bc589278b49cee00f01421"
+	pauseStartDate := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	pauseEndDate := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
 
 	gomock.InOrder(
 		appInstance.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&domain.UserSubscription{
-			ID: subscriptionID,
+			ID:      subscriptionID,
+			Version: 3,
+			Status:  domain.SubscriptionStatusPaused,
+			Pauses: []domain.Pause{
+				{
+					StartDate: pauseStartDate,
+					EndDate:   &pauseEndDate,
+					Reason:    "holiday",
+					Actor:     "user@test.com",
+				},
+				{
+					StartDate: pauseEndDate.AddDate(0, 0, 1),
+				},
+			},
+		}, nil).Times(1),
+
+		appInstance.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&domain.UserSubscription{
+			ID:               subscriptionID,
+			Status:           domain.SubscriptionStatusPastDue,
+			NextPaymentRetry: &pauseEndDate,
+			PaymentAttempts: []domain.PaymentAttempt{
+				{
+					PeriodStart: pauseStartDate,
+					AttemptedAt: pauseStartDate,
+					Error:       "card declined",
+				},
+			},
 		}, nil).Times(1),
 
 		appInstance.EXPECT().GetSubscriptionByID(gomock.Any(), "invalidid").Return(nil, app.InvalidArgErr).Times(1),
@@ -245,6 +723,30 @@ func (suite *HandlerTestSuite) TestGetSubscriptionByID() {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 
+	resp := &getSubscriptionByIDResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.Pauses), 2)
+	assert.Equal(t, resp.Pauses[0].Reason, "holiday")
+	assert.Equal(t, resp.Pauses[0].Actor, "user@test.com")
+	assert.Equal(t, resp.PauseStartDate.Equal(pauseEndDate.AddDate(0, 0, 1)), true)
+	assert.Equal(t, resp.Version, int64(3))
+	assert.Equal(t, w.Header().Get("ETag"), `"3"`)
+
+	// success test for past due subscription
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp = &getSubscriptionByIDResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.Status, "past_due")
+	assert.Equal(t, resp.NextPaymentRetry.Equal(pauseEndDate), true)
+	assert.Equal(t, len(resp.PaymentAttempts), 1)
+	assert.Equal(t, resp.PaymentAttempts[0].Error, "card declined")
+
 	// invalid id test
 	w = httptest.NewRecorder()
 	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+"invalidid", nil)
@@ -258,6 +760,87 @@ func (suite *HandlerTestSuite) TestGetSubscriptionByID() {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func (suite *HandlerTestSuite) TestListSubscriptions() {
+	t := suite.T()
+
+	appInstance := suite.App
+	email := "test@test.com"
+	createdAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2022, 6, 30, 23, 59, 59, 999999999, time.UTC)
+	endDateFrom := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		appInstance.EXPECT().ListSubscriptions(gomock.Any(), domain.SubscriptionQuery{
+			Email:       email,
+			ProductID:   "62bc589278b49cee00f01421",
+			Statuses:    []domain.SubscriptionStatus{domain.SubscriptionStatusActive, domain.SubscriptionStatusPaused, domain.SubscriptionStatusTrialing},
+			CreatedFrom: &createdAt,
+			CreatedTo:   &createdTo,
+			EndDateFrom: &endDateFrom,
+			SortBy:      domain.SubscriptionSortByEndDate,
+			SortDesc:    true,
+			Limit:       10,
+			Cursor:      "eyJzIjoiZW5kX2RhdGUifQ",
+		}).Return(&domain.SubscriptionPage{
+			Subscriptions: []domain.UserSubscription{
+				{
+					ID:        "62bc589278b49cee00f01430",
+					Email:     email,
+					CreatedAt: createdAt,
+					Price:     domain.NewMoney(1000, "EUR"),
+					Status:    domain.SubscriptionStatusActive,
+				},
+			},
+			NextCursor: "eyJzIjoiZW5kX2RhdGUifR",
+		}, nil).Times(1),
+
+		appInstance.EXPECT().ListSubscriptions(gomock.Any(), domain.SubscriptionQuery{
+			Email: email,
+		}).Return(nil, app.InvalidArgErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app: appInstance,
+	}
+	router := api.setupRouter()
+
+	// success test
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/subscription?email=test@test.com&product_id=62bc589278b49cee00f01421"+
+		"&status=active,paused&status=trialing&created_from=2022-06-01&created_to=2022-06-30&end_date_from=2022-07-01T10:00:00Z"+
+		"&sort=end_date&order=desc&limit=10&cursor=eyJzIjoiZW5kX2RhdGUifQ", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := &listSubscriptionsResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.Subscriptions), 1)
+	assert.Equal(t, resp.Subscriptions[0].ID, "62bc589278b49cee00f01430")
+	assert.Equal(t, resp.Subscriptions[0].Price, "10.00")
+	assert.Equal(t, resp.NextCursor, "eyJzIjoiZW5kX2RhdGUifR")
+
+	// invalid query returned by app test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription?email=test@test.com", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid query params tests
+	for _, query := range []string{
+		"",
+		"?email=test@test.com&order=up",
+		"?email=test@test.com&limit=ten",
+		"?email=test@test.com&created_from=yesterday",
+		"?email=test@test.com&end_date_to=2022-13-01",
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
 func (suite *HandlerTestSuite) TestUpdateSubscriptionStatusByID() {
 	t := suite.T()
 
@@ -273,10 +856,51 @@ func (suite *HandlerTestSuite) TestUpdateSubscriptionStatusByID() {
 		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), notFoundSubscriptionID, domain.SubscriptionStatusPaused).Return(nil, app.NotFoundErr).Times(1),
 
 		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), "invalidID", domain.SubscriptionStatusPaused).Return(nil, app.InvalidArgErr).Times(1),
+
+		appInstance.EXPECT().CancelSubscriptionAtPeriodEnd(gomock.Any(), subscriptionID).Return(&domain.UserSubscription{
+			ID:                subscriptionID,
+			Status:            domain.SubscriptionStatusActive,
+			CancelAtPeriodEnd: true,
+		}, nil).Times(1),
+
+		appInstance.EXPECT().CancelSubscriptionAtPeriodEnd(gomock.Any(), subscriptionID).Return(nil, app.StatusUnchangedErr).Times(1),
+
+		appInstance.EXPECT().PauseSubscriptionByID(gomock.Any(), subscriptionID, gomock.Any(), "holiday").DoAndReturn(func(ctx context.Context, id string, resumeDate *time.Time, reason string) (*domain.UserSubscription, error) {
+			if resumeDate == nil || !resumeDate.Equal(time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)) {
+				return nil, fmt.Errorf("unexpected resume date %v", resumeDate)
+			}
+			if app.ActorFromContext(ctx) != "support@test.com" {
+				return nil, fmt.Errorf("unexpected actor %v", app.ActorFromContext(ctx))
+			}
+			return &domain.UserSubscription{
+				ID:         subscriptionID,
+				Status:     domain.SubscriptionStatusPaused,
+				ResumeDate: resumeDate,
+			}, nil
+		}).Times(1),
+
+		appInstance.EXPECT().PauseSubscriptionByID(gomock.Any(), subscriptionID, gomock.Any(), "").Return(nil, app.NotAllowedArgErr).Times(1),
+
+		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), subscriptionID, domain.SubscriptionStatusCancelled).DoAndReturn(func(ctx context.Context, id string, status domain.SubscriptionStatus) (*domain.UserSubscription, error) {
+			version, ok := app.ExpectedVersionFromContext(ctx)
+			if !ok || version != 2 {
+				return nil, fmt.Errorf("unexpected expected version %v", version)
+			}
+			return &domain.UserSubscription{
+				ID:      subscriptionID,
+				Version: 3,
+				Status:  domain.SubscriptionStatusCancelled,
+			}, nil
+		}).Times(1),
+
+		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), subscriptionID, domain.SubscriptionStatusCancelled).Return(nil, app.VersionMismatchErr).Times(1),
+
+		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), subscriptionID, domain.SubscriptionStatusCancelled).Return(nil, app.ConflictErr).Times(1),
 	)
 
 	api := &apiDetails{
-		app: appInstance,
+		app:             appInstance,
+		actorSigningKey: "actor-key",
 	}
 	router := api.setupRouter()
 
@@ -303,4 +927,679 @@ func (suite *HandlerTestSuite) TestUpdateSubscriptionStatusByID() {
 	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/invalidID"+"/changeStatus/pause", nil)
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// cancel at period end test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel?mode=period_end", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// cancel at period end for already flagged subscription test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel?mode=period_end", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// period end mode with pause status test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/pause?mode=period_end", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid mode test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel?mode=later", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// pause with resume date test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/pause?resume_date=2030-01-15&reason=holiday", nil)
+	req.Header.Set("X-Actor", "support@test.com")
+	req.Header.Set("X-Actor-Signature", sign("actor-key", "support@test.com"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// actor with invalid signature test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/pause?resume_date=2030-01-15&reason=holiday", nil)
+	req.Header.Set("X-Actor", "admin@test.com")
+	req.Header.Set("X-Actor-Signature", sign("actor-key", "support@test.com"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// pause quota used up test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/pause?resume_date=2030-01-15T00:00:00Z", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid resume date test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/pause?resume_date=tomorrow", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// resume date with cancel status test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel?resume_date=2030-01-15", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// reason with cancel status test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel?reason=holiday", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// cancel with matching If-Match test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel", nil)
+	req.Header.Set("If-Match", `"2"`)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, w.Header().Get("ETag"), `"3"`)
+
+	// cancel with If-Match not matching subscription version test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel", nil)
+	req.Header.Set("If-Match", `"1"`)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// cancel with If-Match which is not a version test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel", nil)
+	req.Header.Set("If-Match", `W/"2"`)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// cancel of concurrently changed subscription test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func (suite *HandlerTestSuite) TestChangePlan() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+	productID := "62bac25f83b5fcd9ddeb8170"
+
+	gomock.InOrder(
+		appInstance.EXPECT().ChangePlan(gomock.Any(), subscriptionID, productID, domain.PlanChangeImmediate).Return(&domain.UserSubscription{
+			ID:        subscriptionID,
+			ProductID: productID,
+		}, nil).Times(1),
+
+		appInstance.EXPECT().ChangePlan(gomock.Any(), subscriptionID, productID, domain.PlanChangeAtPeriodEnd).Return(&domain.UserSubscription{
+			ID: subscriptionID,
+			PendingPlanChange: &domain.PlanChange{
+				ToProductID: productID,
+			},
+		}, nil).Times(1),
+
+		appInstance.EXPECT().ChangePlan(gomock.Any(), subscriptionID, productID, domain.PlanChangeImmediate).Return(nil, app.NotAllowedArgErr).Times(1),
+
+		appInstance.EXPECT().ChangePlan(gomock.Any(), subscriptionID, productID, domain.PlanChangeImmediate).Return(nil, app.NotFoundErr).Times(1),
+
+		appInstance.EXPECT().ChangePlan(gomock.Any(), subscriptionID, productID, domain.PlanChangeImmediate).Return(nil, app.PaymentDeclinedErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app: appInstance,
+	}
+	router := api.setupRouter()
+
+	// success test
+	w := httptest.NewRecorder()
+	body := strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170"}`)
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// success test for period end change
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170","timing":"period_end"}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// invalid timing
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170","timing":"tomorrow"}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// missing product id
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// subscription is not active
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170"}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// subscription or product not found
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170"}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// prorated charge declined
+	w = httptest.NewRecorder()
+	body = strings.NewReader(`{"product_id":"62bac25f83b5fcd9ddeb8170"}`)
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changePlan", body)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+}
+
+func (suite *HandlerTestSuite) TestGetSubscriptionHistory() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+	createdAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		appInstance.EXPECT().GetSubscriptionHistory(gomock.Any(), subscriptionID).DoAndReturn(func(ctx context.Context, id string) ([]domain.AuditEvent, error) {
+			if app.RequestIDFromContext(ctx) != "8f14e45fceea167a" {
+				return nil, fmt.Errorf("unexpected request ID %v", app.RequestIDFromContext(ctx))
+			}
+			return []domain.AuditEvent{
+				{
+					ID:             "62bc589278b49cee00f01430",
+					SubscriptionID: subscriptionID,
+					Action:         domain.AuditActionCreated,
+					NewStatus:      domain.SubscriptionStatusActive,
+					Actor:          "anonymous",
+					RequestID:      "5d41402abc4b2a76",
+					CreatedAt:      createdAt,
+				},
+				{
+					ID:             "62bc589278b49cee00f01431",
+					SubscriptionID: subscriptionID,
+					Action:         domain.AuditActionStatusChanged,
+					PreviousStatus: domain.SubscriptionStatusActive,
+					NewStatus:      domain.SubscriptionStatusPaused,
+					Actor:          "support@test.com",
+					CreatedAt:      createdAt.AddDate(0, 0, 1),
+				},
+			}, nil
+		}).Times(1),
+
+		appInstance.EXPECT().GetSubscriptionHistory(gomock.Any(), "invalidid").Return(nil, app.InvalidArgErr).Times(1),
+
+		appInstance.EXPECT().GetSubscriptionHistory(gomock.Any(), subscriptionID).Return(nil, app.NotFoundErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app: appInstance,
+	}
+	router := api.setupRouter()
+
+	// success test
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/history", nil)
+	req.Header.Set("X-Request-ID", "8f14e45fceea167a")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, w.Header().Get("X-Request-ID"), "8f14e45fceea167a")
+
+	resp := &getSubscriptionHistoryResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.Events), 2)
+	assert.Equal(t, resp.Events[0].Action, "created")
+	assert.Equal(t, resp.Events[0].RequestID, "5d41402abc4b2a76")
+	assert.Equal(t, resp.Events[1].PreviousStatus, "active")
+	assert.Equal(t, resp.Events[1].NewStatus, "paused")
+	assert.Equal(t, resp.Events[1].Actor, "support@test.com")
+
+	// invalid id test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+"invalidid"+"/history", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, len(w.Header().Get("X-Request-ID")), 32)
+
+	// subscription not found for id test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/history", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func (suite *HandlerTestSuite) TestGetSubscriptionInvoices() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+	issuedAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	invoice := domain.Invoice{
+		ID:              "62bc589278b49cee00f01440",
+		Number:          "2022-000001",
+		Year:            2022,
+		Sequence:        1,
+		SubscriptionID:  subscriptionID,
+		Email:           "test@test.com",
+		Country:         "DE",
+		AuthorizationID: "fake_auth_1",
+		Reason:          domain.PaymentReasonPurchase,
+		Lines: []domain.InvoiceLine{
+			{
+				ProductID:   "62bac26a69c9410f916fc262",
+				ProductName: "hiphop cardio",
+				PeriodStart: issuedAt,
+				PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+				Net:         domain.NewMoney(1000, "EUR"),
+				Tax:         domain.NewMoney(190, "EUR"),
+				Gross:       domain.NewMoney(1190, "EUR"),
+				TaxRate:     19,
+			},
+		},
+		Net:      domain.NewMoney(1000, "EUR"),
+		Tax:      domain.NewMoney(190, "EUR"),
+		Total:    domain.NewMoney(1190, "EUR"),
+		IssuedAt: issuedAt,
+	}
+
+	gomock.InOrder(
+		appInstance.EXPECT().GetSubscriptionInvoices(gomock.Any(), subscriptionID).Return([]domain.Invoice{invoice}, nil).Times(1),
+		appInstance.EXPECT().GetSubscriptionInvoices(gomock.Any(), "invalidid").Return(nil, app.InvalidArgErr).Times(1),
+		appInstance.EXPECT().GetSubscriptionInvoices(gomock.Any(), subscriptionID).Return(nil, app.NotFoundErr).Times(1),
+
+		appInstance.EXPECT().GetSubscriptionInvoice(gomock.Any(), subscriptionID, invoice.Number).Return(&invoice, nil).Times(1),
+		appInstance.EXPECT().GetSubscriptionInvoice(gomock.Any(), subscriptionID, "2022-000002").Return(nil, app.NotFoundErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app: appInstance,
+	}
+	router := api.setupRouter()
+
+	// list success test
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := &getSubscriptionInvoicesResponse{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.Invoices), 1)
+	assert.Equal(t, resp.Invoices[0].Number, "2022-000001")
+	assert.Equal(t, resp.Invoices[0].Total, "11.90")
+	assert.Equal(t, resp.Invoices[0].Currency, "EUR")
+	assert.Equal(t, len(resp.Invoices[0].Lines), 1)
+	assert.Equal(t, resp.Invoices[0].Lines[0].ProductName, "hiphop cardio")
+	assert.Equal(t, resp.Invoices[0].Lines[0].Net, "10.00")
+	assert.Equal(t, resp.Invoices[0].Lines[0].Tax, "1.90")
+
+	// list invalid id test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+"invalidid"+"/invoices", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// list subscription not found for id test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// get by number success test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices/"+invoice.Number, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	invoiceResp := &invoiceResponse{}
+	err = json.Unmarshal(w.Body.Bytes(), invoiceResp)
+	assert.NilError(t, err)
+	assert.Equal(t, invoiceResp.Number, "2022-000001")
+	assert.Equal(t, invoiceResp.SubscriptionID, subscriptionID)
+	assert.Equal(t, invoiceResp.Net, "10.00")
+	assert.Equal(t, invoiceResp.Tax, "1.90")
+
+	// get by number invoice not found test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices/2022-000002", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func (suite *HandlerTestSuite) TestGetSubscriptionInvoicePDF() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+	issuedAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	invoice := domain.Invoice{
+		ID:             "62bc589278b49cee00f01440",
+		Number:         "2022-000001",
+		SubscriptionID: subscriptionID,
+		Email:          "test@test.com",
+		Lines: []domain.InvoiceLine{
+			{
+				ProductName: "hiphop cardio",
+				PeriodStart: issuedAt,
+				PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+				Net:         domain.NewMoney(1000, "EUR"),
+				Tax:         domain.NewMoney(190, "EUR"),
+				Gross:       domain.NewMoney(1190, "EUR"),
+				TaxRate:     19,
+			},
+		},
+		Net:      domain.NewMoney(1000, "EUR"),
+		Tax:      domain.NewMoney(190, "EUR"),
+		Total:    domain.NewMoney(1190, "EUR"),
+		IssuedAt: issuedAt,
+	}
+
+	gomock.InOrder(
+		appInstance.EXPECT().GetSubscriptionInvoice(gomock.Any(), subscriptionID, invoice.Number).Return(&invoice, nil).Times(1),
+		appInstance.EXPECT().GetSubscriptionInvoice(gomock.Any(), subscriptionID, "2022-000002").Return(nil, app.NotFoundErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app:             appInstance,
+		invoiceTemplate: DefaultInvoiceTemplate(),
+	}
+	router := api.setupRouter()
+
+	// success test
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices/"+invoice.Number+".pdf", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/pdf")
+	assert.Equal(t, w.Header().Get("Content-Disposition"), `inline; filename="invoice-2022-000001.pdf"`)
+	assert.Assert(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+
+	// invoice not found test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices/2022-000002.pdf", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// empty number test
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/subscription/"+subscriptionID+"/invoices/.pdf", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *HandlerTestSuite) TestIdempotencyMiddleware() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+	key := "8f14e45fceea167a"
+	buyBody := `{
+		"product_id":"62bc589278b49cee00f01421",
+		"email_id":"test@test.com",
+		"payment_token":"tok_visa"
+	}`
+
+	gomock.InOrder(
+		// first request is processed and its response is stored
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(nil, nil).Times(1),
+		appInstance.EXPECT().BuySubscription(gomock.Any(), domain.Purchase{
+			ProductID:    subscriptionID,
+			EmailID:      "test@test.com",
+			PaymentToken: "tok_visa",
+		}).Return(&domain.UserSubscription{
+			ID:      subscriptionID,
+			Version: 1,
+		}, nil).Times(1),
+		appInstance.EXPECT().FinishIdempotentRequest(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record *domain.IdempotencyRecord) error {
+			if record.Key != key || record.StatusCode != http.StatusCreated || record.Header[etagHeader] != `"1"` ||
+				!strings.Contains(string(record.Body), subscriptionID) {
+				return fmt.Errorf("unexpected idempotency record %v", record)
+			}
+			return nil
+		}).Times(1),
+
+		// retry gets the stored response
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(&domain.IdempotencyRecord{
+			Key:        key,
+			StatusCode: http.StatusCreated,
+			Header: map[string]string{
+				contentTypeHeader: "application/json; charset=utf-8",
+				etagHeader:        `"1"`,
+			},
+			Body: []byte(`{"id":"` + subscriptionID + `"}`),
+		}, nil).Times(1),
+
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(nil, app.IdempotencyKeyReusedErr).Times(1),
+
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(nil, app.ConflictErr).Times(1),
+
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(nil, app.InvalidArgErr).Times(1),
+
+		// status change is processed and its response is stored
+		appInstance.EXPECT().StartIdempotentRequest(gomock.Any(), key, gomock.Any()).Return(nil, nil).Times(1),
+		appInstance.EXPECT().UpdateSubscriptionStatusByID(gomock.Any(), subscriptionID, domain.SubscriptionStatusCancelled).Return(&domain.UserSubscription{
+			ID:      subscriptionID,
+			Version: 2,
+			Status:  domain.SubscriptionStatusCancelled,
+		}, nil).Times(1),
+		appInstance.EXPECT().FinishIdempotentRequest(gomock.Any(), gomock.Any()).Return(fmt.Errorf("db error")).Times(1),
+	)
+
+	api := &apiDetails{
+		app: appInstance,
+	}
+	router := api.setupRouter()
+
+	// first request
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/subscription", strings.NewReader(buyBody))
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, w.Header().Get(idempotentReplayedHeader), "")
+
+	// retry
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", strings.NewReader(buyBody))
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, w.Header().Get(idempotentReplayedHeader), "true")
+	assert.Equal(t, w.Header().Get(etagHeader), `"1"`)
+	assert.Equal(t, w.Header().Get(contentTypeHeader), "application/json; charset=utf-8")
+	assert.Equal(t, w.Body.String(), `{"id":"`+subscriptionID+`"}`)
+
+	// key reused with different request
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", strings.NewReader(`{}`))
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// request with the key in progress
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", strings.NewReader(buyBody))
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// invalid key
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/subscription", strings.NewReader(buyBody))
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// status change, failure to store the response does not fail the request
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, "/api/v1/subscription/"+subscriptionID+"/changeStatus/cancel", nil)
+	req.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_requestFingerprint(t *testing.T) {
+	newRequest := func(method string, target string) *http.Request {
+		req, _ := http.NewRequest(method, target, nil)
+		return req
+	}
+
+	fingerprint := requestFingerprint(newRequest(http.MethodPost, "/api/v1/subscription"), []byte(`{"a":1}`))
+	assert.Equal(t, len(fingerprint), 64)
+	assert.Equal(t, fingerprint, requestFingerprint(newRequest(http.MethodPost, "/api/v1/subscription"), []byte(`{"a":1}`)))
+	assert.Assert(t, fingerprint != requestFingerprint(newRequest(http.MethodPost, "/api/v1/subscription"), []byte(`{"a":2}`)))
+	assert.Assert(t, fingerprint != requestFingerprint(newRequest(http.MethodPost, "/api/v1/subscription?x=1"), []byte(`{"a":1}`)))
+	assert.Assert(t, fingerprint != requestFingerprint(newRequest(http.MethodPatch, "/api/v1/subscription"), []byte(`{"a":1}`)))
+}
+
+func Test_actorMiddleware(t *testing.T) {
+	serve := func(actorSigningKey string, actor string, signature string) (int, string) {
+		api := &apiDetails{actorSigningKey: actorSigningKey}
+		router := gin.New()
+		router.Use(api.actorMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, app.ActorFromContext(c.Request.Context()))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Actor", actor)
+		req.Header.Set("X-Actor-Signature", signature)
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	code, actor := serve("actor-key", "support@test.com", sign("actor-key", "support@test.com"))
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, actor, "support@test.com")
+
+	code, _ = serve("actor-key", "support@test.com", sign("other-key", "support@test.com"))
+	assert.Equal(t, code, http.StatusUnauthorized)
+
+	code, _ = serve("actor-key", "support@test.com", "")
+	assert.Equal(t, code, http.StatusUnauthorized)
+
+	code, actor = serve("actor-key", "", "")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, actor, "anonymous")
+
+	code, actor = serve("", "support@test.com", "")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, actor, "anonymous")
+}
+
+func (suite *HandlerTestSuite) TestConfirmPayment() {
+	t := suite.T()
+
+	appInstance := suite.App
+	subscriptionID := "62bc589278b49cee00f01421"
+
+	gomock.InOrder(
+		appInstance.EXPECT().ConfirmPayment(gomock.Any(), subscriptionID, "fake_auth_1", true).Return(&domain.UserSubscription{
+			ID:     subscriptionID,
+			Status: domain.SubscriptionStatusActive,
+		}, nil).Times(1),
+
+		appInstance.EXPECT().ConfirmPayment(gomock.Any(), subscriptionID, "fake_auth_1", false).Return(&domain.UserSubscription{
+			ID:     subscriptionID,
+			Status: domain.SubscriptionStatusPaymentFailed,
+		}, nil).Times(1),
+
+		appInstance.EXPECT().ConfirmPayment(gomock.Any(), subscriptionID, "fake_auth_1", true).Return(nil, app.NotAllowedArgErr).Times(1),
+
+		appInstance.EXPECT().ConfirmPayment(gomock.Any(), subscriptionID, "fake_auth_1", true).Return(nil, app.NotFoundErr).Times(1),
+
+		appInstance.EXPECT().ConfirmPayment(gomock.Any(), subscriptionID, "fake_auth_1", true).Return(nil, app.PaymentTimeoutErr).Times(1),
+	)
+
+	api := &apiDetails{
+		app:                appInstance,
+		paymentCallbackKey: "payment-key",
+	}
+	router := api.setupRouter()
+
+	// success test
+	w := httptest.NewRecorder()
+	req := newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Assert(t, strings.Contains(w.Body.String(), `"status": "active"`))
+
+	// success test for failed payment
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"failed"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Assert(t, strings.Contains(w.Body.String(), `"status": "payment_failed"`))
+
+	// unsigned callback
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// callback signed with other key
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "other-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// invalid status
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"pending"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// missing authorization id
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"status":"succeeded"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// subscription is not pending payment
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// subscription not found
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// payment provider timed out
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "payment-key")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	// callback is rejected if the payment callback key is not configured
+	api.paymentCallbackKey = ""
+	router = api.setupRouter()
+	w = httptest.NewRecorder()
+	req = newPaymentRequest(subscriptionID, `{"authorization_id":"fake_auth_1","status":"succeeded"}`, "")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// newPaymentRequest creates payment callback request of the subscription signed with the key, unsigned if the key is empty
+func newPaymentRequest(subscriptionID string, body string, key string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/subscription/"+subscriptionID+"/payment", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-Payment-Signature", sign(key, body))
+	}
+	return req
 }
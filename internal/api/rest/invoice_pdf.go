@@ -0,0 +1,218 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfSuffix      = ".pdf"
+	pdfContentType = "application/pdf"
+	pdfFont        = "Helvetica"
+)
+
+// InvoiceTemplate is the layout of the invoice PDF, seller details are printed in the header and the footer at the bottom of every page
+// Title is the title of the invoice and CreditNoteTitle is the title of the credit note of the refund
+// labels are the captions of the invoice fields, so the invoice can be translated, dates are formatted with DateFormat (Go time layout)
+type InvoiceTemplate struct {
+	Title           string        `json:"title"`
+	CreditNoteTitle string        `json:"credit_note_title"`
+	SellerName      string        `json:"seller_name"`
+	SellerAddress   []string      `json:"seller_address"`
+	SellerEmail     string        `json:"seller_email"`
+	SellerTaxID     string        `json:"seller_tax_id"`
+	DateFormat      string        `json:"date_format"`
+	Labels          InvoiceLabels `json:"labels"`
+	Footer          string        `json:"footer"`
+}
+
+// InvoiceLabels are the captions of the invoice PDF fields
+type InvoiceLabels struct {
+	Number    string `json:"number"`
+	IssueDate string `json:"issue_date"`
+	TaxID     string `json:"tax_id"`
+	BillTo    string `json:"bill_to"`
+	Product   string `json:"product"`
+	Period    string `json:"period"`
+	Net       string `json:"net"`
+	TaxRate   string `json:"tax_rate"`
+	Tax       string `json:"tax"`
+	Gross     string `json:"gross"`
+	Total     string `json:"total"`
+}
+
+// DefaultInvoiceTemplate returns the template used if no template file is configured
+func DefaultInvoiceTemplate() InvoiceTemplate {
+	return InvoiceTemplate{
+		Title:           "Invoice",
+		CreditNoteTitle: "Credit note",
+		SellerName:      "Gymondo GmbH",
+		DateFormat:      "2006-01-02",
+		Labels: InvoiceLabels{
+			Number:    "Invoice number",
+			IssueDate: "Issue date",
+			TaxID:     "VAT ID",
+			BillTo:    "Bill to",
+			Product:   "Product",
+			Period:    "Billing period",
+			Net:       "Net",
+			TaxRate:   "Tax rate",
+			Tax:       "Tax",
+			Gross:     "Gross",
+			Total:     "Total",
+		},
+		Footer: "Thank you for training with us.",
+	}
+}
+
+// LoadInvoiceTemplate returns the template of the JSON file at given path, fields missing in the file are taken from the default template
+// returns the default template if path is empty, otherwise error if the file cannot be read or is not valid JSON
+func LoadInvoiceTemplate(path string) (InvoiceTemplate, error) {
+	template := DefaultInvoiceTemplate()
+	if path == "" {
+		return template, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return template, fmt.Errorf("invoice template: %w", err)
+	}
+
+	err = json.Unmarshal(data, &template)
+	if err != nil {
+		return template, fmt.Errorf("invoice template %v: %w", path, err)
+	}
+	return template, nil
+}
+
+// renderInvoicePDF renders the invoice as PDF document of the template
+func renderInvoicePDF(template InvoiceTemplate, invoice *domain.Invoice) ([]byte, error) {
+	pdf := newInvoicePDF(template, invoice)
+
+	buf := bytes.Buffer{}
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newInvoicePDF lays out the invoice PDF document with seller details, customer, line items and totals
+// the document dates are the invoice issue time, so the same invoice is always rendered the same
+func newInvoicePDF(template InvoiceTemplate, invoice *domain.Invoice) *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	// core fonts are cp1252 encoded, the UTF-8 text is translated e.g. umlauts of the address
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	labels := template.Labels
+	title := template.Title
+	if invoice.Type == domain.InvoiceTypeCreditNote {
+		title = template.CreditNoteTitle
+	}
+
+	pdf.SetTitle(title+" "+invoice.Number, true)
+	pdf.SetAuthor(template.SellerName, true)
+	pdf.SetCreationDate(invoice.IssuedAt)
+	pdf.SetModificationDate(invoice.IssuedAt)
+	// the resource catalogs are kept in maps, they are sorted so the document bytes do not depend on the map order
+	pdf.SetCatalogSort(true)
+	pdf.SetAutoPageBreak(true, 25)
+	pdf.SetFooterFunc(func() {
+		if template.Footer == "" {
+			return
+		}
+		pdf.SetY(-20)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.MultiCell(0, 4, tr(template.Footer), "", "C", false)
+	})
+	pdf.AddPage()
+
+	// seller details
+	pdf.SetFont(pdfFont, "B", 14)
+	pdf.CellFormat(0, 7, tr(template.SellerName), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 9)
+	for _, v := range template.SellerAddress {
+		pdf.CellFormat(0, 4.5, tr(v), "", 1, "L", false, 0, "")
+	}
+	if template.SellerEmail != "" {
+		pdf.CellFormat(0, 4.5, tr(template.SellerEmail), "", 1, "L", false, 0, "")
+	}
+	if template.SellerTaxID != "" {
+		pdf.CellFormat(0, 4.5, tr(labels.TaxID+": "+template.SellerTaxID), "", 1, "L", false, 0, "")
+	}
+
+	// invoice details
+	pdf.Ln(10)
+	pdf.SetFont(pdfFont, "B", 18)
+	pdf.CellFormat(0, 10, tr(title), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(40, 5, tr(labels.Number), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 5, invoice.Number, "", 1, "L", false, 0, "")
+	pdf.CellFormat(40, 5, tr(labels.IssueDate), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 5, invoice.IssuedAt.Format(template.DateFormat), "", 1, "L", false, 0, "")
+
+	// customer
+	pdf.Ln(6)
+	pdf.SetFont(pdfFont, "B", 10)
+	pdf.CellFormat(0, 5, tr(labels.BillTo), "", 1, "L", false, 0, "")
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(0, 5, tr(invoice.Email), "", 1, "L", false, 0, "")
+	if invoice.Country != "" {
+		pdf.CellFormat(0, 5, invoice.Country, "", 1, "L", false, 0, "")
+	}
+
+	// line items
+	pdf.Ln(8)
+	widths := []float64{56, 50, 22, 18, 20, 24}
+	pdf.SetFont(pdfFont, "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for i, v := range []string{labels.Product, labels.Period, labels.Net, labels.TaxRate, labels.Tax, labels.Gross} {
+		align := "R"
+		if i < 2 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, tr(v), "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(pdfFont, "", 9)
+	for _, v := range invoice.Lines {
+		period := v.PeriodStart.Format(template.DateFormat) + " - " + v.PeriodEnd.Format(template.DateFormat)
+		pdf.CellFormat(widths[0], 7, tr(v.ProductName), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 7, period, "B", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 7, formatPDFMoney(v.Net), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, strconv.FormatFloat(v.TaxRate, 'f', -1, 64)+" %", "B", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, formatPDFMoney(v.Tax), "B", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 7, formatPDFMoney(v.Gross), "B", 1, "R", false, 0, "")
+	}
+
+	// totals
+	pdf.Ln(4)
+	totals := []struct {
+		label string
+		value domain.Money
+	}{
+		{label: labels.Net, value: invoice.Net},
+		{label: labels.Tax, value: invoice.Tax},
+		{label: labels.Total, value: invoice.Total},
+	}
+	for i, v := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont(pdfFont, "B", 10)
+		}
+		pdf.CellFormat(146, 6, tr(v.label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(44, 6, formatPDFMoney(v.value), "", 1, "R", false, 0, "")
+	}
+
+	return pdf
+}
+
+// formatPDFMoney returns the money as decimal amount followed by the currency code e.g. 10.00 EUR
+func formatPDFMoney(m domain.Money) string {
+	return m.String() + " " + m.Currency
+}
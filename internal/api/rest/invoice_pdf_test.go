@@ -0,0 +1,176 @@
+package rest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+func TestLoadInvoiceTemplate(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "invoice.json")
+	err := os.WriteFile(templatePath, []byte(`{
+		"title": "Rechnung",
+		"seller_name": "Fitness AG",
+		"seller_address": ["Hauptstraße 1", "10115 Berlin"],
+		"labels": {"total": "Gesamt"}
+	}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.json")
+	err = os.WriteFile(invalidPath, []byte(`{"title":`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultInvoiceTemplate()
+	want.Title = "Rechnung"
+	want.SellerName = "Fitness AG"
+	want.SellerAddress = []string{"Hauptstraße 1", "10115 Berlin"}
+	want.Labels.Total = "Gesamt"
+
+	tests := []struct {
+		name    string
+		path    string
+		want    InvoiceTemplate
+		wantErr bool
+	}{
+		{
+			name: "should return default template for empty path",
+			want: DefaultInvoiceTemplate(),
+		},
+		{
+			name: "should return template of the file with defaults for missing fields",
+			path: templatePath,
+			want: want,
+		},
+		{
+			name:    "should return error if file does not exist",
+			path:    filepath.Join(dir, "missing.json"),
+			wantErr: true,
+		},
+		{
+			name:    "should return error if file is not valid JSON",
+			path:    invalidPath,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadInvoiceTemplate(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadInvoiceTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadInvoiceTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_renderInvoicePDF(t *testing.T) {
+	issuedAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	invoice := &domain.Invoice{
+		Number:         "2022-000001",
+		SubscriptionID: "62bc589278b49cee00f01421",
+		Email:          "test@test.com",
+		Country:        "DE",
+		Lines: []domain.InvoiceLine{
+			{
+				ProductName: "hiphop cardio",
+				PeriodStart: issuedAt,
+				PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+				Net:         domain.NewMoney(1000, "EUR"),
+				Tax:         domain.NewMoney(190, "EUR"),
+				Gross:       domain.NewMoney(1190, "EUR"),
+				TaxRate:     19,
+			},
+		},
+		Net:      domain.NewMoney(1000, "EUR"),
+		Tax:      domain.NewMoney(190, "EUR"),
+		Total:    domain.NewMoney(1190, "EUR"),
+		IssuedAt: issuedAt,
+	}
+	template := DefaultInvoiceTemplate()
+	template.SellerAddress = []string{"Hauptstraße 1", "10115 Berlin"}
+	template.SellerTaxID = "DE123456789"
+
+	got, err := renderInvoicePDF(template, invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(got, []byte("%PDF-")) {
+		t.Errorf("renderInvoicePDF() = %q, want PDF document", got[:16])
+	}
+
+	again, err := renderInvoicePDF(template, invoice)
+	if err != nil || !bytes.Equal(got, again) {
+		t.Errorf("renderInvoicePDF() is not the same for the same invoice, error = %v", err)
+	}
+
+	// page content is not compressed, so its text can be checked
+	pdf := newInvoicePDF(template, invoice)
+	pdf.SetCompression(false)
+	buf := bytes.Buffer{}
+	err = pdf.Output(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		"Gymondo GmbH", "10115 Berlin", "VAT ID: DE123456789", "2022-000001", "test@test.com", "hiphop cardio",
+		"2022-06-01 - 2022-07-01", "10.00 EUR", "19 %", "1.90 EUR", "11.90 EUR", template.Footer,
+	} {
+		if !strings.Contains(buf.String(), "("+v+")") {
+			t.Errorf("newInvoicePDF() content does not contain %v", v)
+		}
+	}
+}
+
+func Test_newInvoicePDF_CreditNote(t *testing.T) {
+	issuedAt := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	invoice := &domain.Invoice{
+		Type:   domain.InvoiceTypeCreditNote,
+		Number: "2022-000002",
+		Email:  "test@test.com",
+		Lines: []domain.InvoiceLine{
+			{
+				ProductName: "get in shape",
+				PeriodStart: issuedAt,
+				PeriodEnd:   issuedAt.AddDate(0, 0, 16),
+				Net:         domain.NewMoney(-420, "EUR"),
+				Tax:         domain.NewMoney(-80, "EUR"),
+				Gross:       domain.NewMoney(-500, "EUR"),
+				TaxRate:     19,
+			},
+		},
+		Net:      domain.NewMoney(-420, "EUR"),
+		Tax:      domain.NewMoney(-80, "EUR"),
+		Total:    domain.NewMoney(-500, "EUR"),
+		IssuedAt: issuedAt,
+	}
+	template := DefaultInvoiceTemplate()
+
+	pdf := newInvoicePDF(template, invoice)
+	pdf.SetCompression(false)
+	buf := bytes.Buffer{}
+	err := pdf.Output(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{template.CreditNoteTitle, "2022-000002", "-4.20 EUR", "-0.80 EUR", "-5.00 EUR"} {
+		if !strings.Contains(buf.String(), "("+v+")") {
+			t.Errorf("newInvoicePDF() content does not contain %v", v)
+		}
+	}
+	if strings.Contains(buf.String(), "("+template.Title+")") {
+		t.Errorf("newInvoicePDF() content contains invoice title %v, want credit note title", template.Title)
+	}
+}
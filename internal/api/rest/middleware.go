@@ -0,0 +1,261 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/app"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	actorHeader              = "X-Actor"
+	actorSignatureHeader     = "X-Actor-Signature"
+	paymentSignatureHeader   = "X-Payment-Signature"
+	anonymousActor           = "anonymous"
+	requestIDHeader          = "X-Request-ID"
+	ifMatchHeader            = "If-Match"
+	etagHeader               = "ETag"
+	contentTypeHeader        = "Content-Type"
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers which are stored and replayed with the response of the idempotent request
+var replayedHeaders = []string{contentTypeHeader, etagHeader}
+
+// actorMiddleware adds actor from the X-Actor header to the request context, the header is trusted only if
+// X-Actor-Signature header has its HMAC-SHA256 signature with the actor signing key, so only the gateway
+// which authenticated the caller can set the actor, request with invalid signature is rejected as unauthorized
+// anonymous actor is used if header is not set or the actor signing key is not configured
+func (api *apiDetails) actorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := c.GetHeader(actorHeader)
+		if actor == "" || api.actorSigningKey == "" {
+			actor = anonymousActor
+		} else if !validSignature(api.actorSigningKey, actor, c.GetHeader(actorSignatureHeader)) {
+			createErrorResponse(c, http.StatusUnauthorized, fmt.Sprintf("%v does not match %v", actorSignatureHeader, actorHeader))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(app.ContextWithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// verifiedActorMiddleware rejects the request of the anonymous actor as unauthorized, so only the callers
+// authenticated by the gateway with signed X-Actor header reach the admin APIs, see actorMiddleware
+func verifiedActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if app.ActorFromContext(c.Request.Context()) == anonymousActor {
+			createErrorResponse(c, http.StatusUnauthorized, fmt.Sprintf("admin API requires %v signed with %v", actorHeader, actorSignatureHeader))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// paymentSignatureMiddleware accepts the payment provider callback only if X-Payment-Signature header has
+// the HMAC-SHA256 signature of the request body with the payment callback key, so only the provider can
+// confirm or fail the payment, request with missing or invalid signature is rejected as unauthorized
+// every callback is rejected if the payment callback key is not configured
+func (api *apiDetails) paymentSignatureMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				createErrorResponse(c, http.StatusBadRequest, err.Error())
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if api.paymentCallbackKey == "" || !validSignature(api.paymentCallbackKey, string(body), c.GetHeader(paymentSignatureHeader)) {
+			createErrorResponse(c, http.StatusUnauthorized, fmt.Sprintf("%v does not match request body", paymentSignatureHeader))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// sign returns hex encoded HMAC-SHA256 signature of the message with the key
+func sign(key string, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature returns true if the hex encoded signature is the signature of the message with the key
+func validSignature(key string, message string, signature string) bool {
+	return hmac.Equal([]byte(sign(key, message)), []byte(strings.ToLower(signature)))
+}
+
+// requestIDMiddleware adds request ID from the X-Request-ID header to the request context and the response header,
+// random request ID is generated if header is not set
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header(requestIDHeader, requestID)
+		c.Request = c.Request.WithContext(app.ContextWithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// newRequestID returns random 16 bytes hex encoded request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ifMatchMiddleware adds subscription version of the If-Match header ETag to the request context,
+// the change is rejected by the app if the subscription has a different version
+// any version matches if header is not set or is *, ETag which is not a version does not match any version
+func ifMatchMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		etag := c.GetHeader(ifMatchHeader)
+		if etag == "" || etag == "*" {
+			c.Next()
+			return
+		}
+
+		version, err := parseETag(etag)
+		if err != nil {
+			createErrorResponse(c, http.StatusPreconditionFailed, fmt.Sprintf("%v %v does not match subscription version", ifMatchHeader, etag))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(app.ContextWithExpectedVersion(c.Request.Context(), version))
+		c.Next()
+	}
+}
+
+// createETag returns strong ETag of the subscription version
+func createETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseETag returns subscription version of the strong ETag, returns error if ETag is not a version
+func parseETag(etag string) (int64, error) {
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return 0, fmt.Errorf("invalid etag %v", etag)
+	}
+	return strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+}
+
+// idempotencyMiddleware processes the request with the Idempotency-Key header only once,
+// retries of the request with the same key get the stored response of the first request
+// the key cannot be reused with a different request (method, path, query and body),
+// request without the header is processed as usual
+func (api *apiDetails) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				createErrorResponse(c, http.StatusBadRequest, err.Error())
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		record, err := api.app.StartIdempotentRequest(c, key, requestFingerprint(c.Request, body))
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, app.InvalidArgErr):
+				statusCode = http.StatusBadRequest
+			case errors.Is(err, app.IdempotencyKeyReusedErr):
+				statusCode = http.StatusUnprocessableEntity
+			case errors.Is(err, app.ConflictErr):
+				statusCode = http.StatusConflict
+			}
+			createErrorResponse(c, statusCode, err.Error())
+			c.Abort()
+			return
+		}
+
+		if record != nil {
+			for k, v := range record.Header {
+				c.Header(k, v)
+			}
+			c.Header(idempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, record.Header[contentTypeHeader], record.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		header := map[string]string{}
+		for _, v := range replayedHeaders {
+			if value := writer.Header().Get(v); value != "" {
+				header[v] = value
+			}
+		}
+		err = api.app.FinishIdempotentRequest(c, &domain.IdempotencyRecord{
+			Key:        key,
+			StatusCode: writer.Status(),
+			Header:     header,
+			Body:       writer.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("response of the request with idempotency key %v is not stored: %v", key, err)
+		}
+	}
+}
+
+// requestFingerprint returns hex encoded SHA-256 hash of the request method, path, query and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%v %v?%v\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body written by the handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements io.Writer
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString implements io.StringWriter
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
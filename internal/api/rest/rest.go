@@ -17,11 +17,18 @@ const (
 )
 
 type apiDetails struct {
-	app    app.App
-	server *http.Server
+	app                app.App
+	invoiceTemplate    InvoiceTemplate
+	actorSigningKey    string
+	paymentCallbackKey string
+	server             *http.Server
 }
 
-func NewApi(a app.App, port string) (api.Api, error) {
+// NewApi creates new rest api instance, invoice template is the layout of the invoice PDF
+// actor signing key verifies the X-Actor header, the header is ignored if the key is empty
+// payment callback key verifies the payment provider callback, the callback is rejected if the key is empty
+// returns error if app is nil, port is empty or the template has no seller name
+func NewApi(a app.App, port string, invoiceTemplate InvoiceTemplate, actorSigningKey string, paymentCallbackKey string) (api.Api, error) {
 	if a == nil {
 		return nil, fmt.Errorf(nilArgErr, "app")
 	}
@@ -30,8 +37,15 @@ func NewApi(a app.App, port string) (api.Api, error) {
 		return nil, fmt.Errorf(emptyArgErr, "port")
 	}
 
+	if invoiceTemplate.SellerName == "" {
+		return nil, fmt.Errorf(emptyArgErr, "invoice seller name")
+	}
+
 	api := &apiDetails{
-		app: a,
+		app:                a,
+		invoiceTemplate:    invoiceTemplate,
+		actorSigningKey:    actorSigningKey,
+		paymentCallbackKey: paymentCallbackKey,
 	}
 
 	router := api.setupRouter()
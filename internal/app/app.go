@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
@@ -16,6 +17,10 @@ var (
 	NotFoundErr        = errors.New("not found")
 	NotAllowedArgErr   = errors.New("not allowed")
 	StatusUnchangedErr = errors.New("status is unchanged")
+	ConflictErr        = errors.New("conflict")
+	VersionMismatchErr = errors.New("version mismatch")
+
+	IdempotencyKeyReusedErr = errors.New("reused with different request")
 )
 
 // App interface which consists of business logic/use cases
@@ -23,23 +28,85 @@ var (
 //go:generate mockgen -destination=../mocks/mock_app.go -package=mocks github.com/ganeshdipdumbare/gymondo-subscription/internal/app App
 type App interface {
 	GetProduct(ctx context.Context, id string) ([]domain.Product, error)
-	BuySubscription(ctx context.Context, productID string, emailID string) (*domain.UserSubscription, error)
+	ListProducts(ctx context.Context, query domain.ProductQuery) (*domain.ProductPage, error)
+	CreateProduct(ctx context.Context, product domain.Product) (*domain.Product, error)
+	UpdateProduct(ctx context.Context, product domain.Product) (*domain.Product, error)
+	ArchiveProduct(ctx context.Context, id string) (*domain.Product, error)
+	RestoreProduct(ctx context.Context, id string) (*domain.Product, error)
+	SchedulePriceVersion(ctx context.Context, productID string, version domain.PriceVersion) (*domain.Product, error)
+	BuySubscription(ctx context.Context, purchase domain.Purchase) (*domain.UserSubscription, error)
 	GetSubscriptionByID(ctx context.Context, id string) (*domain.UserSubscription, error)
+	ListSubscriptions(ctx context.Context, query domain.SubscriptionQuery) (*domain.SubscriptionPage, error)
 	UpdateSubscriptionStatusByID(ctx context.Context, id string, status domain.SubscriptionStatus) (*domain.UserSubscription, error)
+	CancelSubscriptionAtPeriodEnd(ctx context.Context, id string) (*domain.UserSubscription, error)
+	PauseSubscriptionByID(ctx context.Context, id string, resumeDate *time.Time, reason string) (*domain.UserSubscription, error)
+	ResumeSubscriptions(ctx context.Context, at time.Time) (int, error)
+	RenewSubscriptions(ctx context.Context, at time.Time) (int, error)
+	RetryPayments(ctx context.Context, at time.Time) (int, error)
+	ChangePlan(ctx context.Context, id string, productID string, timing domain.PlanChangeTiming) (*domain.UserSubscription, error)
+	ConfirmPayment(ctx context.Context, id string, authorizationID string, succeeded bool) (*domain.UserSubscription, error)
+	GetSubscriptionHistory(ctx context.Context, id string) ([]domain.AuditEvent, error)
+	GetSubscriptionInvoices(ctx context.Context, id string) ([]domain.Invoice, error)
+	GetSubscriptionInvoice(ctx context.Context, id string, number string) (*domain.Invoice, error)
+	IssuePendingInvoices(ctx context.Context) (int, error)
+	RecordPendingAuditEvents(ctx context.Context) (int, error)
+	StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*domain.IdempotencyRecord, error)
+	FinishIdempotentRequest(ctx context.Context, record *domain.IdempotencyRecord) error
 }
 
 type appDetails struct {
-	database db.DB
+	database        db.DB
+	taxCalculator   TaxCalculator
+	uniquenessRule  UniquenessRule
+	idempotencyTTL  time.Duration
+	renewalPricing  RenewalPricing
+	paymentProvider PaymentProvider
+	dunningPolicy   DunningPolicy
 }
 
 // NewApp creates new app instance
-func NewApp(database db.DB) (App, error) {
+// uniqueness rule limits live subscriptions of the user, empty rule allows any number of them
+// idempotency TTL is how long the response of the request with idempotency key is replayed for its retries
+// renewal pricing tells if the subscription is renewed with its grandfathered price or the current product price
+// payment provider charges the purchase, renewal and plan change of the subscription
+// dunning policy tells how the declined renewal charge is retried before the subscription is ended
+func NewApp(database db.DB, taxCalculator TaxCalculator, uniquenessRule UniquenessRule, idempotencyTTL time.Duration, renewalPricing RenewalPricing, paymentProvider PaymentProvider, dunningPolicy DunningPolicy) (App, error) {
 	if database == nil {
 		return nil, fmt.Errorf("database %w", NilArgErr)
 	}
 
+	if taxCalculator == nil {
+		return nil, fmt.Errorf("tax calculator %w", NilArgErr)
+	}
+
+	if !uniquenessRule.IsValid() {
+		return nil, fmt.Errorf("uniqueness rule %v %w", uniquenessRule, InvalidArgErr)
+	}
+
+	if idempotencyTTL <= 0 {
+		return nil, fmt.Errorf("idempotency ttl %v %w", idempotencyTTL, InvalidArgErr)
+	}
+
+	if !renewalPricing.IsValid() {
+		return nil, fmt.Errorf("renewal pricing %v %w", renewalPricing, InvalidArgErr)
+	}
+
+	if paymentProvider == nil {
+		return nil, fmt.Errorf("payment provider %w", NilArgErr)
+	}
+
+	if !dunningPolicy.IsValid() {
+		return nil, fmt.Errorf("dunning policy %v %w", dunningPolicy, InvalidArgErr)
+	}
+
 	return &appDetails{
-		database: database,
+		database:        database,
+		taxCalculator:   taxCalculator,
+		uniquenessRule:  uniquenessRule,
+		idempotencyTTL:  idempotencyTTL,
+		renewalPricing:  renewalPricing,
+		paymentProvider: paymentProvider,
+		dunningPolicy:   dunningPolicy,
 	}, nil
 }
 
@@ -60,35 +127,148 @@ func (a *appDetails) GetProduct(ctx context.Context, id string) ([]domain.Produc
 }
 
 // BuySubscription subscription for given user id will be created for given product id
-// returns invalid argument error if productID or emailID is empty
-func (a *appDetails) BuySubscription(ctx context.Context, productID string, emailID string) (*domain.UserSubscription, error) {
-	if productID == "" || emailID == "" {
+// price matching the currency/country is selected from the product price version effective at purchase time
+// and the price version is stored with the subscription
+// and tax is calculated by the tax calculator for the country
+// if the product has a trial and the user did not have a trial for the product before,
+// subscription starts with a free trial and is converted to active at the trial end by the renewal
+// the price is authorized with the payment token by the payment provider and the subscription waits in pending payment status
+// for the payment confirmation, see ConfirmPayment, trial is started right away and charged by the renewal at its end
+// returns invalid argument error if productID, emailID or payment token is empty or price is not available for the currency/country
+// returns payment declined or payment timeout error if the authorization fails
+// returns not allowed error if the product is archived
+// returns DuplicateSubscriptionError if the user already has a live subscription not allowed by the uniqueness rule
+// the email is normalized before the checks, so the trial and uniqueness rules do not depend on its case
+func (a *appDetails) BuySubscription(ctx context.Context, purchase domain.Purchase) (*domain.UserSubscription, error) {
+	purchase.EmailID = normalizeEmail(purchase.EmailID)
+	if purchase.ProductID == "" || purchase.EmailID == "" || purchase.PaymentToken == "" {
 		return nil, InvalidArgErr
 	}
 
-	records, err := a.GetProduct(ctx, productID)
+	records, err := a.GetProduct(ctx, purchase.ProductID)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(records) == 0 {
-		return nil, fmt.Errorf("%v %w", productID, NotFoundErr)
+		return nil, fmt.Errorf("%v %w", purchase.ProductID, NotFoundErr)
 	}
 
 	product := records[0]
+	if product.IsArchived() {
+		return nil, fmt.Errorf("product %v is archived %w", product.ID, NotAllowedArgErr)
+	}
+
+	existing, err := a.findLiveSubscription(ctx, purchase.EmailID, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, &DuplicateSubscriptionError{SubscriptionID: existing.ID}
+	}
+
 	timeNow := time.Now().UTC()
+	tax, priceVersionID, err := a.productPrice(ctx, &product, purchase.Currency, purchase.Country, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
 	userSubscription := &domain.UserSubscription{
-		CreatedAt:   timeNow,
-		Email:       emailID,
-		ProductName: product.Name,
-		StartDate:   timeNow,
-		EndDate:     timeNow.AddDate(0, int(product.SubscriptionPeriod), 0),
-		Price:       product.Price,
-		Status:      domain.SubscriptionStatusActive,
-		Tax:         product.Price * product.TaxPercentage / 100,
+		CreatedAt:      timeNow,
+		Email:          purchase.EmailID,
+		Country:        strings.ToUpper(purchase.Country),
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		StartDate:      timeNow,
+		EndDate:        timeNow.AddDate(0, int(product.SubscriptionPeriod), 0),
+		Price:          tax.Gross,
+		NetPrice:       tax.Net,
+		Tax:            tax.Tax,
+		TaxRate:        tax.Rate,
+		PriceVersionID: priceVersionID,
+		Status:         domain.SubscriptionStatusActive,
+		AutoRenew:      true,
+		PaymentToken:   purchase.PaymentToken,
+	}
+
+	trialAllowed, err := a.isTrialAllowed(ctx, &product, purchase.EmailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if trialAllowed {
+		trialEndDate := timeNow.AddDate(0, 0, int(product.TrialDays))
+		userSubscription.Status = domain.SubscriptionStatusTrialing
+		userSubscription.EndDate = trialEndDate
+		userSubscription.TrialEndDate = &trialEndDate
+	}
+
+	if !trialAllowed {
+		userSubscription.PendingPayment, err = a.authorizePayment(ctx, purchase.PaymentToken, userSubscription.Price, domain.PaymentReasonPurchase, timeNow)
+		if err != nil {
+			return nil, err
+		}
+		if userSubscription.PendingPayment != nil {
+			userSubscription.Status = domain.SubscriptionStatusPendingPayment
+		}
+	}
+
+	savedSubscription, err := a.saveSubscription(ctx, domain.AuditActionCreated, "", userSubscription)
+	if err != nil {
+		a.voidPayment(ctx, userSubscription.PendingPayment)
+		if errors.Is(err, ConflictErr) {
+			// concurrent purchase created the live subscription after the check above
+			existing, findErr := a.findLiveSubscription(ctx, purchase.EmailID, product.ID)
+			if findErr == nil && existing != nil {
+				return nil, &DuplicateSubscriptionError{SubscriptionID: existing.ID}
+			}
+		}
+		return nil, err
 	}
 
-	return a.database.SaveSubscription(ctx, userSubscription)
+	return savedSubscription, nil
+}
+
+// productPrice returns tax breakdown of the product price for the currency/country at given time
+// together with ID of the price version effective at the time
+// returns invalid argument error if price is not available for the currency/country
+func (a *appDetails) productPrice(ctx context.Context, product *domain.Product, currency string, country string, at time.Time) (*domain.TaxBreakdown, string, error) {
+	version := product.PriceVersionAt(at)
+	price, ok := version.PriceFor(currency, country)
+	if !ok {
+		return nil, "", fmt.Errorf("price not available for currency %v country %v %w", currency, country, InvalidArgErr)
+	}
+
+	tax, err := a.taxCalculator.Calculate(ctx, product, price, country, at)
+	if err != nil {
+		return nil, "", err
+	}
+	return tax, version.ID, nil
+}
+
+// normalizeEmail returns the email trimmed and lowercased
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// isTrialAllowed returns true if the product has a trial and the user did not have trial for the product yet
+func (a *appDetails) isTrialAllowed(ctx context.Context, product *domain.Product, emailID string) (bool, error) {
+	if product.TrialDays == 0 {
+		return false, nil
+	}
+
+	trials, err := a.database.FindSubscriptions(ctx, db.SubscriptionFilter{
+		Email:     emailID,
+		ProductID: product.ID,
+		HadTrial:  true,
+		Limit:     1,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(trials) == 0, nil
 }
 
 // GetSubscriptionByID return subscription for given subscription id
@@ -113,7 +293,13 @@ func (a *appDetails) GetSubscriptionByID(ctx context.Context, id string) (*domai
 // UpdateSubscriptionStatusByID update subscription status by id
 // status can be changed from active to cancelled or paused
 // paused subscription can be unpaused/active or cancelled
-// cancelled subscription status cannot be changed
+// pausing is limited by the pause rules of the product, see PauseSubscriptionByID
+// trialing and past due subscription can only be cancelled
+// pending payment subscription can only be cancelled, its pending payment is voided
+// changing status to active undoes the cancellation at period end of the active or trialing subscription,
+// a paused subscription is resumed and keeps its cancellation at period end, like the automatic resume,
+// the cancellation is undone by another change to active of the resumed subscription
+// cancelled, expired or payment failed subscription status cannot be changed
 func (a *appDetails) UpdateSubscriptionStatusByID(ctx context.Context, id string, status domain.SubscriptionStatus) (*domain.UserSubscription, error) {
 	if id == "" {
 		return nil, InvalidArgErr
@@ -129,30 +315,90 @@ func (a *appDetails) UpdateSubscriptionStatusByID(ctx context.Context, id string
 		}
 	}
 
+	timeNow := time.Now().UTC()
+	isRunning := subscriptionDetails.Status == domain.SubscriptionStatusActive || subscriptionDetails.Status == domain.SubscriptionStatusTrialing
+	if status == domain.SubscriptionStatusActive && subscriptionDetails.CancelAtPeriodEnd && isRunning {
+		updatedSubscriptionDetails := *subscriptionDetails
+		updatedSubscriptionDetails.CancelAtPeriodEnd = false
+		updatedSubscriptionDetails.UpdatedAt = &timeNow
+		return a.saveSubscription(ctx, domain.AuditActionCancelUndone, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	}
+
 	// check if the status is being changed
 	if status == subscriptionDetails.Status {
 		return nil, StatusUnchangedErr
 	}
 
-	timeNow := time.Now().UTC()
 	updatedSubscriptionDetails := *subscriptionDetails
 	updatedSubscriptionDetails.Status = status
 	updatedSubscriptionDetails.UpdatedAt = &timeNow
 
 	// check subscription's current status
 	switch subscriptionDetails.Status {
-	case domain.SubscriptionStatusCancelled:
-		return nil, fmt.Errorf("cancelled subscription status change %w", NotAllowedArgErr)
+	case domain.SubscriptionStatusCancelled, domain.SubscriptionStatusExpired, domain.SubscriptionStatusPaymentFailed:
+		return nil, fmt.Errorf("%v subscription status change %w", subscriptionDetails.Status, NotAllowedArgErr)
+	case domain.SubscriptionStatusPendingPayment:
+		if status != domain.SubscriptionStatusCancelled {
+			return nil, fmt.Errorf("pending payment subscription status change to %v %w", status, NotAllowedArgErr)
+		}
+		updatedSubscriptionDetails.PendingPayment = nil
+	case domain.SubscriptionStatusTrialing:
+		if status != domain.SubscriptionStatusCancelled {
+			return nil, fmt.Errorf("trialing subscription status change to %v %w", status, NotAllowedArgErr)
+		}
+	case domain.SubscriptionStatusPastDue:
+		if status != domain.SubscriptionStatusCancelled {
+			return nil, fmt.Errorf("past due subscription status change to %v %w", status, NotAllowedArgErr)
+		}
+		updatedSubscriptionDetails.NextPaymentRetry = nil
 	case domain.SubscriptionStatusPaused:
 		if status == domain.SubscriptionStatusActive {
-			pausedPeriod := timeNow.Sub(*subscriptionDetails.PauseStartDate)
-			updatedSubscriptionDetails.EndDate = subscriptionDetails.EndDate.Add(pausedPeriod)
+			resumeSubscription(&updatedSubscriptionDetails, timeNow)
+		} else {
+			closePause(&updatedSubscriptionDetails, timeNow)
+			updatedSubscriptionDetails.ResumeDate = nil
 		}
 	case domain.SubscriptionStatusActive:
 		if status == domain.SubscriptionStatusPaused {
-			updatedSubscriptionDetails.PauseStartDate = &timeNow
+			err = a.pauseSubscription(ctx, &updatedSubscriptionDetails, nil, "", timeNow)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return a.database.SaveSubscription(ctx, &updatedSubscriptionDetails)
+	savedSubscription, err := a.saveSubscription(ctx, domain.AuditActionStatusChanged, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	// the purchase is abandoned, so its pending payment is not going to be captured
+	a.voidPayment(ctx, subscriptionDetails.PendingPayment)
+	return savedSubscription, nil
+}
+
+// CancelSubscriptionAtPeriodEnd flags the active or trialing subscription to be cancelled at its end date
+// the subscription stays active until the end date and is cancelled instead of renewed by the renewal
+// the cancellation can be undone by changing the subscription status to active before the end date
+// returns status unchanged error if the subscription is already flagged
+func (a *appDetails) CancelSubscriptionAtPeriodEnd(ctx context.Context, id string) (*domain.UserSubscription, error) {
+	subscriptionDetails, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscriptionDetails.Status != domain.SubscriptionStatusActive && subscriptionDetails.Status != domain.SubscriptionStatusTrialing {
+		return nil, fmt.Errorf("%v subscription cancellation at period end %w", subscriptionDetails.Status, NotAllowedArgErr)
+	}
+
+	if subscriptionDetails.CancelAtPeriodEnd {
+		return nil, StatusUnchangedErr
+	}
+
+	timeNow := time.Now().UTC()
+	updatedSubscriptionDetails := *subscriptionDetails
+	updatedSubscriptionDetails.CancelAtPeriodEnd = true
+	updatedSubscriptionDetails.UpdatedAt = &timeNow
+
+	return a.saveSubscription(ctx, domain.AuditActionCancelScheduled, subscriptionDetails.Status, &updatedSubscriptionDetails)
 }
@@ -2,6 +2,8 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -15,8 +17,9 @@ import (
 
 type AppTestSuite struct {
 	suite.Suite
-	Database       *mocks.MockDB
-	MockController *gomock.Controller
+	Database        *mocks.MockDB
+	PaymentProvider *mocks.MockPaymentProvider
+	MockController  *gomock.Controller
 }
 
 // SetupTest runs before every test
@@ -24,6 +27,7 @@ func (suite *AppTestSuite) SetupTest() {
 	mockCtrl := gomock.NewController(suite.T())
 	suite.MockController = mockCtrl
 	suite.Database = mocks.NewMockDB(mockCtrl)
+	suite.PaymentProvider = mocks.NewMockPaymentProvider(mockCtrl)
 }
 
 // TearDownTest runs after every test
@@ -38,8 +42,23 @@ func TestAppTestSuite(t *testing.T) {
 func (suite *AppTestSuite) TestNewApp() {
 	t := suite.T()
 
+	taxCalculator := &ruleTaxCalculator{
+		database: suite.Database,
+	}
+	paymentProvider := NewFakePaymentProvider()
+	dunningPolicy := DunningPolicy{
+		RetryDays:   []uint{1, 3, 7},
+		FinalStatus: domain.SubscriptionStatusCancelled,
+	}
+
 	type args struct {
-		database db.DB
+		database        db.DB
+		taxCalculator   TaxCalculator
+		uniquenessRule  UniquenessRule
+		idempotencyTTL  time.Duration
+		renewalPricing  RenewalPricing
+		paymentProvider PaymentProvider
+		dunningPolicy   DunningPolicy
 	}
 	tests := []struct {
 		name    string
@@ -50,17 +69,99 @@ func (suite *AppTestSuite) TestNewApp() {
 		{
 			name: "should return app when valid input db",
 			args: args{
-				database: suite.Database,
+				database:        suite.Database,
+				taxCalculator:   taxCalculator,
+				uniquenessRule:  UniquenessPerEmailProduct,
+				idempotencyTTL:  time.Hour,
+				renewalPricing:  RenewalPricingCurrent,
+				paymentProvider: paymentProvider,
+				dunningPolicy:   dunningPolicy,
 			},
 			want: &appDetails{
-				database: suite.Database,
+				database:        suite.Database,
+				taxCalculator:   taxCalculator,
+				uniquenessRule:  UniquenessPerEmailProduct,
+				idempotencyTTL:  time.Hour,
+				renewalPricing:  RenewalPricingCurrent,
+				paymentProvider: paymentProvider,
+				dunningPolicy:   dunningPolicy,
 			},
 			wantErr: false,
 		},
 		{
 			name: "should return error when nil input db",
 			args: args{
-				database: nil,
+				database:      nil,
+				taxCalculator: taxCalculator,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when nil input tax calculator",
+			args: args{
+				database:      suite.Database,
+				taxCalculator: nil,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when invalid uniqueness rule",
+			args: args{
+				database:       suite.Database,
+				taxCalculator:  taxCalculator,
+				uniquenessRule: "product",
+				idempotencyTTL: time.Hour,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when idempotency ttl is not positive",
+			args: args{
+				database:       suite.Database,
+				taxCalculator:  taxCalculator,
+				uniquenessRule: UniquenessPerEmailProduct,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when invalid renewal pricing",
+			args: args{
+				database:       suite.Database,
+				taxCalculator:  taxCalculator,
+				uniquenessRule: UniquenessPerEmailProduct,
+				idempotencyTTL: time.Hour,
+				renewalPricing: "latest",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when nil input payment provider",
+			args: args{
+				database:       suite.Database,
+				taxCalculator:  taxCalculator,
+				uniquenessRule: UniquenessPerEmailProduct,
+				idempotencyTTL: time.Hour,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "should return error when invalid dunning policy",
+			args: args{
+				database:        suite.Database,
+				taxCalculator:   taxCalculator,
+				uniquenessRule:  UniquenessPerEmailProduct,
+				idempotencyTTL:  time.Hour,
+				paymentProvider: paymentProvider,
+				dunningPolicy: DunningPolicy{
+					RetryDays:   []uint{3, 1},
+					FinalStatus: domain.SubscriptionStatusCancelled,
+				},
 			},
 			want:    nil,
 			wantErr: true,
@@ -68,7 +169,8 @@ func (suite *AppTestSuite) TestNewApp() {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewApp(tt.args.database)
+			got, err := NewApp(tt.args.database, tt.args.taxCalculator, tt.args.uniquenessRule, tt.args.idempotencyTTL, tt.args.renewalPricing,
+				tt.args.paymentProvider, tt.args.dunningPolicy)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewApp() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -90,8 +192,9 @@ func (suite *AppTestSuite) TestGetProduct() {
 		ID:                 id,
 		Name:               "testname",
 		SubscriptionPeriod: 1,
-		Price:              10,
-		TaxPercentage:      10,
+		Price:              domain.NewMoney(1000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
 	}
 	notFoundID := "62bb4ecdba3bbe275f8c7789"
 	gomock.InOrder(
@@ -203,16 +306,94 @@ func (suite *AppTestSuite) TestBuySubscription() {
 		ID:                 productId,
 		Name:               "testproduct",
 		SubscriptionPeriod: 1,
-		Price:              10,
-		TaxPercentage:      10,
+		Price:              domain.NewMoney(1000, "EUR"),
+		Prices: []domain.ProductPrice{
+			{
+				Price: domain.NewMoney(1100, "CHF"),
+			},
+		},
+		TaxCategory:  "digital_service",
+		TaxInclusive: true,
+	}
+	trialProductRecord := productRecord
+	trialProductRecord.ID = "62bb4ecdba3bbe275f8c7799"
+	trialProductRecord.TrialDays = 7
+	trialFilter := db.SubscriptionFilter{
+		Email:     "testmail@test.com",
+		ProductID: trialProductRecord.ID,
+		HadTrial:  true,
+		Limit:     1,
+	}
+	taxRules := []domain.TaxRule{
+		{
+			Category: "digital_service",
+			Rate:     10,
+		},
+		{
+			Country:  "CH",
+			Category: "digital_service",
+			Rate:     8.1,
+		},
 	}
 
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	gomock.InOrder(
 		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return([]domain.Product{
 			productRecord,
 		}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
 		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionRecord)).Return(&subscriptionRecord, nil).Times(1),
 
+		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return([]domain.Product{
+			productRecord,
+		}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Price != domain.NewMoney(1100, "CHF") || us.NetPrice != domain.NewMoney(1018, "CHF") ||
+				us.Tax != domain.NewMoney(82, "CHF") || us.TaxRate != 8.1 {
+				return nil, fmt.Errorf("unexpected price %v net price %v tax %v rate %v", us.Price, us.NetPrice, us.Tax, us.TaxRate)
+			}
+			return us, nil
+		}).Times(1),
+
+		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return([]domain.Product{
+			productRecord,
+		}, nil).Times(1),
+
+		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return([]domain.Product{
+			productRecord,
+		}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return([]domain.TaxRule{}, nil).Times(1),
+
+		database.EXPECT().GetProduct(gomock.Any(), trialProductRecord.ID).Return([]domain.Product{
+			trialProductRecord,
+		}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), trialFilter).Return([]domain.UserSubscription{}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusTrialing || us.TrialEndDate == nil || !us.EndDate.Equal(us.StartDate.AddDate(0, 0, 7)) {
+				return nil, fmt.Errorf("unexpected trial subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		database.EXPECT().GetProduct(gomock.Any(), trialProductRecord.ID).Return([]domain.Product{
+			trialProductRecord,
+		}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), trialFilter).Return([]domain.UserSubscription{
+			{
+				ID: subscriptionId,
+			},
+		}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Email != "testmail@test.com" || us.Status != domain.SubscriptionStatusPendingPayment || us.TrialEndDate != nil || us.PendingPayment == nil {
+				return nil, fmt.Errorf("unexpected second trial subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
 		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return(nil, db.RecordNotFoundErr).Times(1),
 
 		database.EXPECT().GetProduct(gomock.Any(), gomock.AssignableToTypeOf(productRecord.ID)).Return([]domain.Product{}, nil).Times(1),
@@ -222,9 +403,8 @@ func (suite *AppTestSuite) TestBuySubscription() {
 		database db.DB
 	}
 	type args struct {
-		ctx       context.Context
-		productID string
-		emailID   string
+		ctx      context.Context
+		purchase domain.Purchase
 	}
 	tests := []struct {
 		name    string
@@ -239,9 +419,90 @@ func (suite *AppTestSuite) TestBuySubscription() {
 				database: database,
 			},
 			args: args{
-				ctx:       ctx,
-				productID: productId,
-				emailID:   "testmail@test.com",
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "should return success with price and tax for given currency and country",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+					Currency:     "CHF",
+					Country:      "CH",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "should return error if price is not available for currency",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+					Currency:     "USD",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error if tax rule is not found",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should start trial for product with trial",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    trialProductRecord.ID,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "should not start second trial for the same email in other case and product",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    trialProductRecord.ID,
+					EmailID:      " TestMail@Test.com ",
+					PaymentToken: "tok_visa",
+				},
 			},
 			wantErr: false,
 		},
@@ -251,9 +512,12 @@ func (suite *AppTestSuite) TestBuySubscription() {
 				database: database,
 			},
 			args: args{
-				ctx:       ctx,
-				productID: "",
-				emailID:   "testmail@test.com",
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    "",
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
 			},
 			wantErr: true,
 		},
@@ -263,9 +527,12 @@ func (suite *AppTestSuite) TestBuySubscription() {
 				database: database,
 			},
 			args: args{
-				ctx:       ctx,
-				productID: productId,
-				emailID:   "testmail@test.com",
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
 			},
 			wantErr: true,
 		},
@@ -275,9 +542,12 @@ func (suite *AppTestSuite) TestBuySubscription() {
 				database: database,
 			},
 			args: args{
-				ctx:       ctx,
-				productID: productId,
-				emailID:   "testmail@test.com",
+				ctx: ctx,
+				purchase: domain.Purchase{
+					ProductID:    productId,
+					EmailID:      "testmail@test.com",
+					PaymentToken: "tok_visa",
+				},
 			},
 			wantErr: true,
 		},
@@ -286,8 +556,12 @@ func (suite *AppTestSuite) TestBuySubscription() {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &appDetails{
 				database: tt.fields.database,
+				taxCalculator: &ruleTaxCalculator{
+					database: tt.fields.database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
 			}
-			_, err := a.BuySubscription(tt.args.ctx, tt.args.productID, tt.args.emailID)
+			_, err := a.BuySubscription(tt.args.ctx, tt.args.purchase)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("appDetails.BuySubscription() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -407,9 +681,13 @@ func (suite *AppTestSuite) TestUpdateSubscriptionStatusByID() {
 	}
 
 	subscriptionPuasedRecord := domain.UserSubscription{
-		ID:             subscriptionId,
-		Status:         domain.SubscriptionStatusPaused,
-		PauseStartDate: &timeNow,
+		ID:     subscriptionId,
+		Status: domain.SubscriptionStatusPaused,
+		Pauses: []domain.Pause{
+			{
+				StartDate: timeNow,
+			},
+		},
 	}
 
 	subscriptionCancelledRecord := domain.UserSubscription{
@@ -417,6 +695,32 @@ func (suite *AppTestSuite) TestUpdateSubscriptionStatusByID() {
 		Status: domain.SubscriptionStatusCancelled,
 	}
 
+	subscriptionCancelAtPeriodEndRecord := domain.UserSubscription{
+		ID:                subscriptionId,
+		Status:            domain.SubscriptionStatusActive,
+		CancelAtPeriodEnd: true,
+	}
+
+	subscriptionTrialingRecord := domain.UserSubscription{
+		ID:           subscriptionId,
+		Status:       domain.SubscriptionStatusTrialing,
+		TrialEndDate: &timeNow,
+	}
+
+	subscriptionPausedCancelAtPeriodEndRecord := domain.UserSubscription{
+		ID:                subscriptionId,
+		Status:            domain.SubscriptionStatusPaused,
+		CancelAtPeriodEnd: true,
+		ResumeDate:        &timeNow,
+		Pauses: []domain.Pause{
+			{
+				StartDate: timeNow,
+			},
+		},
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	gomock.InOrder(
 		// test 1
 		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionRecord, nil).Times(1),
@@ -449,6 +753,34 @@ func (suite *AppTestSuite) TestUpdateSubscriptionStatusByID() {
 		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionPuasedRecord, nil).Times(1),
 
 		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionPuasedRecord)).Return(&subscriptionPuasedRecord, nil).Times(1),
+
+		// test 9
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionTrialingRecord, nil).Times(1),
+
+		// test 10
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionTrialingRecord, nil).Times(1),
+
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionTrialingRecord)).Return(&subscriptionTrialingRecord, nil).Times(1),
+
+		// test 11
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionCancelAtPeriodEndRecord, nil).Times(1),
+
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionCancelAtPeriodEndRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.CancelAtPeriodEnd || us.Status != domain.SubscriptionStatusActive {
+				return nil, fmt.Errorf("unexpected undone cancellation %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 12
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionPausedCancelAtPeriodEndRecord, nil).Times(1),
+
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionPausedCancelAtPeriodEndRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if !us.CancelAtPeriodEnd || us.Status != domain.SubscriptionStatusActive || us.ResumeDate != nil || us.CurrentPause() != nil {
+				t.Errorf("unexpected resumed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
 	)
 
 	type fields struct {
@@ -573,6 +905,54 @@ func (suite *AppTestSuite) TestUpdateSubscriptionStatusByID() {
 			},
 			wantErr: false,
 		},
+		{
+			name: "should return error for pausing the trialing subscription",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx:    ctx,
+				id:     subscriptionId,
+				status: domain.SubscriptionStatusPaused,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return success for cancelling the trialing subscription",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx:    ctx,
+				id:     subscriptionId,
+				status: domain.SubscriptionStatusCancelled,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should undo cancellation at period end for active status",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx:    ctx,
+				id:     subscriptionId,
+				status: domain.SubscriptionStatusActive,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should resume paused subscription and keep its cancellation at period end",
+			fields: fields{
+				database: database,
+			},
+			args: args{
+				ctx:    ctx,
+				id:     subscriptionId,
+				status: domain.SubscriptionStatusActive,
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -587,3 +967,83 @@ func (suite *AppTestSuite) TestUpdateSubscriptionStatusByID() {
 		})
 	}
 }
+
+func (suite *AppTestSuite) TestCancelSubscriptionAtPeriodEnd() {
+	t := suite.T()
+
+	database := suite.Database
+	subscriptionId := "62bb4ecdba3bbe275f8c7788"
+	ctx := context.Background()
+	subscriptionRecord := domain.UserSubscription{
+		ID:     subscriptionId,
+		Status: domain.SubscriptionStatusActive,
+	}
+
+	subscriptionCancelAtPeriodEndRecord := domain.UserSubscription{
+		ID:                subscriptionId,
+		Status:            domain.SubscriptionStatusActive,
+		CancelAtPeriodEnd: true,
+	}
+
+	subscriptionCancelledRecord := domain.UserSubscription{
+		ID:     subscriptionId,
+		Status: domain.SubscriptionStatusCancelled,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionRecord, nil).Times(1),
+
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.AssignableToTypeOf(&subscriptionRecord)).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if !us.CancelAtPeriodEnd || us.Status != domain.SubscriptionStatusActive {
+				return nil, fmt.Errorf("unexpected subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionCancelAtPeriodEndRecord, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionId).Return(&subscriptionCancelledRecord, nil).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name: "should flag active subscription to be cancelled at period end",
+			id:   subscriptionId,
+		},
+		{
+			name:    "should return error if subscription is already flagged",
+			id:      subscriptionId,
+			wantErr: StatusUnchangedErr,
+		},
+		{
+			name:    "should return error for cancelled subscription",
+			id:      subscriptionId,
+			wantErr: NotAllowedArgErr,
+		},
+		{
+			name:    "should return error for empty id",
+			id:      "",
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			_, err := a.CancelSubscriptionAtPeriodEnd(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.CancelSubscriptionAtPeriodEnd() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// GetSubscriptionHistory returns audit log of the subscription with given id sorted by creation time
+// returns invalid argument error if id is empty or invalid, not found error if subscription does not exist
+func (a *appDetails) GetSubscriptionHistory(ctx context.Context, id string) ([]domain.AuditEvent, error) {
+	_, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			return nil, fmt.Errorf("subscription %v %w", id, NotFoundErr)
+		}
+		return nil, err
+	}
+
+	return a.database.GetAuditEvents(ctx, id)
+}
+
+// RecordPendingAuditEvents records the pending audit events of all the subscriptions in the audit log,
+// the events which failed to be recorded after the change was saved are recorded by the next run
+// returns number of subscriptions whose events are recorded and the last error if any subscription failed
+func (a *appDetails) RecordPendingAuditEvents(ctx context.Context) (int, error) {
+	filter := db.SubscriptionFilter{
+		HasPendingAuditEvents: true,
+	}
+	return a.processDueSubscriptions(ctx, filter, func(subscription *domain.UserSubscription) error {
+		err := a.recordPendingAuditEvents(ctx, subscription)
+		if err != nil {
+			log.Printf("audit of subscription %v failed: %v", subscription.ID, err)
+			return fmt.Errorf("audit of subscription %v failed: %w", subscription.ID, err)
+		}
+		return nil
+	})
+}
+
+// saveSubscription saves the subscription and records the change in the audit log
+// together with the actor and request ID from the context
+// the audit event is saved with the subscription as pending audit event, so the change is never saved without it,
+// and it is recorded in the append-only audit log right after the save, the event which failed to be recorded
+// stays pending with the subscription and is recorded by the next RecordPendingAuditEvents run
+// returns version mismatch error if the subscription version is not the expected version from the context
+// and conflict error if the subscription was changed concurrently since it was read
+// or other live subscription has the same uniqueness key
+func (a *appDetails) saveSubscription(ctx context.Context, action domain.AuditAction, previousStatus domain.SubscriptionStatus, subscription *domain.UserSubscription) (*domain.UserSubscription, error) {
+	err := checkExpectedVersion(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.UniquenessKey = a.uniquenessKey(subscription)
+	// the events are copied, so the failed save does not leave the event on the subscription of the caller
+	pendingAuditEvents := subscription.PendingAuditEvents
+	subscription.PendingAuditEvents = append(append([]domain.AuditEvent(nil), pendingAuditEvents...), domain.AuditEvent{
+		Action:         action,
+		PreviousStatus: previousStatus,
+		NewStatus:      subscription.Status,
+		Actor:          ActorFromContext(ctx),
+		RequestID:      RequestIDFromContext(ctx),
+		CreatedAt:      time.Now().UTC(),
+	})
+	savedSubscription, err := a.database.SaveSubscription(ctx, subscription)
+	if err != nil {
+		subscription.PendingAuditEvents = pendingAuditEvents
+		if errors.Is(err, db.VersionConflictErr) || errors.Is(err, db.DuplicateRecordErr) {
+			return nil, fmt.Errorf("subscription %v %w", subscription.ID, ConflictErr)
+		}
+		return nil, err
+	}
+
+	err = a.recordPendingAuditEvents(ctx, savedSubscription)
+	if err != nil {
+		log.Printf("audit of subscription %v %v is left pending: %v", savedSubscription.ID, action, err)
+	}
+
+	return savedSubscription, nil
+}
+
+// checkExpectedVersion returns version mismatch error if the subscription version is not the expected version from the context
+// new subscription without version matches any version
+func checkExpectedVersion(ctx context.Context, subscription *domain.UserSubscription) error {
+	if version, ok := ExpectedVersionFromContext(ctx); ok && subscription.Version != 0 && subscription.Version != version {
+		return fmt.Errorf("subscription %v version %v expected %v %w", subscription.ID, subscription.Version, version, VersionMismatchErr)
+	}
+	return nil
+}
+
+// recordPendingAuditEvents records the pending audit events of the saved subscription in the audit log
+// and removes the recorded ones from it, the event keeps its ID while it is pending, so it is recorded only once
+// returns error if any event failed to be recorded, it stays pending and is recorded by the next RecordPendingAuditEvents run
+func (a *appDetails) recordPendingAuditEvents(ctx context.Context, subscription *domain.UserSubscription) error {
+	if len(subscription.PendingAuditEvents) == 0 {
+		return nil
+	}
+
+	recorded := []string{}
+	pendingAuditEvents := []domain.AuditEvent{}
+	var recordErr error
+	for _, v := range subscription.PendingAuditEvents {
+		event := v
+		_, err := a.database.SaveAuditEvent(ctx, &event)
+		if err != nil {
+			recordErr = fmt.Errorf("audit event %v %v: %w", v.ID, v.Action, err)
+			pendingAuditEvents = append(pendingAuditEvents, v)
+			continue
+		}
+		recorded = append(recorded, v.ID)
+	}
+
+	if len(recorded) > 0 {
+		err := a.database.RemovePendingAuditEvents(ctx, subscription.ID, recorded)
+		if err != nil {
+			return err
+		}
+	}
+
+	subscription.PendingAuditEvents = nil
+	if len(pendingAuditEvents) > 0 {
+		subscription.PendingAuditEvents = pendingAuditEvents
+	}
+	return recordErr
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestGetSubscriptionHistory() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	events := []domain.AuditEvent{
+		{
+			ID:             "62bb4ecdba3bbe275f8c7790",
+			SubscriptionID: subscriptionID,
+			Action:         domain.AuditActionCreated,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "anonymous",
+		},
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&domain.UserSubscription{ID: subscriptionID}, nil).Times(1),
+		database.EXPECT().GetAuditEvents(gomock.Any(), subscriptionID).Return(events, nil).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(nil, db.RecordNotFoundErr).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		want    []domain.AuditEvent
+		wantErr error
+	}{
+		{
+			name: "should return audit events of the subscription",
+			id:   subscriptionID,
+			want: events,
+		},
+		{
+			name:    "should return error if subscription is not found",
+			id:      subscriptionID,
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if id is empty",
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.GetSubscriptionHistory(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.GetSubscriptionHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appDetails.GetSubscriptionHistory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) Test_saveSubscription() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := ContextWithRequestID(ContextWithActor(context.Background(), "user@test.com"), "8f14e45fceea167a")
+	subscriptionRecord := domain.UserSubscription{
+		ID:     "62bb4ecdba3bbe275f8c7788",
+		Status: domain.SubscriptionStatusPaused,
+	}
+	eventID := "62bb4ecdba3bbe275f8c7790"
+	// saveWithAuditEvent checks the pending audit event of the change and assigns its ID like the database does
+	saveWithAuditEvent := func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+		if len(us.PendingAuditEvents) != 1 {
+			return nil, fmt.Errorf("unexpected pending audit events %v", us.PendingAuditEvents)
+		}
+		e := us.PendingAuditEvents[0]
+		if e.Action != domain.AuditActionStatusChanged || e.PreviousStatus != domain.SubscriptionStatusActive ||
+			e.NewStatus != domain.SubscriptionStatusPaused || e.Actor != "user@test.com" ||
+			e.RequestID != "8f14e45fceea167a" || e.CreatedAt.IsZero() {
+			return nil, fmt.Errorf("unexpected audit event %v", e)
+		}
+		us.PendingAuditEvents[0].ID = eventID
+		us.PendingAuditEvents[0].SubscriptionID = us.ID
+		return us, nil
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(saveWithAuditEvent).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, e *domain.AuditEvent) (*domain.AuditEvent, error) {
+			if e.ID != eventID || e.SubscriptionID != subscriptionRecord.ID {
+				return nil, fmt.Errorf("unexpected audit event %v", e)
+			}
+			return e, nil
+		}).Times(1),
+		database.EXPECT().RemovePendingAuditEvents(gomock.Any(), subscriptionRecord.ID, []string{eventID}).Return(nil).Times(1),
+
+		// test 2
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(saveWithAuditEvent).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 3
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name                   string
+		wantPendingAuditEvents int
+		wantErr                bool
+	}{
+		{
+			name:                   "should save subscription with the audit event and record it in the audit log",
+			wantPendingAuditEvents: 0,
+			wantErr:                false,
+		},
+		{
+			name:                   "should save subscription with the audit event left pending if the audit log fails",
+			wantPendingAuditEvents: 1,
+			wantErr:                false,
+		},
+		{
+			name:                   "should return error and not record the change if subscription cannot be saved",
+			wantPendingAuditEvents: 0,
+			wantErr:                true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			subscription := subscriptionRecord
+			_, err := a.saveSubscription(ctx, domain.AuditActionStatusChanged, domain.SubscriptionStatusActive, &subscription)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.saveSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(subscription.PendingAuditEvents) != tt.wantPendingAuditEvents {
+				t.Errorf("appDetails.saveSubscription() pending audit events = %v, want %v", subscription.PendingAuditEvents, tt.wantPendingAuditEvents)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestRecordPendingAuditEvents() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	event := domain.AuditEvent{
+		ID:             "62bb4ecdba3bbe275f8c7790",
+		SubscriptionID: subscriptionID,
+		Action:         domain.AuditActionRenewed,
+		NewStatus:      domain.SubscriptionStatusActive,
+		Actor:          ActorSystem,
+	}
+	otherEvent := event
+	otherEvent.ID = "62bb4ecdba3bbe275f8c7791"
+	// pendingSubscriptions returns new subscriptions with pending audit events, as they are changed by the recording
+	pendingSubscriptions := func() []domain.UserSubscription {
+		return []domain.UserSubscription{
+			{
+				ID:                 subscriptionID,
+				PendingAuditEvents: []domain.AuditEvent{event, otherEvent},
+			},
+		}
+	}
+	filter := db.SubscriptionFilter{
+		HasPendingAuditEvents: true,
+		Limit:                 renewalBatchSize,
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(pendingSubscriptions(), nil).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), &event).Return(&event, nil).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), &otherEvent).Return(&otherEvent, nil).Times(1),
+		database.EXPECT().RemovePendingAuditEvents(gomock.Any(), subscriptionID, []string{event.ID, otherEvent.ID}).Return(nil).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(pendingSubscriptions(), nil).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), &event).Return(nil, fmt.Errorf("db error")).Times(1),
+		database.EXPECT().SaveAuditEvent(gomock.Any(), &otherEvent).Return(&otherEvent, nil).Times(1),
+		database.EXPECT().RemovePendingAuditEvents(gomock.Any(), subscriptionID, []string{otherEvent.ID}).Return(nil).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name     string
+		recorded int
+		wantErr  bool
+	}{
+		{
+			name:     "should record the pending audit events and remove them from the subscription",
+			recorded: 1,
+		},
+		{
+			name:     "should keep the audit event pending if it cannot be recorded",
+			recorded: 0,
+			wantErr:  true,
+		},
+		{
+			name:    "should return error if subscriptions cannot be found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			recorded, err := a.RecordPendingAuditEvents(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.RecordPendingAuditEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if recorded != tt.recorded {
+				t.Errorf("appDetails.RecordPendingAuditEvents() = %v, want %v", recorded, tt.recorded)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) Test_saveSubscriptionVersion() {
+	t := suite.T()
+
+	database := suite.Database
+	subscriptionRecord := domain.UserSubscription{
+		ID:      "62bb4ecdba3bbe275f8c7788",
+		Version: 2,
+		Status:  domain.SubscriptionStatusPaused,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(&subscriptionRecord, nil).Times(1),
+
+		// test 2
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("subscription version 2 %w", db.VersionConflictErr)).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name: "should save subscription with expected version",
+			ctx:  ContextWithExpectedVersion(context.Background(), 2),
+		},
+		{
+			name:    "should return conflict error if subscription is changed concurrently",
+			ctx:     context.Background(),
+			wantErr: ConflictErr,
+		},
+		{
+			name:    "should return version mismatch error if subscription version is not expected version",
+			ctx:     ContextWithExpectedVersion(context.Background(), 1),
+			wantErr: VersionMismatchErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			subscription := subscriptionRecord
+			_, err := a.saveSubscription(tt.ctx, domain.AuditActionStatusChanged, domain.SubscriptionStatusActive, &subscription)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.saveSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
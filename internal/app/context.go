@@ -0,0 +1,52 @@
+package app
+
+import "context"
+
+// ActorSystem is the actor of changes made by the service itself e.g. background jobs
+const ActorSystem = "system"
+
+type contextKey string
+
+const (
+	actorContextKey     contextKey = "actor"
+	requestIDContextKey contextKey = "request_id"
+	versionContextKey   contextKey = "version"
+)
+
+// ContextWithActor returns context carrying the actor i.e. who makes the change
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext returns the actor carried by the context, returns ActorSystem if the context has no actor
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorContextKey).(string)
+	if !ok || actor == "" {
+		return ActorSystem
+	}
+	return actor
+}
+
+// ContextWithRequestID returns context carrying ID of the request which makes the change
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, returns empty string if the context has no request ID
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// ContextWithExpectedVersion returns context carrying the subscription version the change is based on,
+// the change is rejected if the subscription has a different version
+func ContextWithExpectedVersion(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, versionContextKey, version)
+}
+
+// ExpectedVersionFromContext returns the expected subscription version carried by the context,
+// returns false if the context has no expected version
+func ExpectedVersionFromContext(ctx context.Context) (int64, bool) {
+	version, ok := ctx.Value(versionContextKey).(int64)
+	return version, ok
+}
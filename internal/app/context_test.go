@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"testing"
+)
+
+func TestActorFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "should return actor from the context",
+			ctx:  ContextWithActor(context.Background(), "support@gymondo.com"),
+			want: "support@gymondo.com",
+		},
+		{
+			name: "should return system actor if context has no actor",
+			ctx:  context.Background(),
+			want: ActorSystem,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ActorFromContext(tt.ctx); got != tt.want {
+				t.Errorf("ActorFromContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "should return request ID from the context",
+			ctx:  ContextWithRequestID(context.Background(), "8f14e45fceea167a"),
+			want: "8f14e45fceea167a",
+		},
+		{
+			name: "should return empty request ID if context has no request ID",
+			ctx:  context.Background(),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequestIDFromContext(tt.ctx); got != tt.want {
+				t.Errorf("RequestIDFromContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpectedVersionFromContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		want   int64
+		wantOk bool
+	}{
+		{
+			name:   "should return expected version from the context",
+			ctx:    ContextWithExpectedVersion(context.Background(), 3),
+			want:   3,
+			wantOk: true,
+		},
+		{
+			name:   "should return false if context has no expected version",
+			ctx:    context.Background(),
+			want:   0,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotOk := ExpectedVersionFromContext(tt.ctx)
+			if got != tt.want || gotOk != tt.wantOk {
+				t.Errorf("ExpectedVersionFromContext() = %v, %v, want %v, %v", got, gotOk, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
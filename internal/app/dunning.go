@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// DunningPolicy tells how the declined renewal charge of the subscription is retried
+// RetryDays are the days after the first declined charge of the period the charge is retried on, in increasing order,
+// the subscription stays past due while it is retried and is moved to FinalStatus (cancelled or expired) when the retries are exhausted
+type DunningPolicy struct {
+	RetryDays   []uint
+	FinalStatus domain.SubscriptionStatus
+}
+
+// IsValid returns true if the retry days are positive and increasing and the final status is cancelled or expired
+func (p DunningPolicy) IsValid() bool {
+	if p.FinalStatus != domain.SubscriptionStatusCancelled && p.FinalStatus != domain.SubscriptionStatusExpired {
+		return false
+	}
+
+	var previous uint
+	for _, v := range p.RetryDays {
+		if v <= previous {
+			return false
+		}
+		previous = v
+	}
+	return true
+}
+
+// ParseRetryDays parses comma separated retry days e.g. "1,3,7", empty value means no retries
+// returns invalid argument error if any of the days is not a number
+func ParseRetryDays(value string) ([]uint, error) {
+	var days []uint
+	if strings.TrimSpace(value) == "" {
+		return days, nil
+	}
+
+	for _, v := range strings.Split(value, ",") {
+		day, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("retry day %v %w", v, InvalidArgErr)
+		}
+		days = append(days, uint(day))
+	}
+	return days, nil
+}
+
+// RetryPayments retries the declined renewal charge of past due subscriptions with next payment retry before or equal to given time
+// the subscription is renewed from its end date when the charge succeeds, see RenewSubscriptions,
+// otherwise the next retry is scheduled as per dunning policy or the subscription is moved to the final status
+// the payments of all the due subscriptions are retried in batches, see processDueSubscriptions
+// returns number of processed subscriptions and the last error if any subscription failed
+func (a *appDetails) RetryPayments(ctx context.Context, at time.Time) (int, error) {
+	filter := db.SubscriptionFilter{
+		Statuses:           []domain.SubscriptionStatus{domain.SubscriptionStatusPastDue},
+		PaymentRetryBefore: &at,
+	}
+	return a.processDueSubscriptions(ctx, filter, func(subscription *domain.UserSubscription) error {
+		err := a.renewSubscription(ctx, subscription, at)
+		if err != nil {
+			log.Printf("payment retry of subscription %v failed: %v", subscription.ID, err)
+			return fmt.Errorf("payment retry of subscription %v failed: %w", subscription.ID, err)
+		}
+		return nil
+	})
+}
+
+// failRenewalPayment records the declined renewal charge attempt of the subscription and schedules the next retry
+// subscription whose retries are exhausted is moved to the final status of the dunning policy
+func (a *appDetails) failRenewalPayment(ctx context.Context, subscription *domain.UserSubscription, previousStatus domain.SubscriptionStatus, at time.Time, chargeErr error) error {
+	subscription.PaymentAttempts = append(subscription.PaymentAttempts, domain.PaymentAttempt{
+		PeriodStart: subscription.EndDate,
+		AttemptedAt: at,
+		Error:       chargeErr.Error(),
+	})
+
+	attempts := subscription.FailedPaymentAttempts(subscription.EndDate)
+	if len(attempts) > len(a.dunningPolicy.RetryDays) {
+		subscription.Status = a.dunningPolicy.FinalStatus
+		subscription.NextPaymentRetry = nil
+		_, err := a.saveSubscription(ctx, domain.AuditActionPaymentRetriesExhausted, previousStatus, subscription)
+		return err
+	}
+
+	nextRetry := attempts[0].AttemptedAt.AddDate(0, 0, int(a.dunningPolicy.RetryDays[len(attempts)-1]))
+	subscription.Status = domain.SubscriptionStatusPastDue
+	subscription.NextPaymentRetry = &nextRetry
+	_, err := a.saveSubscription(ctx, domain.AuditActionRenewalPaymentFailed, previousStatus, subscription)
+	return err
+}
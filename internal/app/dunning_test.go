@@ -0,0 +1,249 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func TestDunningPolicy_IsValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy DunningPolicy
+		want   bool
+	}{
+		{
+			name:   "should accept increasing retry days",
+			policy: DunningPolicy{RetryDays: []uint{1, 3, 7}, FinalStatus: domain.SubscriptionStatusCancelled},
+			want:   true,
+		},
+		{
+			name:   "should accept no retries",
+			policy: DunningPolicy{FinalStatus: domain.SubscriptionStatusExpired},
+			want:   true,
+		},
+		{
+			name:   "should reject retry days which are not increasing",
+			policy: DunningPolicy{RetryDays: []uint{1, 1}, FinalStatus: domain.SubscriptionStatusCancelled},
+			want:   false,
+		},
+		{
+			name:   "should reject zero retry day",
+			policy: DunningPolicy{RetryDays: []uint{0}, FinalStatus: domain.SubscriptionStatusCancelled},
+			want:   false,
+		},
+		{
+			name:   "should reject final status which does not end the subscription",
+			policy: DunningPolicy{RetryDays: []uint{1}, FinalStatus: domain.SubscriptionStatusPaused},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.IsValid(); got != tt.want {
+				t.Errorf("DunningPolicy.IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []uint
+		wantErr error
+	}{
+		{
+			name:  "should parse comma separated days",
+			value: "1, 3,7",
+			want:  []uint{1, 3, 7},
+		},
+		{
+			name:  "should return no days for empty value",
+			value: "",
+		},
+		{
+			name:    "should return error for invalid day",
+			value:   "1,week",
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetryDays(tt.value)
+			if !errors.Is(err, tt.wantErr) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRetryDays() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestRetryPayments() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	at := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+	}
+	nextPaymentRetry := at
+	failedAttempt := domain.PaymentAttempt{
+		PeriodStart: endDate,
+		AttemptedAt: endDate,
+		Error:       "card declined",
+	}
+	subscription := domain.UserSubscription{
+		ID:               "62bb4ecdba3bbe275f8c7781",
+		ProductID:        product.ID,
+		EndDate:          endDate,
+		Price:            domain.NewMoney(1000, "EUR"),
+		Status:           domain.SubscriptionStatusPastDue,
+		AutoRenew:        true,
+		PaymentToken:     "tok_visa",
+		NextPaymentRetry: &nextPaymentRetry,
+		PaymentAttempts:  []domain.PaymentAttempt{failedAttempt},
+	}
+	declinedSubscription := subscription
+	declinedSubscription.PaymentToken = FakeTokenDecline
+	exhaustedSubscription := declinedSubscription
+	exhaustedSubscription.PaymentAttempts = []domain.PaymentAttempt{failedAttempt, failedAttempt, failedAttempt}
+	expectedFilter := db.SubscriptionFilter{
+		Statuses:           []domain.SubscriptionStatus{domain.SubscriptionStatusPastDue},
+		PaymentRetryBefore: &at,
+		Limit:              renewalBatchSize,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	database.EXPECT().SaveInvoice(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingInvoices(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{subscription}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantEndDate := endDate.AddDate(0, 1, 0)
+			if us.Status != domain.SubscriptionStatusActive || us.NextPaymentRetry != nil || !us.EndDate.Equal(wantEndDate) ||
+				len(us.Payments) != 1 || len(us.PaymentAttempts) != 2 || !us.PaymentAttempts[1].Succeeded {
+				return nil, fmt.Errorf("unexpected renewed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{declinedSubscription}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantRetry := endDate.AddDate(0, 0, 3)
+			if us.Status != domain.SubscriptionStatusPastDue || us.NextPaymentRetry == nil || !us.NextPaymentRetry.Equal(wantRetry) ||
+				!us.EndDate.Equal(endDate) || len(us.PaymentAttempts) != 2 || us.PaymentAttempts[1].Succeeded {
+				return nil, fmt.Errorf("unexpected past due subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{exhaustedSubscription}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusCancelled || us.NextPaymentRetry != nil || len(us.PaymentAttempts) != 4 {
+				return nil, fmt.Errorf("unexpected cancelled subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 4
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "should renew past due subscription when the retried payment succeeds",
+			want: 1,
+		},
+		{
+			name: "should schedule next retry when the retried payment is declined",
+			want: 1,
+		},
+		{
+			name: "should move subscription to final status when the retries are exhausted",
+			want: 1,
+		},
+		{
+			name:    "should return error if past due subscriptions cannot be fetched",
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database:        database,
+				paymentProvider: NewFakePaymentProvider(),
+				dunningPolicy: DunningPolicy{
+					RetryDays:   []uint{1, 3, 7},
+					FinalStatus: domain.SubscriptionStatusCancelled,
+				},
+			}
+			got, err := a.RetryPayments(ctx, at)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("appDetails.RetryPayments() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestCancelPastDueSubscription() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	nextPaymentRetry := time.Now().UTC().AddDate(0, 0, 1)
+	subscription := domain.UserSubscription{
+		ID:               "62bb4ecdba3bbe275f8c7781",
+		Status:           domain.SubscriptionStatusPastDue,
+		NextPaymentRetry: &nextPaymentRetry,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusCancelled || us.NextPaymentRetry != nil {
+				return nil, fmt.Errorf("unexpected cancelled subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+	)
+
+	a := &appDetails{
+		database: database,
+	}
+	_, err := a.UpdateSubscriptionStatusByID(ctx, subscription.ID, domain.SubscriptionStatusCancelled)
+	if err != nil {
+		t.Errorf("appDetails.UpdateSubscriptionStatusByID() error = %v, want cancelled subscription", err)
+	}
+
+	_, err = a.UpdateSubscriptionStatusByID(ctx, subscription.ID, domain.SubscriptionStatusPaused)
+	if !errors.Is(err, NotAllowedArgErr) {
+		t.Errorf("appDetails.UpdateSubscriptionStatusByID() error = %v, wantErr %v", err, NotAllowedArgErr)
+	}
+}
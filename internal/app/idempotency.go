@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+const (
+	// maxIdempotencyKeyLength is the maximum length of the idempotency key
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTTL is how long the request in progress holds the idempotency key,
+	// so that the key of the failed service instance is released
+	idempotencyLockTTL = time.Minute
+	// serverErrorStatusCode is the lowest status code of the server error response
+	serverErrorStatusCode = 500
+)
+
+// StartIdempotentRequest reserves the idempotency key for the request with given fingerprint
+// returns nil record if the request should be processed and FinishIdempotentRequest called with its response,
+// otherwise returns the record with the response of the earlier request with the same key which should be replayed
+// returns invalid argument error if the key is empty or too long, idempotency key reused error if the key was used
+// with a different request and conflict error if the request with the key is still in progress
+func (a *appDetails) StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*domain.IdempotencyRecord, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("idempotency key length must be 1 to %v %w", maxIdempotencyKeyLength, InvalidArgErr)
+	}
+
+	timeNow := time.Now().UTC()
+	err := a.database.CreateIdempotencyRecord(ctx, &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   timeNow,
+		ExpiresAt:   timeNow.Add(idempotencyLockTTL),
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, db.DuplicateRecordErr) {
+		return nil, err
+	}
+
+	record, err := a.database.GetIdempotencyRecord(ctx, key)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			// the record expired after the key was found in use, the request can be retried
+			return nil, fmt.Errorf("idempotency key %v %w", key, ConflictErr)
+		}
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("idempotency key %v %w", key, IdempotencyKeyReusedErr)
+	}
+
+	if !record.IsCompleted() {
+		return nil, fmt.Errorf("request with idempotency key %v is in progress %w", key, ConflictErr)
+	}
+
+	return record, nil
+}
+
+// FinishIdempotentRequest stores the response of the request started by StartIdempotentRequest,
+// the response is replayed for the retries of the request until the idempotency TTL expires
+// server error response is not stored and the key is released, so that the request can be retried
+func (a *appDetails) FinishIdempotentRequest(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil {
+		return fmt.Errorf("idempotency record %w", NilArgErr)
+	}
+
+	if record.StatusCode >= serverErrorStatusCode {
+		return a.database.DeleteIdempotencyRecord(ctx, record.Key)
+	}
+
+	record.ExpiresAt = time.Now().UTC().Add(a.idempotencyTTL)
+	return a.database.CompleteIdempotencyRecord(ctx, record)
+}
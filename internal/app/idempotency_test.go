@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestStartIdempotentRequest() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	key := "8f14e45fceea167a"
+	fingerprint := "fingerprint1"
+	completedRecord := &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		StatusCode:  201,
+		Body:        []byte(`{"id":"62bb4ecdba3bbe275f8c7788"}`),
+	}
+	inProgressRecord := &domain.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+	}
+	duplicateErr := fmt.Errorf("idempotency key %v %w", key, db.DuplicateRecordErr)
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record *domain.IdempotencyRecord) error {
+			if record.Key != key || record.Fingerprint != fingerprint || record.IsCompleted() ||
+				!record.ExpiresAt.Equal(record.CreatedAt.Add(idempotencyLockTTL)) {
+				return fmt.Errorf("unexpected idempotency record %v", record)
+			}
+			return nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(duplicateErr).Times(1),
+		database.EXPECT().GetIdempotencyRecord(gomock.Any(), key).Return(completedRecord, nil).Times(1),
+
+		// test 3
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(duplicateErr).Times(1),
+		database.EXPECT().GetIdempotencyRecord(gomock.Any(), key).Return(completedRecord, nil).Times(1),
+
+		// test 4
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(duplicateErr).Times(1),
+		database.EXPECT().GetIdempotencyRecord(gomock.Any(), key).Return(inProgressRecord, nil).Times(1),
+
+		// test 5
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(duplicateErr).Times(1),
+		database.EXPECT().GetIdempotencyRecord(gomock.Any(), key).Return(nil, db.RecordNotFoundErr).Times(1),
+
+		// test 6
+		database.EXPECT().CreateIdempotencyRecord(gomock.Any(), gomock.Any()).Return(fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name        string
+		key         string
+		fingerprint string
+		want        *domain.IdempotencyRecord
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{
+			name:        "should reserve the key for new request",
+			key:         key,
+			fingerprint: fingerprint,
+		},
+		{
+			name:        "should return completed record of the same request",
+			key:         key,
+			fingerprint: fingerprint,
+			want:        completedRecord,
+		},
+		{
+			name:        "should return error if the key is used with different request",
+			key:         key,
+			fingerprint: "fingerprint2",
+			wantErr:     IdempotencyKeyReusedErr,
+		},
+		{
+			name:        "should return conflict error if the request is in progress",
+			key:         key,
+			fingerprint: fingerprint,
+			wantErr:     ConflictErr,
+		},
+		{
+			name:        "should return conflict error if the record expired meanwhile",
+			key:         key,
+			fingerprint: fingerprint,
+			wantErr:     ConflictErr,
+		},
+		{
+			name:        "should return error if the key cannot be reserved",
+			key:         key,
+			fingerprint: fingerprint,
+			wantAnyErr:  true,
+		},
+		{
+			name:        "should return error if the key is empty",
+			fingerprint: fingerprint,
+			wantErr:     InvalidArgErr,
+		},
+		{
+			name:        "should return error if the key is too long",
+			key:         strings.Repeat("k", maxIdempotencyKeyLength+1),
+			fingerprint: fingerprint,
+			wantErr:     InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database:       database,
+				idempotencyTTL: time.Hour,
+			}
+			got, err := a.StartIdempotentRequest(ctx, tt.key, tt.fingerprint)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Errorf("appDetails.StartIdempotentRequest() error = %v, wantErr %v", err, tt.wantAnyErr)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.StartIdempotentRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appDetails.StartIdempotentRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestFinishIdempotentRequest() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	key := "8f14e45fceea167a"
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().CompleteIdempotencyRecord(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record *domain.IdempotencyRecord) error {
+			if record.Key != key || record.StatusCode != 201 || record.ExpiresAt.Before(time.Now().UTC().Add(59*time.Minute)) {
+				return fmt.Errorf("unexpected idempotency record %v", record)
+			}
+			return nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().DeleteIdempotencyRecord(gomock.Any(), key).Return(nil).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		record  *domain.IdempotencyRecord
+		wantErr bool
+	}{
+		{
+			name: "should store the response until the idempotency ttl expires",
+			record: &domain.IdempotencyRecord{
+				Key:        key,
+				StatusCode: 201,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should release the key if the response is server error",
+			record: &domain.IdempotencyRecord{
+				Key:        key,
+				StatusCode: 503,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should return error if the record is nil",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database:       database,
+				idempotencyTTL: time.Hour,
+			}
+			err := a.FinishIdempotentRequest(ctx, tt.record)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.FinishIdempotentRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
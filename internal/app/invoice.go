@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// GetSubscriptionInvoices returns invoices of the subscription with given id sorted by issue time
+// returns invalid argument error if id is empty or invalid, not found error if subscription does not exist
+func (a *appDetails) GetSubscriptionInvoices(ctx context.Context, id string) ([]domain.Invoice, error) {
+	_, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			return nil, fmt.Errorf("subscription %v %w", id, NotFoundErr)
+		}
+		return nil, err
+	}
+
+	return a.database.GetInvoices(ctx, id)
+}
+
+// GetSubscriptionInvoice returns invoice of the subscription with given id and invoice number
+// returns invalid argument error if id or number is empty or id is invalid,
+// not found error if subscription does not exist or the invoice does not belong to it
+func (a *appDetails) GetSubscriptionInvoice(ctx context.Context, id string, number string) (*domain.Invoice, error) {
+	if number == "" {
+		return nil, InvalidArgErr
+	}
+
+	_, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			return nil, fmt.Errorf("subscription %v %w", id, NotFoundErr)
+		}
+		return nil, err
+	}
+
+	invoice, err := a.database.GetInvoiceByNumber(ctx, number)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			return nil, fmt.Errorf("invoice %v %w", number, NotFoundErr)
+		}
+		return nil, err
+	}
+
+	if invoice.SubscriptionID != id {
+		return nil, fmt.Errorf("invoice %v of subscription %v %w", number, id, NotFoundErr)
+	}
+	return invoice, nil
+}
+
+// IssuePendingInvoices issues the pending invoices and credit notes of all the subscriptions,
+// the invoices which failed to be issued after the payment was saved are issued again by the next run
+// returns number of subscriptions whose invoices are issued and the last error if any subscription failed
+func (a *appDetails) IssuePendingInvoices(ctx context.Context) (int, error) {
+	filter := db.SubscriptionFilter{
+		HasPendingInvoices: true,
+	}
+	return a.processDueSubscriptions(ctx, filter, func(subscription *domain.UserSubscription) error {
+		err := a.issuePendingInvoices(ctx, subscription)
+		if err != nil {
+			log.Printf("invoice of subscription %v failed: %v", subscription.ID, err)
+			return fmt.Errorf("invoice of subscription %v failed: %w", subscription.ID, err)
+		}
+		return nil
+	})
+}
+
+// addPendingInvoice adds the invoice of the captured charge or the credit note of the refund for the period
+// from periodStart to periodEnd to the pending invoices of the subscription, so it is saved together with the payment
+// the tax breakdown of the subscription price is used for the charge of the full price, otherwise it is calculated
+// with the subscription tax rate, the amounts of the credit note are negative
+func addPendingInvoice(subscription *domain.UserSubscription, payment *domain.Payment, periodStart time.Time, periodEnd time.Time) {
+	if payment == nil {
+		return
+	}
+
+	line := domain.InvoiceLine{
+		ProductID:   subscription.ProductID,
+		ProductName: subscription.ProductName,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Net:         subscription.NetPrice,
+		Tax:         subscription.Tax,
+		Gross:       payment.Amount,
+		TaxRate:     subscription.TaxRate,
+	}
+	if payment.Amount != subscription.Price {
+		tax := domain.CalculateTax(payment.Amount, subscription.TaxRate, true)
+		line.Net = tax.Net
+		line.Tax = tax.Tax
+	}
+
+	if payment.Type == domain.PaymentTypeRefund {
+		line.Net.Amount = -line.Net.Amount
+		line.Tax.Amount = -line.Tax.Amount
+		line.Gross.Amount = -line.Gross.Amount
+	}
+
+	invoice := domain.NewInvoice(subscription, payment, []domain.InvoiceLine{line}, payment.CreatedAt)
+	subscription.PendingInvoices = append(subscription.PendingInvoices, invoice)
+}
+
+// issueSavedInvoices issues the pending invoices of the subscription right after it is saved with the payment
+// failure to issue the invoice is logged and does not fail the payment, the invoice stays pending with the subscription
+func (a *appDetails) issueSavedInvoices(ctx context.Context, subscription *domain.UserSubscription) {
+	err := a.issuePendingInvoices(ctx, subscription)
+	if err != nil {
+		log.Printf("invoice of subscription %v is left pending: %v", subscription.ID, err)
+	}
+}
+
+// issuePendingInvoices issues the pending invoices of the saved subscription and removes the issued ones from it
+// the invoice keeps its ID while it is pending, so the invoice issued by the failed attempt is not issued again
+// returns error if any invoice failed to be issued, it stays pending and is issued by the next IssuePendingInvoices run
+func (a *appDetails) issuePendingInvoices(ctx context.Context, subscription *domain.UserSubscription) error {
+	if len(subscription.PendingInvoices) == 0 {
+		return nil
+	}
+
+	issued := []string{}
+	pendingInvoices := []domain.Invoice{}
+	var issueErr error
+	for _, v := range subscription.PendingInvoices {
+		invoice := v
+		_, err := a.database.SaveInvoice(ctx, &invoice)
+		if err != nil {
+			issueErr = fmt.Errorf("invoice %v of authorization %v: %w", v.ID, v.AuthorizationID, err)
+			pendingInvoices = append(pendingInvoices, v)
+			continue
+		}
+		issued = append(issued, v.ID)
+	}
+
+	if len(issued) > 0 {
+		err := a.database.RemovePendingInvoices(ctx, subscription.ID, issued)
+		if err != nil {
+			return err
+		}
+	}
+
+	subscription.PendingInvoices = nil
+	if len(pendingInvoices) > 0 {
+		subscription.PendingInvoices = pendingInvoices
+	}
+	return issueErr
+}
@@ -0,0 +1,443 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestGetSubscriptionInvoices() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	invoices := []domain.Invoice{
+		{
+			ID:             "62bb4ecdba3bbe275f8c7790",
+			Number:         "2022-000001",
+			SubscriptionID: subscriptionID,
+			Total:          domain.NewMoney(1000, "EUR"),
+		},
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&domain.UserSubscription{ID: subscriptionID}, nil).Times(1),
+		database.EXPECT().GetInvoices(gomock.Any(), subscriptionID).Return(invoices, nil).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(nil, db.RecordNotFoundErr).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		want    []domain.Invoice
+		wantErr error
+	}{
+		{
+			name: "should return invoices of the subscription",
+			id:   subscriptionID,
+			want: invoices,
+		},
+		{
+			name:    "should return error if subscription is not found",
+			id:      subscriptionID,
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if id is empty",
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.GetSubscriptionInvoices(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.GetSubscriptionInvoices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appDetails.GetSubscriptionInvoices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestGetSubscriptionInvoice() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	subscription := &domain.UserSubscription{ID: subscriptionID}
+	invoice := &domain.Invoice{
+		ID:             "62bb4ecdba3bbe275f8c7790",
+		Number:         "2022-000001",
+		SubscriptionID: subscriptionID,
+		Total:          domain.NewMoney(1000, "EUR"),
+	}
+	otherInvoice := &domain.Invoice{
+		ID:             "62bb4ecdba3bbe275f8c7791",
+		Number:         "2022-000002",
+		SubscriptionID: "62bb4ecdba3bbe275f8c7789",
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(subscription, nil).Times(1),
+		database.EXPECT().GetInvoiceByNumber(gomock.Any(), invoice.Number).Return(invoice, nil).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(subscription, nil).Times(1),
+		database.EXPECT().GetInvoiceByNumber(gomock.Any(), otherInvoice.Number).Return(otherInvoice, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(subscription, nil).Times(1),
+		database.EXPECT().GetInvoiceByNumber(gomock.Any(), "2022-000003").Return(nil, db.RecordNotFoundErr).Times(1),
+
+		// test 4
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(nil, db.RecordNotFoundErr).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		id      string
+		number  string
+		want    *domain.Invoice
+		wantErr error
+	}{
+		{
+			name:   "should return invoice of the subscription",
+			id:     subscriptionID,
+			number: invoice.Number,
+			want:   invoice,
+		},
+		{
+			name:    "should return error if invoice belongs to other subscription",
+			id:      subscriptionID,
+			number:  otherInvoice.Number,
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if invoice is not found",
+			id:      subscriptionID,
+			number:  "2022-000003",
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if subscription is not found",
+			id:      subscriptionID,
+			number:  invoice.Number,
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if number is empty",
+			id:      subscriptionID,
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.GetSubscriptionInvoice(ctx, tt.id, tt.number)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.GetSubscriptionInvoice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appDetails.GetSubscriptionInvoice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_addPendingInvoice(t *testing.T) {
+	chargedAt := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	newSubscription := func() *domain.UserSubscription {
+		return &domain.UserSubscription{
+			ID:          "62bb4ecdba3bbe275f8c7788",
+			Email:       "user@test.com",
+			Country:     "DE",
+			ProductID:   "62bac26a69c9410f916fc262",
+			ProductName: "hiphop cardio",
+			Price:       domain.NewMoney(1190, "EUR"),
+			NetPrice:    domain.NewMoney(1000, "EUR"),
+			Tax:         domain.NewMoney(190, "EUR"),
+			TaxRate:     19,
+		}
+	}
+	renewal := &domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeCharge,
+		Reason:          domain.PaymentReasonRenewal,
+		Amount:          domain.NewMoney(1190, "EUR"),
+		CreatedAt:       chargedAt,
+	}
+	proration := &domain.Payment{
+		AuthorizationID: "auth_2",
+		Type:            domain.PaymentTypeCharge,
+		Reason:          domain.PaymentReasonPlanChange,
+		Amount:          domain.NewMoney(595, "EUR"),
+		CreatedAt:       chargedAt,
+	}
+	refund := &domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeRefund,
+		Reason:          domain.PaymentReasonPlanChange,
+		Amount:          domain.NewMoney(595, "EUR"),
+		CreatedAt:       chargedAt,
+	}
+
+	tests := []struct {
+		name     string
+		payment  *domain.Payment
+		wantType domain.InvoiceType
+		wantNet  int64
+		wantTax  int64
+		wantSum  int64
+	}{
+		{
+			name:     "should add invoice of the full price charge with the subscription tax breakdown",
+			payment:  renewal,
+			wantType: domain.InvoiceTypeInvoice,
+			wantNet:  1000,
+			wantTax:  190,
+			wantSum:  1190,
+		},
+		{
+			name:     "should add invoice of the partial charge with calculated tax breakdown",
+			payment:  proration,
+			wantType: domain.InvoiceTypeInvoice,
+			wantNet:  500,
+			wantTax:  95,
+			wantSum:  595,
+		},
+		{
+			name:     "should add credit note of the refund with negative amounts",
+			payment:  refund,
+			wantType: domain.InvoiceTypeCreditNote,
+			wantNet:  -500,
+			wantTax:  -95,
+			wantSum:  -595,
+		},
+		{
+			name: "should not add invoice without payment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := newSubscription()
+			addPendingInvoice(subscription, tt.payment, chargedAt, periodEnd)
+
+			if tt.payment == nil {
+				if len(subscription.PendingInvoices) != 0 {
+					t.Errorf("addPendingInvoice() pending invoices = %v, want none", subscription.PendingInvoices)
+				}
+				return
+			}
+
+			if len(subscription.PendingInvoices) != 1 {
+				t.Fatalf("addPendingInvoice() pending invoices = %v, want 1 invoice", subscription.PendingInvoices)
+			}
+			invoice := subscription.PendingInvoices[0]
+			line := domain.InvoiceLine{
+				ProductID:   subscription.ProductID,
+				ProductName: subscription.ProductName,
+				PeriodStart: chargedAt,
+				PeriodEnd:   periodEnd,
+				Net:         domain.NewMoney(tt.wantNet, "EUR"),
+				Tax:         domain.NewMoney(tt.wantTax, "EUR"),
+				Gross:       domain.NewMoney(tt.wantSum, "EUR"),
+				TaxRate:     19,
+			}
+			if invoice.Type != tt.wantType || invoice.SubscriptionID != subscription.ID || invoice.Email != subscription.Email ||
+				invoice.AuthorizationID != tt.payment.AuthorizationID || !invoice.IssuedAt.Equal(chargedAt) ||
+				!reflect.DeepEqual(invoice.Lines, []domain.InvoiceLine{line}) || invoice.Total != domain.NewMoney(tt.wantSum, "EUR") {
+				t.Errorf("addPendingInvoice() invoice = %v, want %v of %v with line %v", invoice, tt.wantType, tt.payment, line)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) Test_issuePendingInvoices() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	issuedAt := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	invoice := domain.Invoice{
+		ID:             "62bb4ecdba3bbe275f8c7701",
+		Type:           domain.InvoiceTypeInvoice,
+		SubscriptionID: subscriptionID,
+		Total:          domain.NewMoney(1190, "EUR"),
+		IssuedAt:       issuedAt,
+	}
+	creditNote := domain.Invoice{
+		ID:             "62bb4ecdba3bbe275f8c7702",
+		Type:           domain.InvoiceTypeCreditNote,
+		SubscriptionID: subscriptionID,
+		Total:          domain.NewMoney(-595, "EUR"),
+		IssuedAt:       issuedAt,
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().SaveInvoice(gomock.Any(), &invoice).Return(&invoice, nil).Times(1),
+		database.EXPECT().SaveInvoice(gomock.Any(), &creditNote).Return(&creditNote, nil).Times(1),
+		database.EXPECT().RemovePendingInvoices(gomock.Any(), subscriptionID, []string{invoice.ID, creditNote.ID}).Return(nil).Times(1),
+
+		// test 2
+		database.EXPECT().SaveInvoice(gomock.Any(), &invoice).Return(nil, fmt.Errorf("db error")).Times(1),
+		database.EXPECT().SaveInvoice(gomock.Any(), &creditNote).Return(&creditNote, nil).Times(1),
+		database.EXPECT().RemovePendingInvoices(gomock.Any(), subscriptionID, []string{creditNote.ID}).Return(nil).Times(1),
+
+		// test 3
+		database.EXPECT().SaveInvoice(gomock.Any(), &invoice).Return(&invoice, nil).Times(1),
+		database.EXPECT().RemovePendingInvoices(gomock.Any(), subscriptionID, []string{invoice.ID}).Return(fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name            string
+		pendingInvoices []domain.Invoice
+		wantPending     []domain.Invoice
+		wantErr         bool
+	}{
+		{
+			name:            "should issue pending invoices and remove them from the subscription",
+			pendingInvoices: []domain.Invoice{invoice, creditNote},
+			wantPending:     nil,
+			wantErr:         false,
+		},
+		{
+			name:            "should keep the invoice pending if it cannot be issued",
+			pendingInvoices: []domain.Invoice{invoice, creditNote},
+			wantPending:     []domain.Invoice{invoice},
+			wantErr:         true,
+		},
+		{
+			name:            "should return error if issued invoices cannot be removed",
+			pendingInvoices: []domain.Invoice{invoice},
+			wantPending:     []domain.Invoice{invoice},
+			wantErr:         true,
+		},
+		{
+			name:            "should do nothing without pending invoices",
+			pendingInvoices: nil,
+			wantPending:     nil,
+			wantErr:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			subscription := &domain.UserSubscription{
+				ID:              subscriptionID,
+				PendingInvoices: append([]domain.Invoice(nil), tt.pendingInvoices...),
+			}
+			err := a.issuePendingInvoices(ctx, subscription)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.issuePendingInvoices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(subscription.PendingInvoices, tt.wantPending) {
+				t.Errorf("appDetails.issuePendingInvoices() pending invoices = %v, want %v", subscription.PendingInvoices, tt.wantPending)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestIssuePendingInvoices() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	invoice := domain.Invoice{
+		ID:             "62bb4ecdba3bbe275f8c7701",
+		Type:           domain.InvoiceTypeInvoice,
+		SubscriptionID: "62bb4ecdba3bbe275f8c7788",
+		Total:          domain.NewMoney(1190, "EUR"),
+		IssuedAt:       time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC),
+	}
+	// the pending invoices are removed from the found subscriptions, so every run finds new ones
+	pendingSubscriptions := func() []domain.UserSubscription {
+		return []domain.UserSubscription{
+			{
+				ID:              "62bb4ecdba3bbe275f8c7788",
+				PendingInvoices: []domain.Invoice{invoice},
+			},
+		}
+	}
+	filter := db.SubscriptionFilter{
+		HasPendingInvoices: true,
+		Limit:              renewalBatchSize,
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(pendingSubscriptions(), nil).Times(1),
+		database.EXPECT().SaveInvoice(gomock.Any(), &invoice).Return(&invoice, nil).Times(1),
+		database.EXPECT().RemovePendingInvoices(gomock.Any(), invoice.SubscriptionID, []string{invoice.ID}).Return(nil).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(pendingSubscriptions(), nil).Times(1),
+		database.EXPECT().SaveInvoice(gomock.Any(), &invoice).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), filter).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "should issue pending invoices of the subscriptions",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should return error if invoice cannot be issued",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "should return error if subscriptions cannot be found",
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.IssuePendingInvoices(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.IssuePendingInvoices() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("appDetails.IssuePendingInvoices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
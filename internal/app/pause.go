@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// PauseSubscriptionByID pauses the active subscription with given id until the optional resume date
+// the pause is limited by the product rules - number of pauses per subscription period and maximum pause length,
+// if the product limits the pause length then subscription without resume date is resumed when the limit is reached
+// returns invalid argument error if resume date is not in the future or exceeds maximum pause length
+// reason is recorded in the pause history of the subscription together with the actor from the context
+// returns not allowed error if subscription is not active or pause quota is used up
+func (a *appDetails) PauseSubscriptionByID(ctx context.Context, id string, resumeDate *time.Time, reason string) (*domain.UserSubscription, error) {
+	subscriptionDetails, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscriptionDetails.Status != domain.SubscriptionStatusActive {
+		return nil, fmt.Errorf("%v subscription pause %w", subscriptionDetails.Status, NotAllowedArgErr)
+	}
+
+	timeNow := time.Now().UTC()
+	updatedSubscriptionDetails := *subscriptionDetails
+	updatedSubscriptionDetails.UpdatedAt = &timeNow
+	err = a.pauseSubscription(ctx, &updatedSubscriptionDetails, resumeDate, reason, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.saveSubscription(ctx, domain.AuditActionStatusChanged, subscriptionDetails.Status, &updatedSubscriptionDetails)
+}
+
+// ResumeSubscriptions resumes paused subscriptions with resume date before or equal to given time
+// subscription is resumed as of its resume date, so the end date is extended only for the paused period
+// all the due subscriptions are resumed in batches, see processDueSubscriptions
+// returns number of processed subscriptions and the last error if any subscription failed
+func (a *appDetails) ResumeSubscriptions(ctx context.Context, at time.Time) (int, error) {
+	filter := db.SubscriptionFilter{
+		Statuses:         []domain.SubscriptionStatus{domain.SubscriptionStatusPaused},
+		ResumeDateBefore: &at,
+	}
+	return a.processDueSubscriptions(ctx, filter, func(subscription *domain.UserSubscription) error {
+		subscription.UpdatedAt = &at
+		resumeSubscription(subscription, *subscription.ResumeDate)
+
+		_, err := a.saveSubscription(ctx, domain.AuditActionStatusChanged, domain.SubscriptionStatusPaused, subscription)
+		if err != nil {
+			log.Printf("resume of subscription %v failed: %v", subscription.ID, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// pauseSubscription pauses the subscription at given time applying the pause rules of the subscription product
+// number of pauses per period is counted from the pause history since the start of the current period
+func (a *appDetails) pauseSubscription(ctx context.Context, subscription *domain.UserSubscription, resumeDate *time.Time, reason string, at time.Time) error {
+	if resumeDate != nil && !resumeDate.After(at) {
+		return fmt.Errorf("resume date must be in the future %w", InvalidArgErr)
+	}
+
+	product, err := a.findProduct(ctx, subscription.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product != nil {
+		if product.MaxPausesPerPeriod > 0 && subscription.PausesSince(currentPeriodStart(subscription)) >= product.MaxPausesPerPeriod {
+			return fmt.Errorf("maximum %v pauses per period reached %w", product.MaxPausesPerPeriod, NotAllowedArgErr)
+		}
+
+		if product.MaxPauseDays > 0 {
+			maxResumeDate := at.AddDate(0, 0, int(product.MaxPauseDays))
+			if resumeDate == nil {
+				resumeDate = &maxResumeDate
+			} else if resumeDate.After(maxResumeDate) {
+				return fmt.Errorf("pause longer than %v days %w", product.MaxPauseDays, InvalidArgErr)
+			}
+		}
+	}
+
+	subscription.Status = domain.SubscriptionStatusPaused
+	subscription.ResumeDate = resumeDate
+	subscription.Pauses = append(append([]domain.Pause{}, subscription.Pauses...), domain.Pause{
+		StartDate: at,
+		Reason:    reason,
+		Actor:     ActorFromContext(ctx),
+	})
+	return nil
+}
+
+// resumeSubscription activates the paused subscription at given time and extends its end date for the closed pause
+func resumeSubscription(subscription *domain.UserSubscription, at time.Time) {
+	pause := closePause(subscription, at)
+	if pause != nil {
+		subscription.EndDate = subscription.EndDate.Add(pause.Duration())
+	}
+	subscription.Status = domain.SubscriptionStatusActive
+	subscription.ResumeDate = nil
+}
+
+// closePause ends the open pause of the subscription at given time and returns it, returns nil if there is no open pause
+func closePause(subscription *domain.UserSubscription, at time.Time) *domain.Pause {
+	subscription.Pauses = append([]domain.Pause{}, subscription.Pauses...)
+	pause := subscription.CurrentPause()
+	if pause != nil {
+		pause.EndDate = &at
+	}
+	return pause
+}
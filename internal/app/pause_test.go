@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestPauseSubscriptionByID() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	productID := "62bac24b0bf33af1c877d97f"
+	product := domain.Product{
+		ID:                 productID,
+		SubscriptionPeriod: 1,
+		MaxPauseDays:       30,
+		MaxPausesPerPeriod: 2,
+	}
+	subscriptionRecord := domain.UserSubscription{
+		ID:        subscriptionID,
+		ProductID: productID,
+		Status:    domain.SubscriptionStatusActive,
+	}
+	quotaUsedSubscriptionRecord := subscriptionRecord
+	previousPauseEndDate := time.Now().UTC().AddDate(0, 0, -1)
+	quotaUsedSubscriptionRecord.StartDate = time.Now().UTC().AddDate(0, 0, -10)
+	quotaUsedSubscriptionRecord.Pauses = []domain.Pause{
+		{
+			StartDate: time.Now().UTC().AddDate(0, 0, -5),
+			EndDate:   &previousPauseEndDate,
+		},
+		{
+			StartDate: time.Now().UTC().AddDate(0, 0, -1),
+			EndDate:   &previousPauseEndDate,
+		},
+	}
+	pausedSubscriptionRecord := subscriptionRecord
+	pausedSubscriptionRecord.Status = domain.SubscriptionStatusPaused
+
+	resumeDate := time.Now().UTC().AddDate(0, 0, 10)
+	tooLateResumeDate := time.Now().UTC().AddDate(0, 0, 31)
+	pastResumeDate := time.Now().UTC().Add(-time.Hour)
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			pause := us.CurrentPause()
+			if us.Status != domain.SubscriptionStatusPaused || len(us.Pauses) != 1 || pause == nil || pause.Reason != "holiday" ||
+				pause.Actor != "user@test.com" || us.ResumeDate == nil || !us.ResumeDate.Equal(resumeDate) {
+				return nil, fmt.Errorf("unexpected paused subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			pause := us.CurrentPause()
+			if pause == nil || pause.Actor != ActorSystem || us.ResumeDate == nil || !us.ResumeDate.Equal(pause.StartDate.AddDate(0, 0, 30)) {
+				return nil, fmt.Errorf("unexpected resume date %v", us.ResumeDate)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+
+		// test 4
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&quotaUsedSubscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+
+		// test 5
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+
+		// test 6
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&pausedSubscriptionRecord, nil).Times(1),
+	)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		resumeDate *time.Time
+		reason     string
+		wantErr    error
+	}{
+		{
+			name:       "should pause subscription until resume date",
+			ctx:        ContextWithActor(ctx, "user@test.com"),
+			resumeDate: &resumeDate,
+			reason:     "holiday",
+		},
+		{
+			name: "should limit pause without resume date to max pause length",
+			ctx:  ctx,
+		},
+		{
+			name:       "should return error if pause is longer than max pause length",
+			ctx:        ctx,
+			resumeDate: &tooLateResumeDate,
+			wantErr:    InvalidArgErr,
+		},
+		{
+			name:    "should return error if pause quota is used up",
+			ctx:     ctx,
+			wantErr: NotAllowedArgErr,
+		},
+		{
+			name:       "should return error if resume date is in the past",
+			ctx:        ctx,
+			resumeDate: &pastResumeDate,
+			wantErr:    InvalidArgErr,
+		},
+		{
+			name:    "should return error if subscription is not active",
+			ctx:     ctx,
+			wantErr: NotAllowedArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			_, err := a.PauseSubscriptionByID(tt.ctx, subscriptionID, tt.resumeDate, tt.reason)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.PauseSubscriptionByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestResumeSubscriptions() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pauseStartDate := at.AddDate(0, 0, -10)
+	resumeDate := at.Add(-time.Hour)
+	endDate := at.AddDate(0, 0, 5)
+	pausedSubscription := domain.UserSubscription{
+		ID:      "62bb4ecdba3bbe275f8c7781",
+		EndDate: endDate,
+		Status:  domain.SubscriptionStatusPaused,
+		Pauses: []domain.Pause{
+			{
+				StartDate: pauseStartDate,
+			},
+		},
+		ResumeDate: &resumeDate,
+	}
+	expectedFilter := db.SubscriptionFilter{
+		Statuses:         []domain.SubscriptionStatus{domain.SubscriptionStatusPaused},
+		ResumeDateBefore: &at,
+		Limit:            renewalBatchSize,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			pausedSubscription,
+		}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantEndDate := endDate.Add(resumeDate.Sub(pauseStartDate))
+			if us.Status != domain.SubscriptionStatusActive || !us.EndDate.Equal(wantEndDate) || us.ResumeDate != nil ||
+				us.Pauses[0].EndDate == nil || !us.Pauses[0].EndDate.Equal(resumeDate) {
+				return nil, fmt.Errorf("unexpected resumed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			pausedSubscription,
+		}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "should resume paused subscription as of its resume date",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should return error if resumed subscription cannot be saved",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "should return error if due subscriptions cannot be fetched",
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.ResumeSubscriptions(ctx, at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.ResumeSubscriptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("appDetails.ResumeSubscriptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
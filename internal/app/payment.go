@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+var (
+	PaymentDeclinedErr = errors.New("payment declined")
+	PaymentTimeoutErr  = errors.New("payment provider timed out")
+)
+
+// PaymentProvider charges and refunds the customer through the payment gateway
+// Authorize reserves the amount on the card of the token and returns the authorization ID,
+// the authorization is either captured (charged) or voided (released)
+// Refund returns the amount of the captured authorization back to the card
+// errors of declined payment match PaymentDeclinedErr and errors of unanswered request match PaymentTimeoutErr
+//
+//go:generate mockgen -destination=../mocks/mock_payment_provider.go -package=mocks github.com/ganeshdipdumbare/gymondo-subscription/internal/app PaymentProvider
+type PaymentProvider interface {
+	Authorize(ctx context.Context, token string, amount domain.Money) (string, error)
+	Capture(ctx context.Context, authorizationID string) error
+	Void(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, authorizationID string, amount domain.Money) error
+}
+
+// chargePayment authorizes and captures the amount on the card of the token, the authorization is voided if capture fails
+// returns nil payment if there is nothing to charge
+func (a *appDetails) chargePayment(ctx context.Context, token string, amount domain.Money, reason domain.PaymentReason, at time.Time) (*domain.Payment, error) {
+	payment, err := a.authorizePayment(ctx, token, amount, reason, at)
+	if err != nil || payment == nil {
+		return nil, err
+	}
+
+	err = a.capturePayment(ctx, payment)
+	if err != nil {
+		a.voidPayment(ctx, payment)
+		return nil, err
+	}
+	return payment, nil
+}
+
+// authorizePayment reserves the amount on the card of the token and returns the charge to be captured
+// returns nil payment if there is nothing to charge
+func (a *appDetails) authorizePayment(ctx context.Context, token string, amount domain.Money, reason domain.PaymentReason, at time.Time) (*domain.Payment, error) {
+	if amount.Amount <= 0 {
+		return nil, nil
+	}
+
+	authorizationID, err := a.paymentProvider.Authorize(ctx, token, amount)
+	if err != nil {
+		return nil, fmt.Errorf("authorize %v payment failed: %w", reason, err)
+	}
+
+	return &domain.Payment{
+		AuthorizationID: authorizationID,
+		Type:            domain.PaymentTypeCharge,
+		Reason:          reason,
+		Amount:          amount,
+		CreatedAt:       at,
+	}, nil
+}
+
+// capturePayment captures the authorized charge
+func (a *appDetails) capturePayment(ctx context.Context, charge *domain.Payment) error {
+	err := a.paymentProvider.Capture(ctx, charge.AuthorizationID)
+	if err != nil {
+		return fmt.Errorf("capture %v payment failed: %w", charge.Reason, err)
+	}
+	return nil
+}
+
+// voidPayment releases the authorized charge which is not going to be captured
+func (a *appDetails) voidPayment(ctx context.Context, charge *domain.Payment) {
+	if charge == nil {
+		return
+	}
+
+	err := a.paymentProvider.Void(ctx, charge.AuthorizationID)
+	if err != nil {
+		log.Printf("void of authorization %v failed: %v", charge.AuthorizationID, err)
+	}
+}
+
+// refundPayment refunds the amount of the captured charge, the refund is limited to the charged amount
+func (a *appDetails) refundPayment(ctx context.Context, charge *domain.Payment, amount domain.Money, reason domain.PaymentReason, at time.Time) (*domain.Payment, error) {
+	if amount.Amount > charge.Amount.Amount {
+		amount = charge.Amount
+	}
+
+	if amount.Amount <= 0 {
+		return nil, nil
+	}
+
+	err := a.paymentProvider.Refund(ctx, charge.AuthorizationID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("refund %v payment failed: %w", reason, err)
+	}
+
+	return &domain.Payment{
+		AuthorizationID: charge.AuthorizationID,
+		Type:            domain.PaymentTypeRefund,
+		Reason:          reason,
+		Amount:          amount,
+		CreatedAt:       at,
+	}, nil
+}
+
+// reverseCharge refunds the charge of the change which could not be saved
+func (a *appDetails) reverseCharge(ctx context.Context, charge *domain.Payment) {
+	if charge == nil {
+		return
+	}
+
+	err := a.paymentProvider.Refund(ctx, charge.AuthorizationID, charge.Amount)
+	if err != nil {
+		log.Printf("refund of unsaved charge %v failed: %v", charge.AuthorizationID, err)
+	}
+}
+
+// ConfirmPayment settles the pending purchase payment of the subscription with given id
+// succeeded payment is captured and the subscription is activated for the product subscription period
+// starting at the confirmation time and the invoice of the charge is saved with it and issued, failed payment is voided and the subscription is moved to payment failed status
+// payment declined by the capture fails the subscription as well, other capture errors keep the payment pending
+// returns invalid argument error if id or authorizationID is empty or authorizationID is not the pending payment of the subscription
+// returns not allowed error if the subscription is not pending payment
+func (a *appDetails) ConfirmPayment(ctx context.Context, id string, authorizationID string, succeeded bool) (*domain.UserSubscription, error) {
+	if id == "" || authorizationID == "" {
+		return nil, InvalidArgErr
+	}
+
+	subscriptionDetails, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscriptionDetails.Status != domain.SubscriptionStatusPendingPayment {
+		return nil, fmt.Errorf("%v subscription payment confirmation %w", subscriptionDetails.Status, NotAllowedArgErr)
+	}
+
+	pendingPayment := subscriptionDetails.PendingPayment
+	if pendingPayment == nil || pendingPayment.AuthorizationID != authorizationID {
+		return nil, fmt.Errorf("authorization %v is not pending for subscription %v %w", authorizationID, id, InvalidArgErr)
+	}
+
+	timeNow := time.Now().UTC()
+	updatedSubscriptionDetails := *subscriptionDetails
+	updatedSubscriptionDetails.UpdatedAt = &timeNow
+	updatedSubscriptionDetails.PendingPayment = nil
+
+	if !succeeded {
+		a.voidPayment(ctx, pendingPayment)
+		updatedSubscriptionDetails.Status = domain.SubscriptionStatusPaymentFailed
+		return a.saveSubscription(ctx, domain.AuditActionPaymentFailed, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	}
+
+	product, err := a.findProduct(ctx, subscriptionDetails.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, fmt.Errorf("product %v %w", subscriptionDetails.ProductID, NotFoundErr)
+	}
+
+	err = a.capturePayment(ctx, pendingPayment)
+	if err != nil {
+		if !errors.Is(err, PaymentDeclinedErr) {
+			return nil, err
+		}
+		log.Printf("payment of subscription %v failed: %v", id, err)
+		a.voidPayment(ctx, pendingPayment)
+		updatedSubscriptionDetails.Status = domain.SubscriptionStatusPaymentFailed
+		return a.saveSubscription(ctx, domain.AuditActionPaymentFailed, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	}
+
+	charge := *pendingPayment
+	charge.CreatedAt = timeNow
+	updatedSubscriptionDetails.Payments = append(updatedSubscriptionDetails.Payments, charge)
+	updatedSubscriptionDetails.Status = domain.SubscriptionStatusActive
+	updatedSubscriptionDetails.StartDate = timeNow
+	updatedSubscriptionDetails.EndDate = timeNow.AddDate(0, int(product.SubscriptionPeriod), 0)
+	addPendingInvoice(&updatedSubscriptionDetails, &charge, updatedSubscriptionDetails.StartDate, updatedSubscriptionDetails.EndDate)
+
+	savedSubscription, err := a.saveSubscription(ctx, domain.AuditActionPaymentConfirmed, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	if err != nil {
+		a.reverseCharge(ctx, &charge)
+		return nil, err
+	}
+
+	a.issueSavedInvoices(ctx, savedSubscription)
+	return savedSubscription, nil
+}
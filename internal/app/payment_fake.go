@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+const (
+	// FakeTokenDecline is the card token the fake payment provider declines
+	FakeTokenDecline = "tok_decline"
+	// FakeTokenTimeout is the card token the fake payment provider does not answer for
+	FakeTokenTimeout = "tok_timeout"
+)
+
+// fakeAuthorization is the authorization kept by the fake payment provider
+type fakeAuthorization struct {
+	amount   domain.Money
+	captured bool
+	voided   bool
+	refunded int64
+}
+
+type fakePaymentProvider struct {
+	mu             sync.Mutex
+	authorizations map[string]*fakeAuthorization
+}
+
+// NewFakePaymentProvider creates payment provider which does not charge anyone, it is meant for tests and local setup
+// payment with FakeTokenDecline token is declined, payment with FakeTokenTimeout token times out
+// and payment with any other non-empty token succeeds
+func NewFakePaymentProvider() PaymentProvider {
+	return &fakePaymentProvider{
+		authorizations: map[string]*fakeAuthorization{},
+	}
+}
+
+// Authorize returns new authorization ID for the amount, the result depends on the token only
+func (f *fakePaymentProvider) Authorize(ctx context.Context, token string, amount domain.Money) (string, error) {
+	switch token {
+	case "":
+		return "", fmt.Errorf("card token %w", InvalidArgErr)
+	case FakeTokenDecline:
+		return "", fmt.Errorf("card %v %w", token, PaymentDeclinedErr)
+	case FakeTokenTimeout:
+		return "", fmt.Errorf("authorize with card %v %w", token, PaymentTimeoutErr)
+	}
+
+	if amount.Amount <= 0 {
+		return "", fmt.Errorf("amount %v %w", amount, InvalidArgErr)
+	}
+
+	id, err := newFakeAuthorizationID()
+	if err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.authorizations[id] = &fakeAuthorization{
+		amount: amount,
+	}
+	return id, nil
+}
+
+// Capture charges the authorized amount, authorization can be captured only once and not after it is voided
+func (f *fakePaymentProvider) Capture(ctx context.Context, authorizationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	authorization, err := f.authorization(authorizationID)
+	if err != nil {
+		return err
+	}
+
+	if authorization.captured || authorization.voided {
+		return fmt.Errorf("authorization %v is closed %w", authorizationID, NotAllowedArgErr)
+	}
+
+	authorization.captured = true
+	return nil
+}
+
+// Void releases the authorized amount, captured authorization cannot be voided
+func (f *fakePaymentProvider) Void(ctx context.Context, authorizationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	authorization, err := f.authorization(authorizationID)
+	if err != nil {
+		return err
+	}
+
+	if authorization.captured || authorization.voided {
+		return fmt.Errorf("authorization %v is closed %w", authorizationID, NotAllowedArgErr)
+	}
+
+	authorization.voided = true
+	return nil
+}
+
+// Refund returns the amount of the captured authorization, refunds cannot exceed the captured amount
+func (f *fakePaymentProvider) Refund(ctx context.Context, authorizationID string, amount domain.Money) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	authorization, err := f.authorization(authorizationID)
+	if err != nil {
+		return err
+	}
+
+	if !authorization.captured {
+		return fmt.Errorf("authorization %v is not captured %w", authorizationID, NotAllowedArgErr)
+	}
+
+	if amount.Currency != authorization.amount.Currency || amount.Amount <= 0 ||
+		authorization.refunded+amount.Amount > authorization.amount.Amount {
+		return fmt.Errorf("refund %v of authorization %v %w", amount, authorizationID, InvalidArgErr)
+	}
+
+	authorization.refunded += amount.Amount
+	return nil
+}
+
+// newFakeAuthorizationID returns random authorization ID, it stays unique across the restarts of the service
+// so the authorization IDs stored with the subscriptions are never reused
+func newFakeAuthorizationID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("authorization ID: %w", err)
+	}
+	return "fake_auth_" + hex.EncodeToString(b), nil
+}
+
+// authorization returns the authorization for given ID, returns not found error if it does not exist
+func (f *fakePaymentProvider) authorization(authorizationID string) (*fakeAuthorization, error) {
+	authorization, ok := f.authorizations[authorizationID]
+	if !ok {
+		return nil, fmt.Errorf("authorization %v %w", authorizationID, NotFoundErr)
+	}
+	return authorization, nil
+}
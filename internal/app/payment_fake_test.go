@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+func TestFakePaymentProvider(t *testing.T) {
+	ctx := context.Background()
+	amount := domain.NewMoney(1000, "EUR")
+
+	tests := []struct {
+		name    string
+		run     func(provider PaymentProvider) error
+		wantErr error
+	}{
+		{
+			name: "should authorize, capture and refund the payment",
+			run: func(provider PaymentProvider) error {
+				id, err := provider.Authorize(ctx, "tok_visa", amount)
+				if err != nil {
+					return err
+				}
+				err = provider.Capture(ctx, id)
+				if err != nil {
+					return err
+				}
+				err = provider.Refund(ctx, id, domain.NewMoney(400, "EUR"))
+				if err != nil {
+					return err
+				}
+				return provider.Refund(ctx, id, domain.NewMoney(600, "EUR"))
+			},
+		},
+		{
+			name: "should decline the payment of the decline token",
+			run: func(provider PaymentProvider) error {
+				_, err := provider.Authorize(ctx, FakeTokenDecline, amount)
+				return err
+			},
+			wantErr: PaymentDeclinedErr,
+		},
+		{
+			name: "should time out the payment of the timeout token",
+			run: func(provider PaymentProvider) error {
+				_, err := provider.Authorize(ctx, FakeTokenTimeout, amount)
+				return err
+			},
+			wantErr: PaymentTimeoutErr,
+		},
+		{
+			name: "should return error for empty token",
+			run: func(provider PaymentProvider) error {
+				_, err := provider.Authorize(ctx, "", amount)
+				return err
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should not capture voided authorization",
+			run: func(provider PaymentProvider) error {
+				id, err := provider.Authorize(ctx, "tok_visa", amount)
+				if err != nil {
+					return err
+				}
+				err = provider.Void(ctx, id)
+				if err != nil {
+					return err
+				}
+				return provider.Capture(ctx, id)
+			},
+			wantErr: NotAllowedArgErr,
+		},
+		{
+			name: "should not refund more than captured amount",
+			run: func(provider PaymentProvider) error {
+				id, err := provider.Authorize(ctx, "tok_visa", amount)
+				if err != nil {
+					return err
+				}
+				err = provider.Capture(ctx, id)
+				if err != nil {
+					return err
+				}
+				return provider.Refund(ctx, id, domain.NewMoney(1001, "EUR"))
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return not found error for unknown authorization",
+			run: func(provider PaymentProvider) error {
+				return provider.Capture(ctx, "fake_auth_0")
+			},
+			wantErr: NotFoundErr,
+		},
+		{
+			name: "should not reuse authorization ID after restart",
+			run: func(provider PaymentProvider) error {
+				id, err := provider.Authorize(ctx, "tok_visa", amount)
+				if err != nil {
+					return err
+				}
+				restartedID, err := NewFakePaymentProvider().Authorize(ctx, "tok_visa", amount)
+				if err != nil {
+					return err
+				}
+				if restartedID == id {
+					return fmt.Errorf("authorization ID %v reused", id)
+				}
+				return provider.Capture(ctx, restartedID)
+			},
+			wantErr: NotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(NewFakePaymentProvider())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("fakePaymentProvider error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
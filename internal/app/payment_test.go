@@ -0,0 +1,653 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestChargePayment() {
+	t := suite.T()
+
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	amount := domain.NewMoney(1000, "EUR")
+
+	gomock.InOrder(
+		// test 1
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", amount).Return("auth_1", nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_1").Return(nil).Times(1),
+		// test 3
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", amount).Return("", PaymentDeclinedErr).Times(1),
+		// test 4
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", amount).Return("auth_2", nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_2").Return(PaymentTimeoutErr).Times(1),
+		paymentProvider.EXPECT().Void(gomock.Any(), "auth_2").Return(nil).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		amount  domain.Money
+		want    *domain.Payment
+		wantErr error
+	}{
+		{
+			name:   "should authorize and capture the amount",
+			amount: amount,
+			want: &domain.Payment{
+				AuthorizationID: "auth_1",
+				Type:            domain.PaymentTypeCharge,
+				Reason:          domain.PaymentReasonPurchase,
+				Amount:          amount,
+				CreatedAt:       at,
+			},
+		},
+		{
+			name:   "should not charge zero amount",
+			amount: domain.NewMoney(0, "EUR"),
+			want:   nil,
+		},
+		{
+			name:    "should return declined error if authorization is declined",
+			amount:  amount,
+			wantErr: PaymentDeclinedErr,
+		},
+		{
+			name:    "should void the authorization if capture fails",
+			amount:  amount,
+			wantErr: PaymentTimeoutErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				paymentProvider: paymentProvider,
+			}
+			got, err := a.chargePayment(ctx, "tok_visa", tt.amount, domain.PaymentReasonPurchase, at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.chargePayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appDetails.chargePayment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestRefundPayment() {
+	t := suite.T()
+
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	charge := &domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeCharge,
+		Reason:          domain.PaymentReasonPurchase,
+		Amount:          domain.NewMoney(1000, "EUR"),
+	}
+
+	paymentProvider.EXPECT().Refund(gomock.Any(), "auth_1", charge.Amount).Return(nil).Times(1)
+
+	a := &appDetails{
+		paymentProvider: paymentProvider,
+	}
+	got, err := a.refundPayment(ctx, charge, domain.NewMoney(1500, "EUR"), domain.PaymentReasonPlanChange, at)
+	want := &domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeRefund,
+		Reason:          domain.PaymentReasonPlanChange,
+		Amount:          charge.Amount,
+		CreatedAt:       at,
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("appDetails.refundPayment() = %v, %v, want refund limited to the charged amount", got, err)
+	}
+}
+
+func (suite *AppTestSuite) TestBuySubscriptionPayment() {
+	t := suite.T()
+
+	database := suite.Database
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
+	}
+	taxRules := []domain.TaxRule{
+		{
+			Category: "digital_service",
+			Rate:     19,
+		},
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), product.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusPendingPayment || us.PaymentToken != "tok_visa" || len(us.Payments) != 0 ||
+				us.PendingPayment == nil || us.PendingPayment.Reason != domain.PaymentReasonPurchase || us.PendingPayment.Amount != us.Price {
+				return nil, fmt.Errorf("unexpected pending subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+		// test 2
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), product.TaxCategory).Return(taxRules, nil).Times(1),
+		// test 3
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), product.TaxCategory).Return(taxRules, nil).Times(1),
+		// test 4
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), product.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+	gomock.InOrder(
+		// test 4
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", product.Price).Return("auth_1", nil).Times(1),
+		paymentProvider.EXPECT().Void(gomock.Any(), "auth_1").Return(nil).Times(1),
+	)
+
+	tests := []struct {
+		name            string
+		paymentProvider PaymentProvider
+		token           string
+		wantErr         bool
+		wantErrIs       error
+	}{
+		{
+			name:            "should authorize the price and wait for the payment confirmation",
+			paymentProvider: NewFakePaymentProvider(),
+			token:           "tok_visa",
+		},
+		{
+			name:            "should not create subscription if payment is declined",
+			paymentProvider: NewFakePaymentProvider(),
+			token:           FakeTokenDecline,
+			wantErr:         true,
+			wantErrIs:       PaymentDeclinedErr,
+		},
+		{
+			name:            "should not create subscription if payment times out",
+			paymentProvider: NewFakePaymentProvider(),
+			token:           FakeTokenTimeout,
+			wantErr:         true,
+			wantErrIs:       PaymentTimeoutErr,
+		},
+		{
+			name:            "should void the authorization if subscription is not saved",
+			paymentProvider: paymentProvider,
+			token:           "tok_visa",
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: tt.paymentProvider,
+			}
+			_, err := a.BuySubscription(ctx, domain.Purchase{
+				EmailID:      "testmail@test.com",
+				ProductID:    product.ID,
+				PaymentToken: tt.token,
+			})
+			if (err != nil) != tt.wantErr || (tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs)) {
+				t.Errorf("appDetails.BuySubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestRenewSubscriptionsPayment() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+	}
+	subscription := domain.UserSubscription{
+		ID:           "62bb4ecdba3bbe275f8c7781",
+		ProductID:    product.ID,
+		EndDate:      at.Add(-time.Hour),
+		Price:        domain.NewMoney(1000, "EUR"),
+		Status:       domain.SubscriptionStatusActive,
+		AutoRenew:    true,
+		PaymentToken: "tok_visa",
+	}
+	declinedSubscription := subscription
+	declinedSubscription.PaymentToken = FakeTokenDecline
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	database.EXPECT().SaveInvoice(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingInvoices(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), gomock.Any()).Return([]domain.UserSubscription{subscription}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if len(us.Payments) != 1 || us.Payments[0].Reason != domain.PaymentReasonRenewal || us.Payments[0].Amount != us.Price {
+				return nil, fmt.Errorf("unexpected subscription payments %v", us.Payments)
+			}
+			return us, nil
+		}).Times(1),
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), gomock.Any()).Return([]domain.UserSubscription{declinedSubscription}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantRetry := at.AddDate(0, 0, 1)
+			if us.Status != domain.SubscriptionStatusPastDue || us.NextPaymentRetry == nil || !us.NextPaymentRetry.Equal(wantRetry) ||
+				!us.EndDate.Equal(declinedSubscription.EndDate) || len(us.Payments) != 0 || len(us.PaymentAttempts) != 1 {
+				return nil, fmt.Errorf("unexpected past due subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr error
+	}{
+		{
+			name: "should charge the renewed period",
+			want: 1,
+		},
+		{
+			name: "should move subscription to past due if renewal payment is declined",
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
+				dunningPolicy: DunningPolicy{
+					RetryDays:   []uint{1, 3, 7},
+					FinalStatus: domain.SubscriptionStatusCancelled,
+				},
+			}
+			got, err := a.RenewSubscriptions(ctx, at)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("appDetails.RenewSubscriptions() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestChangePlanPayment() {
+	t := suite.T()
+
+	database := suite.Database
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	endDate := time.Now().UTC().AddDate(0, 0, 15)
+	charge := domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeCharge,
+		Reason:          domain.PaymentReasonPurchase,
+		Amount:          domain.NewMoney(1000, "EUR"),
+	}
+	subscription := domain.UserSubscription{
+		ID:           "62bb4ecdba3bbe275f8c7781",
+		ProductID:    "62bac24b0bf33af1c877d97f",
+		StartDate:    endDate.AddDate(0, -1, 0),
+		EndDate:      endDate,
+		Price:        domain.NewMoney(1000, "EUR"),
+		Status:       domain.SubscriptionStatusActive,
+		AutoRenew:    true,
+		PaymentToken: "tok_visa",
+		Payments:     []domain.Payment{charge},
+	}
+	upgrade := domain.Product{
+		ID:                 "62bac25f83b5fcd9ddeb8170",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(2000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
+	}
+	downgrade := upgrade
+	downgrade.Price = domain.NewMoney(500, "EUR")
+
+	// last charge is a small proration charge which was partly refunded already
+	prorationChargedSubscription := subscription
+	prorationChargedSubscription.Version = 3
+	prorationChargedSubscription.Payments = []domain.Payment{
+		charge,
+		{AuthorizationID: "auth_3", Type: domain.PaymentTypeCharge, Reason: domain.PaymentReasonPlanChange, Amount: domain.NewMoney(100, "EUR")},
+		{AuthorizationID: "auth_3", Type: domain.PaymentTypeRefund, Reason: domain.PaymentReasonPlanChange, Amount: domain.NewMoney(40, "EUR")},
+	}
+	taxRules := []domain.TaxRule{
+		{
+			Category: "digital_service",
+			Rate:     10,
+		},
+	}
+
+	// expectPayment expects the saved subscription to have the purchase charge followed by given payment
+	expectPayment := func(paymentType domain.PaymentType, invoiceType domain.InvoiceType) func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+		return func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if len(us.Payments) != 2 || us.Payments[1].Type != paymentType || us.Payments[1].Reason != domain.PaymentReasonPlanChange ||
+				us.Payments[1].Amount.Amount <= 0 || us.Payments[1].Amount.Amount >= 1000 {
+				return nil, fmt.Errorf("unexpected subscription payments %v", us.Payments)
+			}
+			if len(us.PendingInvoices) != 1 || us.PendingInvoices[0].Type != invoiceType {
+				return nil, fmt.Errorf("unexpected subscription pending invoices %v", us.PendingInvoices)
+			}
+			return us, nil
+		}
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	database.EXPECT().SaveInvoice(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingInvoices(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), upgrade.ID).Return([]domain.Product{upgrade}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), upgrade.TaxCategory).Return(taxRules, nil).Times(1),
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", gomock.Any()).Return("auth_2", nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_2").Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(expectPayment(domain.PaymentTypeCharge, domain.InvoiceTypeInvoice)).Times(1),
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), downgrade.ID).Return([]domain.Product{downgrade}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), downgrade.TaxCategory).Return(taxRules, nil).Times(1),
+		paymentProvider.EXPECT().Refund(gomock.Any(), "auth_1", gomock.Any()).Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(expectPayment(domain.PaymentTypeRefund, domain.InvoiceTypeCreditNote)).Times(1),
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), upgrade.ID).Return([]domain.Product{upgrade}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), upgrade.TaxCategory).Return(taxRules, nil).Times(1),
+		paymentProvider.EXPECT().Authorize(gomock.Any(), "tok_visa", gomock.Any()).Return("", PaymentDeclinedErr).Times(1),
+		// test 4
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&prorationChargedSubscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), downgrade.ID).Return([]domain.Product{downgrade}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), downgrade.TaxCategory).Return(taxRules, nil).Times(1),
+		paymentProvider.EXPECT().Refund(gomock.Any(), "auth_3", domain.NewMoney(60, "EUR")).Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if len(us.PlanChanges) != 1 || us.PlanChanges[0].Proration != domain.NewMoney(-60, "EUR") {
+				return nil, fmt.Errorf("unexpected plan changes %v, want the refunded credit", us.PlanChanges)
+			}
+			return us, nil
+		}).Times(1),
+		// test 5
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&prorationChargedSubscription, nil).Times(1),
+	)
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		productID string
+		wantErr   error
+	}{
+		{
+			name:      "should charge prorated amount of the upgrade",
+			productID: upgrade.ID,
+		},
+		{
+			name:      "should refund prorated credit of the downgrade from the last charge",
+			productID: downgrade.ID,
+		},
+		{
+			name:      "should not change plan if prorated charge is declined",
+			productID: upgrade.ID,
+			wantErr:   PaymentDeclinedErr,
+		},
+		{
+			name:      "should record the credit refunded from the refundable amount of the last charge",
+			productID: downgrade.ID,
+		},
+		{
+			name:      "should not charge the change of other subscription version",
+			ctx:       ContextWithExpectedVersion(ctx, 2),
+			productID: upgrade.ID,
+			wantErr:   VersionMismatchErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: paymentProvider,
+			}
+			testCtx := ctx
+			if tt.ctx != nil {
+				testCtx = tt.ctx
+			}
+			_, err := a.ChangePlan(testCtx, subscription.ID, tt.productID, domain.PlanChangeImmediate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.ChangePlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestConfirmPayment() {
+	t := suite.T()
+
+	database := suite.Database
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	requestedAt := time.Now().UTC().AddDate(0, 0, -1)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+	}
+	pendingPayment := &domain.Payment{
+		AuthorizationID: "auth_1",
+		Type:            domain.PaymentTypeCharge,
+		Reason:          domain.PaymentReasonPurchase,
+		Amount:          domain.NewMoney(1000, "EUR"),
+		CreatedAt:       requestedAt,
+	}
+	subscription := domain.UserSubscription{
+		ID:             "62bb4ecdba3bbe275f8c7781",
+		ProductID:      product.ID,
+		StartDate:      requestedAt,
+		EndDate:        requestedAt.AddDate(0, 1, 0),
+		Price:          domain.NewMoney(1000, "EUR"),
+		Status:         domain.SubscriptionStatusPendingPayment,
+		AutoRenew:      true,
+		PaymentToken:   "tok_visa",
+		PendingPayment: pendingPayment,
+	}
+	activeSubscription := subscription
+	activeSubscription.Status = domain.SubscriptionStatusActive
+	activeSubscription.PendingPayment = nil
+
+	// expectFailed expects the subscription to be saved in payment failed status
+	expectFailed := func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+		if us.Status != domain.SubscriptionStatusPaymentFailed || us.PendingPayment != nil || len(us.Payments) != 0 {
+			return nil, fmt.Errorf("unexpected failed subscription %v", us)
+		}
+		return us, nil
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_1").Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusActive || us.PendingPayment != nil || len(us.Payments) != 1 ||
+				us.Payments[0].AuthorizationID != "auth_1" || !us.StartDate.After(requestedAt) ||
+				!us.EndDate.Equal(us.StartDate.AddDate(0, 1, 0)) || len(us.PendingInvoices) != 1 {
+				return nil, fmt.Errorf("unexpected confirmed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+		database.EXPECT().SaveInvoice(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
+			if invoice.SubscriptionID != subscription.ID || invoice.AuthorizationID != "auth_1" || invoice.Reason != domain.PaymentReasonPurchase ||
+				len(invoice.Lines) != 1 || invoice.Total != pendingPayment.Amount || invoice.Lines[0].PeriodStart.IsZero() {
+				t.Errorf("unexpected purchase invoice %v", invoice)
+			}
+			return invoice, nil
+		}).Times(1),
+		database.EXPECT().RemovePendingInvoices(gomock.Any(), subscription.ID, gomock.Any()).Return(nil).Times(1),
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		paymentProvider.EXPECT().Void(gomock.Any(), "auth_1").Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(expectFailed).Times(1),
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_1").Return(PaymentDeclinedErr).Times(1),
+		paymentProvider.EXPECT().Void(gomock.Any(), "auth_1").Return(nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(expectFailed).Times(1),
+		// test 4
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		paymentProvider.EXPECT().Capture(gomock.Any(), "auth_1").Return(PaymentTimeoutErr).Times(1),
+		// test 5
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		// test 6
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&activeSubscription, nil).Times(1),
+	)
+
+	tests := []struct {
+		name            string
+		id              string
+		authorizationID string
+		succeeded       bool
+		wantErr         error
+	}{
+		{
+			name:            "should capture the payment and activate the subscription from the confirmation time",
+			id:              subscription.ID,
+			authorizationID: "auth_1",
+			succeeded:       true,
+		},
+		{
+			name:            "should void the payment and fail the subscription",
+			id:              subscription.ID,
+			authorizationID: "auth_1",
+			succeeded:       false,
+		},
+		{
+			name:            "should fail the subscription if capture is declined",
+			id:              subscription.ID,
+			authorizationID: "auth_1",
+			succeeded:       true,
+		},
+		{
+			name:            "should keep the payment pending if capture times out",
+			id:              subscription.ID,
+			authorizationID: "auth_1",
+			succeeded:       true,
+			wantErr:         PaymentTimeoutErr,
+		},
+		{
+			name:            "should return error if authorization is not pending for the subscription",
+			id:              subscription.ID,
+			authorizationID: "auth_2",
+			succeeded:       true,
+			wantErr:         InvalidArgErr,
+		},
+		{
+			name:            "should return error if subscription is not pending payment",
+			id:              subscription.ID,
+			authorizationID: "auth_1",
+			succeeded:       true,
+			wantErr:         NotAllowedArgErr,
+		},
+		{
+			name:            "should return error for empty id",
+			authorizationID: "auth_1",
+			succeeded:       true,
+			wantErr:         InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database:        database,
+				paymentProvider: paymentProvider,
+			}
+			_, err := a.ConfirmPayment(ctx, tt.id, tt.authorizationID, tt.succeeded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.ConfirmPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestCancelPendingPaymentSubscription() {
+	t := suite.T()
+
+	database := suite.Database
+	paymentProvider := suite.PaymentProvider
+	ctx := context.Background()
+	subscription := domain.UserSubscription{
+		ID:     "62bb4ecdba3bbe275f8c7781",
+		Status: domain.SubscriptionStatusPendingPayment,
+		PendingPayment: &domain.Payment{
+			AuthorizationID: "auth_1",
+			Type:            domain.PaymentTypeCharge,
+			Reason:          domain.PaymentReasonPurchase,
+			Amount:          domain.NewMoney(1000, "EUR"),
+		},
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusCancelled || us.PendingPayment != nil {
+				return nil, fmt.Errorf("unexpected cancelled subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+		paymentProvider.EXPECT().Void(gomock.Any(), "auth_1").Return(nil).Times(1),
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscription.ID).Return(&subscription, nil).Times(1),
+	)
+
+	a := &appDetails{
+		database:        database,
+		paymentProvider: paymentProvider,
+	}
+	_, err := a.UpdateSubscriptionStatusByID(ctx, subscription.ID, domain.SubscriptionStatusCancelled)
+	if err != nil {
+		t.Errorf("appDetails.UpdateSubscriptionStatusByID() error = %v, want cancelled subscription", err)
+	}
+
+	_, err = a.UpdateSubscriptionStatusByID(ctx, subscription.ID, domain.SubscriptionStatusActive)
+	if !errors.Is(err, NotAllowedArgErr) {
+		t.Errorf("appDetails.UpdateSubscriptionStatusByID() error = %v, wantErr %v", err, NotAllowedArgErr)
+	}
+}
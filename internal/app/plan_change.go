@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// ChangePlan changes product of the active subscription with given id to the product with given productID
+// immediate change swaps the product right away keeping the end date, prorated charge or credit for the
+// remaining days of the current period is recorded with the change, the prorated charge is charged and invoiced and
+// the prorated credit is refunded from the last charge by the payment provider with a credit note, the refund is limited
+// to the amount of the last charge which is not refunded yet and the change records the credit actually refunded
+// period end change is scheduled and applied by the renewal at the end date
+// returns invalid argument error if id or productID is empty, timing is unknown, product is the same
+// or price is not available for the subscription currency/country
+// returns not allowed error if subscription is not active or the product is archived
+// returns version mismatch error if the subscription version is not the expected version from the context,
+// it is checked before the payment, so the stale change is not charged
+// returns payment declined or payment timeout error if the prorated charge fails
+func (a *appDetails) ChangePlan(ctx context.Context, id string, productID string, timing domain.PlanChangeTiming) (*domain.UserSubscription, error) {
+	if id == "" || productID == "" {
+		return nil, InvalidArgErr
+	}
+
+	if timing != domain.PlanChangeImmediate && timing != domain.PlanChangeAtPeriodEnd {
+		return nil, fmt.Errorf("plan change timing %v %w", timing, InvalidArgErr)
+	}
+
+	subscriptionDetails, err := a.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkExpectedVersion(ctx, subscriptionDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscriptionDetails.Status != domain.SubscriptionStatusActive {
+		return nil, fmt.Errorf("%v subscription plan change %w", subscriptionDetails.Status, NotAllowedArgErr)
+	}
+
+	if subscriptionDetails.ProductID == productID {
+		return nil, fmt.Errorf("subscription already has product %v %w", productID, InvalidArgErr)
+	}
+
+	records, err := a.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%v %w", productID, NotFoundErr)
+	}
+
+	product := records[0]
+	if product.IsArchived() {
+		return nil, fmt.Errorf("product %v is archived %w", productID, NotAllowedArgErr)
+	}
+
+	timeNow := time.Now().UTC()
+	tax, priceVersionID, err := a.productPrice(ctx, &product, subscriptionDetails.Price.Currency, subscriptionDetails.Country, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedSubscriptionDetails := *subscriptionDetails
+	updatedSubscriptionDetails.UpdatedAt = &timeNow
+	planChange := domain.PlanChange{
+		RequestedAt:   timeNow,
+		FromProductID: subscriptionDetails.ProductID,
+		ToProductID:   product.ID,
+	}
+
+	if timing == domain.PlanChangeAtPeriodEnd {
+		planChange.EffectiveAt = subscriptionDetails.EndDate
+		updatedSubscriptionDetails.PendingPlanChange = &planChange
+		return a.saveSubscription(ctx, domain.AuditActionPlanChangeScheduled, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	}
+
+	proration, err := domain.CalculateProration(subscriptionDetails.Price, currentPeriodStart(subscriptionDetails),
+		subscriptionDetails.EndDate, tax.Gross, product.SubscriptionPeriod, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, paid, err := a.payProration(ctx, &updatedSubscriptionDetails, proration.Amount, timeNow)
+	if err != nil {
+		return nil, err
+	}
+
+	planChange.EffectiveAt = timeNow
+	planChange.Proration = paid
+	applyPlanChange(&updatedSubscriptionDetails, &product, tax, priceVersionID, planChange)
+	addPendingInvoice(&updatedSubscriptionDetails, payment, timeNow, updatedSubscriptionDetails.EndDate)
+
+	savedSubscription, err := a.saveSubscription(ctx, domain.AuditActionPlanChanged, subscriptionDetails.Status, &updatedSubscriptionDetails)
+	if err != nil {
+		if payment != nil && payment.Type == domain.PaymentTypeCharge {
+			a.reverseCharge(ctx, payment)
+		}
+		return nil, err
+	}
+
+	a.issueSavedInvoices(ctx, savedSubscription)
+	return savedSubscription, nil
+}
+
+// payProration charges the positive proration amount or refunds the negative one from the last charge
+// and records the payment with the subscription, the refund is limited to the amount of the last charge
+// which is not refunded yet, subscription without payment token is not charged
+// returns the payment and the proration amount actually paid, the refund is a negative amount,
+// the proration amount of the subscription without payment token is returned as it is
+func (a *appDetails) payProration(ctx context.Context, subscription *domain.UserSubscription, amount domain.Money, at time.Time) (*domain.Payment, domain.Money, error) {
+	if subscription.PaymentToken == "" {
+		return nil, amount, nil
+	}
+
+	var payment *domain.Payment
+	var err error
+	if amount.Amount >= 0 {
+		payment, err = a.chargePayment(ctx, subscription.PaymentToken, amount, domain.PaymentReasonPlanChange, at)
+	} else if lastCharge := subscription.LastCharge(); lastCharge != nil {
+		credit := domain.NewMoney(-amount.Amount, amount.Currency)
+		if refundable := subscription.RefundableAmount(lastCharge); credit.Amount > refundable.Amount {
+			credit = refundable
+		}
+		payment, err = a.refundPayment(ctx, lastCharge, credit, domain.PaymentReasonPlanChange, at)
+	}
+	if err != nil {
+		return nil, domain.Money{}, err
+	}
+
+	paid := domain.NewMoney(0, amount.Currency)
+	if payment != nil {
+		subscription.Payments = append(subscription.Payments, *payment)
+		paid = payment.Amount
+		if payment.Type == domain.PaymentTypeRefund {
+			paid.Amount = -paid.Amount
+		}
+	}
+	return payment, paid, nil
+}
+
+// applyPlanChange swaps the subscription product and price and records the plan change
+func applyPlanChange(subscription *domain.UserSubscription, product *domain.Product, tax *domain.TaxBreakdown, priceVersionID string, planChange domain.PlanChange) {
+	planChange.Price = tax.Gross
+
+	subscription.ProductID = product.ID
+	subscription.ProductName = product.Name
+	applyPrice(subscription, tax, priceVersionID)
+	subscription.PendingPlanChange = nil
+	subscription.PlanChanges = append(subscription.PlanChanges, planChange)
+}
+
+// applyPrice sets the subscription price and its tax breakdown taken from the price version
+func applyPrice(subscription *domain.UserSubscription, tax *domain.TaxBreakdown, priceVersionID string) {
+	subscription.Price = tax.Gross
+	subscription.NetPrice = tax.Net
+	subscription.Tax = tax.Tax
+	subscription.TaxRate = tax.Rate
+	subscription.PriceVersionID = priceVersionID
+}
+
+// currentPeriodStart returns start of the current subscription period i.e. start of the last renewal or the start date
+func currentPeriodStart(subscription *domain.UserSubscription) time.Time {
+	if len(subscription.Renewals) > 0 {
+		return subscription.Renewals[len(subscription.Renewals)-1].PeriodStart
+	}
+	return subscription.StartDate
+}
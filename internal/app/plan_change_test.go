@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestChangePlan() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	productID := "62bac24b0bf33af1c877d97f"
+	newProductID := "62bac25f83b5fcd9ddeb8170"
+	timeNow := time.Now().UTC()
+	endDate := timeNow.AddDate(0, 0, 15)
+
+	subscriptionRecord := domain.UserSubscription{
+		ID:          subscriptionID,
+		ProductID:   productID,
+		ProductName: "get in shape",
+		StartDate:   endDate.AddDate(0, -1, 0),
+		EndDate:     endDate,
+		Price:       domain.NewMoney(1000, "EUR"),
+		Status:      domain.SubscriptionStatusActive,
+		AutoRenew:   true,
+	}
+	pausedSubscriptionRecord := subscriptionRecord
+	pausedSubscriptionRecord.Status = domain.SubscriptionStatusPaused
+
+	newProduct := domain.Product{
+		ID:                 newProductID,
+		Name:               "hiit extreme",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(2000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
+	}
+	taxRules := []domain.TaxRule{
+		{
+			Category: "digital_service",
+			Rate:     10,
+		},
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), newProductID).Return([]domain.Product{newProduct}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), newProduct.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.ProductID != newProductID || us.ProductName != newProduct.Name || us.Price != newProduct.Price ||
+				!us.EndDate.Equal(endDate) || len(us.PlanChanges) != 1 || us.PlanChanges[0].Proration.Amount <= 0 {
+				return nil, fmt.Errorf("unexpected plan changed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), newProductID).Return([]domain.Product{newProduct}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), newProduct.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.ProductID != productID || us.PendingPlanChange == nil || us.PendingPlanChange.ToProductID != newProductID ||
+				!us.PendingPlanChange.EffectiveAt.Equal(endDate) || len(us.PlanChanges) != 0 {
+				return nil, fmt.Errorf("unexpected scheduled plan change subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 3
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&pausedSubscriptionRecord, nil).Times(1),
+
+		// test 4
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+
+		// test 5
+		database.EXPECT().GetSubscriptionByID(gomock.Any(), subscriptionID).Return(&subscriptionRecord, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), newProductID).Return([]domain.Product{}, nil).Times(1),
+	)
+
+	type args struct {
+		id        string
+		productID string
+		timing    domain.PlanChangeTiming
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "should change plan immediately with prorated charge",
+			args: args{
+				id:        subscriptionID,
+				productID: newProductID,
+				timing:    domain.PlanChangeImmediate,
+			},
+		},
+		{
+			name: "should schedule plan change for the period end",
+			args: args{
+				id:        subscriptionID,
+				productID: newProductID,
+				timing:    domain.PlanChangeAtPeriodEnd,
+			},
+		},
+		{
+			name: "should return error if subscription is not active",
+			args: args{
+				id:        subscriptionID,
+				productID: newProductID,
+				timing:    domain.PlanChangeImmediate,
+			},
+			wantErr: NotAllowedArgErr,
+		},
+		{
+			name: "should return error if product is the same",
+			args: args{
+				id:        subscriptionID,
+				productID: productID,
+				timing:    domain.PlanChangeImmediate,
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if product is not found",
+			args: args{
+				id:        subscriptionID,
+				productID: newProductID,
+				timing:    domain.PlanChangeImmediate,
+			},
+			wantErr: NotFoundErr,
+		},
+		{
+			name: "should return error if id is empty",
+			args: args{
+				productID: newProductID,
+				timing:    domain.PlanChangeImmediate,
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if timing is invalid",
+			args: args{
+				id:        subscriptionID,
+				productID: newProductID,
+				timing:    "tomorrow",
+			},
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
+			}
+			_, err := a.ChangePlan(ctx, tt.args.id, tt.args.productID, tt.args.timing)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.ChangePlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
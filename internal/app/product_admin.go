@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// CreateProduct validates and creates new product, the product is not archived
+// the product is sold for its price until a price version is scheduled with SchedulePriceVersion
+// returns invalid argument error if the product is invalid or its tax category has no tax rules
+func (a *appDetails) CreateProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	product.ID = ""
+	product.ArchivedAt = nil
+	product.PriceVersions = nil
+	err := a.validateProduct(ctx, &product)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.database.SaveProduct(ctx, &product)
+}
+
+// UpdateProduct validates and replaces the product with the same ID, archived state and price versions of the product are kept
+// subscriptions of the product keep the product name and price they were bought with
+// returns invalid argument error if the product is invalid or its tax category has no tax rules
+// returns not found error if the product does not exist
+func (a *appDetails) UpdateProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	existing, err := a.getProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	product.ArchivedAt = existing.ArchivedAt
+	product.PriceVersions = existing.PriceVersions
+	err = a.validateProduct(ctx, &product)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.saveProduct(ctx, &product)
+}
+
+// SchedulePriceVersion adds new price version to the product with given id, the version is effective from its
+// effective from date, which is now if it is not given
+// new subscriptions are bought for the price of the version effective at purchase time, existing subscriptions
+// are renewed with their price or the version effective at the renewal as per renewal pricing
+// returns invalid argument error if the effective from is in the past or not after the last price version of the product,
+// or the prices are invalid
+// returns not found error if the product does not exist
+func (a *appDetails) SchedulePriceVersion(ctx context.Context, productID string, version domain.PriceVersion) (*domain.Product, error) {
+	product, err := a.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now().UTC()
+	version.ID = ""
+	if version.EffectiveFrom.IsZero() {
+		version.EffectiveFrom = timeNow
+	}
+	version.EffectiveFrom = version.EffectiveFrom.UTC()
+
+	if version.EffectiveFrom.Before(timeNow.Add(-time.Minute)) {
+		return nil, fmt.Errorf("price version effective from %v is in the past %w", version.EffectiveFrom, InvalidArgErr)
+	}
+
+	if len(product.PriceVersions) > 0 {
+		last := product.PriceVersions[len(product.PriceVersions)-1]
+		if !version.EffectiveFrom.After(last.EffectiveFrom) {
+			return nil, fmt.Errorf("price version effective from must be after %v %w", last.EffectiveFrom, InvalidArgErr)
+		}
+	}
+
+	product.PriceVersions = append(product.PriceVersions, version)
+	err = a.validateProduct(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.saveProduct(ctx, product)
+}
+
+// ArchiveProduct archives the product with given id, archived product cannot be bought or changed to
+// existing subscriptions of the product are renewed as before
+// returns status unchanged error if the product is already archived
+func (a *appDetails) ArchiveProduct(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := a.getProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.IsArchived() {
+		return nil, fmt.Errorf("product %v is archived %w", id, StatusUnchangedErr)
+	}
+
+	timeNow := time.Now().UTC()
+	product.ArchivedAt = &timeNow
+	return a.saveProduct(ctx, product)
+}
+
+// RestoreProduct restores the archived product with given id, so it can be bought again
+// returns status unchanged error if the product is not archived
+func (a *appDetails) RestoreProduct(ctx context.Context, id string) (*domain.Product, error) {
+	product, err := a.getProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.IsArchived() {
+		return nil, fmt.Errorf("product %v is not archived %w", id, StatusUnchangedErr)
+	}
+
+	product.ArchivedAt = nil
+	return a.saveProduct(ctx, product)
+}
+
+// getProduct returns product for given id, returns not found error if it does not exist
+func (a *appDetails) getProduct(ctx context.Context, id string) (*domain.Product, error) {
+	if id == "" {
+		return nil, fmt.Errorf("product id %w", InvalidArgErr)
+	}
+
+	records, err := a.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%v %w", id, NotFoundErr)
+	}
+	return &records[0], nil
+}
+
+// saveProduct saves existing product, returns not found error if the product is deleted meanwhile
+func (a *appDetails) saveProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	saved, err := a.database.SaveProduct(ctx, product)
+	if err != nil {
+		if errors.Is(err, db.RecordNotFoundErr) {
+			return nil, fmt.Errorf("%v %w", product.ID, NotFoundErr)
+		}
+		return nil, err
+	}
+	return saved, nil
+}
+
+// validateProduct normalizes the codes of the product and validates it
+// tax category must have tax rules, so that the tax of the product can be calculated
+func (a *appDetails) validateProduct(ctx context.Context, product *domain.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+	normalizePrices(&product.Price, product.Prices)
+	for i := range product.PriceVersions {
+		normalizePrices(&product.PriceVersions[i].Price, product.PriceVersions[i].Prices)
+	}
+
+	err := product.Validate()
+	if err != nil {
+		return fmt.Errorf("%v %w", err, InvalidArgErr)
+	}
+
+	rules, err := a.database.GetTaxRules(ctx, product.TaxCategory)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		return fmt.Errorf("tax category %v has no tax rules %w", product.TaxCategory, InvalidArgErr)
+	}
+	return nil
+}
+
+// normalizePrices normalizes currency and country codes of the default price and the price list
+func normalizePrices(price *domain.Money, prices []domain.ProductPrice) {
+	*price = domain.NewMoney(price.Amount, price.Currency)
+	for i := range prices {
+		prices[i].Country = strings.ToUpper(prices[i].Country)
+		prices[i].Price = domain.NewMoney(prices[i].Price.Amount, prices[i].Price.Currency)
+	}
+}
@@ -0,0 +1,461 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestCreateProduct() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	product := domain.Product{
+		Name:               " yoga flow ",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "eur"),
+		Prices: []domain.ProductPrice{
+			{Country: "ch", Price: domain.NewMoney(5000, "chf")},
+		},
+		TaxCategory: "digital_service",
+	}
+	taxRules := []domain.TaxRule{{Category: "digital_service", Rate: 10}}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if p.ID != "" || p.Name != "yoga flow" || p.Price.Currency != "EUR" || p.Prices[0].Country != "CH" ||
+				p.Prices[0].Price.Currency != "CHF" || p.IsArchived() {
+				return nil, fmt.Errorf("unexpected product %v", p)
+			}
+			p.ID = "62bb4ecdba3bbe275f8c7788"
+			return p, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return([]domain.TaxRule{}, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	archivedAt := time.Now()
+	tests := []struct {
+		name       string
+		change     func(p *domain.Product)
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{
+			name: "should create not archived product",
+			change: func(p *domain.Product) {
+				p.ID = "62bb4ecdba3bbe275f8c7700"
+				p.ArchivedAt = &archivedAt
+			},
+		},
+		{
+			name:    "should return error if tax category has no tax rules",
+			change:  func(p *domain.Product) {},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:       "should return error if tax rules cannot be fetched",
+			change:     func(p *domain.Product) {},
+			wantAnyErr: true,
+		},
+		{
+			name:    "should return error if subscription period is not positive",
+			change:  func(p *domain.Product) { p.SubscriptionPeriod = 0 },
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if price is negative",
+			change:  func(p *domain.Product) { p.Price = domain.NewMoney(-100, "EUR") },
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			p := product
+			p.Prices = append([]domain.ProductPrice(nil), product.Prices...)
+			tt.change(&p)
+			got, err := a.CreateProduct(ctx, p)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Errorf("appDetails.CreateProduct() error = %v, wantErr %v", err, tt.wantAnyErr)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.CreateProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.ID != "62bb4ecdba3bbe275f8c7788" {
+				t.Errorf("appDetails.CreateProduct() = %v, want created product", got)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestUpdateProduct() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	archivedAt := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	existing := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "EUR"),
+		TaxCategory:        "digital_service",
+		ArchivedAt:         &archivedAt,
+	}
+	update := existing
+	update.Name = "yoga flow plus"
+	update.ArchivedAt = nil
+	taxRules := []domain.TaxRule{{Category: "digital_service", Rate: 10}}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetProduct(gomock.Any(), existing.ID).Return([]domain.Product{existing}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if p.Name != "yoga flow plus" || p.ArchivedAt != existing.ArchivedAt {
+				return nil, fmt.Errorf("unexpected product %v", p)
+			}
+			return p, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetProduct(gomock.Any(), existing.ID).Return([]domain.Product{}, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetProduct(gomock.Any(), existing.ID).Return([]domain.Product{existing}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("product %w", db.RecordNotFoundErr)).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		product domain.Product
+		wantErr error
+	}{
+		{
+			name:    "should update product and keep archived state",
+			product: update,
+		},
+		{
+			name:    "should return error if product does not exist",
+			product: update,
+			wantErr: NotFoundErr,
+		},
+		{
+			name:    "should return error if product is deleted meanwhile",
+			product: update,
+			wantErr: NotFoundErr,
+		},
+		{
+			name: "should return error if id is empty",
+			product: domain.Product{
+				Name: "yoga flow",
+			},
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			_, err := a.UpdateProduct(ctx, tt.product)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.UpdateProduct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestArchiveAndRestoreProduct() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	archivedAt := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "EUR"),
+		TaxCategory:        "digital_service",
+	}
+	archived := product
+	archived.ArchivedAt = &archivedAt
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if !p.IsArchived() {
+				return nil, fmt.Errorf("product %v is not archived", p)
+			}
+			return p, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{archived}, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{archived}, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if p.IsArchived() {
+				return nil, fmt.Errorf("product %v is archived", p)
+			}
+			return p, nil
+		}).Times(1),
+
+		// test 4
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+	)
+
+	a := &appDetails{
+		database: database,
+	}
+
+	got, err := a.ArchiveProduct(ctx, product.ID)
+	if err != nil || !got.IsArchived() {
+		t.Errorf("appDetails.ArchiveProduct() = %v, %v, want archived product", got, err)
+	}
+
+	_, err = a.ArchiveProduct(ctx, product.ID)
+	if !errors.Is(err, StatusUnchangedErr) {
+		t.Errorf("appDetails.ArchiveProduct() error = %v, wantErr %v", err, StatusUnchangedErr)
+	}
+
+	got, err = a.RestoreProduct(ctx, product.ID)
+	if err != nil || got.IsArchived() {
+		t.Errorf("appDetails.RestoreProduct() = %v, %v, want restored product", got, err)
+	}
+
+	_, err = a.RestoreProduct(ctx, product.ID)
+	if !errors.Is(err, StatusUnchangedErr) {
+		t.Errorf("appDetails.RestoreProduct() error = %v, wantErr %v", err, StatusUnchangedErr)
+	}
+}
+
+func (suite *AppTestSuite) TestBuyArchivedProduct() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	archivedAt := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "EUR"),
+		TaxCategory:        "digital_service",
+		ArchivedAt:         &archivedAt,
+	}
+
+	database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1)
+
+	a := &appDetails{
+		database: database,
+	}
+	_, err := a.BuySubscription(ctx, domain.Purchase{
+		EmailID:      "testmail@test.com",
+		PaymentToken: "tok_visa",
+		ProductID:    product.ID,
+	})
+	if !errors.Is(err, NotAllowedArgErr) {
+		t.Errorf("appDetails.BuySubscription() error = %v, wantErr %v", err, NotAllowedArgErr)
+	}
+}
+
+func (suite *AppTestSuite) TestSchedulePriceVersion() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	effectiveFrom := time.Now().UTC().AddDate(0, 1, 0)
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "EUR"),
+		TaxCategory:        "digital_service",
+	}
+	scheduled := product
+	scheduled.PriceVersions = []domain.PriceVersion{
+		{
+			ID:            "62bb4ecdba3bbe275f8c7790",
+			EffectiveFrom: effectiveFrom,
+			Price:         domain.NewMoney(5000, "EUR"),
+		},
+	}
+	taxRules := []domain.TaxRule{{Category: "digital_service", Rate: 10}}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if len(p.PriceVersions) != 1 || p.PriceVersions[0].Price != domain.NewMoney(5000, "EUR") ||
+				p.PriceVersions[0].Prices[0].Country != "CH" || !p.PriceVersions[0].EffectiveFrom.Equal(effectiveFrom) {
+				return nil, fmt.Errorf("unexpected product %v", p)
+			}
+			return p, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{scheduled}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveProduct(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+			if len(p.PriceVersions) != 2 || p.PriceVersions[0].ID != scheduled.PriceVersions[0].ID {
+				return nil, fmt.Errorf("unexpected product %v", p)
+			}
+			return p, nil
+		}).Times(1),
+
+		// test 3
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{scheduled}, nil).Times(1),
+
+		// test 4
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+
+		// test 5
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1),
+
+		// test 6
+		database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{}, nil).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		version domain.PriceVersion
+		wantErr error
+	}{
+		{
+			name: "should schedule price version with normalized codes",
+			version: domain.PriceVersion{
+				EffectiveFrom: effectiveFrom,
+				Price:         domain.NewMoney(5000, "eur"),
+				Prices:        []domain.ProductPrice{{Country: "ch", Price: domain.NewMoney(5500, "chf")}},
+			},
+		},
+		{
+			name: "should schedule price version after the last version",
+			version: domain.PriceVersion{
+				EffectiveFrom: effectiveFrom.AddDate(0, 1, 0),
+				Price:         domain.NewMoney(5500, "EUR"),
+			},
+		},
+		{
+			name: "should return error if price version is not after the last version",
+			version: domain.PriceVersion{
+				EffectiveFrom: effectiveFrom,
+				Price:         domain.NewMoney(5500, "EUR"),
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if price version is effective in the past",
+			version: domain.PriceVersion{
+				EffectiveFrom: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC),
+				Price:         domain.NewMoney(5500, "EUR"),
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if price is negative",
+			version: domain.PriceVersion{
+				EffectiveFrom: effectiveFrom,
+				Price:         domain.NewMoney(-1, "EUR"),
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if product does not exist",
+			version: domain.PriceVersion{
+				EffectiveFrom: effectiveFrom,
+				Price:         domain.NewMoney(5500, "EUR"),
+			},
+			wantErr: NotFoundErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			_, err := a.SchedulePriceVersion(ctx, product.ID, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.SchedulePriceVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestBuySubscriptionPriceVersion() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	priceVersionID := "62bb4ecdba3bbe275f8c7790"
+	product := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		Price:              domain.NewMoney(4500, "EUR"),
+		PriceVersions: []domain.PriceVersion{
+			{
+				ID:            priceVersionID,
+				EffectiveFrom: time.Now().UTC().AddDate(0, -1, 0),
+				Price:         domain.NewMoney(5000, "EUR"),
+			},
+			{
+				ID:            "62bb4ecdba3bbe275f8c7791",
+				EffectiveFrom: time.Now().UTC().AddDate(0, 1, 0),
+				Price:         domain.NewMoney(6000, "EUR"),
+			},
+		},
+		TaxCategory:  "digital_service",
+		TaxInclusive: true,
+	}
+
+	database.EXPECT().GetProduct(gomock.Any(), product.ID).Return([]domain.Product{product}, nil).Times(1)
+	database.EXPECT().GetTaxRules(gomock.Any(), "digital_service").Return([]domain.TaxRule{{Category: "digital_service", Rate: 10}}, nil).Times(1)
+	database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+		return us, nil
+	}).Times(1)
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	a := &appDetails{
+		database: database,
+		taxCalculator: &ruleTaxCalculator{
+			database: database,
+		},
+		paymentProvider: NewFakePaymentProvider(),
+	}
+	got, err := a.BuySubscription(ctx, domain.Purchase{
+		EmailID:      "testmail@test.com",
+		PaymentToken: "tok_visa",
+		ProductID:    product.ID,
+	})
+	if err != nil || got.Price != domain.NewMoney(5000, "EUR") || got.PriceVersionID != priceVersionID {
+		t.Errorf("appDetails.BuySubscription() = %v, %v, want price of the effective price version", got, err)
+	}
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+const (
+	defaultProductPageLimit = 20
+	maxProductPageLimit     = 100
+)
+
+// productCursor is content of the opaque cursor of the product list
+// sort and order are kept in the cursor, so that the cursor cannot be used with different sorting
+type productCursor struct {
+	SortBy             domain.ProductSortField `json:"s"`
+	SortDesc           bool                    `json:"d,omitempty"`
+	Name               string                  `json:"n"`
+	PriceAmount        int64                   `json:"p"`
+	SubscriptionPeriod uint                    `json:"sp"`
+	ID                 string                  `json:"i"`
+}
+
+// encodeProductCursor returns cursor pointing after the product in the sort order of the query,
+// the price is taken from the product price version effective at given time
+func encodeProductCursor(query *domain.ProductQuery, product *domain.Product, priceAt time.Time) (string, error) {
+	data, err := json.Marshal(productCursor{
+		SortBy:             query.SortBy,
+		SortDesc:           query.SortDesc,
+		Name:               product.Name,
+		PriceAmount:        product.PriceVersionAt(priceAt).Price.Amount,
+		SubscriptionPeriod: product.SubscriptionPeriod,
+		ID:                 product.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeProductCursor returns position of the cursor, it must be created with the same sorting as the query
+func decodeProductCursor(query *domain.ProductQuery) (*db.ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor %w", InvalidArgErr)
+	}
+
+	cursor := productCursor{}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("cursor %w", InvalidArgErr)
+	}
+
+	if cursor.SortBy != query.SortBy || cursor.SortDesc != query.SortDesc {
+		return nil, fmt.Errorf("cursor of different sorting %w", InvalidArgErr)
+	}
+
+	return &db.ProductCursor{
+		Name:               cursor.Name,
+		PriceAmount:        cursor.PriceAmount,
+		SubscriptionPeriod: cursor.SubscriptionPeriod,
+		ID:                 cursor.ID,
+	}, nil
+}
+
+// ListProducts returns a page of the products matching the query together with the total count of matching products
+// products are filtered and sorted by the price of the price version effective now
+// products are sorted by name if sort field is not given, default page size is 20 and max is 100
+// returns invalid argument error if sort field, limit, price range or cursor is invalid
+func (a *appDetails) ListProducts(ctx context.Context, query domain.ProductQuery) (*domain.ProductPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = domain.ProductSortByName
+	}
+
+	if !query.SortBy.IsValid() {
+		return nil, fmt.Errorf("sort field %v %w", query.SortBy, InvalidArgErr)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultProductPageLimit
+	}
+
+	if query.Limit < 0 || query.Limit > maxProductPageLimit {
+		return nil, fmt.Errorf("limit %v %w", query.Limit, InvalidArgErr)
+	}
+
+	if query.MinPrice != nil && query.MaxPrice != nil {
+		if query.MinPrice.Currency != query.MaxPrice.Currency {
+			return nil, fmt.Errorf("price range currency %w", InvalidArgErr)
+		}
+
+		if query.MinPrice.Amount > query.MaxPrice.Amount {
+			return nil, fmt.Errorf("min price is greater than max price %w", InvalidArgErr)
+		}
+	}
+
+	filter := db.ProductFilter{
+		Name:               query.Name,
+		SubscriptionPeriod: query.SubscriptionPeriod,
+		MinPrice:           query.MinPrice,
+		MaxPrice:           query.MaxPrice,
+		PriceAt:            time.Now().UTC(),
+		IncludeArchived:    query.IncludeArchived,
+		SortBy:             query.SortBy,
+		SortDesc:           query.SortDesc,
+		Limit:              query.Limit + 1,
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeProductCursor(&query)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	products, err := a.database.FindProducts(ctx, filter)
+	if err != nil {
+		if errors.Is(err, db.InvalidArgErr) {
+			return nil, fmt.Errorf("list products failed:%s %w", err.Error(), InvalidArgErr)
+		}
+		return nil, err
+	}
+
+	totalCount, err := a.database.CountProducts(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.ProductPage{
+		Products:   products,
+		TotalCount: totalCount,
+	}
+	if int64(len(products)) > query.Limit {
+		page.Products = products[:query.Limit]
+		page.NextCursor, err = encodeProductCursor(&query, &page.Products[query.Limit-1], filter.PriceAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+// productFilterMatcher matches the product filter with the price time of the current time
+type productFilterMatcher struct {
+	filter db.ProductFilter
+}
+
+func (m productFilterMatcher) Matches(x interface{}) bool {
+	filter, ok := x.(db.ProductFilter)
+	if !ok || filter.PriceAt.After(time.Now()) || time.Since(filter.PriceAt) > time.Minute {
+		return false
+	}
+	filter.PriceAt = m.filter.PriceAt
+	return reflect.DeepEqual(filter, m.filter)
+}
+
+func (m productFilterMatcher) String() string {
+	return fmt.Sprintf("is %v with current price time", m.filter)
+}
+
+func (suite *AppTestSuite) TestListProducts() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	products := []domain.Product{
+		{ID: "62bac24b0bf33af1c877d97f", Name: "get in shape", SubscriptionPeriod: 1, Price: domain.NewMoney(1000, "EUR")},
+		{ID: "62bac25f83b5fcd9ddeb8170", Name: "hiit extreme", SubscriptionPeriod: 2, Price: domain.NewMoney(2000, "EUR")},
+		{ID: "62bac26a69c9410f916fc262", Name: "hiphop cardio", SubscriptionPeriod: 3, Price: domain.NewMoney(3000, "EUR")},
+	}
+	repricedProducts := []domain.Product{
+		products[0],
+		{
+			ID:                 "62bac25f83b5fcd9ddeb8170",
+			Name:               "hiit extreme",
+			SubscriptionPeriod: 2,
+			Price:              domain.NewMoney(500, "EUR"),
+			PriceVersions: []domain.PriceVersion{
+				{ID: "62bac25f83b5fcd9ddeb8171", EffectiveFrom: time.Now().AddDate(0, -1, 0), Price: domain.NewMoney(1500, "EUR")},
+				{ID: "62bac25f83b5fcd9ddeb8172", EffectiveFrom: time.Now().AddDate(0, 1, 0), Price: domain.NewMoney(9000, "EUR")},
+			},
+		},
+	}
+	cursor, err := encodeProductCursor(&domain.ProductQuery{SortBy: domain.ProductSortByName}, &products[1], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	priceCursor, err := encodeProductCursor(&domain.ProductQuery{SortBy: domain.ProductSortByPrice}, &products[1], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cursor of the repriced product holds the price of the price version effective now
+	repricedCursor, err := encodeProductCursor(&domain.ProductQuery{SortBy: domain.ProductSortByPrice},
+		&domain.Product{ID: repricedProducts[1].ID, Name: repricedProducts[1].Name, SubscriptionPeriod: 2, Price: domain.NewMoney(1500, "EUR")}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repricedFilter := db.ProductFilter{
+		SortBy: domain.ProductSortByPrice,
+		Limit:  3,
+	}
+	minPrice := domain.NewMoney(1500, "EUR")
+	maxPrice := domain.NewMoney(3000, "EUR")
+	maxPriceCHF := domain.NewMoney(3000, "CHF")
+	firstPageFilter := db.ProductFilter{
+		SortBy: domain.ProductSortByName,
+		Limit:  3,
+	}
+	secondPageFilter := db.ProductFilter{
+		SortBy: domain.ProductSortByName,
+		After: &db.ProductCursor{
+			Name:               products[1].Name,
+			PriceAmount:        products[1].Price.Amount,
+			SubscriptionPeriod: products[1].SubscriptionPeriod,
+			ID:                 products[1].ID,
+		},
+		Limit: 3,
+	}
+	rangeFilter := db.ProductFilter{
+		Name:               "hi",
+		SubscriptionPeriod: 2,
+		MinPrice:           &minPrice,
+		MaxPrice:           &maxPrice,
+		SortBy:             domain.ProductSortByPrice,
+		SortDesc:           true,
+		Limit:              defaultProductPageLimit + 1,
+	}
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{firstPageFilter}).Return(products, nil).Times(1),
+		database.EXPECT().CountProducts(gomock.Any(), productFilterMatcher{firstPageFilter}).Return(int64(3), nil).Times(1),
+
+		// test 2
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{secondPageFilter}).Return(products[2:], nil).Times(1),
+		database.EXPECT().CountProducts(gomock.Any(), productFilterMatcher{secondPageFilter}).Return(int64(3), nil).Times(1),
+
+		// test 3
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{rangeFilter}).Return(products[1:2], nil).Times(1),
+		database.EXPECT().CountProducts(gomock.Any(), productFilterMatcher{rangeFilter}).Return(int64(1), nil).Times(1),
+
+		// test 4
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{firstPageFilter}).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 5
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{firstPageFilter}).Return(products, nil).Times(1),
+		database.EXPECT().CountProducts(gomock.Any(), productFilterMatcher{firstPageFilter}).Return(int64(0), fmt.Errorf("db error")).Times(1),
+
+		// test 6
+		database.EXPECT().FindProducts(gomock.Any(), productFilterMatcher{repricedFilter}).Return(append(repricedProducts, products[2]), nil).Times(1),
+		database.EXPECT().CountProducts(gomock.Any(), productFilterMatcher{repricedFilter}).Return(int64(3), nil).Times(1),
+	)
+
+	tests := []struct {
+		name           string
+		query          domain.ProductQuery
+		want           []domain.Product
+		wantTotalCount int64
+		wantNextCursor string
+		wantErr        error
+		wantAnyErr     bool
+	}{
+		{
+			name:           "should return first page with total count and next cursor",
+			query:          domain.ProductQuery{Limit: 2},
+			want:           products[:2],
+			wantTotalCount: 3,
+			wantNextCursor: cursor,
+		},
+		{
+			name:           "should return last page after the cursor",
+			query:          domain.ProductQuery{Limit: 2, Cursor: cursor},
+			want:           products[2:],
+			wantTotalCount: 3,
+		},
+		{
+			name: "should return products matching the filter",
+			query: domain.ProductQuery{
+				Name:               "hi",
+				SubscriptionPeriod: 2,
+				MinPrice:           &minPrice,
+				MaxPrice:           &maxPrice,
+				SortBy:             domain.ProductSortByPrice,
+				SortDesc:           true,
+			},
+			want:           products[1:2],
+			wantTotalCount: 1,
+		},
+		{
+			name:       "should return error if db returns error",
+			query:      domain.ProductQuery{Limit: 2},
+			wantAnyErr: true,
+		},
+		{
+			name:       "should return error if count fails",
+			query:      domain.ProductQuery{Limit: 2},
+			wantAnyErr: true,
+		},
+		{
+			name:           "should return next cursor with price effective now",
+			query:          domain.ProductQuery{SortBy: domain.ProductSortByPrice, Limit: 2},
+			want:           repricedProducts,
+			wantTotalCount: 3,
+			wantNextCursor: repricedCursor,
+		},
+		{
+			name:    "should return error if sort field is invalid",
+			query:   domain.ProductQuery{SortBy: "trial_days"},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if limit is negative",
+			query:   domain.ProductQuery{Limit: -1},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if price range has different currencies",
+			query:   domain.ProductQuery{MinPrice: &minPrice, MaxPrice: &maxPriceCHF},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if min price is greater than max price",
+			query:   domain.ProductQuery{MinPrice: &maxPrice, MaxPrice: &minPrice},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if cursor is of different sorting",
+			query:   domain.ProductQuery{Cursor: priceCursor},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name:    "should return error if cursor is invalid",
+			query:   domain.ProductQuery{Cursor: "invalid cursor"},
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.ListProducts(ctx, tt.query)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Errorf("appDetails.ListProducts() error = %v, wantErr %v", err, tt.wantAnyErr)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.ListProducts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Products, tt.want) {
+				t.Errorf("appDetails.ListProducts() = %v, want %v", got.Products, tt.want)
+			}
+			if got.TotalCount != tt.wantTotalCount {
+				t.Errorf("appDetails.ListProducts() total count = %v, want %v", got.TotalCount, tt.wantTotalCount)
+			}
+			if got.NextCursor != tt.wantNextCursor {
+				t.Errorf("appDetails.ListProducts() next cursor = %v, want %v", got.NextCursor, tt.wantNextCursor)
+			}
+		})
+	}
+}
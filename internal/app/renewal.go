@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// renewalBatchSize is maximum number of subscriptions fetched at once by a renewal, payment retry or resume run
+const renewalBatchSize = 100
+
+// RenewalPricing tells which price the subscription is renewed with
+// empty pricing is the same as grandfathered pricing
+type RenewalPricing string
+
+const (
+	// RenewalPricingGrandfathered renews the subscription with the price it was bought with
+	RenewalPricingGrandfathered RenewalPricing = "grandfathered"
+	// RenewalPricingCurrent renews the subscription with the product price version effective at the renewal
+	RenewalPricingCurrent RenewalPricing = "current"
+)
+
+// IsValid returns true if the pricing is empty or one of the known pricings
+func (p RenewalPricing) IsValid() bool {
+	switch p {
+	case "", RenewalPricingGrandfathered, RenewalPricingCurrent:
+		return true
+	}
+	return false
+}
+
+// RenewSubscriptions renews active and trialing subscriptions with end date before or equal to given time
+// auto renewed subscription is extended by subscription period of the product and the renewal is recorded,
+// trialing subscription is converted to active at the trial end in the same way,
+// plan change scheduled for the period end is applied before the subscription is extended,
+// the price is kept or moved to the current product price version as per renewal pricing,
+// the renewed period is charged by the payment provider with the payment token of the subscription,
+// subscription whose charge is declined is moved to past due and the charge is retried as per dunning policy, see RetryPayments,
+// subscription whose charge fails otherwise is kept due and the renewal is retried by the next run,
+// subscription flagged to be cancelled at period end is cancelled,
+// subscription which is not auto renewed or whose product is not found is expired
+// all the due subscriptions are renewed in batches, failed subscription does not block the others, see processDueSubscriptions
+// returns number of processed subscriptions and the last error if any subscription failed
+func (a *appDetails) RenewSubscriptions(ctx context.Context, at time.Time) (int, error) {
+	filter := db.SubscriptionFilter{
+		Statuses:      []domain.SubscriptionStatus{domain.SubscriptionStatusActive, domain.SubscriptionStatusTrialing},
+		EndDateBefore: &at,
+	}
+	return a.processDueSubscriptions(ctx, filter, func(subscription *domain.UserSubscription) error {
+		err := a.renewSubscription(ctx, subscription, at)
+		if err != nil {
+			log.Printf("renewal of subscription %v failed: %v", subscription.ID, err)
+			return fmt.Errorf("renewal of subscription %v failed: %w", subscription.ID, err)
+		}
+		return nil
+	})
+}
+
+// processDueSubscriptions processes all the subscriptions matching the filter in batches of renewalBatchSize
+// sorted by end date, the next batch is found after the last subscription of the previous batch,
+// so the subscriptions which failed and are still due do not block processing of the other due subscriptions
+// returns number of subscriptions processed without error and the last error if any subscription failed
+func (a *appDetails) processDueSubscriptions(ctx context.Context, filter db.SubscriptionFilter, process func(subscription *domain.UserSubscription) error) (int, error) {
+	filter.Limit = renewalBatchSize
+
+	processed := 0
+	var processErr error
+	for {
+		dueSubscriptions, err := a.database.FindSubscriptions(ctx, filter)
+		if err != nil {
+			return processed, err
+		}
+
+		if len(dueSubscriptions) == 0 {
+			return processed, processErr
+		}
+		// the cursor is taken before processing, which changes the end date of the subscription
+		last := dueSubscriptions[len(dueSubscriptions)-1]
+		filter.After = &db.SubscriptionCursor{Value: last.EndDate, ID: last.ID}
+
+		for i := range dueSubscriptions {
+			err := process(&dueSubscriptions[i])
+			if err != nil {
+				processErr = err
+				continue
+			}
+			processed++
+		}
+
+		if int64(len(dueSubscriptions)) < filter.Limit {
+			return processed, processErr
+		}
+	}
+}
+
+// renewSubscription extends the subscription by the product subscription period or expires it,
+// the invoice of the renewal charge is saved with the renewal and issued
+// subscription whose renewal charge is declined is moved to past due, see failRenewalPayment
+func (a *appDetails) renewSubscription(ctx context.Context, subscription *domain.UserSubscription, at time.Time) error {
+	subscription.UpdatedAt = &at
+	subscription.NextPaymentRetry = nil
+	previousStatus := subscription.Status
+	// the declined renewal is recorded on the subscription as it was due, without the price and plan changes of the renewal
+	dueSubscription := *subscription
+
+	if subscription.CancelAtPeriodEnd {
+		subscription.Status = domain.SubscriptionStatusCancelled
+		_, err := a.saveSubscription(ctx, domain.AuditActionStatusChanged, previousStatus, subscription)
+		return err
+	}
+
+	product, err := a.renewalProduct(ctx, subscription)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		subscription.Status = domain.SubscriptionStatusExpired
+		_, err = a.saveSubscription(ctx, domain.AuditActionStatusChanged, previousStatus, subscription)
+		return err
+	}
+
+	periodStart := subscription.EndDate
+	if subscription.PendingPlanChange != nil {
+		tax, priceVersionID, err := a.productPrice(ctx, product, subscription.Price.Currency, subscription.Country, at)
+		if err != nil {
+			return err
+		}
+		applyPlanChange(subscription, product, tax, priceVersionID, *subscription.PendingPlanChange)
+	} else if a.renewalPricing == RenewalPricingCurrent {
+		err = a.applyCurrentPrice(ctx, subscription, product, at)
+		if err != nil {
+			return err
+		}
+	}
+
+	var charge *domain.Payment
+	if subscription.PaymentToken != "" {
+		charge, err = a.chargePayment(ctx, subscription.PaymentToken, subscription.Price, domain.PaymentReasonRenewal, at)
+		if err != nil {
+			if errors.Is(err, PaymentDeclinedErr) {
+				return a.failRenewalPayment(ctx, &dueSubscription, previousStatus, at, err)
+			}
+			return err
+		}
+		if charge != nil {
+			subscription.Payments = append(subscription.Payments, *charge)
+		}
+	}
+
+	if previousStatus == domain.SubscriptionStatusPastDue {
+		subscription.PaymentAttempts = append(subscription.PaymentAttempts, domain.PaymentAttempt{
+			PeriodStart: periodStart,
+			AttemptedAt: at,
+			Succeeded:   true,
+		})
+	}
+
+	subscription.Status = domain.SubscriptionStatusActive
+	subscription.EndDate = periodStart.AddDate(0, int(product.SubscriptionPeriod), 0)
+	subscription.Renewals = append(subscription.Renewals, domain.Renewal{
+		RenewedAt:      at,
+		PeriodStart:    periodStart,
+		PeriodEnd:      subscription.EndDate,
+		Price:          subscription.Price,
+		PriceVersionID: subscription.PriceVersionID,
+	})
+	addPendingInvoice(subscription, charge, periodStart, subscription.EndDate)
+
+	savedSubscription, err := a.saveSubscription(ctx, domain.AuditActionRenewed, previousStatus, subscription)
+	if err != nil {
+		a.reverseCharge(ctx, charge)
+		return err
+	}
+
+	a.issueSavedInvoices(ctx, savedSubscription)
+	return nil
+}
+
+// applyCurrentPrice moves the subscription to the product price version effective at given time
+// grandfathered price is kept if the current price version has no price for the subscription currency/country
+func (a *appDetails) applyCurrentPrice(ctx context.Context, subscription *domain.UserSubscription, product *domain.Product, at time.Time) error {
+	if product.PriceVersionAt(at).ID == subscription.PriceVersionID {
+		return nil
+	}
+
+	tax, priceVersionID, err := a.productPrice(ctx, product, subscription.Price.Currency, subscription.Country, at)
+	if err != nil {
+		if errors.Is(err, InvalidArgErr) {
+			log.Printf("subscription %v keeps its price: %v", subscription.ID, err)
+			return nil
+		}
+		return err
+	}
+
+	applyPrice(subscription, tax, priceVersionID)
+	return nil
+}
+
+// renewalProduct returns product the subscription is renewed with, which is the product of the pending plan change if any
+// returns nil if subscription is not auto renewed or its product is not found
+func (a *appDetails) renewalProduct(ctx context.Context, subscription *domain.UserSubscription) (*domain.Product, error) {
+	if !subscription.AutoRenew || subscription.ProductID == "" {
+		return nil, nil
+	}
+
+	productID := subscription.ProductID
+	if subscription.PendingPlanChange != nil {
+		productID = subscription.PendingPlanChange.ToProductID
+	}
+
+	return a.findProduct(ctx, productID)
+}
+
+// findProduct returns product for given id, returns nil if id is empty or the product is not found
+func (a *appDetails) findProduct(ctx context.Context, id string) (*domain.Product, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	records, err := a.database.GetProduct(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.InvalidArgErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
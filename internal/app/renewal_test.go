@@ -0,0 +1,370 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestRenewSubscriptions() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	endDate := at.Add(-time.Hour)
+	productID := "62bb4ecdba3bbe275f8c7788"
+	product := domain.Product{
+		ID:                 productID,
+		SubscriptionPeriod: 2,
+	}
+	autoRenewSubscription := domain.UserSubscription{
+		ID:        "62bb4ecdba3bbe275f8c7781",
+		ProductID: productID,
+		EndDate:   endDate,
+		Price:     domain.NewMoney(1000, "EUR"),
+		Status:    domain.SubscriptionStatusActive,
+		AutoRenew: true,
+	}
+	nonRenewingSubscription := domain.UserSubscription{
+		ID:        "62bb4ecdba3bbe275f8c7782",
+		ProductID: productID,
+		EndDate:   endDate,
+		Status:    domain.SubscriptionStatusActive,
+	}
+	trialingSubscription := domain.UserSubscription{
+		ID:           "62bb4ecdba3bbe275f8c7783",
+		ProductID:    productID,
+		EndDate:      endDate,
+		Status:       domain.SubscriptionStatusTrialing,
+		AutoRenew:    true,
+		TrialEndDate: &endDate,
+	}
+	newProductID := "62bb4ecdba3bbe275f8c7789"
+	newProduct := domain.Product{
+		ID:                 newProductID,
+		Name:               "hiit extreme",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(2000, "EUR"),
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
+	}
+	planChangeSubscription := autoRenewSubscription
+	planChangeSubscription.PendingPlanChange = &domain.PlanChange{
+		EffectiveAt:   endDate,
+		FromProductID: productID,
+		ToProductID:   newProductID,
+	}
+	cancelAtPeriodEndSubscription := autoRenewSubscription
+	cancelAtPeriodEndSubscription.CancelAtPeriodEnd = true
+	expectedFilter := db.SubscriptionFilter{
+		Statuses:      []domain.SubscriptionStatus{domain.SubscriptionStatusActive, domain.SubscriptionStatusTrialing},
+		EndDateBefore: &at,
+		Limit:         renewalBatchSize,
+	}
+	// full batch of subscriptions failing to renew, which stay due
+	failingProductID := "62bb4ecdba3bbe275f8c7790"
+	failingSubscriptions := []domain.UserSubscription{}
+	for i := 0; i < renewalBatchSize; i++ {
+		failingSubscription := autoRenewSubscription
+		failingSubscription.ID = fmt.Sprintf("62bb4ecdba3bbe275f8c%04d", i)
+		failingSubscription.ProductID = failingProductID
+		failingSubscriptions = append(failingSubscriptions, failingSubscription)
+	}
+	nextBatchFilter := expectedFilter
+	nextBatchFilter.After = &db.SubscriptionCursor{
+		Value: endDate,
+		ID:    failingSubscriptions[renewalBatchSize-1].ID,
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			autoRenewSubscription,
+			nonRenewingSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantEndDate := endDate.AddDate(0, 2, 0)
+			if us.Status != domain.SubscriptionStatusActive || !us.EndDate.Equal(wantEndDate) || len(us.Renewals) != 1 {
+				return nil, fmt.Errorf("unexpected renewed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusExpired || !us.EndDate.Equal(endDate) {
+				return nil, fmt.Errorf("unexpected expired subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			autoRenewSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusExpired {
+				return nil, fmt.Errorf("unexpected subscription status %v", us.Status)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			autoRenewSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 4
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return(nil, fmt.Errorf("db error")).Times(1),
+
+		// test 5
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			trialingSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantEndDate := endDate.AddDate(0, 2, 0)
+			if us.Status != domain.SubscriptionStatusActive || !us.EndDate.Equal(wantEndDate) {
+				return nil, fmt.Errorf("unexpected converted subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 6
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			planChangeSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), newProductID).Return([]domain.Product{newProduct}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), newProduct.TaxCategory).Return([]domain.TaxRule{
+			{
+				Category: "digital_service",
+				Rate:     10,
+			},
+		}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			wantEndDate := endDate.AddDate(0, 1, 0)
+			if us.ProductID != newProductID || us.PendingPlanChange != nil || len(us.PlanChanges) != 1 ||
+				us.Price != newProduct.Price || !us.EndDate.Equal(wantEndDate) || us.Renewals[0].Price != newProduct.Price {
+				return nil, fmt.Errorf("unexpected plan changed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 7
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return([]domain.UserSubscription{
+			cancelAtPeriodEndSubscription,
+		}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Status != domain.SubscriptionStatusCancelled || !us.EndDate.Equal(endDate) || len(us.Renewals) != 0 {
+				return nil, fmt.Errorf("unexpected cancelled subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 8
+		database.EXPECT().FindSubscriptions(gomock.Any(), expectedFilter).Return(failingSubscriptions, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), failingProductID).Return(nil, fmt.Errorf("db error")).Times(renewalBatchSize),
+		database.EXPECT().FindSubscriptions(gomock.Any(), nextBatchFilter).Return([]domain.UserSubscription{
+			autoRenewSubscription,
+		}, nil).Times(1),
+		database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.ID != autoRenewSubscription.ID || us.Status != domain.SubscriptionStatusActive || len(us.Renewals) != 1 {
+				return nil, fmt.Errorf("unexpected renewed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1),
+	)
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "should renew auto renewed subscription and expire non renewing subscription",
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "should expire subscription if product is not found",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should return error if renewal of subscription fails",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "should return error if due subscriptions cannot be fetched",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "should convert trialing subscription to active at trial end",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should apply pending plan change on renewal",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should cancel subscription flagged to be cancelled at period end",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "should renew due subscriptions after the batch of failing subscriptions",
+			want:    1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
+			}
+			got, err := a.RenewSubscriptions(ctx, at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("appDetails.RenewSubscriptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("appDetails.RenewSubscriptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *AppTestSuite) TestRenewSubscriptionsPricing() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	endDate := at.Add(-time.Hour)
+	productID := "62bb4ecdba3bbe275f8c7788"
+	priceVersionID := "62bb4ecdba3bbe275f8c7790"
+	product := domain.Product{
+		ID:                 productID,
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+		PriceVersions: []domain.PriceVersion{
+			{
+				ID:            priceVersionID,
+				EffectiveFrom: at.AddDate(0, -1, 0),
+				Price:         domain.NewMoney(1500, "EUR"),
+			},
+			{
+				ID:            "62bb4ecdba3bbe275f8c7791",
+				EffectiveFrom: at.AddDate(0, 1, 0),
+				Price:         domain.NewMoney(2000, "EUR"),
+			},
+		},
+		TaxCategory:  "digital_service",
+		TaxInclusive: true,
+	}
+	subscription := domain.UserSubscription{
+		ID:        "62bb4ecdba3bbe275f8c7781",
+		ProductID: productID,
+		EndDate:   endDate,
+		Price:     domain.NewMoney(1000, "EUR"),
+		Status:    domain.SubscriptionStatusActive,
+		AutoRenew: true,
+	}
+	currentSubscription := subscription
+	currentSubscription.Price = domain.NewMoney(1500, "EUR")
+	currentSubscription.PriceVersionID = priceVersionID
+	otherCurrencySubscription := subscription
+	otherCurrencySubscription.Price = domain.NewMoney(1100, "CHF")
+
+	// expectRenewal expects renewal of the subscription with given price and price version
+	expectRenewal := func(us domain.UserSubscription, wantPrice domain.Money, wantPriceVersionID string, calculatesTax bool) []*gomock.Call {
+		calls := []*gomock.Call{
+			database.EXPECT().FindSubscriptions(gomock.Any(), gomock.Any()).Return([]domain.UserSubscription{us}, nil).Times(1),
+			database.EXPECT().GetProduct(gomock.Any(), productID).Return([]domain.Product{product}, nil).Times(1),
+		}
+		if calculatesTax {
+			calls = append(calls, database.EXPECT().GetTaxRules(gomock.Any(), product.TaxCategory).Return([]domain.TaxRule{
+				{
+					Category: "digital_service",
+					Rate:     19,
+				},
+			}, nil).Times(1))
+		}
+		return append(calls, database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.Price != wantPrice || us.PriceVersionID != wantPriceVersionID || len(us.Renewals) != 1 ||
+				us.Renewals[0].Price != wantPrice || us.Renewals[0].PriceVersionID != wantPriceVersionID {
+				return nil, fmt.Errorf("unexpected renewed subscription %v", us)
+			}
+			return us, nil
+		}).Times(1))
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	calls := []*gomock.Call{}
+	// test 1
+	calls = append(calls, expectRenewal(subscription, subscription.Price, "", false)...)
+	// test 2
+	calls = append(calls, expectRenewal(subscription, domain.NewMoney(1500, "EUR"), priceVersionID, true)...)
+	// test 3
+	calls = append(calls, expectRenewal(currentSubscription, currentSubscription.Price, priceVersionID, false)...)
+	// test 4
+	calls = append(calls, expectRenewal(otherCurrencySubscription, otherCurrencySubscription.Price, "", false)...)
+	gomock.InOrder(calls...)
+
+	tests := []struct {
+		name           string
+		renewalPricing RenewalPricing
+	}{
+		{
+			name:           "should keep grandfathered price",
+			renewalPricing: RenewalPricingGrandfathered,
+		},
+		{
+			name:           "should move to the current price version",
+			renewalPricing: RenewalPricingCurrent,
+		},
+		{
+			name:           "should keep price of the current price version",
+			renewalPricing: RenewalPricingCurrent,
+		},
+		{
+			name:           "should keep grandfathered price if current price version has no price for the currency",
+			renewalPricing: RenewalPricingCurrent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
+				renewalPricing:  tt.renewalPricing,
+			}
+			got, err := a.RenewSubscriptions(ctx, at)
+			if err != nil || got != 1 {
+				t.Errorf("appDetails.RenewSubscriptions() = %v, %v, want 1", got, err)
+			}
+		})
+	}
+}
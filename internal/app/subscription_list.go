@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+const (
+	defaultSubscriptionPageLimit = 20
+	maxSubscriptionPageLimit     = 100
+)
+
+// subscriptionCursor is content of the opaque cursor of the subscription list
+// sort and order are kept in the cursor, so that the cursor cannot be used with different sorting
+type subscriptionCursor struct {
+	SortBy   domain.SubscriptionSortField `json:"s"`
+	SortDesc bool                         `json:"d,omitempty"`
+	Value    time.Time                    `json:"v"`
+	ID       string                       `json:"i"`
+}
+
+// encodeSubscriptionCursor returns cursor pointing after the subscription in the sort order of the query
+func encodeSubscriptionCursor(query *domain.SubscriptionQuery, us *domain.UserSubscription) (string, error) {
+	data, err := json.Marshal(subscriptionCursor{
+		SortBy:   query.SortBy,
+		SortDesc: query.SortDesc,
+		Value:    query.SortBy.SortValue(us),
+		ID:       us.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeSubscriptionCursor returns position of the cursor, it must be created with the same sorting as the query
+func decodeSubscriptionCursor(query *domain.SubscriptionQuery) (*db.SubscriptionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor %w", InvalidArgErr)
+	}
+
+	cursor := subscriptionCursor{}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("cursor %w", InvalidArgErr)
+	}
+
+	if cursor.SortBy != query.SortBy || cursor.SortDesc != query.SortDesc {
+		return nil, fmt.Errorf("cursor of different sorting %w", InvalidArgErr)
+	}
+
+	return &db.SubscriptionCursor{
+		Value: cursor.Value,
+		ID:    cursor.ID,
+	}, nil
+}
+
+// ListSubscriptions returns a page of the subscriptions of the user matching the query
+// subscriptions are sorted by created at if sort field is not given, default page size is 20 and max is 100
+// returns invalid argument error if email is empty, sort field, limit, date range or cursor is invalid
+func (a *appDetails) ListSubscriptions(ctx context.Context, query domain.SubscriptionQuery) (*domain.SubscriptionPage, error) {
+	query.Email = normalizeEmail(query.Email)
+	if query.Email == "" {
+		return nil, fmt.Errorf("email %w", InvalidArgErr)
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = domain.SubscriptionSortByCreatedAt
+	}
+
+	if !query.SortBy.IsValid() {
+		return nil, fmt.Errorf("sort field %v %w", query.SortBy, InvalidArgErr)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultSubscriptionPageLimit
+	}
+
+	if query.Limit < 0 || query.Limit > maxSubscriptionPageLimit {
+		return nil, fmt.Errorf("limit %v %w", query.Limit, InvalidArgErr)
+	}
+
+	for _, v := range query.Statuses {
+		if !v.IsValid() {
+			return nil, fmt.Errorf("status %v %w", v, InvalidArgErr)
+		}
+	}
+
+	if query.CreatedFrom != nil && query.CreatedTo != nil && query.CreatedFrom.After(*query.CreatedTo) {
+		return nil, fmt.Errorf("created from is after created to %w", InvalidArgErr)
+	}
+
+	if query.EndDateFrom != nil && query.EndDateTo != nil && query.EndDateFrom.After(*query.EndDateTo) {
+		return nil, fmt.Errorf("end date from is after end date to %w", InvalidArgErr)
+	}
+
+	filter := db.SubscriptionFilter{
+		Email:         query.Email,
+		ProductID:     query.ProductID,
+		Statuses:      query.Statuses,
+		CreatedFrom:   query.CreatedFrom,
+		CreatedTo:     query.CreatedTo,
+		EndDateFrom:   query.EndDateFrom,
+		EndDateBefore: query.EndDateTo,
+		SortBy:        query.SortBy,
+		SortDesc:      query.SortDesc,
+		Limit:         query.Limit + 1,
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeSubscriptionCursor(&query)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	subscriptions, err := a.database.FindSubscriptions(ctx, filter)
+	if err != nil {
+		if errors.Is(err, db.InvalidArgErr) {
+			return nil, fmt.Errorf("list subscriptions failed:%s %w", err.Error(), InvalidArgErr)
+		}
+		return nil, err
+	}
+
+	page := &domain.SubscriptionPage{
+		Subscriptions: subscriptions,
+	}
+	if int64(len(subscriptions)) > query.Limit {
+		page.Subscriptions = subscriptions[:query.Limit]
+		page.NextCursor, err = encodeSubscriptionCursor(&query, &page.Subscriptions[query.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestListSubscriptions() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	email := "testmail@test.com"
+	createdAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	subscriptions := []domain.UserSubscription{
+		{ID: "62bb4ecdba3bbe275f8c7788", Email: email, CreatedAt: createdAt},
+		{ID: "62bb4ecdba3bbe275f8c7789", Email: email, CreatedAt: createdAt.AddDate(0, 0, 1)},
+		{ID: "62bb4ecdba3bbe275f8c7790", Email: email, CreatedAt: createdAt.AddDate(0, 0, 2)},
+	}
+	firstPageQuery := domain.SubscriptionQuery{
+		Email: email,
+		Limit: 2,
+	}
+	cursor, err := encodeSubscriptionCursor(&domain.SubscriptionQuery{SortBy: domain.SubscriptionSortByCreatedAt}, &subscriptions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	endDateCursor, err := encodeSubscriptionCursor(&domain.SubscriptionQuery{SortBy: domain.SubscriptionSortByEndDate}, &subscriptions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdFrom := createdAt.AddDate(0, 0, 1)
+
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().FindSubscriptions(gomock.Any(), db.SubscriptionFilter{
+			Email:  email,
+			SortBy: domain.SubscriptionSortByCreatedAt,
+			Limit:  3,
+		}).Return(subscriptions, nil).Times(1),
+
+		// test 2
+		database.EXPECT().FindSubscriptions(gomock.Any(), db.SubscriptionFilter{
+			Email:  email,
+			SortBy: domain.SubscriptionSortByCreatedAt,
+			After: &db.SubscriptionCursor{
+				Value: subscriptions[1].CreatedAt,
+				ID:    subscriptions[1].ID,
+			},
+			Limit: 3,
+		}).Return(subscriptions[2:], nil).Times(1),
+
+		// test 3
+		database.EXPECT().FindSubscriptions(gomock.Any(), db.SubscriptionFilter{
+			Email:       email,
+			ProductID:   "62bac24b0bf33af1c877d97f",
+			Statuses:    []domain.SubscriptionStatus{domain.SubscriptionStatusActive},
+			CreatedFrom: &createdFrom,
+			SortBy:      domain.SubscriptionSortByEndDate,
+			SortDesc:    true,
+			Limit:       defaultSubscriptionPageLimit + 1,
+		}).Return([]domain.UserSubscription{}, nil).Times(1),
+
+		// test 4
+		database.EXPECT().FindSubscriptions(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name           string
+		query          domain.SubscriptionQuery
+		want           []domain.UserSubscription
+		wantNextCursor bool
+		wantErr        error
+		wantAnyErr     bool
+	}{
+		{
+			name:           "should return first page with next cursor",
+			query:          firstPageQuery,
+			want:           subscriptions[:2],
+			wantNextCursor: true,
+		},
+		{
+			name: "should return last page after the cursor",
+			query: domain.SubscriptionQuery{
+				Email:  email,
+				Limit:  2,
+				Cursor: cursor,
+			},
+			want: subscriptions[2:],
+		},
+		{
+			name: "should return subscriptions matching the filter",
+			query: domain.SubscriptionQuery{
+				Email:       email,
+				ProductID:   "62bac24b0bf33af1c877d97f",
+				Statuses:    []domain.SubscriptionStatus{domain.SubscriptionStatusActive},
+				CreatedFrom: &createdFrom,
+				SortBy:      domain.SubscriptionSortByEndDate,
+				SortDesc:    true,
+			},
+			want: []domain.UserSubscription{},
+		},
+		{
+			name:       "should return error if db returns error",
+			query:      firstPageQuery,
+			wantAnyErr: true,
+		},
+		{
+			name:    "should return error if email is empty",
+			query:   domain.SubscriptionQuery{},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if sort field is invalid",
+			query: domain.SubscriptionQuery{
+				Email:  email,
+				SortBy: "price",
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if limit is too big",
+			query: domain.SubscriptionQuery{
+				Email: email,
+				Limit: maxSubscriptionPageLimit + 1,
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if status is invalid",
+			query: domain.SubscriptionQuery{
+				Email:    email,
+				Statuses: []domain.SubscriptionStatus{"cancel"},
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if date range is invalid",
+			query: domain.SubscriptionQuery{
+				Email:       email,
+				CreatedFrom: &createdFrom,
+				CreatedTo:   &createdAt,
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if cursor is invalid",
+			query: domain.SubscriptionQuery{
+				Email:  email,
+				Cursor: "invalid cursor",
+			},
+			wantErr: InvalidArgErr,
+		},
+		{
+			name: "should return error if cursor is of different sorting",
+			query: domain.SubscriptionQuery{
+				Email:  email,
+				Cursor: endDateCursor,
+			},
+			wantErr: InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+			}
+			got, err := a.ListSubscriptions(ctx, tt.query)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Errorf("appDetails.ListSubscriptions() error = %v, wantErr %v", err, tt.wantAnyErr)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.ListSubscriptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Subscriptions, tt.want) {
+				t.Errorf("appDetails.ListSubscriptions() = %v, want %v", got.Subscriptions, tt.want)
+			}
+			if (got.NextCursor != "") != tt.wantNextCursor {
+				t.Errorf("appDetails.ListSubscriptions() next cursor = %v, want %v", got.NextCursor, tt.wantNextCursor)
+			}
+			if tt.wantNextCursor && got.NextCursor != cursor {
+				t.Errorf("appDetails.ListSubscriptions() next cursor = %v, want %v", got.NextCursor, cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// TaxCalculator calculates tax for the product price sold to the customer of given country at given time
+type TaxCalculator interface {
+	Calculate(ctx context.Context, product *domain.Product, price domain.Money, country string, at time.Time) (*domain.TaxBreakdown, error)
+}
+
+type ruleTaxCalculator struct {
+	database db.DB
+}
+
+// NewRuleTaxCalculator creates tax calculator which uses tax rules stored in the database
+func NewRuleTaxCalculator(database db.DB) (TaxCalculator, error) {
+	if database == nil {
+		return nil, fmt.Errorf("database %w", NilArgErr)
+	}
+
+	return &ruleTaxCalculator{
+		database: database,
+	}, nil
+}
+
+// Calculate calculates tax breakdown using tax rule for product tax category and country
+// returns not found error if no rule is effective for the category and country
+func (r *ruleTaxCalculator) Calculate(ctx context.Context, product *domain.Product, price domain.Money, country string, at time.Time) (*domain.TaxBreakdown, error) {
+	if product == nil {
+		return nil, fmt.Errorf("product %w", NilArgErr)
+	}
+
+	rules, err := r.database.GetTaxRules(ctx, product.TaxCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := selectTaxRule(rules, country, at)
+	if rule == nil {
+		return nil, fmt.Errorf("tax rule for category %v country %v %w", product.TaxCategory, country, NotFoundErr)
+	}
+
+	breakdown := domain.CalculateTax(price, rule.Rate, product.TaxInclusive)
+	return &breakdown, nil
+}
+
+// selectTaxRule returns rule effective at given time for the country, if there is no rule
+// for the country then default rule (empty country) is returned
+// if more rules are effective then the one with latest EffectiveFrom is selected
+func selectTaxRule(rules []domain.TaxRule, country string, at time.Time) *domain.TaxRule {
+	country = strings.ToUpper(country)
+
+	var countryRule, defaultRule *domain.TaxRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsEffective(at) {
+			continue
+		}
+
+		switch {
+		case country != "" && rule.Country == country:
+			if countryRule == nil || rule.EffectiveFrom.After(countryRule.EffectiveFrom) {
+				countryRule = rule
+			}
+		case rule.Country == "":
+			if defaultRule == nil || rule.EffectiveFrom.After(defaultRule.EffectiveFrom) {
+				defaultRule = rule
+			}
+		}
+	}
+
+	if countryRule != nil {
+		return countryRule
+	}
+	return defaultRule
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+func Test_selectTaxRule(t *testing.T) {
+	rateChangeDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defaultRule := domain.TaxRule{
+		ID:       "default",
+		Category: "digital_service",
+		Rate:     10,
+	}
+	oldSwissRule := domain.TaxRule{
+		ID:          "ch-old",
+		Country:     "CH",
+		Category:    "digital_service",
+		Rate:        7.7,
+		EffectiveTo: &rateChangeDate,
+	}
+	swissRule := domain.TaxRule{
+		ID:            "ch",
+		Country:       "CH",
+		Category:      "digital_service",
+		Rate:          8.1,
+		EffectiveFrom: rateChangeDate,
+	}
+	rules := []domain.TaxRule{defaultRule, oldSwissRule, swissRule}
+
+	type args struct {
+		rules   []domain.TaxRule
+		country string
+		at      time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want *domain.TaxRule
+	}{
+		{
+			name: "should return country rule effective at given time",
+			args: args{
+				rules:   rules,
+				country: "ch",
+				at:      rateChangeDate.AddDate(0, 1, 0),
+			},
+			want: &swissRule,
+		},
+		{
+			name: "should return older country rule for time before rate change",
+			args: args{
+				rules:   rules,
+				country: "CH",
+				at:      rateChangeDate.AddDate(0, -1, 0),
+			},
+			want: &oldSwissRule,
+		},
+		{
+			name: "should return default rule for country without rule",
+			args: args{
+				rules:   rules,
+				country: "DE",
+				at:      rateChangeDate,
+			},
+			want: &defaultRule,
+		},
+		{
+			name: "should return default rule for empty country",
+			args: args{
+				rules: rules,
+				at:    rateChangeDate,
+			},
+			want: &defaultRule,
+		},
+		{
+			name: "should return nil if no rule matches",
+			args: args{
+				rules:   []domain.TaxRule{swissRule},
+				country: "DE",
+				at:      rateChangeDate,
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectTaxRule(tt.args.rules, tt.args.country, tt.args.at); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectTaxRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
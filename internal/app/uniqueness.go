@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// UniquenessRule tells which live (trialing, active or paused) subscriptions of the user are not allowed at the same time
+// empty or none rule allows any number of live subscriptions
+type UniquenessRule string
+
+const (
+	// UniquenessNone allows any number of live subscriptions
+	UniquenessNone UniquenessRule = "none"
+	// UniquenessPerEmailProduct allows one live subscription per email and product
+	UniquenessPerEmailProduct UniquenessRule = "email_product"
+	// UniquenessPerEmail allows one live subscription per email for any product
+	UniquenessPerEmail UniquenessRule = "email"
+)
+
+// IsValid returns true if the rule is empty or one of the known rules
+func (r UniquenessRule) IsValid() bool {
+	switch r {
+	case "", UniquenessNone, UniquenessPerEmailProduct, UniquenessPerEmail:
+		return true
+	}
+	return false
+}
+
+// DuplicateSubscriptionError is returned when the user already has a live subscription
+// which is not allowed by the uniqueness rule, it matches ConflictErr
+type DuplicateSubscriptionError struct {
+	SubscriptionID string
+}
+
+func (e *DuplicateSubscriptionError) Error() string {
+	return fmt.Sprintf("live subscription %v already exists %v", e.SubscriptionID, ConflictErr)
+}
+
+func (e *DuplicateSubscriptionError) Unwrap() error {
+	return ConflictErr
+}
+
+// uniquenessKey returns the key stored with the live subscription, the db allows only one subscription per key
+// the key has the normalized email, so subscriptions saved before the email normalization get the same key
+// returns empty key if the subscription is not live or there is no uniqueness rule
+func (a *appDetails) uniquenessKey(subscription *domain.UserSubscription) string {
+	if !subscription.IsLive() {
+		return ""
+	}
+
+	switch a.uniquenessRule {
+	case UniquenessPerEmailProduct:
+		return normalizeEmail(subscription.Email) + "/" + subscription.ProductID
+	case UniquenessPerEmail:
+		return normalizeEmail(subscription.Email)
+	default:
+		return ""
+	}
+}
+
+// findLiveSubscription returns live subscription of the user which is not allowed by the uniqueness rule
+// together with a new subscription of the product, returns nil if there is none
+func (a *appDetails) findLiveSubscription(ctx context.Context, emailID string, productID string) (*domain.UserSubscription, error) {
+	if a.uniquenessRule != UniquenessPerEmailProduct && a.uniquenessRule != UniquenessPerEmail {
+		return nil, nil
+	}
+
+	filter := db.SubscriptionFilter{
+		Email:    normalizeEmail(emailID),
+		Statuses: domain.LiveSubscriptionStatuses,
+		Limit:    1,
+	}
+	if a.uniquenessRule == UniquenessPerEmailProduct {
+		filter.ProductID = productID
+	}
+
+	subscriptions, err := a.database.FindSubscriptions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+
+	return &subscriptions[0], nil
+}
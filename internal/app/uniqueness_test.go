@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/golang/mock/gomock"
+)
+
+func (suite *AppTestSuite) TestBuySubscriptionUniqueness() {
+	t := suite.T()
+
+	database := suite.Database
+	ctx := context.Background()
+	existingID := "62bb4ecdba3bbe275f8c7790"
+	productRecord := domain.Product{
+		ID:                 "62bb4ecdba3bbe275f8c7788",
+		Name:               "testproduct",
+		SubscriptionPeriod: 1,
+		Price:              domain.NewMoney(1000, "EUR"),
+		TaxCategory:        "digital_service",
+	}
+	productFilter := db.SubscriptionFilter{
+		Email:     "testmail@test.com",
+		ProductID: productRecord.ID,
+		Statuses:  domain.LiveSubscriptionStatuses,
+		Limit:     1,
+	}
+	emailFilter := db.SubscriptionFilter{
+		Email:    "testmail@test.com",
+		Statuses: domain.LiveSubscriptionStatuses,
+		Limit:    1,
+	}
+	taxRules := []domain.TaxRule{
+		{
+			Category: "digital_service",
+			Rate:     10,
+		},
+	}
+
+	database.EXPECT().SaveAuditEvent(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	database.EXPECT().RemovePendingAuditEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	gomock.InOrder(
+		// test 1
+		database.EXPECT().GetProduct(gomock.Any(), productRecord.ID).Return([]domain.Product{productRecord}, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), productFilter).Return([]domain.UserSubscription{}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+			if us.UniquenessKey != "testmail@test.com/"+productRecord.ID {
+				return nil, fmt.Errorf("unexpected uniqueness key %v", us.UniquenessKey)
+			}
+			return us, nil
+		}).Times(1),
+
+		// test 2
+		database.EXPECT().GetProduct(gomock.Any(), productRecord.ID).Return([]domain.Product{productRecord}, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), productFilter).Return([]domain.UserSubscription{{ID: existingID}}, nil).Times(1),
+
+		// test 3
+		database.EXPECT().GetProduct(gomock.Any(), productRecord.ID).Return([]domain.Product{productRecord}, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), emailFilter).Return([]domain.UserSubscription{{ID: existingID}}, nil).Times(1),
+
+		// test 4
+		database.EXPECT().GetProduct(gomock.Any(), productRecord.ID).Return([]domain.Product{productRecord}, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), productFilter).Return([]domain.UserSubscription{}, nil).Times(1),
+		database.EXPECT().GetTaxRules(gomock.Any(), productRecord.TaxCategory).Return(taxRules, nil).Times(1),
+		database.EXPECT().SaveSubscription(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("uniqueness key %w", db.DuplicateRecordErr)).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), productFilter).Return([]domain.UserSubscription{{ID: existingID}}, nil).Times(1),
+
+		// test 5
+		database.EXPECT().GetProduct(gomock.Any(), productRecord.ID).Return([]domain.Product{productRecord}, nil).Times(1),
+		database.EXPECT().FindSubscriptions(gomock.Any(), productFilter).Return(nil, fmt.Errorf("db error")).Times(1),
+	)
+
+	tests := []struct {
+		name            string
+		uniquenessRule  UniquenessRule
+		wantErr         error
+		wantExistingID  string
+		wantUniqueError bool
+	}{
+		{
+			name:           "should create subscription with uniqueness key if user has no live subscription of the product",
+			uniquenessRule: UniquenessPerEmailProduct,
+		},
+		{
+			name:            "should return duplicate subscription error if user has live subscription of the product",
+			uniquenessRule:  UniquenessPerEmailProduct,
+			wantErr:         ConflictErr,
+			wantExistingID:  existingID,
+			wantUniqueError: true,
+		},
+		{
+			name:            "should return duplicate subscription error if user has live subscription of any product",
+			uniquenessRule:  UniquenessPerEmail,
+			wantErr:         ConflictErr,
+			wantExistingID:  existingID,
+			wantUniqueError: true,
+		},
+		{
+			name:            "should return duplicate subscription error if concurrent purchase created the subscription",
+			uniquenessRule:  UniquenessPerEmailProduct,
+			wantErr:         ConflictErr,
+			wantExistingID:  existingID,
+			wantUniqueError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				database: database,
+				taxCalculator: &ruleTaxCalculator{
+					database: database,
+				},
+				paymentProvider: NewFakePaymentProvider(),
+				uniquenessRule:  tt.uniquenessRule,
+			}
+			_, err := a.BuySubscription(ctx, domain.Purchase{
+				EmailID:      "testmail@test.com",
+				PaymentToken: "tok_visa",
+				ProductID:    productRecord.ID,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("appDetails.BuySubscription() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var duplicateErr *DuplicateSubscriptionError
+			if errors.As(err, &duplicateErr) != tt.wantUniqueError {
+				t.Errorf("appDetails.BuySubscription() error = %v, want duplicate subscription error %v", err, tt.wantUniqueError)
+				return
+			}
+			if tt.wantUniqueError && duplicateErr.SubscriptionID != tt.wantExistingID {
+				t.Errorf("appDetails.BuySubscription() existing subscription = %v, want %v", duplicateErr.SubscriptionID, tt.wantExistingID)
+			}
+		})
+	}
+
+	t.Run("should return error if live subscriptions cannot be found", func(t *testing.T) {
+		a := &appDetails{
+			database:       database,
+			uniquenessRule: UniquenessPerEmailProduct,
+		}
+		_, err := a.BuySubscription(ctx, domain.Purchase{
+			EmailID:      "testmail@test.com",
+			PaymentToken: "tok_visa",
+			ProductID:    productRecord.ID,
+		})
+		if err == nil {
+			t.Errorf("appDetails.BuySubscription() error = %v, wantErr %v", err, true)
+		}
+	})
+}
+
+func Test_uniquenessKey(t *testing.T) {
+	subscription := &domain.UserSubscription{
+		Email:     "testmail@test.com",
+		ProductID: "62bb4ecdba3bbe275f8c7788",
+		Status:    domain.SubscriptionStatusPaused,
+	}
+	cancelled := *subscription
+	cancelled.Status = domain.SubscriptionStatusCancelled
+	mixedCase := *subscription
+	mixedCase.Email = " TestMail@Test.com "
+
+	tests := []struct {
+		name         string
+		rule         UniquenessRule
+		subscription *domain.UserSubscription
+		want         string
+	}{
+		{
+			name:         "should return email and product for per email product rule",
+			rule:         UniquenessPerEmailProduct,
+			subscription: subscription,
+			want:         "testmail@test.com/62bb4ecdba3bbe275f8c7788",
+		},
+		{
+			name:         "should return email for per email rule",
+			rule:         UniquenessPerEmail,
+			subscription: subscription,
+			want:         "testmail@test.com",
+		},
+		{
+			name:         "should return normalized email for email in other case",
+			rule:         UniquenessPerEmailProduct,
+			subscription: &mixedCase,
+			want:         "testmail@test.com/62bb4ecdba3bbe275f8c7788",
+		},
+		{
+			name:         "should return empty key for none rule",
+			rule:         UniquenessNone,
+			subscription: subscription,
+		},
+		{
+			name:         "should return empty key if subscription is not live",
+			rule:         UniquenessPerEmailProduct,
+			subscription: &cancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &appDetails{
+				uniquenessRule: tt.rule,
+			}
+			if got := a.uniquenessKey(tt.subscription); got != tt.want {
+				t.Errorf("appDetails.uniquenessKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
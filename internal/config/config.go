@@ -3,17 +3,45 @@ package config
 import "github.com/ganeshdipdumbare/goenv"
 
 type envVar struct {
-	MongoUri           string `json:"mongo_uri"`
-	MongoDb            string `json:"mongo_db"`
-	Port               string `json:"port"`
-	MigrationFilesPath string `json:"migration_files_path"`
+	DbDriver               string `json:"db_driver"`
+	MongoUri               string `json:"mongo_uri"`
+	MongoDb                string `json:"mongo_db"`
+	PostgresUri            string `json:"postgres_uri"`
+	SqlitePath             string `json:"sqlite_path"`
+	Port                   string `json:"port"`
+	MigrationFilesPath     string `json:"migration_files_path"`
+	SeedFilesPath          string `json:"seed_files_path"`
+	RenewalInterval        string `json:"renewal_interval"`
+	ResumeInterval         string `json:"resume_interval"`
+	SubscriptionUniqueness string `json:"subscription_uniqueness"`
+	IdempotencyKeyTTL      string `json:"idempotency_key_ttl"`
+	RenewalPricing         string `json:"renewal_pricing"`
+	PaymentProvider        string `json:"payment_provider"`
+	PaymentRetryDays       string `json:"payment_retry_days"`
+	PaymentRetryStatus     string `json:"payment_retry_status"`
+	PaymentRetryInterval   string `json:"payment_retry_interval"`
+	InvoiceTemplatePath    string `json:"invoice_template_path"`
+	InvoiceInterval        string `json:"invoice_interval"`
+	AuditInterval          string `json:"audit_interval"`
+	ActorSigningKey        string `json:"actor_signing_key"`
+	PaymentCallbackKey     string `json:"payment_callback_key"`
 }
 
 var (
 	envVars = &envVar{
-		Port:               "8080",
-		MongoDb:            "gymondodb",
-		MigrationFilesPath: "file://migration",
+		Port:                   "8080",
+		MongoDb:                "gymondodb",
+		MigrationFilesPath:     "file://migration",
+		RenewalInterval:        "1m",
+		ResumeInterval:         "1m",
+		SubscriptionUniqueness: "email_product",
+		IdempotencyKeyTTL:      "24h",
+		RenewalPricing:         "grandfathered",
+		PaymentRetryDays:       "1,3,7",
+		PaymentRetryStatus:     "cancelled",
+		PaymentRetryInterval:   "1m",
+		InvoiceInterval:        "1m",
+		AuditInterval:          "1m",
 	}
 )
 
@@ -3,22 +3,126 @@ package db
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 )
 
 var (
-	InvalidArgErr     = errors.New("invalid argument")
-	EmptyArgErr       = errors.New("empty argument not allowed")
-	RecordNotFoundErr = errors.New("record not found")
+	InvalidArgErr      = errors.New("invalid argument")
+	EmptyArgErr        = errors.New("empty argument not allowed")
+	RecordNotFoundErr  = errors.New("record not found")
+	VersionConflictErr = errors.New("version conflict")
+	DuplicateRecordErr = errors.New("duplicate record")
 )
 
+// SubscriptionCursor is position of the subscription in the sort order, Value is the sort field value of the subscription
+type SubscriptionCursor struct {
+	Value time.Time
+	ID    string
+}
+
+// SubscriptionFilter is used to find subscriptions, empty fields are not used in the filter
+// CreatedFrom and CreatedTo match subscriptions created in the range including both
+// EndDateFrom and EndDateBefore match subscriptions with end date after or equal and before or equal to given time
+// ResumeDateBefore matches subscriptions with resume date before or equal to given time
+// PaymentRetryBefore matches subscriptions with next payment retry before or equal to given time
+// HadTrial matches subscriptions which started with a free trial
+// HasPendingInvoices matches subscriptions with invoices which are not issued yet
+// HasPendingAuditEvents matches subscriptions with audit events which are not recorded yet
+// subscriptions are sorted by SortBy field (end date if empty) and by ID, in descending order if SortDesc is set
+// After matches subscriptions after the cursor in the sort order
+// Limit is maximum number of records returned, 0 means no limit
+type SubscriptionFilter struct {
+	Email                 string
+	ProductID             string
+	Statuses              []domain.SubscriptionStatus
+	CreatedFrom           *time.Time
+	CreatedTo             *time.Time
+	EndDateFrom           *time.Time
+	EndDateBefore         *time.Time
+	ResumeDateBefore      *time.Time
+	PaymentRetryBefore    *time.Time
+	HadTrial              bool
+	HasPendingInvoices    bool
+	HasPendingAuditEvents bool
+	SortBy                domain.SubscriptionSortField
+	SortDesc              bool
+	After                 *SubscriptionCursor
+	Limit                 int64
+}
+
+// ProductCursor is position of the product in the sort order, it holds the sort field values of the product
+type ProductCursor struct {
+	Name               string
+	PriceAmount        int64
+	SubscriptionPeriod uint
+	ID                 string
+}
+
+// ProductFilter is used to find products, empty fields are not used in the filter
+// Name matches products whose name contains it ignoring case
+// MinPrice and MaxPrice match products with default price in their currency within the range including both,
+// both must have the same currency, the default price is the price of the product price version effective at PriceAt
+// archived products are matched only if IncludeArchived is set
+// products are sorted by SortBy field (name if empty) and by ID, in descending order if SortDesc is set,
+// products are sorted by the default price effective at PriceAt as well
+// After matches products after the cursor in the sort order
+// Limit is maximum number of records returned, 0 means no limit
+type ProductFilter struct {
+	Name               string
+	SubscriptionPeriod uint
+	MinPrice           *domain.Money
+	MaxPrice           *domain.Money
+	PriceAt            time.Time
+	IncludeArchived    bool
+	SortBy             domain.ProductSortField
+	SortDesc           bool
+	After              *ProductCursor
+	Limit              int64
+}
+
 // DB interface to interact with database
+// SaveSubscription inserts the subscription with version 0, otherwise updates the subscription only if the stored
+// version is equal to the subscription version, returns VersionConflictErr if it is not
+// the saved subscription has its version incremented
+// SaveSubscription returns DuplicateRecordErr if other subscription has the same non-empty uniqueness key
+// pending invoices of the subscription get the subscription ID and new ID if they have none, so the invoice keeps its ID until it is issued
+// RemovePendingInvoices removes the pending invoices with given IDs from the subscription, the version is not incremented
+// pending audit events get the subscription ID and new ID the same way and are removed by RemovePendingAuditEvents
+// SaveProduct inserts the product without ID, otherwise updates the product, returns RecordNotFoundErr if it does not exist
+// price versions of the product without ID get new ID
+// CountProducts returns number of the products matching the filter, After and Limit are not used
+// CreateIdempotencyRecord returns DuplicateRecordErr if unexpired record with the key exists, expired record is replaced
+// CompleteIdempotencyRecord stores the response of the in-progress record, returns RecordNotFoundErr if there is none
+// SaveInvoice inserts the invoice with the next sequence of the year it is issued in, so the invoice numbers of the year
+// are sequential without gaps, the saved invoice has its ID, year, sequence and number set
+// invoice with ID is inserted with the ID, if it is already inserted the stored invoice is returned, so it is issued only once
+// SaveAuditEvent with ID inserts the event with the ID, if it is already inserted the stored event is returned
+// GetInvoices returns invoices of the subscription sorted by issue time, GetInvoiceByNumber returns RecordNotFoundErr if it does not exist
 //
 //go:generate mockgen -destination=../mocks/mock_db.go -package=mocks github.com/ganeshdipdumbare/gymondo-subscription/internal/db DB
 type DB interface {
 	GetProduct(ctx context.Context, id string) ([]domain.Product, error)
+	FindProducts(ctx context.Context, filter ProductFilter) ([]domain.Product, error)
+	CountProducts(ctx context.Context, filter ProductFilter) (int64, error)
+	SaveProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
 	SaveSubscription(ctx context.Context, subsciption *domain.UserSubscription) (*domain.UserSubscription, error)
 	GetSubscriptionByID(ctx context.Context, id string) (*domain.UserSubscription, error)
+	GetTaxRules(ctx context.Context, category string) ([]domain.TaxRule, error)
+	FindSubscriptions(ctx context.Context, filter SubscriptionFilter) ([]domain.UserSubscription, error)
+	RemovePendingInvoices(ctx context.Context, subscriptionID string, invoiceIDs []string) error
+	RemovePendingAuditEvents(ctx context.Context, subscriptionID string, eventIDs []string) error
+	SaveAuditEvent(ctx context.Context, event *domain.AuditEvent) (*domain.AuditEvent, error)
+	GetAuditEvents(ctx context.Context, subscriptionID string) ([]domain.AuditEvent, error)
+	AcquireLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, name string, owner string) error
+	CreateIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error
+	GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
+	SaveInvoice(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error)
+	GetInvoices(ctx context.Context, subscriptionID string) ([]domain.Invoice, error)
+	GetInvoiceByNumber(ctx context.Context, number string) (*domain.Invoice, error)
 	Disconnect(ctx context.Context) error
 }
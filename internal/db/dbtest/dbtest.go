@@ -0,0 +1,1558 @@
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/stretchr/testify/suite"
+)
+
+// seeded product IDs of the migration files
+const (
+	getInShapeProductID   = "62bac24b0bf33af1c877d97f"
+	hiitExtremeProductID  = "62bac25f83b5fcd9ddeb8170"
+	hiphopCardioProductID = "62bac26a69c9410f916fc262"
+	unknownID             = "62bb4ecdba3bbe275f8c7700"
+)
+
+// Suite is conformance test suite which every db.DB implementation must pass
+// NewDB returns new database with the reference data of the migration files and without any subscription,
+// it is called before every test
+type Suite struct {
+	suite.Suite
+	NewDB    func() (db.DB, error)
+	Database db.DB
+}
+
+// SetupTest runs before every test
+func (suite *Suite) SetupTest() {
+	database, err := suite.NewDB()
+	suite.Require().NoError(err)
+	suite.Database = database
+}
+
+// TearDownTest runs after every test
+func (suite *Suite) TearDownTest() {
+	suite.Database.Disconnect(context.Background())
+}
+
+// date returns UTC time without sub-millisecond part, so it is stored without loss of precision
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 10, 30, 0, 0, time.UTC)
+}
+
+func (suite *Suite) TestGetProduct() {
+	t := suite.T()
+
+	getInShape := domain.Product{
+		ID:                 getInShapeProductID,
+		Name:               "get in shape",
+		SubscriptionPeriod: 1,
+		TrialDays:          7,
+		MaxPauseDays:       30,
+		MaxPausesPerPeriod: 2,
+		Price:              domain.NewMoney(1000, "EUR"),
+		Prices: []domain.ProductPrice{
+			{
+				Price: domain.NewMoney(1100, "CHF"),
+			},
+			{
+				Price: domain.NewMoney(900, "GBP"),
+			},
+		},
+		TaxCategory:  "digital_service",
+		TaxInclusive: true,
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantIDs []string
+		want    *domain.Product
+		wantErr error
+	}{
+		{
+			name:    "should return all the seeded products",
+			wantIDs: []string{getInShapeProductID, hiitExtremeProductID, hiphopCardioProductID},
+		},
+		{
+			name:    "should return product for given id",
+			id:      getInShapeProductID,
+			wantIDs: []string{getInShapeProductID},
+			want:    &getInShape,
+		},
+		{
+			name:    "should return empty slice for unknown id",
+			id:      unknownID,
+			wantIDs: []string{},
+		},
+		{
+			name:    "should return error for invalid id",
+			id:      "invalid",
+			wantErr: db.InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suite.Database.GetProduct(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetProduct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("GetProduct() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got[0], *tt.want) {
+				t.Errorf("GetProduct() = %v, want %v", got[0], *tt.want)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestFindProducts() {
+	t := suite.T()
+	ctx := context.Background()
+
+	minPrice := domain.NewMoney(1500, "EUR")
+	maxPrice := domain.NewMoney(3000, "EUR")
+	minPriceCHF := domain.NewMoney(0, "CHF")
+	tests := []struct {
+		name      string
+		filter    db.ProductFilter
+		wantIDs   []string
+		wantCount int64
+	}{
+		{
+			name:      "should return all the products sorted by name",
+			filter:    db.ProductFilter{},
+			wantIDs:   []string{getInShapeProductID, hiitExtremeProductID, hiphopCardioProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should return products with name containing given name ignoring case",
+			filter: db.ProductFilter{
+				Name: "HI",
+			},
+			wantIDs:   []string{hiitExtremeProductID, hiphopCardioProductID},
+			wantCount: 2,
+		},
+		{
+			name: "should return products with subscription period",
+			filter: db.ProductFilter{
+				SubscriptionPeriod: 2,
+			},
+			wantIDs:   []string{hiitExtremeProductID},
+			wantCount: 1,
+		},
+		{
+			name: "should return products in the price range sorted by price in descending order",
+			filter: db.ProductFilter{
+				MinPrice: &minPrice,
+				MaxPrice: &maxPrice,
+				SortBy:   domain.ProductSortByPrice,
+				SortDesc: true,
+			},
+			wantIDs:   []string{hiphopCardioProductID, hiitExtremeProductID},
+			wantCount: 2,
+		},
+		{
+			name: "should return empty slice if default price is in other currency",
+			filter: db.ProductFilter{
+				MinPrice: &minPriceCHF,
+			},
+			wantIDs:   []string{},
+			wantCount: 0,
+		},
+		{
+			name: "should return limited number of products after the cursor",
+			filter: db.ProductFilter{
+				SortBy: domain.ProductSortByPrice,
+				After: &db.ProductCursor{
+					Name:               "get in shape",
+					PriceAmount:        1000,
+					SubscriptionPeriod: 1,
+					ID:                 getInShapeProductID,
+				},
+				Limit: 1,
+			},
+			wantIDs:   []string{hiitExtremeProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should return products after the cursor sorted by subscription period in descending order",
+			filter: db.ProductFilter{
+				SortBy:   domain.ProductSortBySubscriptionPeriod,
+				SortDesc: true,
+				After: &db.ProductCursor{
+					Name:               "hiphop cardio",
+					PriceAmount:        3000,
+					SubscriptionPeriod: 3,
+					ID:                 hiphopCardioProductID,
+				},
+			},
+			wantIDs:   []string{hiitExtremeProductID, getInShapeProductID},
+			wantCount: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suite.Database.FindProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("FindProducts() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+
+			gotCount, err := suite.Database.CountProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotCount != tt.wantCount {
+				t.Errorf("CountProducts() = %v, want %v", gotCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestFindProductsPriceVersions() {
+	t := suite.T()
+	ctx := context.Background()
+
+	products, err := suite.Database.GetProduct(ctx, getInShapeProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product := products[0]
+	product.PriceVersions = []domain.PriceVersion{
+		{
+			EffectiveFrom: date(2022, 8, 1),
+			Price:         domain.NewMoney(3500, "EUR"),
+		},
+		{
+			EffectiveFrom: date(2022, 10, 1),
+			Price:         domain.NewMoney(500, "EUR"),
+		},
+	}
+	_, err = suite.Database.SaveProduct(ctx, &product)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minPrice := domain.NewMoney(1500, "EUR")
+	maxPrice := domain.NewMoney(3000, "EUR")
+	tests := []struct {
+		name      string
+		filter    db.ProductFilter
+		wantIDs   []string
+		wantCount int64
+	}{
+		{
+			name: "should sort by product price before the first price version",
+			filter: db.ProductFilter{
+				PriceAt: date(2022, 7, 1),
+				SortBy:  domain.ProductSortByPrice,
+			},
+			wantIDs:   []string{getInShapeProductID, hiitExtremeProductID, hiphopCardioProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should sort by price of the effective price version",
+			filter: db.ProductFilter{
+				PriceAt: date(2022, 9, 1),
+				SortBy:  domain.ProductSortByPrice,
+			},
+			wantIDs:   []string{hiitExtremeProductID, hiphopCardioProductID, getInShapeProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should return products in the price range of the effective price version",
+			filter: db.ProductFilter{
+				PriceAt:  date(2022, 9, 1),
+				MinPrice: &minPrice,
+				SortBy:   domain.ProductSortByPrice,
+			},
+			wantIDs:   []string{hiitExtremeProductID, hiphopCardioProductID, getInShapeProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should not return products with effective price out of the price range",
+			filter: db.ProductFilter{
+				PriceAt:  date(2022, 9, 1),
+				MaxPrice: &maxPrice,
+				SortBy:   domain.ProductSortByPrice,
+			},
+			wantIDs:   []string{hiitExtremeProductID, hiphopCardioProductID},
+			wantCount: 2,
+		},
+		{
+			name: "should return products after the cursor of the effective price",
+			filter: db.ProductFilter{
+				PriceAt: date(2022, 9, 1),
+				SortBy:  domain.ProductSortByPrice,
+				After: &db.ProductCursor{
+					Name:               "hiphop cardio",
+					PriceAmount:        3000,
+					SubscriptionPeriod: 3,
+					ID:                 hiphopCardioProductID,
+				},
+			},
+			wantIDs:   []string{getInShapeProductID},
+			wantCount: 3,
+		},
+		{
+			name: "should use price version effective from the price time",
+			filter: db.ProductFilter{
+				PriceAt:  date(2022, 10, 1),
+				MaxPrice: &maxPrice,
+				SortBy:   domain.ProductSortByPrice,
+				SortDesc: true,
+			},
+			wantIDs:   []string{hiphopCardioProductID, hiitExtremeProductID, getInShapeProductID},
+			wantCount: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suite.Database.FindProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("FindProducts() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+
+			gotCount, err := suite.Database.CountProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotCount != tt.wantCount {
+				t.Errorf("CountProducts() = %v, want %v", gotCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestSaveProduct() {
+	t := suite.T()
+	ctx := context.Background()
+
+	saved, err := suite.Database.SaveProduct(ctx, &domain.Product{
+		Name:               "yoga flow",
+		SubscriptionPeriod: 6,
+		TrialDays:          7,
+		MaxPauseDays:       14,
+		MaxPausesPerPeriod: 1,
+		Price:              domain.NewMoney(4500, "EUR"),
+		Prices: []domain.ProductPrice{
+			{
+				Country: "CH",
+				Price:   domain.NewMoney(5000, "CHF"),
+			},
+		},
+		TaxCategory:  "digital_service",
+		TaxInclusive: true,
+	})
+	if err != nil || saved.ID == "" {
+		t.Fatalf("SaveProduct() = %v, %v, want product with ID", saved, err)
+	}
+	suite.assertProduct(saved)
+
+	archivedAt := date(2022, 7, 1)
+	saved.Name = "yoga flow plus"
+	saved.Prices = nil
+	saved.ArchivedAt = &archivedAt
+	saved.PriceVersions = []domain.PriceVersion{
+		{
+			EffectiveFrom: date(2022, 8, 1),
+			Price:         domain.NewMoney(5000, "EUR"),
+			Prices: []domain.ProductPrice{
+				{
+					Country: "CH",
+					Price:   domain.NewMoney(5500, "CHF"),
+				},
+			},
+		},
+	}
+	saved, err = suite.Database.SaveProduct(ctx, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.PriceVersions[0].ID == "" {
+		t.Fatalf("SaveProduct() = %v, want price version with ID", saved)
+	}
+	suite.assertProduct(saved)
+
+	// price version keeps its ID when the product is saved again
+	versionID := saved.PriceVersions[0].ID
+	saved.PriceVersions = append(saved.PriceVersions, domain.PriceVersion{
+		EffectiveFrom: date(2022, 9, 1),
+		Price:         domain.NewMoney(5200, "EUR"),
+	})
+	saved, err = suite.Database.SaveProduct(ctx, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.PriceVersions[0].ID != versionID || saved.PriceVersions[1].ID == "" || saved.PriceVersions[1].ID == versionID {
+		t.Errorf("SaveProduct() price versions = %v, want kept and new ID", saved.PriceVersions)
+	}
+	suite.assertProduct(saved)
+
+	tests := []struct {
+		name      string
+		filter    db.ProductFilter
+		archived  bool
+		wantCount int64
+	}{
+		{
+			name:      "should not find archived product",
+			filter:    db.ProductFilter{Name: "yoga"},
+			archived:  true,
+			wantCount: 0,
+		},
+		{
+			name:      "should find archived product if archived products are included",
+			filter:    db.ProductFilter{Name: "yoga", IncludeArchived: true},
+			archived:  true,
+			wantCount: 1,
+		},
+		{
+			name:      "should find restored product",
+			filter:    db.ProductFilter{Name: "yoga"},
+			wantCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.archived && saved.ArchivedAt != nil {
+				saved.ArchivedAt = nil
+				_, err := suite.Database.SaveProduct(ctx, saved)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			products, err := suite.Database.FindProducts(ctx, tt.filter)
+			if err != nil || int64(len(products)) != tt.wantCount {
+				t.Errorf("FindProducts() = %v, %v, want %v products", products, err, tt.wantCount)
+			}
+
+			count, err := suite.Database.CountProducts(ctx, tt.filter)
+			if err != nil || count != tt.wantCount {
+				t.Errorf("CountProducts() = %v, %v, want %v", count, err, tt.wantCount)
+			}
+		})
+	}
+
+	_, err = suite.Database.SaveProduct(ctx, &domain.Product{ID: unknownID, Name: "unknown"})
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("SaveProduct() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+
+	_, err = suite.Database.SaveProduct(ctx, &domain.Product{ID: "invalid", Name: "invalid"})
+	if !errors.Is(err, db.InvalidArgErr) {
+		t.Errorf("SaveProduct() error = %v, wantErr %v", err, db.InvalidArgErr)
+	}
+}
+
+// assertProduct checks that the stored product is equal to the product
+func (suite *Suite) assertProduct(product *domain.Product) {
+	t := suite.T()
+
+	got, err := suite.Database.GetProduct(context.Background(), product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], *product) {
+		t.Errorf("GetProduct() = %v, want %v", got, *product)
+	}
+}
+
+func (suite *Suite) TestGetTaxRules() {
+	t := suite.T()
+
+	got, err := suite.Database.GetTaxRules(context.Background(), "digital_service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates := map[string]float64{}
+	for _, v := range got {
+		if v.IsEffective(date(2025, 1, 1)) {
+			rates[v.Country] = v.Rate
+		}
+	}
+	want := map[string]float64{"": 10, "DE": 19, "AT": 20, "GB": 20, "CH": 8.1}
+	if len(got) != 6 || !reflect.DeepEqual(rates, want) {
+		t.Errorf("GetTaxRules() = %v, want effective rates %v", got, want)
+	}
+
+	got, err = suite.Database.GetTaxRules(context.Background(), "unknown")
+	if err != nil || len(got) != 0 {
+		t.Errorf("GetTaxRules() = %v, %v, want empty slice", got, err)
+	}
+}
+
+func (suite *Suite) TestSaveSubscription() {
+	t := suite.T()
+	ctx := context.Background()
+
+	updatedAt := date(2022, 6, 5)
+	pauseEndDate := date(2022, 6, 3)
+	trialEndDate := date(2022, 6, 8)
+	nextPaymentRetry := date(2022, 7, 4)
+	priceVersionID := "62c5a1f0e4b0a1b2c3d4e5f6"
+	subscription := domain.UserSubscription{
+		CreatedAt:      date(2022, 6, 1),
+		UpdatedAt:      &updatedAt,
+		Email:          "user@test.com",
+		Country:        "DE",
+		ProductID:      getInShapeProductID,
+		ProductName:    "get in shape",
+		StartDate:      date(2022, 6, 1),
+		EndDate:        date(2022, 7, 1),
+		Price:          domain.NewMoney(1000, "EUR"),
+		NetPrice:       domain.NewMoney(840, "EUR"),
+		Tax:            domain.NewMoney(160, "EUR"),
+		TaxRate:        19,
+		PriceVersionID: priceVersionID,
+		Status:         domain.SubscriptionStatusActive,
+		Pauses: []domain.Pause{
+			{
+				StartDate: date(2022, 6, 2),
+				EndDate:   &pauseEndDate,
+				Reason:    "holiday",
+				Actor:     "user@test.com",
+			},
+		},
+		AutoRenew: true,
+		Renewals: []domain.Renewal{
+			{
+				RenewedAt:      date(2022, 6, 8),
+				PeriodStart:    date(2022, 6, 8),
+				PeriodEnd:      date(2022, 7, 8),
+				Price:          domain.NewMoney(1000, "EUR"),
+				PriceVersionID: priceVersionID,
+			},
+		},
+		TrialEndDate:      &trialEndDate,
+		CancelAtPeriodEnd: true,
+		PendingPlanChange: &domain.PlanChange{
+			RequestedAt:   date(2022, 6, 4),
+			EffectiveAt:   date(2022, 7, 1),
+			FromProductID: getInShapeProductID,
+			ToProductID:   hiitExtremeProductID,
+		},
+		PlanChanges: []domain.PlanChange{
+			{
+				RequestedAt:   date(2022, 6, 3),
+				EffectiveAt:   date(2022, 6, 3),
+				FromProductID: hiitExtremeProductID,
+				ToProductID:   getInShapeProductID,
+				Price:         domain.NewMoney(1000, "EUR"),
+				Proration:     domain.NewMoney(-500, "EUR"),
+			},
+		},
+		UniquenessKey: "user@test.com/" + getInShapeProductID,
+		PaymentToken:  "tok_visa",
+		Payments: []domain.Payment{
+			{
+				AuthorizationID: "fake_auth_1",
+				Type:            domain.PaymentTypeCharge,
+				Reason:          domain.PaymentReasonPurchase,
+				Amount:          domain.NewMoney(1000, "EUR"),
+				CreatedAt:       date(2022, 6, 1),
+			},
+			{
+				AuthorizationID: "fake_auth_1",
+				Type:            domain.PaymentTypeRefund,
+				Reason:          domain.PaymentReasonPlanChange,
+				Amount:          domain.NewMoney(500, "EUR"),
+				CreatedAt:       date(2022, 6, 3),
+			},
+		},
+		PendingPayment: &domain.Payment{
+			AuthorizationID: "fake_auth_2",
+			Type:            domain.PaymentTypeCharge,
+			Reason:          domain.PaymentReasonPurchase,
+			Amount:          domain.NewMoney(1000, "EUR"),
+			CreatedAt:       date(2022, 6, 4),
+		},
+		NextPaymentRetry: &nextPaymentRetry,
+		PaymentAttempts: []domain.PaymentAttempt{
+			{
+				PeriodStart: date(2022, 7, 1),
+				AttemptedAt: date(2022, 7, 1),
+				Error:       "card declined",
+			},
+			{
+				PeriodStart: date(2022, 7, 1),
+				AttemptedAt: date(2022, 7, 2),
+				Succeeded:   true,
+			},
+		},
+	}
+
+	saved, err := suite.Database.SaveSubscription(ctx, &subscription)
+	if err != nil || saved.ID == "" || saved.Version != 1 {
+		t.Fatalf("SaveSubscription() = %v, %v, want record with ID and version 1", saved, err)
+	}
+
+	got, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, subscription) {
+		t.Errorf("GetSubscriptionByID() = %v, want %v", *got, subscription)
+	}
+
+	// changes of the returned record are not stored until it is saved
+	got.Pauses[0].Reason = "changed"
+	got.Status = domain.SubscriptionStatusCancelled
+	stored, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || stored.Status != domain.SubscriptionStatusActive || stored.Pauses[0].Reason != "holiday" {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want unchanged record", stored, err)
+	}
+
+	_, err = suite.Database.SaveSubscription(ctx, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || stored.Status != domain.SubscriptionStatusCancelled || stored.Pauses[0].Reason != "changed" {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want updated record", stored, err)
+	}
+
+	_, err = suite.Database.SaveSubscription(ctx, &domain.UserSubscription{ID: "invalid"})
+	if !errors.Is(err, db.InvalidArgErr) {
+		t.Errorf("SaveSubscription() error = %v, wantErr %v", err, db.InvalidArgErr)
+	}
+}
+
+func (suite *Suite) TestSaveSubscriptionVersionConflict() {
+	t := suite.T()
+	ctx := context.Background()
+
+	saved, err := suite.Database.SaveSubscription(ctx, &domain.UserSubscription{
+		CreatedAt: date(2022, 6, 1),
+		Email:     "user@test.com",
+		StartDate: date(2022, 6, 1),
+		EndDate:   date(2022, 7, 1),
+		Status:    domain.SubscriptionStatusActive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Status = domain.SubscriptionStatusPaused
+	updated, err := suite.Database.SaveSubscription(ctx, first)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("SaveSubscription() = %v, %v, want record with version 2", updated, err)
+	}
+
+	tests := []struct {
+		name string
+		us   *domain.UserSubscription
+	}{
+		{
+			name: "should return error if record is changed since it was read",
+			us:   second,
+		},
+		{
+			name: "should return error if new record already exists",
+			us: &domain.UserSubscription{
+				ID:        saved.ID,
+				CreatedAt: date(2022, 6, 1),
+				StartDate: date(2022, 6, 1),
+				EndDate:   date(2022, 7, 1),
+				Status:    domain.SubscriptionStatusActive,
+			},
+		},
+		{
+			name: "should return error if record does not exist",
+			us: &domain.UserSubscription{
+				ID:        unknownID,
+				Version:   1,
+				CreatedAt: date(2022, 6, 1),
+				StartDate: date(2022, 6, 1),
+				EndDate:   date(2022, 7, 1),
+				Status:    domain.SubscriptionStatusActive,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := suite.Database.SaveSubscription(ctx, tt.us)
+			if !errors.Is(err, db.VersionConflictErr) {
+				t.Errorf("SaveSubscription() error = %v, wantErr %v", err, db.VersionConflictErr)
+			}
+		})
+	}
+
+	stored, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || stored.Version != 2 || stored.Status != domain.SubscriptionStatusPaused {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want record of the first update", stored, err)
+	}
+}
+
+func (suite *Suite) TestSaveSubscriptionUniquenessKey() {
+	t := suite.T()
+	ctx := context.Background()
+
+	newSubscription := func(key string) *domain.UserSubscription {
+		return &domain.UserSubscription{
+			CreatedAt:     date(2022, 6, 1),
+			Email:         "user@test.com",
+			ProductID:     getInShapeProductID,
+			StartDate:     date(2022, 6, 1),
+			EndDate:       date(2022, 7, 1),
+			Status:        domain.SubscriptionStatusActive,
+			UniquenessKey: key,
+		}
+	}
+	key := "user@test.com/" + getInShapeProductID
+
+	first, err := suite.Database.SaveSubscription(ctx, newSubscription(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = suite.Database.SaveSubscription(ctx, newSubscription(key))
+	if !errors.Is(err, db.DuplicateRecordErr) {
+		t.Errorf("SaveSubscription() error = %v, wantErr %v", err, db.DuplicateRecordErr)
+	}
+
+	// subscriptions without the key are not unique
+	for i := 0; i < 2; i++ {
+		_, err = suite.Database.SaveSubscription(ctx, newSubscription(""))
+		if err != nil {
+			t.Errorf("SaveSubscription() error = %v, want nil", err)
+		}
+	}
+
+	// the key is released when it is removed from the subscription
+	first.Status = domain.SubscriptionStatusCancelled
+	first.UniquenessKey = ""
+	_, err = suite.Database.SaveSubscription(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := suite.Database.SaveSubscription(ctx, newSubscription(key))
+	if err != nil {
+		t.Fatalf("SaveSubscription() error = %v, want nil", err)
+	}
+
+	first.UniquenessKey = key
+	_, err = suite.Database.SaveSubscription(ctx, first)
+	if !errors.Is(err, db.DuplicateRecordErr) {
+		t.Errorf("SaveSubscription() error = %v, wantErr %v", err, db.DuplicateRecordErr)
+	}
+
+	stored, err := suite.Database.GetSubscriptionByID(ctx, second.ID)
+	if err != nil || stored.UniquenessKey != key {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want record with uniqueness key %v", stored, err, key)
+	}
+}
+
+func (suite *Suite) TestGetSubscriptionByID() {
+	t := suite.T()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name:    "should return error if record not found for id",
+			id:      unknownID,
+			wantErr: db.RecordNotFoundErr,
+		},
+		{
+			name:    "should return error for invalid id",
+			id:      "invalid",
+			wantErr: db.InvalidArgErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := suite.Database.GetSubscriptionByID(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetSubscriptionByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestFindSubscriptions() {
+	t := suite.T()
+	ctx := context.Background()
+
+	resumeDate := date(2022, 6, 10)
+	trialEndDate := date(2022, 6, 8)
+	nextPaymentRetry := date(2022, 6, 17)
+	records := []domain.UserSubscription{
+		{
+			Email:     "first@test.com",
+			ProductID: getInShapeProductID,
+			EndDate:   date(2022, 7, 1),
+			Status:    domain.SubscriptionStatusActive,
+		},
+		{
+			Email:        "first@test.com",
+			ProductID:    hiitExtremeProductID,
+			EndDate:      date(2022, 6, 1),
+			Status:       domain.SubscriptionStatusTrialing,
+			TrialEndDate: &trialEndDate,
+		},
+		{
+			Email:      "second@test.com",
+			ProductID:  getInShapeProductID,
+			EndDate:    date(2022, 8, 1),
+			Status:     domain.SubscriptionStatusPaused,
+			ResumeDate: &resumeDate,
+		},
+		{
+			Email:     "second@test.com",
+			ProductID: hiitExtremeProductID,
+			EndDate:   date(2022, 5, 1),
+			Status:    domain.SubscriptionStatusCancelled,
+		},
+		{
+			Email:            "third@test.com",
+			ProductID:        getInShapeProductID,
+			EndDate:          date(2022, 6, 15),
+			Status:           domain.SubscriptionStatusPastDue,
+			NextPaymentRetry: &nextPaymentRetry,
+		},
+	}
+	ids := []string{}
+	for i := range records {
+		saved, err := suite.Database.SaveSubscription(ctx, &records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, saved.ID)
+	}
+
+	endDateBefore := date(2022, 7, 1)
+	resumeDateBefore := date(2022, 6, 15)
+	paymentRetryBefore := date(2022, 6, 18)
+	tests := []struct {
+		name    string
+		filter  db.SubscriptionFilter
+		wantIDs []string
+	}{
+		{
+			name:    "should return all subscriptions sorted by end date",
+			filter:  db.SubscriptionFilter{},
+			wantIDs: []string{ids[3], ids[1], ids[4], ids[0], ids[2]},
+		},
+		{
+			name: "should return subscriptions with given statuses and end date before",
+			filter: db.SubscriptionFilter{
+				Statuses:      []domain.SubscriptionStatus{domain.SubscriptionStatusActive, domain.SubscriptionStatusTrialing},
+				EndDateBefore: &endDateBefore,
+			},
+			wantIDs: []string{ids[1], ids[0]},
+		},
+		{
+			name: "should return subscriptions with resume date before",
+			filter: db.SubscriptionFilter{
+				ResumeDateBefore: &resumeDateBefore,
+			},
+			wantIDs: []string{ids[2]},
+		},
+		{
+			name: "should return subscriptions with next payment retry before",
+			filter: db.SubscriptionFilter{
+				PaymentRetryBefore: &paymentRetryBefore,
+			},
+			wantIDs: []string{ids[4]},
+		},
+		{
+			name: "should return subscriptions with trial for email and product",
+			filter: db.SubscriptionFilter{
+				Email:     "first@test.com",
+				ProductID: hiitExtremeProductID,
+				HadTrial:  true,
+			},
+			wantIDs: []string{ids[1]},
+		},
+		{
+			name: "should return limited number of subscriptions",
+			filter: db.SubscriptionFilter{
+				Email: "second@test.com",
+				Limit: 1,
+			},
+			wantIDs: []string{ids[3]},
+		},
+		{
+			name: "should return empty slice if no subscription matches",
+			filter: db.SubscriptionFilter{
+				Email: "unknown@test.com",
+			},
+			wantIDs: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suite.Database.FindSubscriptions(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("FindSubscriptions() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestFindSubscriptionsPagination() {
+	t := suite.T()
+	ctx := context.Background()
+
+	records := []domain.UserSubscription{
+		{
+			Email:     "first@test.com",
+			CreatedAt: date(2022, 6, 1),
+			EndDate:   date(2022, 9, 1),
+			Status:    domain.SubscriptionStatusActive,
+		},
+		{
+			Email:     "first@test.com",
+			CreatedAt: date(2022, 6, 1),
+			EndDate:   date(2022, 7, 1),
+			Status:    domain.SubscriptionStatusActive,
+		},
+		{
+			Email:     "first@test.com",
+			CreatedAt: date(2022, 5, 1),
+			EndDate:   date(2022, 8, 1),
+			Status:    domain.SubscriptionStatusCancelled,
+		},
+		{
+			Email:     "first@test.com",
+			CreatedAt: date(2022, 7, 1),
+			EndDate:   date(2022, 7, 1),
+			Status:    domain.SubscriptionStatusActive,
+		},
+	}
+	ids := []string{}
+	for i := range records {
+		saved, err := suite.Database.SaveSubscription(ctx, &records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, saved.ID)
+	}
+
+	createdFrom := date(2022, 6, 1)
+	createdTo := date(2022, 6, 30)
+	endDateFrom := date(2022, 8, 1)
+	tests := []struct {
+		name    string
+		filter  db.SubscriptionFilter
+		wantIDs []string
+	}{
+		{
+			name: "should return subscriptions sorted by created at and ID",
+			filter: db.SubscriptionFilter{
+				Email:  "first@test.com",
+				SortBy: domain.SubscriptionSortByCreatedAt,
+			},
+			wantIDs: []string{ids[2], ids[0], ids[1], ids[3]},
+		},
+		{
+			name: "should return subscriptions sorted by end date and ID in descending order",
+			filter: db.SubscriptionFilter{
+				Email:    "first@test.com",
+				SortBy:   domain.SubscriptionSortByEndDate,
+				SortDesc: true,
+			},
+			wantIDs: []string{ids[0], ids[2], ids[3], ids[1]},
+		},
+		{
+			name: "should return subscriptions after the cursor",
+			filter: db.SubscriptionFilter{
+				Email:  "first@test.com",
+				SortBy: domain.SubscriptionSortByCreatedAt,
+				After: &db.SubscriptionCursor{
+					Value: date(2022, 6, 1),
+					ID:    ids[0],
+				},
+			},
+			wantIDs: []string{ids[1], ids[3]},
+		},
+		{
+			name: "should return subscriptions after the cursor in descending order",
+			filter: db.SubscriptionFilter{
+				Email:    "first@test.com",
+				SortBy:   domain.SubscriptionSortByEndDate,
+				SortDesc: true,
+				After: &db.SubscriptionCursor{
+					Value: date(2022, 7, 1),
+					ID:    ids[3],
+				},
+				Limit: 1,
+			},
+			wantIDs: []string{ids[1]},
+		},
+		{
+			name: "should return subscriptions created in the range",
+			filter: db.SubscriptionFilter{
+				Email:       "first@test.com",
+				SortBy:      domain.SubscriptionSortByCreatedAt,
+				CreatedFrom: &createdFrom,
+				CreatedTo:   &createdTo,
+			},
+			wantIDs: []string{ids[0], ids[1]},
+		},
+		{
+			name: "should return subscriptions with end date from",
+			filter: db.SubscriptionFilter{
+				Email:       "first@test.com",
+				EndDateFrom: &endDateFrom,
+			},
+			wantIDs: []string{ids[2], ids[0]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suite.Database.FindSubscriptions(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("FindSubscriptions() ids = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func (suite *Suite) TestAuditEvents() {
+	t := suite.T()
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+
+	// saved out of order to check the sorting
+	events := []domain.AuditEvent{
+		{
+			SubscriptionID: subscriptionID,
+			Action:         domain.AuditActionStatusChanged,
+			PreviousStatus: domain.SubscriptionStatusActive,
+			NewStatus:      domain.SubscriptionStatusPaused,
+			Actor:          "user@test.com",
+			CreatedAt:      date(2022, 6, 2),
+		},
+		{
+			SubscriptionID: subscriptionID,
+			Action:         domain.AuditActionCreated,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "user@test.com",
+			RequestID:      "8f14e45fceea167a",
+			CreatedAt:      date(2022, 6, 1),
+		},
+		{
+			SubscriptionID: unknownID,
+			Action:         domain.AuditActionCreated,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "system",
+			CreatedAt:      date(2022, 6, 1),
+		},
+	}
+	for i := range events {
+		saved, err := suite.Database.SaveAuditEvent(ctx, &events[i])
+		if err != nil || saved.ID == "" {
+			t.Fatalf("SaveAuditEvent() = %v, %v, want record with ID", saved, err)
+		}
+	}
+
+	got, err := suite.Database.GetAuditEvents(ctx, subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.AuditEvent{events[1], events[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAuditEvents() = %v, want %v", got, want)
+	}
+
+	got, err = suite.Database.GetAuditEvents(ctx, "62bb4ecdba3bbe275f8c7799")
+	if err != nil || len(got) != 0 {
+		t.Errorf("GetAuditEvents() = %v, %v, want empty slice", got, err)
+	}
+}
+
+func (suite *Suite) TestAcquireLock() {
+	t := suite.T()
+	ctx := context.Background()
+
+	acquired, err := suite.Database.AcquireLock(ctx, "job", "owner1", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want true", acquired, err)
+	}
+
+	// lock is held by owner1
+	acquired, err = suite.Database.AcquireLock(ctx, "job", "owner2", time.Minute)
+	if err != nil || acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want false", acquired, err)
+	}
+
+	// owner can extend its lock
+	acquired, err = suite.Database.AcquireLock(ctx, "job", "owner1", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want true", acquired, err)
+	}
+
+	// other owner cannot release the lock
+	err = suite.Database.ReleaseLock(ctx, "job", "owner2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired, err = suite.Database.AcquireLock(ctx, "job", "owner2", time.Minute)
+	if err != nil || acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want false", acquired, err)
+	}
+
+	err = suite.Database.ReleaseLock(ctx, "job", "owner1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// released lock can be acquired by other owner
+	acquired, err = suite.Database.AcquireLock(ctx, "job", "owner2", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want true", acquired, err)
+	}
+
+	// expired lock can be acquired by other owner
+	acquired, err = suite.Database.AcquireLock(ctx, "expiring", "owner1", -time.Second)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want true", acquired, err)
+	}
+	acquired, err = suite.Database.AcquireLock(ctx, "expiring", "owner2", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("AcquireLock() = %v, %v, want true", acquired, err)
+	}
+}
+
+func (suite *Suite) TestIdempotencyRecords() {
+	t := suite.T()
+	ctx := context.Background()
+
+	expiresAt := time.Now().UTC().Truncate(time.Millisecond).Add(time.Hour)
+	record := domain.IdempotencyRecord{
+		Key:         "key1",
+		Fingerprint: "fingerprint1",
+		CreatedAt:   date(2022, 6, 1),
+		ExpiresAt:   expiresAt,
+	}
+
+	err := suite.Database.CreateIdempotencyRecord(ctx, &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := suite.Database.GetIdempotencyRecord(ctx, record.Key)
+	if err != nil || !reflect.DeepEqual(*got, record) {
+		t.Fatalf("GetIdempotencyRecord() = %v, %v, want %v", got, err, record)
+	}
+
+	// key is used by the request in progress
+	err = suite.Database.CreateIdempotencyRecord(ctx, &domain.IdempotencyRecord{
+		Key:         record.Key,
+		Fingerprint: "fingerprint2",
+		CreatedAt:   date(2022, 6, 1),
+		ExpiresAt:   expiresAt,
+	})
+	if !errors.Is(err, db.DuplicateRecordErr) {
+		t.Errorf("CreateIdempotencyRecord() error = %v, wantErr %v", err, db.DuplicateRecordErr)
+	}
+
+	record.StatusCode = 201
+	record.Header = map[string]string{"ETag": `"1"`}
+	record.Body = []byte(`{"id":"62bb4ecdba3bbe275f8c7788"}`)
+	record.ExpiresAt = expiresAt.Add(time.Hour)
+	err = suite.Database.CompleteIdempotencyRecord(ctx, &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = suite.Database.GetIdempotencyRecord(ctx, record.Key)
+	if err != nil || !reflect.DeepEqual(*got, record) {
+		t.Errorf("GetIdempotencyRecord() = %v, %v, want %v", got, err, record)
+	}
+
+	// completed record is not completed again
+	err = suite.Database.CompleteIdempotencyRecord(ctx, &record)
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("CompleteIdempotencyRecord() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+
+	err = suite.Database.CompleteIdempotencyRecord(ctx, &domain.IdempotencyRecord{Key: "unknown", StatusCode: 201, ExpiresAt: expiresAt})
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("CompleteIdempotencyRecord() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+
+	err = suite.Database.DeleteIdempotencyRecord(ctx, record.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = suite.Database.GetIdempotencyRecord(ctx, record.Key)
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("GetIdempotencyRecord() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+
+	// expired record is not returned and its key can be used again
+	expired := domain.IdempotencyRecord{
+		Key:         "key2",
+		Fingerprint: "fingerprint1",
+		CreatedAt:   date(2022, 6, 1),
+		ExpiresAt:   time.Now().UTC().Add(-time.Second),
+	}
+	err = suite.Database.CreateIdempotencyRecord(ctx, &expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = suite.Database.GetIdempotencyRecord(ctx, expired.Key)
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("GetIdempotencyRecord() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+
+	expired.Fingerprint = "fingerprint2"
+	expired.ExpiresAt = expiresAt
+	err = suite.Database.CreateIdempotencyRecord(ctx, &expired)
+	if err != nil {
+		t.Fatalf("CreateIdempotencyRecord() error = %v, want nil", err)
+	}
+	got, err = suite.Database.GetIdempotencyRecord(ctx, expired.Key)
+	if err != nil || got.Fingerprint != "fingerprint2" {
+		t.Errorf("GetIdempotencyRecord() = %v, %v, want record of the new request", got, err)
+	}
+}
+
+func (suite *Suite) TestInvoices() {
+	t := suite.T()
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	newInvoice := func(subscriptionID string, issuedAt time.Time) domain.Invoice {
+		return domain.Invoice{
+			Type:            domain.InvoiceTypeInvoice,
+			SubscriptionID:  subscriptionID,
+			Email:           "user@test.com",
+			Country:         "DE",
+			AuthorizationID: "fake_auth_1",
+			Reason:          domain.PaymentReasonRenewal,
+			Lines: []domain.InvoiceLine{
+				{
+					ProductID:   hiphopCardioProductID,
+					ProductName: "hiphop cardio",
+					PeriodStart: issuedAt,
+					PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+					Net:         domain.NewMoney(840, "EUR"),
+					Tax:         domain.NewMoney(160, "EUR"),
+					Gross:       domain.NewMoney(1000, "EUR"),
+					TaxRate:     19,
+				},
+			},
+			Net:      domain.NewMoney(840, "EUR"),
+			Tax:      domain.NewMoney(160, "EUR"),
+			Total:    domain.NewMoney(1000, "EUR"),
+			IssuedAt: issuedAt,
+		}
+	}
+
+	// saved out of order to check the sorting, the sequence starts again in the new year
+	invoices := []domain.Invoice{
+		newInvoice(subscriptionID, date(2022, 6, 2)),
+		newInvoice(subscriptionID, date(2022, 6, 1)),
+		newInvoice(unknownID, date(2022, 6, 1)),
+		newInvoice(subscriptionID, date(2023, 1, 1)),
+	}
+	wantNumbers := []string{"2022-000001", "2022-000002", "2022-000003", "2023-000001"}
+	for i := range invoices {
+		saved, err := suite.Database.SaveInvoice(ctx, &invoices[i])
+		if err != nil || saved.ID == "" || saved.Number != wantNumbers[i] {
+			t.Fatalf("SaveInvoice() = %v, %v, want record with ID and number %v", saved, err, wantNumbers[i])
+		}
+	}
+
+	got, err := suite.Database.GetInvoices(ctx, subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.Invoice{invoices[1], invoices[0], invoices[3]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetInvoices() = %v, want %v", got, want)
+	}
+
+	got, err = suite.Database.GetInvoices(ctx, "62bb4ecdba3bbe275f8c7799")
+	if err != nil || len(got) != 0 {
+		t.Errorf("GetInvoices() = %v, %v, want empty slice", got, err)
+	}
+
+	invoice, err := suite.Database.GetInvoiceByNumber(ctx, "2022-000002")
+	if err != nil || !reflect.DeepEqual(invoice, &invoices[1]) {
+		t.Errorf("GetInvoiceByNumber() = %v, %v, want %v", invoice, err, invoices[1])
+	}
+
+	_, err = suite.Database.GetInvoiceByNumber(ctx, "2022-000004")
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("GetInvoiceByNumber() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+}
+
+func (suite *Suite) TestPendingInvoices() {
+	t := suite.T()
+	ctx := context.Background()
+	newInvoice := func(invoiceType domain.InvoiceType, amount int64) domain.Invoice {
+		return domain.Invoice{
+			Type:            invoiceType,
+			Email:           "user@test.com",
+			Country:         "DE",
+			AuthorizationID: "auth_1",
+			Reason:          domain.PaymentReasonPlanChange,
+			Lines: []domain.InvoiceLine{
+				{
+					ProductID:   getInShapeProductID,
+					ProductName: "get in shape",
+					PeriodStart: date(2022, 6, 15),
+					PeriodEnd:   date(2022, 7, 1),
+					Gross:       domain.NewMoney(amount, "EUR"),
+					TaxRate:     19,
+				},
+			},
+			Net:      domain.NewMoney(0, "EUR"),
+			Tax:      domain.NewMoney(0, "EUR"),
+			Total:    domain.NewMoney(amount, "EUR"),
+			IssuedAt: date(2022, 6, 15),
+		}
+	}
+	subscription := domain.UserSubscription{
+		CreatedAt:       date(2022, 6, 1),
+		Email:           "user@test.com",
+		ProductID:       getInShapeProductID,
+		ProductName:     "get in shape",
+		StartDate:       date(2022, 6, 1),
+		EndDate:         date(2022, 7, 1),
+		Price:           domain.NewMoney(1000, "EUR"),
+		NetPrice:        domain.NewMoney(840, "EUR"),
+		Tax:             domain.NewMoney(160, "EUR"),
+		Status:          domain.SubscriptionStatusActive,
+		PendingInvoices: []domain.Invoice{newInvoice(domain.InvoiceTypeInvoice, 500), newInvoice(domain.InvoiceTypeCreditNote, -300)},
+	}
+	otherSubscription := subscription
+	otherSubscription.Email = "other@test.com"
+	otherSubscription.PendingInvoices = nil
+
+	saved, err := suite.Database.SaveSubscription(ctx, &subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = suite.Database.SaveSubscription(ctx, &otherSubscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.PendingInvoices[0].ID == "" || saved.PendingInvoices[1].ID == "" || saved.PendingInvoices[0].ID == saved.PendingInvoices[1].ID {
+		t.Fatalf("SaveSubscription() pending invoices = %v, want pending invoices with new IDs", saved.PendingInvoices)
+	}
+	pendingInvoices := append([]domain.Invoice(nil), saved.PendingInvoices...)
+	for i := range pendingInvoices {
+		pendingInvoices[i].SubscriptionID = saved.ID
+	}
+
+	got, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || !reflect.DeepEqual(got.PendingInvoices, pendingInvoices) {
+		t.Errorf("GetSubscriptionByID() pending invoices = %v, %v, want %v", got, err, pendingInvoices)
+	}
+
+	found, err := suite.Database.FindSubscriptions(ctx, db.SubscriptionFilter{HasPendingInvoices: true})
+	if err != nil || len(found) != 1 || found[0].ID != saved.ID {
+		t.Errorf("FindSubscriptions() = %v, %v, want subscription %v with pending invoices", found, err, saved.ID)
+	}
+
+	// the pending invoice is issued only once with its ID
+	invoice := pendingInvoices[1]
+	issued, err := suite.Database.SaveInvoice(ctx, &invoice)
+	if err != nil || issued.ID != pendingInvoices[1].ID || issued.Number != "2022-000001" {
+		t.Fatalf("SaveInvoice() = %v, %v, want invoice %v with number 2022-000001", issued, err, pendingInvoices[1].ID)
+	}
+	again := pendingInvoices[1]
+	issued, err = suite.Database.SaveInvoice(ctx, &again)
+	if err != nil || issued.ID != pendingInvoices[1].ID || issued.Number != "2022-000001" || issued.Type != domain.InvoiceTypeCreditNote {
+		t.Errorf("SaveInvoice() = %v, %v, want stored credit note %v with number 2022-000001", issued, err, pendingInvoices[1].ID)
+	}
+	invoices, err := suite.Database.GetInvoices(ctx, saved.ID)
+	if err != nil || len(invoices) != 1 {
+		t.Errorf("GetInvoices() = %v, %v, want 1 invoice", invoices, err)
+	}
+
+	// removing the pending invoice does not change the subscription version
+	err = suite.Database.RemovePendingInvoices(ctx, saved.ID, []string{pendingInvoices[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || got.Version != saved.Version || !reflect.DeepEqual(got.PendingInvoices, pendingInvoices[:1]) {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want version %v with pending invoices %v", got, err, saved.Version, pendingInvoices[:1])
+	}
+
+	err = suite.Database.RemovePendingInvoices(ctx, saved.ID, []string{pendingInvoices[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || len(got.PendingInvoices) != 0 {
+		t.Errorf("GetSubscriptionByID() pending invoices = %v, %v, want none", got, err)
+	}
+
+	found, err = suite.Database.FindSubscriptions(ctx, db.SubscriptionFilter{HasPendingInvoices: true})
+	if err != nil || len(found) != 0 {
+		t.Errorf("FindSubscriptions() = %v, %v, want no subscription with pending invoices", found, err)
+	}
+
+	err = suite.Database.RemovePendingInvoices(ctx, unknownID, []string{pendingInvoices[0].ID})
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("RemovePendingInvoices() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+}
+
+func (suite *Suite) TestPendingAuditEvents() {
+	t := suite.T()
+	ctx := context.Background()
+	newAuditEvent := func(action domain.AuditAction, previousStatus domain.SubscriptionStatus) domain.AuditEvent {
+		return domain.AuditEvent{
+			Action:         action,
+			PreviousStatus: previousStatus,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "user@test.com",
+			RequestID:      "8f14e45fceea167a",
+			CreatedAt:      date(2022, 6, 15),
+		}
+	}
+	subscription := domain.UserSubscription{
+		CreatedAt:   date(2022, 6, 1),
+		Email:       "user@test.com",
+		ProductID:   getInShapeProductID,
+		ProductName: "get in shape",
+		StartDate:   date(2022, 6, 1),
+		EndDate:     date(2022, 7, 1),
+		Price:       domain.NewMoney(1000, "EUR"),
+		NetPrice:    domain.NewMoney(840, "EUR"),
+		Tax:         domain.NewMoney(160, "EUR"),
+		Status:      domain.SubscriptionStatusActive,
+		PendingAuditEvents: []domain.AuditEvent{
+			newAuditEvent(domain.AuditActionCreated, ""),
+			newAuditEvent(domain.AuditActionCancelUndone, domain.SubscriptionStatusActive),
+		},
+	}
+	otherSubscription := subscription
+	otherSubscription.Email = "other@test.com"
+	otherSubscription.PendingAuditEvents = nil
+
+	saved, err := suite.Database.SaveSubscription(ctx, &subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = suite.Database.SaveSubscription(ctx, &otherSubscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.PendingAuditEvents[0].ID == "" || saved.PendingAuditEvents[1].ID == "" || saved.PendingAuditEvents[0].ID == saved.PendingAuditEvents[1].ID {
+		t.Fatalf("SaveSubscription() pending audit events = %v, want pending audit events with new IDs", saved.PendingAuditEvents)
+	}
+	pendingAuditEvents := append([]domain.AuditEvent(nil), saved.PendingAuditEvents...)
+	for i := range pendingAuditEvents {
+		pendingAuditEvents[i].SubscriptionID = saved.ID
+	}
+
+	got, err := suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || !reflect.DeepEqual(got.PendingAuditEvents, pendingAuditEvents) {
+		t.Errorf("GetSubscriptionByID() pending audit events = %v, %v, want %v", got, err, pendingAuditEvents)
+	}
+
+	found, err := suite.Database.FindSubscriptions(ctx, db.SubscriptionFilter{HasPendingAuditEvents: true})
+	if err != nil || len(found) != 1 || found[0].ID != saved.ID {
+		t.Errorf("FindSubscriptions() = %v, %v, want subscription %v with pending audit events", found, err, saved.ID)
+	}
+
+	// the pending audit event is recorded only once with its ID
+	for i := 0; i < 2; i++ {
+		event := pendingAuditEvents[1]
+		recorded, err := suite.Database.SaveAuditEvent(ctx, &event)
+		if err != nil || !reflect.DeepEqual(*recorded, pendingAuditEvents[1]) {
+			t.Fatalf("SaveAuditEvent() = %v, %v, want %v", recorded, err, pendingAuditEvents[1])
+		}
+	}
+	events, err := suite.Database.GetAuditEvents(ctx, saved.ID)
+	if err != nil || !reflect.DeepEqual(events, pendingAuditEvents[1:]) {
+		t.Errorf("GetAuditEvents() = %v, %v, want %v", events, err, pendingAuditEvents[1:])
+	}
+
+	// removing the pending audit event does not change the subscription version
+	err = suite.Database.RemovePendingAuditEvents(ctx, saved.ID, []string{pendingAuditEvents[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || got.Version != saved.Version || !reflect.DeepEqual(got.PendingAuditEvents, pendingAuditEvents[:1]) {
+		t.Errorf("GetSubscriptionByID() = %v, %v, want version %v with pending audit events %v", got, err, saved.Version, pendingAuditEvents[:1])
+	}
+
+	err = suite.Database.RemovePendingAuditEvents(ctx, saved.ID, []string{pendingAuditEvents[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = suite.Database.GetSubscriptionByID(ctx, saved.ID)
+	if err != nil || len(got.PendingAuditEvents) != 0 {
+		t.Errorf("GetSubscriptionByID() pending audit events = %v, %v, want none", got, err)
+	}
+
+	found, err = suite.Database.FindSubscriptions(ctx, db.SubscriptionFilter{HasPendingAuditEvents: true})
+	if err != nil || len(found) != 0 {
+		t.Errorf("FindSubscriptions() = %v, %v, want no subscription with pending audit events", found, err)
+	}
+
+	err = suite.Database.RemovePendingAuditEvents(ctx, unknownID, []string{pendingAuditEvents[0].ID})
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("RemovePendingAuditEvents() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SaveAuditEvent appends the audit event to the audit log and returns it with the record ID
+// audit events are never updated, the stored event is returned if the event with the ID is already inserted
+func (m *memoryDetails) SaveAuditEvent(ctx context.Context, event *domain.AuditEvent) (*domain.AuditEvent, error) {
+	if event == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.ID != "" {
+		for _, v := range m.auditEvents {
+			if v.ID == event.ID {
+				stored := v
+				return &stored, nil
+			}
+		}
+	} else {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+
+	m.auditEvents = append(m.auditEvents, *event)
+	return event, nil
+}
+
+// GetAuditEvents returns audit events of the subscription with given id sorted by creation time
+func (m *memoryDetails) GetAuditEvents(ctx context.Context, subscriptionID string) ([]domain.AuditEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []domain.AuditEvent{}
+	for _, v := range m.auditEvents {
+		if v.SubscriptionID == subscriptionID {
+			events = append(events, v)
+		}
+	}
+
+	// events are appended in insertion order which is kept for the same creation time
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// copyIdempotencyRecord returns deep copy of the record, so the stored record is not shared with the caller
+func copyIdempotencyRecord(record *domain.IdempotencyRecord) domain.IdempotencyRecord {
+	c := *record
+	if record.Header != nil {
+		c.Header = map[string]string{}
+		for k, v := range record.Header {
+			c.Header[k] = v
+		}
+	}
+	if record.Body != nil {
+		c.Body = append([]byte{}, record.Body...)
+	}
+	return c
+}
+
+// CreateIdempotencyRecord stores the record of the request in progress
+// returns duplicate record error if unexpired record with the key exists, expired record is replaced
+func (m *memoryDetails) CreateIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil || record.Key == "" {
+		return db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timeNow := time.Now().UTC()
+	for k, v := range m.idempotencyRecords {
+		if !v.ExpiresAt.After(timeNow) {
+			delete(m.idempotencyRecords, k)
+		}
+	}
+
+	if _, ok := m.idempotencyRecords[record.Key]; ok {
+		return fmt.Errorf("idempotency key %v %w", record.Key, db.DuplicateRecordErr)
+	}
+
+	m.idempotencyRecords[record.Key] = copyIdempotencyRecord(record)
+	return nil
+}
+
+// GetIdempotencyRecord returns unexpired record with given key
+func (m *memoryDetails) GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.idempotencyRecords[key]
+	if !ok || !record.ExpiresAt.After(time.Now().UTC()) {
+		return nil, db.RecordNotFoundErr
+	}
+
+	c := copyIdempotencyRecord(&record)
+	return &c, nil
+}
+
+// CompleteIdempotencyRecord stores the response and expiry of the in-progress record with the key
+// returns record not found error if there is no in-progress record with the key
+func (m *memoryDetails) CompleteIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil {
+		return db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.idempotencyRecords[record.Key]
+	if !ok || stored.IsCompleted() {
+		return db.RecordNotFoundErr
+	}
+
+	stored.StatusCode = record.StatusCode
+	stored.Header = record.Header
+	stored.Body = record.Body
+	stored.ExpiresAt = record.ExpiresAt
+	m.idempotencyRecords[record.Key] = copyIdempotencyRecord(&stored)
+	return nil
+}
+
+// DeleteIdempotencyRecord deletes the record with given key if it exists
+func (m *memoryDetails) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotencyRecords, key)
+	return nil
+}
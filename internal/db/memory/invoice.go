@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// copyInvoice returns copy of the invoice, so the stored line items are not shared with the caller
+func copyInvoice(invoice *domain.Invoice) domain.Invoice {
+	c := *invoice
+	c.Lines = append([]domain.InvoiceLine(nil), invoice.Lines...)
+	return c
+}
+
+// SaveInvoice inserts the invoice with the next sequence of the year it is issued in and returns it with the record ID
+// invoices are never updated, the stored invoice is returned if the invoice with the ID is already inserted
+func (m *memoryDetails) SaveInvoice(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
+	if invoice == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if invoice.ID != "" {
+		for i := range m.invoices {
+			if m.invoices[i].ID == invoice.ID {
+				stored := copyInvoice(&m.invoices[i])
+				return &stored, nil
+			}
+		}
+	} else {
+		invoice.ID = primitive.NewObjectID().Hex()
+	}
+
+	var sequence int64
+	year := invoice.IssuedAt.UTC().Year()
+	for _, v := range m.invoices {
+		if v.Year == year && v.Sequence > sequence {
+			sequence = v.Sequence
+		}
+	}
+
+	invoice.SetNumber(sequence + 1)
+	m.invoices = append(m.invoices, copyInvoice(invoice))
+	return invoice, nil
+}
+
+// GetInvoices returns invoices of the subscription with given id sorted by issue time
+func (m *memoryDetails) GetInvoices(ctx context.Context, subscriptionID string) ([]domain.Invoice, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	invoices := []domain.Invoice{}
+	for i := range m.invoices {
+		if m.invoices[i].SubscriptionID == subscriptionID {
+			invoices = append(invoices, copyInvoice(&m.invoices[i]))
+		}
+	}
+
+	// invoices are appended in insertion order which is kept for the same issue time
+	sort.SliceStable(invoices, func(i, j int) bool {
+		return invoices[i].IssuedAt.Before(invoices[j].IssuedAt)
+	})
+	return invoices, nil
+}
+
+// GetInvoiceByNumber returns invoice with given number, returns record not found error if it does not exist
+func (m *memoryDetails) GetInvoiceByNumber(ctx context.Context, number string) (*domain.Invoice, error) {
+	if number == "" {
+		return nil, db.InvalidArgErr
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := range m.invoices {
+		if m.invoices[i].Number == number {
+			invoice := copyInvoice(&m.invoices[i])
+			return &invoice, nil
+		}
+	}
+	return nil, db.RecordNotFoundErr
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireLock acquires named lock for the owner until ttl expires
+// returns false if the lock is held by other owner
+func (m *memoryDetails) AcquireLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timeNow := time.Now().UTC()
+	lock, ok := m.jobLocks[name]
+	if ok && lock.owner != owner && lock.expiresAt.After(timeNow) {
+		return false, nil
+	}
+
+	m.jobLocks[name] = jobLock{
+		owner:     owner,
+		expiresAt: timeNow.Add(ttl),
+	}
+	return true, nil
+}
+
+// ReleaseLock releases named lock if it is held by the owner
+func (m *memoryDetails) ReleaseLock(ctx context.Context, name string, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lock, ok := m.jobLocks[name]; ok && lock.owner == owner {
+		delete(m.jobLocks, name)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// jobLock represent lock of the background job held by the owner until it expires
+type jobLock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+type memoryDetails struct {
+	mu                 sync.RWMutex
+	products           []domain.Product
+	taxRules           []domain.TaxRule
+	subscriptions      map[string]domain.UserSubscription
+	auditEvents        []domain.AuditEvent
+	jobLocks           map[string]jobLock
+	idempotencyRecords map[string]domain.IdempotencyRecord
+	invoices           []domain.Invoice
+}
+
+// NewMemoryDB creates new in-memory db instance with the reference data of the migration files,
+// the seed files are applied after the migration files if the seed files path is not empty,
+// returns error if input is invalid or the migration or seed files cannot be applied
+// data is not persisted, the instance is meant for tests and local development
+func NewMemoryDB(migrationFilesPath string, seedFilesPath string) (db.DB, error) {
+	if migrationFilesPath == "" {
+		return nil, fmt.Errorf("NewMemoryDB: empty migration files path %w", db.EmptyArgErr)
+	}
+
+	collections, err := replayMigrations(migrationFilesPath)
+	if err != nil {
+		return nil, fmt.Errorf("NewMemoryDB: %w", err)
+	}
+
+	if seedFilesPath != "" {
+		err = collections.replay(seedFilesPath)
+		if err != nil {
+			return nil, fmt.Errorf("NewMemoryDB: seed: %w", err)
+		}
+	}
+
+	products, err := createDomainProductRecordSl(collections[productCollection])
+	if err != nil {
+		return nil, fmt.Errorf("NewMemoryDB: %w", err)
+	}
+
+	taxRules, err := createDomainTaxRuleRecordSl(collections[taxRuleCollection])
+	if err != nil {
+		return nil, fmt.Errorf("NewMemoryDB: %w", err)
+	}
+
+	return &memoryDetails{
+		products:           products,
+		taxRules:           taxRules,
+		subscriptions:      map[string]domain.UserSubscription{},
+		jobLocks:           map[string]jobLock{},
+		idempotencyRecords: map[string]domain.IdempotencyRecord{},
+	}, nil
+}
+
+// Disconnect does nothing for in-memory db
+func (m *memoryDetails) Disconnect(ctx context.Context) error {
+	return nil
+}
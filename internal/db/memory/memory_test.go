@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/dbtest"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	migrationFilesPath = "file://../../../migration"
+	seedFilesPath      = "file://../../../seed"
+)
+
+func TestMemoryDBConformance(t *testing.T) {
+	suite.Run(t, &dbtest.Suite{
+		NewDB: func() (db.DB, error) {
+			return NewMemoryDB(migrationFilesPath, seedFilesPath)
+		},
+	})
+}
+
+func TestNewMemoryDB(t *testing.T) {
+	unsupportedMigrationPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(unsupportedMigrationPath, "00001_rename.up.mongodb"), []byte(`[{"renameCollection":"product"}]`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name               string
+		migrationFilesPath string
+		seedFilesPath      string
+		wantErr            bool
+	}{
+		{
+			name:    "should return error for empty migration files path",
+			wantErr: true,
+		},
+		{
+			name:               "should return error if no migration files are found",
+			migrationFilesPath: t.TempDir(),
+			wantErr:            true,
+		},
+		{
+			name:               "should return error for unsupported migration command",
+			migrationFilesPath: unsupportedMigrationPath,
+			wantErr:            true,
+		},
+		{
+			name:               "should return error if no seed files are found",
+			migrationFilesPath: migrationFilesPath,
+			seedFilesPath:      t.TempDir(),
+			wantErr:            true,
+		},
+		{
+			name:               "should return success for valid migration files path",
+			migrationFilesPath: migrationFilesPath,
+			wantErr:            false,
+		},
+		{
+			name:               "should return success for valid migration and seed files paths",
+			migrationFilesPath: migrationFilesPath,
+			seedFilesPath:      seedFilesPath,
+			wantErr:            false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMemoryDB(tt.migrationFilesPath, tt.seedFilesPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewMemoryDB() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewMemoryDBWithoutSeed(t *testing.T) {
+	database, err := NewMemoryDB(migrationFilesPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default rate is created by the migrations, so products can be sold without the seed files
+	got, err := database.GetTaxRules(context.Background(), "digital_service")
+	if err != nil || len(got) != 1 || got[0].Country != "" || got[0].Rate != 10 {
+		t.Errorf("GetTaxRules() = %v, %v, want default rate 10", got, err)
+	}
+}
+
+func TestSaveSubscriptionConcurrently(t *testing.T) {
+	database, err := NewMemoryDB(migrationFilesPath, seedFilesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved, err := database.SaveSubscription(ctx, &domain.UserSubscription{
+				Email:  "user@test.com",
+				Status: domain.SubscriptionStatusActive,
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, err = database.GetSubscriptionByID(ctx, saved.ID)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := database.FindSubscriptions(ctx, db.SubscriptionFilter{Email: "user@test.com"})
+	if err != nil || len(got) != 50 {
+		t.Errorf("FindSubscriptions() = %v records, %v, want 50", len(got), err)
+	}
+
+	_, err = database.GetSubscriptionByID(ctx, "62bb4ecdba3bbe275f8c7700")
+	if !errors.Is(err, db.RecordNotFoundErr) {
+		t.Errorf("GetSubscriptionByID() error = %v, wantErr %v", err, db.RecordNotFoundErr)
+	}
+}
@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	productCollection = "product"
+	taxRuleCollection = "tax_rule"
+)
+
+// collections holds migrated documents by collection name
+type collections map[string][]primitive.M
+
+// replayMigrations applies the up migration files from given path in version order and returns migrated documents
+// only the subset of mongodb commands used by the migration files is supported,
+// index and collection commands are ignored as well as aggregations of collections without documents,
+// unsupported commands and operators return error
+func replayMigrations(path string) (collections, error) {
+	c := collections{}
+	err := c.replay(path)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// replay applies the up migration files from given path in version order on the collections
+func (c collections) replay(path string) error {
+	files, err := filepath.Glob(filepath.Join(strings.TrimPrefix(path, "file://"), "*.up.mongodb"))
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no migration files found in %v", path)
+	}
+
+	// file names start with zero padded version
+	sort.Strings(files)
+
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		var migration struct {
+			Commands []primitive.M `bson:"commands"`
+		}
+		err = bson.UnmarshalExtJSON([]byte(`{"commands":`+string(content)+`}`), false, &migration)
+		if err != nil {
+			return fmt.Errorf("migration %v: %w", filepath.Base(f), err)
+		}
+
+		for _, cmd := range migration.Commands {
+			err = c.run(cmd)
+			if err != nil {
+				return fmt.Errorf("migration %v: %w", filepath.Base(f), err)
+			}
+		}
+	}
+	return nil
+}
+
+// run runs the migration command on the collections
+func (c collections) run(cmd primitive.M) error {
+	switch {
+	case cmd["insert"] != nil:
+		name := fmt.Sprint(cmd["insert"])
+		documents, _ := cmd["documents"].(primitive.A)
+		for _, v := range documents {
+			document, ok := v.(primitive.M)
+			if !ok {
+				return fmt.Errorf("insert %v: invalid document %v", name, v)
+			}
+			c[name] = append(c[name], document)
+		}
+		return nil
+	case cmd["update"] != nil:
+		name := fmt.Sprint(cmd["update"])
+		updates, _ := cmd["updates"].(primitive.A)
+		for _, v := range updates {
+			update, ok := v.(primitive.M)
+			if !ok {
+				return fmt.Errorf("update %v: invalid update %v", name, v)
+			}
+			err := c.update(name, update)
+			if err != nil {
+				return fmt.Errorf("update %v: %w", name, err)
+			}
+		}
+		return nil
+	case cmd["create"] != nil, cmd["createIndexes"] != nil, cmd["dropIndexes"] != nil:
+		return nil
+	case cmd["aggregate"] != nil:
+		// aggregation is used to backfill documents, it has nothing to change in a collection without migrated documents
+		name := fmt.Sprint(cmd["aggregate"])
+		if len(c[name]) > 0 {
+			return fmt.Errorf("unsupported aggregate of migrated %v documents", name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported command %v", cmd)
+	}
+}
+
+// update applies the update to the first or, for multi update, all the documents matching the update query
+// upsert inserts the document of the query equality fields with the update applied if no document matches
+func (c collections) update(name string, update primitive.M) error {
+	query, _ := update["q"].(primitive.M)
+	multi, _ := update["multi"].(bool)
+	upsert, _ := update["upsert"].(bool)
+	updated := false
+	for _, document := range c[name] {
+		matched, err := matches(document, query)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		err = apply(document, update["u"])
+		if err != nil {
+			return err
+		}
+		updated = true
+
+		if !multi {
+			break
+		}
+	}
+
+	if updated || !upsert {
+		return nil
+	}
+
+	document := primitive.M{}
+	for field, condition := range query {
+		if _, ok := condition.(primitive.M); !ok {
+			document[field] = condition
+		}
+	}
+	err := apply(document, update["u"])
+	if err != nil {
+		return err
+	}
+	c[name] = append(c[name], document)
+	return nil
+}
+
+// matches returns true if the document matches the query
+// supports field equality, $exists and $type number conditions
+func matches(document primitive.M, query primitive.M) (bool, error) {
+	for field, condition := range query {
+		value, exists := document[field]
+		operators, ok := condition.(primitive.M)
+		if !ok {
+			if !exists || !reflect.DeepEqual(value, condition) {
+				return false, nil
+			}
+			continue
+		}
+
+		for operator, operand := range operators {
+			switch {
+			case operator == "$exists":
+				if exists != (operand == true) {
+					return false, nil
+				}
+			case operator == "$type" && operand == "number":
+				if _, err := toFloat(value); err != nil {
+					return false, nil
+				}
+			default:
+				return false, fmt.Errorf("unsupported query operator %v %v", operator, operand)
+			}
+		}
+	}
+	return true, nil
+}
+
+// apply applies update document with $set/$unset operators or update pipeline with $set stages to the document
+func apply(document primitive.M, update interface{}) error {
+	switch u := update.(type) {
+	case primitive.M:
+		for operator, fields := range u {
+			values, ok := fields.(primitive.M)
+			if !ok {
+				return fmt.Errorf("invalid %v fields %v", operator, fields)
+			}
+
+			for field, value := range values {
+				switch operator {
+				case "$set":
+					document[field] = value
+				case "$unset":
+					delete(document, field)
+				default:
+					return fmt.Errorf("unsupported update operator %v", operator)
+				}
+			}
+		}
+		return nil
+	case primitive.A:
+		for _, v := range u {
+			stage, ok := v.(primitive.M)
+			if !ok || len(stage) != 1 || stage["$set"] == nil {
+				return fmt.Errorf("unsupported pipeline stage %v", v)
+			}
+
+			fields, ok := stage["$set"].(primitive.M)
+			if !ok {
+				return fmt.Errorf("invalid $set fields %v", stage["$set"])
+			}
+
+			// all the expressions of the stage are evaluated on the input document
+			values := primitive.M{}
+			for field, expression := range fields {
+				value, err := evaluate(document, expression)
+				if err != nil {
+					return err
+				}
+				values[field] = value
+			}
+
+			for field, value := range values {
+				document[field] = value
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported update %v", update)
+	}
+}
+
+// evaluate evaluates aggregation expression on the document
+// supports field paths, literals, embedded documents/arrays and $multiply, $round, $toLong operators
+func evaluate(document primitive.M, expression interface{}) (interface{}, error) {
+	switch e := expression.(type) {
+	case string:
+		if strings.HasPrefix(e, "$") {
+			return document[strings.TrimPrefix(e, "$")], nil
+		}
+		return e, nil
+	case primitive.A:
+		values := primitive.A{}
+		for _, v := range e {
+			value, err := evaluate(document, v)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case primitive.M:
+		values := primitive.M{}
+		for k, v := range e {
+			if strings.HasPrefix(k, "$") {
+				return evaluateOperator(document, k, v)
+			}
+
+			value, err := evaluate(document, v)
+			if err != nil {
+				return nil, err
+			}
+			values[k] = value
+		}
+		return values, nil
+	default:
+		return e, nil
+	}
+}
+
+// evaluateOperator evaluates numeric aggregation operator with given arguments on the document
+func evaluateOperator(document primitive.M, operator string, args interface{}) (interface{}, error) {
+	value, err := evaluate(document, args)
+	if err != nil {
+		return nil, err
+	}
+
+	operands, ok := value.(primitive.A)
+	if !ok {
+		operands = primitive.A{value}
+	}
+
+	numbers := []float64{}
+	for _, v := range operands {
+		number, err := toFloat(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", operator, err)
+		}
+		numbers = append(numbers, number)
+	}
+
+	switch {
+	case operator == "$multiply":
+		result := 1.0
+		for _, v := range numbers {
+			result *= v
+		}
+		return result, nil
+	case operator == "$round" && len(numbers) > 0:
+		places := 0.0
+		if len(numbers) > 1 {
+			places = numbers[1]
+		}
+		scale := math.Pow(10, places)
+		return math.RoundToEven(numbers[0]*scale) / scale, nil
+	case operator == "$toLong" && len(numbers) == 1:
+		return int64(numbers[0]), nil
+	default:
+		return nil, fmt.Errorf("unsupported expression operator %v %v", operator, args)
+	}
+}
+
+// toFloat converts numeric bson value to float64
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_matches(t *testing.T) {
+	document := primitive.M{"status": "paused", "price": 10.5}
+	tests := []struct {
+		name    string
+		query   primitive.M
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:  "should match empty query",
+			query: primitive.M{},
+			want:  true,
+		},
+		{
+			name:  "should match equal field and existing field",
+			query: primitive.M{"status": "paused", "price": primitive.M{"$exists": true}},
+			want:  true,
+		},
+		{
+			name:  "should not match different field value",
+			query: primitive.M{"status": "active"},
+			want:  false,
+		},
+		{
+			name:  "should not match missing field",
+			query: primitive.M{"pause_start_date": primitive.M{"$exists": true}},
+			want:  false,
+		},
+		{
+			name:  "should match number type",
+			query: primitive.M{"price": primitive.M{"$type": "number"}},
+			want:  true,
+		},
+		{
+			name:  "should not match other type",
+			query: primitive.M{"status": primitive.M{"$type": "number"}},
+			want:  false,
+		},
+		{
+			name:    "should return error for unsupported operator",
+			query:   primitive.M{"price": primitive.M{"$gt": 5}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matches(document, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("matches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		document primitive.M
+		update   interface{}
+		want     primitive.M
+		wantErr  bool
+	}{
+		{
+			name:     "should set and unset fields",
+			document: primitive.M{"tax_percentage": 10.0},
+			update: primitive.M{
+				"$set":   primitive.M{"tax_category": "digital_service"},
+				"$unset": primitive.M{"tax_percentage": ""},
+			},
+			want: primitive.M{"tax_category": "digital_service"},
+		},
+		{
+			name:     "should evaluate pipeline expressions on the input document",
+			document: primitive.M{"price": 12.34, "pause_start_date": "2022-06-01"},
+			update: primitive.A{
+				primitive.M{"$set": primitive.M{
+					"price": primitive.M{
+						"amount":   primitive.M{"$toLong": primitive.M{"$round": primitive.A{primitive.M{"$multiply": primitive.A{"$price", int32(100)}}, int32(0)}}},
+						"currency": "EUR",
+					},
+					"pauses": primitive.A{primitive.M{"start_date": "$pause_start_date"}},
+				}},
+			},
+			want: primitive.M{
+				"price":            primitive.M{"amount": int64(1234), "currency": "EUR"},
+				"pause_start_date": "2022-06-01",
+				"pauses":           primitive.A{primitive.M{"start_date": "2022-06-01"}},
+			},
+		},
+		{
+			name:     "should return error for unsupported update operator",
+			document: primitive.M{},
+			update:   primitive.M{"$inc": primitive.M{"count": 1}},
+			wantErr:  true,
+		},
+		{
+			name:     "should return error for unsupported pipeline stage",
+			document: primitive.M{},
+			update:   primitive.A{primitive.M{"$project": primitive.M{"price": 1}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apply(tt.document, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("apply() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.document, tt.want) {
+				t.Errorf("apply() = %v, want %v", tt.document, tt.want)
+			}
+		})
+	}
+}
+
+func Test_collections_run(t *testing.T) {
+	aggregate := primitive.M{"aggregate": "user_subscription", "pipeline": primitive.A{}, "cursor": primitive.M{}}
+	tests := []struct {
+		name        string
+		collections collections
+		cmd         primitive.M
+		wantErr     bool
+	}{
+		{
+			name:        "should ignore aggregate of collection without documents",
+			collections: collections{},
+			cmd:         aggregate,
+		},
+		{
+			name:        "should return error for aggregate of migrated documents",
+			collections: collections{"user_subscription": {{"product_name": "get in shape"}}},
+			cmd:         aggregate,
+			wantErr:     true,
+		},
+		{
+			name:        "should return error for unsupported command",
+			collections: collections{},
+			cmd:         primitive.M{"delete": "product"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.collections.run(tt.cmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_collections_update(t *testing.T) {
+	upsert := primitive.M{"q": primitive.M{"_id": "1"}, "u": primitive.M{"$set": primitive.M{"rate": 19.0}}, "upsert": true}
+	tests := []struct {
+		name        string
+		collections collections
+		update      primitive.M
+		want        collections
+	}{
+		{
+			name:        "should insert the document of upsert without matching document",
+			collections: collections{"tax_rule": {{"_id": "2", "rate": 20.0}}},
+			update:      upsert,
+			want:        collections{"tax_rule": {{"_id": "2", "rate": 20.0}, {"_id": "1", "rate": 19.0}}},
+		},
+		{
+			name:        "should update the matching document of upsert",
+			collections: collections{"tax_rule": {{"_id": "1", "rate": 10.0}}},
+			update:      upsert,
+			want:        collections{"tax_rule": {{"_id": "1", "rate": 19.0}}},
+		},
+		{
+			name:        "should not insert without matching document if upsert is not set",
+			collections: collections{"tax_rule": {}},
+			update:      primitive.M{"q": primitive.M{"_id": "1"}, "u": primitive.M{"$set": primitive.M{"rate": 19.0}}},
+			want:        collections{"tax_rule": {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.collections.update("tax_rule", tt.update)
+			if err != nil {
+				t.Fatalf("update() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.collections, tt.want) {
+				t.Errorf("update() collections = %v, want %v", tt.collections, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// money represent money sub-document of the migrated records, amount is in minor units
+type money struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// product represent migrated product record
+type product struct {
+	Id                 primitive.ObjectID `bson:"_id"`
+	Name               string             `bson:"name"`
+	SubscriptionPeriod uint               `bson:"subscription_period"`
+	TrialDays          uint               `bson:"trial_days"`
+	MaxPauseDays       uint               `bson:"max_pause_days"`
+	MaxPausesPerPeriod uint               `bson:"max_pauses_per_period"`
+	Price              money              `bson:"price"`
+	Prices             []struct {
+		Country string `bson:"country"`
+		Price   money  `bson:"price"`
+	} `bson:"prices"`
+	TaxCategory  string `bson:"tax_category"`
+	TaxInclusive bool   `bson:"tax_inclusive"`
+}
+
+// createDomainProductRecordSl creates domain products from migrated product documents
+func createDomainProductRecordSl(documents []primitive.M) ([]domain.Product, error) {
+	products := []domain.Product{}
+	for _, v := range documents {
+		record := product{}
+		err := decodeDocument(v, &record)
+		if err != nil {
+			return nil, fmt.Errorf("product %v: %w", v["_id"], err)
+		}
+
+		p := domain.Product{
+			ID:                 record.Id.Hex(),
+			Name:               record.Name,
+			SubscriptionPeriod: record.SubscriptionPeriod,
+			TrialDays:          record.TrialDays,
+			MaxPauseDays:       record.MaxPauseDays,
+			MaxPausesPerPeriod: record.MaxPausesPerPeriod,
+			Price:              domain.NewMoney(record.Price.Amount, record.Price.Currency),
+			TaxCategory:        record.TaxCategory,
+			TaxInclusive:       record.TaxInclusive,
+		}
+		for _, price := range record.Prices {
+			p.Prices = append(p.Prices, domain.ProductPrice{
+				Country: price.Country,
+				Price:   domain.NewMoney(price.Price.Amount, price.Price.Currency),
+			})
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// decodeDocument decodes migrated document into the record
+func decodeDocument(document primitive.M, record interface{}) error {
+	b, err := bson.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(b, record)
+}
+
+// GetProduct returns product for given id, if id is not given, will return all the products
+func (m *memoryDetails) GetProduct(ctx context.Context, id string) ([]domain.Product, error) {
+	if id != "" {
+		_, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	products := []domain.Product{}
+	for _, v := range m.products {
+		if id != "" && v.ID != id {
+			continue
+		}
+		products = append(products, copyProduct(&v))
+	}
+	return products, nil
+}
+
+// FindProducts returns products matching the filter sorted by the sort field and ID
+func (m *memoryDetails) FindProducts(ctx context.Context, filter db.ProductFilter) ([]domain.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	products := []domain.Product{}
+	for _, v := range m.products {
+		if !matchesProductFilter(&v, &filter) {
+			continue
+		}
+
+		if filter.After != nil && compareProduct(&v, filter.After, &filter) != sortDirection(filter.SortDesc) {
+			continue
+		}
+		products = append(products, copyProduct(&v))
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return compareProduct(&products[i], productCursor(&products[j], filter.PriceAt), &filter) == -sortDirection(filter.SortDesc)
+	})
+
+	if filter.Limit > 0 && int64(len(products)) > filter.Limit {
+		products = products[:filter.Limit]
+	}
+	return products, nil
+}
+
+// SaveProduct inserts the product without ID, otherwise updates the product
+func (m *memoryDetails) SaveProduct(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+	if p == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range p.PriceVersions {
+		if p.PriceVersions[i].ID == "" {
+			p.PriceVersions[i].ID = primitive.NewObjectID().Hex()
+		}
+	}
+
+	if p.ID == "" {
+		p.ID = primitive.NewObjectID().Hex()
+		m.products = append(m.products, copyProduct(p))
+		return p, nil
+	}
+
+	_, err := primitive.ObjectIDFromHex(p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	for i := range m.products {
+		if m.products[i].ID == p.ID {
+			m.products[i] = copyProduct(p)
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("product %v %w", p.ID, db.RecordNotFoundErr)
+}
+
+// copyProduct returns deep copy of the product, so the stored record is not shared with the caller
+func copyProduct(p *domain.Product) domain.Product {
+	product := *p
+	product.Prices = append([]domain.ProductPrice(nil), p.Prices...)
+	product.PriceVersions = nil
+	for _, v := range p.PriceVersions {
+		v.Prices = append([]domain.ProductPrice(nil), v.Prices...)
+		product.PriceVersions = append(product.PriceVersions, v)
+	}
+	product.ArchivedAt = copyTime(p.ArchivedAt)
+	return product
+}
+
+// CountProducts returns number of the products matching the filter
+func (m *memoryDetails) CountProducts(ctx context.Context, filter db.ProductFilter) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, v := range m.products {
+		if matchesProductFilter(&v, &filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesProductFilter returns true if the product matches all the fields of the filter except the cursor
+func matchesProductFilter(p *domain.Product, filter *db.ProductFilter) bool {
+	if filter.Name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+
+	if filter.SubscriptionPeriod != 0 && p.SubscriptionPeriod != filter.SubscriptionPeriod {
+		return false
+	}
+
+	price := p.PriceVersionAt(filter.PriceAt).Price
+	if filter.MinPrice != nil && (price.Currency != filter.MinPrice.Currency || price.Amount < filter.MinPrice.Amount) {
+		return false
+	}
+
+	if filter.MaxPrice != nil && (price.Currency != filter.MaxPrice.Currency || price.Amount > filter.MaxPrice.Amount) {
+		return false
+	}
+
+	if !filter.IncludeArchived && p.IsArchived() {
+		return false
+	}
+	return true
+}
+
+// productCursor returns position of the product in the sort order, the price is taken from the price version effective at given time
+func productCursor(p *domain.Product, priceAt time.Time) *db.ProductCursor {
+	return &db.ProductCursor{
+		Name:               p.Name,
+		PriceAmount:        p.PriceVersionAt(priceAt).Price.Amount,
+		SubscriptionPeriod: p.SubscriptionPeriod,
+		ID:                 p.ID,
+	}
+}
+
+// sortDirection returns 1 for ascending and -1 for descending order, the product after the cursor compares
+// to it with the sort direction
+func sortDirection(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+// compareProduct returns -1, 0 or 1 if the product is before, at or after the cursor in ascending order of the sort field
+// of the filter and ID, the price is taken from the price version effective at the filter time
+func compareProduct(p *domain.Product, cursor *db.ProductCursor, filter *db.ProductFilter) int {
+	result := 0
+	switch filter.SortBy {
+	case domain.ProductSortByPrice:
+		result = compareValues(p.PriceVersionAt(filter.PriceAt).Price.Amount, cursor.PriceAmount)
+	case domain.ProductSortBySubscriptionPeriod:
+		result = compareValues(int64(p.SubscriptionPeriod), int64(cursor.SubscriptionPeriod))
+	default:
+		result = strings.Compare(p.Name, cursor.Name)
+	}
+
+	if result == 0 {
+		result = strings.Compare(p.ID, cursor.ID)
+	}
+	return result
+}
+
+// compareValues returns -1, 0 or 1 if the value is less than, equal to or greater than the other value
+func compareValues(value int64, other int64) int {
+	switch {
+	case value < other:
+		return -1
+	case value > other:
+		return 1
+	}
+	return 0
+}
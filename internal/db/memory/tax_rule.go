@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taxRule represent migrated tax rule record
+type taxRule struct {
+	Id            primitive.ObjectID `bson:"_id"`
+	Country       string             `bson:"country"`
+	Category      string             `bson:"category"`
+	Rate          float64            `bson:"rate"`
+	EffectiveFrom time.Time          `bson:"effective_from"`
+	EffectiveTo   *time.Time         `bson:"effective_to"`
+}
+
+// createDomainTaxRuleRecordSl creates domain tax rules from migrated tax rule documents
+func createDomainTaxRuleRecordSl(documents []primitive.M) ([]domain.TaxRule, error) {
+	rules := []domain.TaxRule{}
+	for _, v := range documents {
+		record := taxRule{}
+		err := decodeDocument(v, &record)
+		if err != nil {
+			return nil, fmt.Errorf("tax rule %v: %w", v["_id"], err)
+		}
+
+		rules = append(rules, domain.TaxRule{
+			ID:            record.Id.Hex(),
+			Country:       record.Country,
+			Category:      record.Category,
+			Rate:          record.Rate,
+			EffectiveFrom: record.EffectiveFrom,
+			EffectiveTo:   record.EffectiveTo,
+		})
+	}
+	return rules, nil
+}
+
+// GetTaxRules returns all the tax rules for given product tax category
+func (m *memoryDetails) GetTaxRules(ctx context.Context, category string) ([]domain.TaxRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := []domain.TaxRule{}
+	for _, v := range m.taxRules {
+		if v.Category == category {
+			rules = append(rules, v)
+		}
+	}
+	return rules, nil
+}
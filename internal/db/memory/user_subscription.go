@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// copySubscription returns deep copy of the subscription, so the stored record is not shared with the caller
+func copySubscription(us *domain.UserSubscription) domain.UserSubscription {
+	subscription := *us
+	subscription.UpdatedAt = copyTime(us.UpdatedAt)
+	subscription.ResumeDate = copyTime(us.ResumeDate)
+	subscription.NextPaymentRetry = copyTime(us.NextPaymentRetry)
+	subscription.TrialEndDate = copyTime(us.TrialEndDate)
+	subscription.Renewals = append([]domain.Renewal(nil), us.Renewals...)
+	subscription.PlanChanges = append([]domain.PlanChange(nil), us.PlanChanges...)
+	subscription.Payments = append([]domain.Payment(nil), us.Payments...)
+	subscription.PaymentAttempts = append([]domain.PaymentAttempt(nil), us.PaymentAttempts...)
+	subscription.PendingAuditEvents = append([]domain.AuditEvent(nil), us.PendingAuditEvents...)
+
+	subscription.PendingInvoices = nil
+	for i := range us.PendingInvoices {
+		subscription.PendingInvoices = append(subscription.PendingInvoices, copyInvoice(&us.PendingInvoices[i]))
+	}
+
+	subscription.Pauses = nil
+	for _, v := range us.Pauses {
+		v.EndDate = copyTime(v.EndDate)
+		subscription.Pauses = append(subscription.Pauses, v)
+	}
+
+	if us.PendingPlanChange != nil {
+		planChange := *us.PendingPlanChange
+		subscription.PendingPlanChange = &planChange
+	}
+
+	if us.PendingPayment != nil {
+		payment := *us.PendingPayment
+		subscription.PendingPayment = &payment
+	}
+	return subscription
+}
+
+// copyTime returns copy of the time pointer
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// SaveSubscription create new subscription if its version is 0 otherwise update the subscription if its version is unchanged
+// and return the subscription record with incremented version
+// returns version conflict error if the subscription already exists or its version is changed
+// and duplicate record error if other subscription has the same uniqueness key
+func (m *memoryDetails) SaveSubscription(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+	if us == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	if us.ID == "" {
+		us.ID = primitive.NewObjectID().Hex()
+	} else if _, err := primitive.ObjectIDFromHex(us.ID); err != nil {
+		return nil, db.InvalidArgErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.subscriptions[us.ID]
+	if us.Version == 0 && ok {
+		return nil, fmt.Errorf("subscription %v %w", us.ID, db.VersionConflictErr)
+	}
+	if us.Version != 0 && (!ok || stored.Version != us.Version) {
+		return nil, fmt.Errorf("subscription %v version %v %w", us.ID, us.Version, db.VersionConflictErr)
+	}
+	if us.UniquenessKey != "" {
+		for id, v := range m.subscriptions {
+			if id != us.ID && v.UniquenessKey == us.UniquenessKey {
+				return nil, fmt.Errorf("subscription uniqueness key %v %w", us.UniquenessKey, db.DuplicateRecordErr)
+			}
+		}
+	}
+
+	for i := range us.PendingInvoices {
+		if us.PendingInvoices[i].ID == "" {
+			us.PendingInvoices[i].ID = primitive.NewObjectID().Hex()
+		}
+		us.PendingInvoices[i].SubscriptionID = us.ID
+	}
+
+	for i := range us.PendingAuditEvents {
+		if us.PendingAuditEvents[i].ID == "" {
+			us.PendingAuditEvents[i].ID = primitive.NewObjectID().Hex()
+		}
+		us.PendingAuditEvents[i].SubscriptionID = us.ID
+	}
+
+	us.Version++
+	m.subscriptions[us.ID] = copySubscription(us)
+	return us, nil
+}
+
+// RemovePendingInvoices removes the pending invoices with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (m *memoryDetails) RemovePendingInvoices(ctx context.Context, subscriptionID string, invoiceIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.subscriptions[subscriptionID]
+	if !ok {
+		return fmt.Errorf("subscription %v %w", subscriptionID, db.RecordNotFoundErr)
+	}
+
+	removed := map[string]bool{}
+	for _, v := range invoiceIDs {
+		removed[v] = true
+	}
+
+	pendingInvoices := []domain.Invoice{}
+	for _, v := range stored.PendingInvoices {
+		if !removed[v.ID] {
+			pendingInvoices = append(pendingInvoices, v)
+		}
+	}
+
+	stored.PendingInvoices = nil
+	if len(pendingInvoices) > 0 {
+		stored.PendingInvoices = pendingInvoices
+	}
+	m.subscriptions[subscriptionID] = stored
+	return nil
+}
+
+// RemovePendingAuditEvents removes the pending audit events with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (m *memoryDetails) RemovePendingAuditEvents(ctx context.Context, subscriptionID string, eventIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.subscriptions[subscriptionID]
+	if !ok {
+		return fmt.Errorf("subscription %v %w", subscriptionID, db.RecordNotFoundErr)
+	}
+
+	removed := map[string]bool{}
+	for _, v := range eventIDs {
+		removed[v] = true
+	}
+
+	pendingAuditEvents := []domain.AuditEvent{}
+	for _, v := range stored.PendingAuditEvents {
+		if !removed[v.ID] {
+			pendingAuditEvents = append(pendingAuditEvents, v)
+		}
+	}
+
+	stored.PendingAuditEvents = nil
+	if len(pendingAuditEvents) > 0 {
+		stored.PendingAuditEvents = pendingAuditEvents
+	}
+	m.subscriptions[subscriptionID] = stored
+	return nil
+}
+
+// GetSubscriptionByID return subscription for given id
+func (m *memoryDetails) GetSubscriptionByID(ctx context.Context, id string) (*domain.UserSubscription, error) {
+	_, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.subscriptions[id]
+	if !ok {
+		return nil, db.RecordNotFoundErr
+	}
+
+	subscription := copySubscription(&record)
+	return &subscription, nil
+}
+
+// FindSubscriptions returns subscriptions matching the filter sorted by end date
+func (m *memoryDetails) FindSubscriptions(ctx context.Context, filter db.SubscriptionFilter) ([]domain.UserSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subscriptions := []domain.UserSubscription{}
+	for _, v := range m.subscriptions {
+		if matchesFilter(&v, &filter) {
+			subscriptions = append(subscriptions, copySubscription(&v))
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return sortsBefore(filter.SortBy.SortValue(&subscriptions[i]), subscriptions[i].ID,
+			filter.SortBy.SortValue(&subscriptions[j]), subscriptions[j].ID, filter.SortDesc)
+	})
+
+	if filter.Limit > 0 && int64(len(subscriptions)) > filter.Limit {
+		subscriptions = subscriptions[:filter.Limit]
+	}
+	return subscriptions, nil
+}
+
+// matchesFilter returns true if the subscription matches all the non empty fields of the filter
+func matchesFilter(us *domain.UserSubscription, filter *db.SubscriptionFilter) bool {
+	if filter.Email != "" && us.Email != filter.Email {
+		return false
+	}
+
+	if filter.ProductID != "" && us.ProductID != filter.ProductID {
+		return false
+	}
+
+	if filter.HadTrial && us.TrialEndDate == nil {
+		return false
+	}
+
+	if filter.HasPendingInvoices && len(us.PendingInvoices) == 0 {
+		return false
+	}
+
+	if filter.HasPendingAuditEvents && len(us.PendingAuditEvents) == 0 {
+		return false
+	}
+
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, v := range filter.Statuses {
+			if us.Status == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if filter.EndDateBefore != nil && us.EndDate.After(*filter.EndDateBefore) {
+		return false
+	}
+
+	if filter.ResumeDateBefore != nil && (us.ResumeDate == nil || us.ResumeDate.After(*filter.ResumeDateBefore)) {
+		return false
+	}
+
+	if filter.PaymentRetryBefore != nil && (us.NextPaymentRetry == nil || us.NextPaymentRetry.After(*filter.PaymentRetryBefore)) {
+		return false
+	}
+
+	if filter.CreatedFrom != nil && us.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+
+	if filter.CreatedTo != nil && us.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+
+	if filter.EndDateFrom != nil && us.EndDate.Before(*filter.EndDateFrom) {
+		return false
+	}
+
+	if filter.After != nil && !sortsBefore(filter.After.Value, filter.After.ID, filter.SortBy.SortValue(us), us.ID, filter.SortDesc) {
+		return false
+	}
+	return true
+}
+
+// sortsBefore returns true if the position given by the sort field value and ID is before the other position
+// in the ascending order or in the descending order if desc is set
+func sortsBefore(value time.Time, id string, otherValue time.Time, otherID string, desc bool) bool {
+	if value.Equal(otherValue) {
+		if desc {
+			return id > otherID
+		}
+		return id < otherID
+	}
+	return value.Before(otherValue) != desc
+}
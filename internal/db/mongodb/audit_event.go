@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditEvent represent mongodb record from subscription_audit collection
+type AuditEvent struct {
+	Id             primitive.ObjectID `bson:"_id,omitempty"`
+	SubscriptionID string             `bson:"subscription_id"`
+	Action         string             `bson:"action"`
+	PreviousStatus string             `bson:"previous_status,omitempty"`
+	NewStatus      string             `bson:"new_status"`
+	Actor          string             `bson:"actor"`
+	RequestID      string             `bson:"request_id,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at"`
+}
+
+// createDBAuditEventRecord creates db audit event record from domain audit event
+func createDBAuditEventRecord(e *domain.AuditEvent) *AuditEvent {
+	return &AuditEvent{
+		SubscriptionID: e.SubscriptionID,
+		Action:         string(e.Action),
+		PreviousStatus: string(e.PreviousStatus),
+		NewStatus:      string(e.NewStatus),
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		CreatedAt:      e.CreatedAt,
+	}
+}
+
+// createDomainAuditEventRecord creates domain audit event record from db audit event
+func createDomainAuditEventRecord(e *AuditEvent) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		ID:             e.Id.Hex(),
+		SubscriptionID: e.SubscriptionID,
+		Action:         domain.AuditAction(e.Action),
+		PreviousStatus: domain.SubscriptionStatus(e.PreviousStatus),
+		NewStatus:      domain.SubscriptionStatus(e.NewStatus),
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		CreatedAt:      e.CreatedAt,
+	}
+}
+
+// SaveAuditEvent appends the audit event to the audit log and returns it with the record ID
+// audit events are never updated, the stored event is returned if the event with the ID is already inserted
+func (m *mongoDetails) SaveAuditEvent(ctx context.Context, event *domain.AuditEvent) (*domain.AuditEvent, error) {
+	record := createDBAuditEventRecord(event)
+	record.Id = primitive.NewObjectID()
+	if event.ID != "" {
+		id, err := primitive.ObjectIDFromHex(event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+		}
+		record.Id = id
+	}
+
+	_, err := m.SubscriptionAuditCollection.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		stored := AuditEvent{}
+		err = m.SubscriptionAuditCollection.FindOne(ctx, primitive.M{"_id": record.Id}).Decode(&stored)
+		if err != nil {
+			return nil, err
+		}
+		return createDomainAuditEventRecord(&stored), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event.ID = record.Id.Hex()
+	return event, nil
+}
+
+// GetAuditEvents returns audit events of the subscription with given id sorted by creation time
+func (m *mongoDetails) GetAuditEvents(ctx context.Context, subscriptionID string) ([]domain.AuditEvent, error) {
+	filter := primitive.M{"subscription_id": subscriptionID}
+	opts := options.Find().SetSort(primitive.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cur, err := m.SubscriptionAuditCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []AuditEvent{}
+	err = cur.All(ctx, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []domain.AuditEvent{}
+	for i := range records {
+		events = append(events, *createDomainAuditEventRecord(&records[i]))
+	}
+	return events, nil
+}
@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_createDomainAuditEventRecord(t *testing.T) {
+	idHex := primitive.NewObjectID()
+	timeNow := time.Now()
+	type args struct {
+		e *AuditEvent
+	}
+	tests := []struct {
+		name string
+		args args
+		want *domain.AuditEvent
+	}{
+		{
+			name: "should return domain AuditEvent record for the DB record",
+			args: args{
+				e: &AuditEvent{
+					Id:             idHex,
+					SubscriptionID: "62bb4ecdba3bbe275f8c7788",
+					Action:         "status_changed",
+					PreviousStatus: "active",
+					NewStatus:      "paused",
+					Actor:          "user@test.com",
+					RequestID:      "8f14e45fceea167a",
+					CreatedAt:      timeNow,
+				},
+			},
+			want: &domain.AuditEvent{
+				ID:             idHex.Hex(),
+				SubscriptionID: "62bb4ecdba3bbe275f8c7788",
+				Action:         domain.AuditActionStatusChanged,
+				PreviousStatus: domain.SubscriptionStatusActive,
+				NewStatus:      domain.SubscriptionStatusPaused,
+				Actor:          "user@test.com",
+				RequestID:      "8f14e45fceea167a",
+				CreatedAt:      timeNow,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createDomainAuditEventRecord(tt.args.e); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createDomainAuditEventRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *MongoTestSuite) TestAuditEvents() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	client, err := connect(fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbName := "testdb"
+	m := &mongoDetails{
+		client:                      client,
+		dbName:                      dbName,
+		SubscriptionAuditCollection: client.Database(dbName).Collection(subscriptionAuditCollection),
+	}
+	ctx := context.Background()
+	subscriptionID := "62bb4ecdba3bbe275f8c7788"
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// saved out of order to check the sorting
+	events := []domain.AuditEvent{
+		{
+			SubscriptionID: subscriptionID,
+			Action:         domain.AuditActionStatusChanged,
+			PreviousStatus: domain.SubscriptionStatusActive,
+			NewStatus:      domain.SubscriptionStatusPaused,
+			Actor:          "user@test.com",
+			CreatedAt:      createdAt.Add(time.Hour),
+		},
+		{
+			SubscriptionID: subscriptionID,
+			Action:         domain.AuditActionCreated,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "user@test.com",
+			RequestID:      "8f14e45fceea167a",
+			CreatedAt:      createdAt,
+		},
+		{
+			SubscriptionID: "62bb4ecdba3bbe275f8c7789",
+			Action:         domain.AuditActionCreated,
+			NewStatus:      domain.SubscriptionStatusActive,
+			Actor:          "user@test.com",
+			CreatedAt:      createdAt,
+		},
+	}
+	for i := range events {
+		saved, err := m.SaveAuditEvent(ctx, &events[i])
+		if err != nil || saved.ID == "" {
+			t.Fatalf("mongoDetails.SaveAuditEvent() = %v, %v", saved, err)
+		}
+	}
+
+	got, err := m.GetAuditEvents(ctx, subscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.AuditEvent{events[1], events[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mongoDetails.GetAuditEvents() = %v, want %v", got, want)
+	}
+}
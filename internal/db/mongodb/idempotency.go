@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyRecord represent mongodb record from idempotency_key collection
+// expired records are deleted by the TTL index of expires_at
+type IdempotencyRecord struct {
+	Key         string            `bson:"_id"`
+	Fingerprint string            `bson:"fingerprint"`
+	StatusCode  int               `bson:"status_code"`
+	Header      map[string]string `bson:"header,omitempty"`
+	Body        []byte            `bson:"body,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at"`
+	ExpiresAt   time.Time         `bson:"expires_at"`
+}
+
+// CreateIdempotencyRecord stores the record of the request in progress
+// returns duplicate record error if unexpired record with the key exists, expired record is replaced
+func (m *mongoDetails) CreateIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil || record.Key == "" {
+		return db.InvalidArgErr
+	}
+
+	// TTL index deletes expired records with a delay, so expired record may still exist
+	filter := primitive.M{
+		"_id":        record.Key,
+		"expires_at": primitive.M{"$lte": time.Now().UTC()},
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := m.IdempotencyKeyCollection.ReplaceOne(ctx, filter, IdempotencyRecord(*record), opts)
+	if err != nil {
+		// unexpired record exists, upsert fails for the existing _id
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("idempotency key %v %w", record.Key, db.DuplicateRecordErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetIdempotencyRecord returns unexpired record with given key
+func (m *mongoDetails) GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	filter := primitive.M{
+		"_id":        key,
+		"expires_at": primitive.M{"$gt": time.Now().UTC()},
+	}
+	record := IdempotencyRecord{}
+	err := m.IdempotencyKeyCollection.FindOne(ctx, filter).Decode(&record)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, db.RecordNotFoundErr
+		}
+		return nil, err
+	}
+
+	domainRecord := domain.IdempotencyRecord(record)
+	domainRecord.CreatedAt = domainRecord.CreatedAt.UTC()
+	domainRecord.ExpiresAt = domainRecord.ExpiresAt.UTC()
+	return &domainRecord, nil
+}
+
+// CompleteIdempotencyRecord stores the response and expiry of the in-progress record with the key
+// returns record not found error if there is no in-progress record with the key
+func (m *mongoDetails) CompleteIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil {
+		return db.InvalidArgErr
+	}
+
+	filter := primitive.M{
+		"_id":         record.Key,
+		"status_code": 0,
+	}
+	set := primitive.M{
+		"status_code": record.StatusCode,
+		"expires_at":  record.ExpiresAt,
+	}
+	if len(record.Header) > 0 {
+		set["header"] = record.Header
+	}
+	if record.Body != nil {
+		set["body"] = record.Body
+	}
+	res, err := m.IdempotencyKeyCollection.UpdateOne(ctx, filter, primitive.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return db.RecordNotFoundErr
+	}
+	return nil
+}
+
+// DeleteIdempotencyRecord deletes the record with given key if it exists
+func (m *mongoDetails) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	_, err := m.IdempotencyKeyCollection.DeleteOne(ctx, primitive.M{"_id": key})
+	return err
+}
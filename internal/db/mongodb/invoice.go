@@ -0,0 +1,206 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invoiceNumberAttempts is number of attempts to insert the invoice with the next sequence of the year,
+// the sequence is taken by concurrent insert if the attempt fails
+const invoiceNumberAttempts = 5
+
+// Invoice represent mongodb record from invoice collection
+// year and sequence are unique together, so the sequence of the year has no duplicates
+type Invoice struct {
+	Id              primitive.ObjectID `bson:"_id,omitempty"`
+	Type            string             `bson:"type"`
+	Number          string             `bson:"number"`
+	Year            int                `bson:"year"`
+	Sequence        int64              `bson:"sequence"`
+	SubscriptionID  string             `bson:"subscription_id"`
+	Email           string             `bson:"email"`
+	Country         string             `bson:"country,omitempty"`
+	AuthorizationID string             `bson:"authorization_id,omitempty"`
+	Reason          string             `bson:"reason,omitempty"`
+	Lines           []InvoiceLine      `bson:"lines"`
+	Net             Money              `bson:"net"`
+	Tax             Money              `bson:"tax"`
+	Total           Money              `bson:"total"`
+	IssuedAt        time.Time          `bson:"issued_at"`
+}
+
+// InvoiceLine represent line item sub-document of the invoice record
+type InvoiceLine struct {
+	ProductID   string    `bson:"product_id"`
+	ProductName string    `bson:"product_name"`
+	PeriodStart time.Time `bson:"period_start"`
+	PeriodEnd   time.Time `bson:"period_end"`
+	Net         Money     `bson:"net"`
+	Tax         Money     `bson:"tax"`
+	Gross       Money     `bson:"gross"`
+	TaxRate     float64   `bson:"tax_rate"`
+}
+
+// createDBInvoiceRecord creates db invoice record from domain invoice
+func createDBInvoiceRecord(i *domain.Invoice) *Invoice {
+	invoice := &Invoice{
+		Type:            string(i.Type),
+		Number:          i.Number,
+		Year:            i.Year,
+		Sequence:        i.Sequence,
+		SubscriptionID:  i.SubscriptionID,
+		Email:           i.Email,
+		Country:         i.Country,
+		AuthorizationID: i.AuthorizationID,
+		Reason:          string(i.Reason),
+		Lines:           []InvoiceLine{},
+		Net:             createDBMoney(i.Net),
+		Tax:             createDBMoney(i.Tax),
+		Total:           createDBMoney(i.Total),
+		IssuedAt:        i.IssuedAt,
+	}
+
+	for _, v := range i.Lines {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			ProductID:   v.ProductID,
+			ProductName: v.ProductName,
+			PeriodStart: v.PeriodStart,
+			PeriodEnd:   v.PeriodEnd,
+			Net:         createDBMoney(v.Net),
+			Tax:         createDBMoney(v.Tax),
+			Gross:       createDBMoney(v.Gross),
+			TaxRate:     v.TaxRate,
+		})
+	}
+	return invoice
+}
+
+// createDomainInvoiceRecord creates domain invoice from db invoice record
+func createDomainInvoiceRecord(i *Invoice) *domain.Invoice {
+	invoice := &domain.Invoice{
+		ID:              i.Id.Hex(),
+		Type:            domain.InvoiceType(i.Type),
+		Number:          i.Number,
+		Year:            i.Year,
+		Sequence:        i.Sequence,
+		SubscriptionID:  i.SubscriptionID,
+		Email:           i.Email,
+		Country:         i.Country,
+		AuthorizationID: i.AuthorizationID,
+		Reason:          domain.PaymentReason(i.Reason),
+		Net:             createDomainMoney(i.Net),
+		Tax:             createDomainMoney(i.Tax),
+		Total:           createDomainMoney(i.Total),
+		IssuedAt:        i.IssuedAt.UTC(),
+	}
+
+	for _, v := range i.Lines {
+		invoice.Lines = append(invoice.Lines, domain.InvoiceLine{
+			ProductID:   v.ProductID,
+			ProductName: v.ProductName,
+			PeriodStart: v.PeriodStart.UTC(),
+			PeriodEnd:   v.PeriodEnd.UTC(),
+			Net:         createDomainMoney(v.Net),
+			Tax:         createDomainMoney(v.Tax),
+			Gross:       createDomainMoney(v.Gross),
+			TaxRate:     v.TaxRate,
+		})
+	}
+	return invoice
+}
+
+// SaveInvoice inserts the invoice with the next sequence of the year it is issued in and returns it with the record ID
+// the insert is retried with the next sequence if concurrent insert took the sequence, invoices are never updated
+// the stored invoice is returned if the invoice with the ID is already inserted
+func (m *mongoDetails) SaveInvoice(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
+	if invoice == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	record := createDBInvoiceRecord(invoice)
+	record.Id = primitive.NewObjectID()
+	if invoice.ID != "" {
+		id, err := primitive.ObjectIDFromHex(invoice.ID)
+		if err != nil {
+			return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+		}
+		record.Id = id
+	}
+	record.Year = invoice.IssuedAt.UTC().Year()
+	for i := 0; i < invoiceNumberAttempts; i++ {
+		// the invoice is inserted by the previous issue or concurrently
+		stored := Invoice{}
+		err := m.InvoiceCollection.FindOne(ctx, primitive.M{"_id": record.Id}).Decode(&stored)
+		if err == nil {
+			return createDomainInvoiceRecord(&stored), nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+
+		last := Invoice{}
+		opts := options.FindOne().SetSort(primitive.D{{Key: "sequence", Value: -1}})
+		err = m.InvoiceCollection.FindOne(ctx, primitive.M{"year": record.Year}, opts).Decode(&last)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+
+		record.Sequence = last.Sequence + 1
+		record.Number = domain.InvoiceNumber(record.Year, record.Sequence)
+		_, err = m.InvoiceCollection.InsertOne(ctx, record)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.ID = record.Id.Hex()
+		invoice.SetNumber(record.Sequence)
+		return invoice, nil
+	}
+	return nil, fmt.Errorf("invoice number of year %v %w", record.Year, db.DuplicateRecordErr)
+}
+
+// GetInvoices returns invoices of the subscription with given id sorted by issue time
+func (m *mongoDetails) GetInvoices(ctx context.Context, subscriptionID string) ([]domain.Invoice, error) {
+	filter := primitive.M{"subscription_id": subscriptionID}
+	opts := options.Find().SetSort(primitive.D{{Key: "issued_at", Value: 1}, {Key: "year", Value: 1}, {Key: "sequence", Value: 1}})
+
+	records := []Invoice{}
+	err := m.getAllDocuments(ctx, m.InvoiceCollection, filter, &records, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := []domain.Invoice{}
+	for i := range records {
+		invoices = append(invoices, *createDomainInvoiceRecord(&records[i]))
+	}
+	return invoices, nil
+}
+
+// GetInvoiceByNumber returns invoice with given number, returns record not found error if it does not exist
+func (m *mongoDetails) GetInvoiceByNumber(ctx context.Context, number string) (*domain.Invoice, error) {
+	if number == "" {
+		return nil, db.InvalidArgErr
+	}
+
+	record := Invoice{}
+	err := m.InvoiceCollection.FindOne(ctx, primitive.M{"number": number}).Decode(&record)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("invoice %v %w", number, db.RecordNotFoundErr)
+		}
+		return nil, err
+	}
+	return createDomainInvoiceRecord(&record), nil
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_createDomainInvoiceRecord(t *testing.T) {
+	idHex := primitive.NewObjectID()
+	issuedAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	type args struct {
+		i *Invoice
+	}
+	tests := []struct {
+		name string
+		args args
+		want *domain.Invoice
+	}{
+		{
+			name: "should return domain Invoice record for the DB record",
+			args: args{
+				i: &Invoice{
+					Id:              idHex,
+					Number:          "2022-000001",
+					Year:            2022,
+					Sequence:        1,
+					SubscriptionID:  "62bb4ecdba3bbe275f8c7788",
+					Email:           "user@test.com",
+					Country:         "DE",
+					AuthorizationID: "fake_auth_1",
+					Reason:          "renewal",
+					Lines: []InvoiceLine{
+						{
+							ProductID:   "62bac26a69c9410f916fc262",
+							ProductName: "hiphop cardio",
+							PeriodStart: issuedAt,
+							PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+							Net:         Money{Amount: 840, Currency: "EUR"},
+							Tax:         Money{Amount: 160, Currency: "EUR"},
+							Gross:       Money{Amount: 1000, Currency: "EUR"},
+							TaxRate:     19,
+						},
+					},
+					Net:      Money{Amount: 840, Currency: "EUR"},
+					Tax:      Money{Amount: 160, Currency: "EUR"},
+					Total:    Money{Amount: 1000, Currency: "EUR"},
+					IssuedAt: issuedAt,
+				},
+			},
+			want: &domain.Invoice{
+				ID:              idHex.Hex(),
+				Number:          "2022-000001",
+				Year:            2022,
+				Sequence:        1,
+				SubscriptionID:  "62bb4ecdba3bbe275f8c7788",
+				Email:           "user@test.com",
+				Country:         "DE",
+				AuthorizationID: "fake_auth_1",
+				Reason:          domain.PaymentReasonRenewal,
+				Lines: []domain.InvoiceLine{
+					{
+						ProductID:   "62bac26a69c9410f916fc262",
+						ProductName: "hiphop cardio",
+						PeriodStart: issuedAt,
+						PeriodEnd:   issuedAt.AddDate(0, 1, 0),
+						Net:         domain.NewMoney(840, "EUR"),
+						Tax:         domain.NewMoney(160, "EUR"),
+						Gross:       domain.NewMoney(1000, "EUR"),
+						TaxRate:     19,
+					},
+				},
+				Net:      domain.NewMoney(840, "EUR"),
+				Tax:      domain.NewMoney(160, "EUR"),
+				Total:    domain.NewMoney(1000, "EUR"),
+				IssuedAt: issuedAt,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createDomainInvoiceRecord(tt.args.i); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createDomainInvoiceRecord() = %v, want %v", got, tt.want)
+			}
+			if got := createDomainInvoiceRecord(createDBInvoiceRecord(tt.want)); !reflect.DeepEqual(got.Lines, tt.want.Lines) {
+				t.Errorf("createDBInvoiceRecord() lines = %v, want %v", got.Lines, tt.want.Lines)
+			}
+		})
+	}
+}
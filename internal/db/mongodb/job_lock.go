@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobLock represent mongodb record from job_lock collection
+type JobLock struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// AcquireLock acquires named lock for the owner until ttl expires
+// returns false if the lock is held by other owner
+func (m *mongoDetails) AcquireLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	timeNow := time.Now().UTC()
+	filter := primitive.M{
+		"_id": name,
+		"$or": primitive.A{
+			primitive.M{"owner": owner},
+			primitive.M{"expires_at": primitive.M{"$lte": timeNow}},
+		},
+	}
+	update := primitive.M{
+		"$set": primitive.M{
+			"owner":      owner,
+			"expires_at": timeNow.Add(ttl),
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := m.JobLockCollection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		// lock is held by other owner, upsert fails for the existing _id
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseLock releases named lock if it is held by the owner
+func (m *mongoDetails) ReleaseLock(ctx context.Context, name string, owner string) error {
+	filter := primitive.M{
+		"_id":   name,
+		"owner": owner,
+	}
+	_, err := m.JobLockCollection.DeleteOne(ctx, filter)
+	return err
+}
@@ -0,0 +1,51 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (suite *MongoTestSuite) TestAcquireLock() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	client, err := connect(fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbName := "testdb"
+	m := &mongoDetails{
+		client:            client,
+		dbName:            dbName,
+		JobLockCollection: client.Database(dbName).Collection(jobLockCollection),
+	}
+	ctx := context.Background()
+
+	acquired, err := m.AcquireLock(ctx, "job", "owner1", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("mongoDetails.AcquireLock() = %v, %v, want true", acquired, err)
+	}
+
+	// lock is held by owner1
+	acquired, err = m.AcquireLock(ctx, "job", "owner2", time.Minute)
+	if err != nil || acquired {
+		t.Fatalf("mongoDetails.AcquireLock() = %v, %v, want false", acquired, err)
+	}
+
+	// owner can extend its lock
+	acquired, err = m.AcquireLock(ctx, "job", "owner1", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("mongoDetails.AcquireLock() = %v, %v, want true", acquired, err)
+	}
+
+	err = m.ReleaseLock(ctx, "job", "owner1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// released lock can be acquired by other owner
+	acquired, err = m.AcquireLock(ctx, "job", "owner2", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("mongoDetails.AcquireLock() = %v, %v, want true", acquired, err)
+	}
+}
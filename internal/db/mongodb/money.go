@@ -0,0 +1,22 @@
+package mongodb
+
+import "github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+
+// Money represent mongodb money sub-document, amount is stored in minor units
+type Money struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// createDBMoney creates db money record from domain money
+func createDBMoney(m domain.Money) Money {
+	return Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+// createDomainMoney creates domain money from db money record
+func createDomainMoney(m Money) domain.Money {
+	return domain.NewMoney(m.Amount, m.Currency)
+}
@@ -12,15 +12,25 @@ import (
 )
 
 const (
-	productCollection          = "product"
-	userSubscriptionCollection = "user_subscription"
+	productCollection           = "product"
+	userSubscriptionCollection  = "user_subscription"
+	taxRuleCollection           = "tax_rule"
+	jobLockCollection           = "job_lock"
+	subscriptionAuditCollection = "subscription_audit"
+	idempotencyKeyCollection    = "idempotency_key"
+	invoiceCollection           = "invoice"
 )
 
 type mongoDetails struct {
-	client                     *mongo.Client
-	dbName                     string
-	ProductCollection          *mongo.Collection
-	UserSubscriptionCollection *mongo.Collection
+	client                      *mongo.Client
+	dbName                      string
+	ProductCollection           *mongo.Collection
+	UserSubscriptionCollection  *mongo.Collection
+	TaxRuleCollection           *mongo.Collection
+	JobLockCollection           *mongo.Collection
+	SubscriptionAuditCollection *mongo.Collection
+	IdempotencyKeyCollection    *mongo.Collection
+	InvoiceCollection           *mongo.Collection
 }
 
 // NewMongoDB created new mongo db instance, returns error if input is invalid
@@ -41,12 +51,22 @@ func NewMongoDB(uri string, dbName string) (db.DB, error) {
 
 	productCollection := client.Database(dbName).Collection(productCollection)
 	userSubscriptionCollection := client.Database(dbName).Collection(userSubscriptionCollection)
+	taxRuleCollection := client.Database(dbName).Collection(taxRuleCollection)
+	jobLockCollection := client.Database(dbName).Collection(jobLockCollection)
+	subscriptionAuditCollection := client.Database(dbName).Collection(subscriptionAuditCollection)
+	idempotencyKeyCollection := client.Database(dbName).Collection(idempotencyKeyCollection)
+	invoiceCollection := client.Database(dbName).Collection(invoiceCollection)
 
 	return &mongoDetails{
-		client:                     client,
-		dbName:                     dbName,
-		ProductCollection:          productCollection,
-		UserSubscriptionCollection: userSubscriptionCollection,
+		client:                      client,
+		dbName:                      dbName,
+		ProductCollection:           productCollection,
+		UserSubscriptionCollection:  userSubscriptionCollection,
+		TaxRuleCollection:           taxRuleCollection,
+		JobLockCollection:           jobLockCollection,
+		SubscriptionAuditCollection: subscriptionAuditCollection,
+		IdempotencyKeyCollection:    idempotencyKeyCollection,
+		InvoiceCollection:           invoiceCollection,
 	}, nil
 }
 
@@ -69,8 +89,9 @@ func connect(uri string) (*mongo.Client, error) {
 }
 
 // getAllDocuments returns all the documents for matching filter from given collection, otherwise error
-func (m *mongoDetails) getAllDocuments(ctx context.Context, collection *mongo.Collection, filter primitive.M, records interface{}) error {
-	cur, err := collection.Find(ctx, filter)
+func (m *mongoDetails) getAllDocuments(ctx context.Context, collection *mongo.Collection, filter primitive.M, records interface{},
+	opts ...*options.FindOptions) error {
+	cur, err := collection.Find(ctx, filter, opts...)
 	if err != nil {
 		return err
 	}
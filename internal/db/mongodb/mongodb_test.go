@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"reflect"
 	"testing"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/dbtest"
+	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/stretchr/testify/suite"
 	testcontainers "github.com/testcontainers/testcontainers-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type mongoTestContainer struct {
@@ -69,6 +74,162 @@ func TestMongoTestSuite(t *testing.T) {
 	suite.Run(t, new(MongoTestSuite))
 }
 
+func (suite *MongoTestSuite) TestConformance() {
+	mgoC := suite.TestContainer
+	runConformanceSuite(suite.T(), fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port))
+}
+
+// runConformanceSuite runs db conformance suite, every test of the suite gets new migrated database
+func runConformanceSuite(t *testing.T, uri string) {
+	dbCount := 0
+	suite.Run(t, &dbtest.Suite{
+		NewDB: func() (db.DB, error) {
+			dbCount++
+			dbName := fmt.Sprintf("conformancedb%v", dbCount)
+			m, err := migrate.New("file://../../../migration", uri+"/"+dbName)
+			if err != nil {
+				return nil, err
+			}
+			err = m.Up()
+			if err != nil {
+				return nil, err
+			}
+
+			// seed version is kept in its own collection
+			seed, err := migrate.New("file://../../../seed", uri+"/"+dbName+"?x-migrations-collection=seed_migrations")
+			if err != nil {
+				return nil, err
+			}
+			err = seed.Up()
+			if err != nil {
+				return nil, err
+			}
+			return NewMongoDB(uri, dbName)
+		},
+	})
+}
+
+func (suite *MongoTestSuite) TestBackfillSubscriptionProduct() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	ctx := context.Background()
+	uri := fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port)
+
+	m, err := migrate.New("file://../../../migration", uri+"/backfilldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// subscriptions created before the renewal support without product ID and auto renew
+	err = m.Migrate(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := connect(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database("backfilldb").Collection(userSubscriptionCollection)
+	knownID, unknownID := primitive.NewObjectID(), primitive.NewObjectID()
+	_, err = collection.InsertMany(ctx, []interface{}{
+		primitive.M{"_id": knownID, "email": "test@test.com", "product_name": "get in shape", "status": "active"},
+		primitive.M{"_id": unknownID, "email": "test@test.com", "product_name": "unknown product", "status": "active"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[primitive.ObjectID]primitive.M{}
+	for _, id := range []primitive.ObjectID{knownID, unknownID} {
+		document := primitive.M{}
+		err = collection.FindOne(ctx, primitive.M{"_id": id}).Decode(&document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[id] = primitive.M{"product_id": document["product_id"], "auto_renew": document["auto_renew"]}
+	}
+
+	want := map[primitive.ObjectID]primitive.M{
+		knownID:   {"product_id": "62bac24b0bf33af1c877d97f", "auto_renew": true},
+		unknownID: {"product_id": nil, "auto_renew": nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backfilled subscriptions = %v, want %v", got, want)
+	}
+}
+
+func (suite *MongoTestSuite) TestBackfillSubscriptionUniquenessKey() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	ctx := context.Background()
+	uri := fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port)
+
+	m, err := migrate.New("file://../../../migration", uri+"/uniquenessdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// live subscriptions created before the uniqueness rule without uniqueness key
+	err = m.Migrate(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := connect(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database("uniquenessdb").Collection(userSubscriptionCollection)
+	firstID, secondID, namedID, cancelledID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	_, err = collection.InsertMany(ctx, []interface{}{
+		primitive.M{"_id": firstID, "email": "Test@Test.com ", "product_id": "62bac24b0bf33af1c877d97f", "status": "active"},
+		primitive.M{"_id": secondID, "email": "test@test.com", "product_id": "62bac24b0bf33af1c877d97f", "status": "paused"},
+		primitive.M{"_id": namedID, "email": "named@test.com", "product_name": "get in shape", "status": "active"},
+		primitive.M{"_id": cancelledID, "email": "other@test.com", "product_id": "62bac24b0bf33af1c877d97f", "status": "cancelled"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Migrate(11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[primitive.ObjectID]interface{}{}
+	for _, id := range []primitive.ObjectID{firstID, secondID, namedID, cancelledID} {
+		document := primitive.M{}
+		err = collection.FindOne(ctx, primitive.M{"_id": id}).Decode(&document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[id] = document["uniqueness_key"]
+	}
+
+	// only the oldest of the duplicate live subscriptions gets the key, so the unique index can be created
+	want := map[primitive.ObjectID]interface{}{
+		firstID:     "test@test.com/62bac24b0bf33af1c877d97f",
+		secondID:    nil,
+		namedID:     "named@test.com/62bac24b0bf33af1c877d97f",
+		cancelledID: nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backfilled uniqueness keys = %v, want %v", got, want)
+	}
+}
+
 func (suite *MongoTestSuite) TestNewMongoDB() {
 	mgoC := suite.TestContainer
 	t := suite.T()
@@ -3,6 +3,8 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
@@ -14,19 +16,111 @@ type Product struct {
 	Id                 primitive.ObjectID `bson:"_id"`
 	Name               string             `bson:"name"`
 	SubscriptionPeriod uint               `bson:"subscription_period"`
-	Price              float64            `bson:"price"`
-	TaxPercentage      float64            `bson:"tax_percentage"`
+	TrialDays          uint               `bson:"trial_days,omitempty"`
+	MaxPauseDays       uint               `bson:"max_pause_days,omitempty"`
+	MaxPausesPerPeriod uint               `bson:"max_pauses_per_period,omitempty"`
+	Price              Money              `bson:"price"`
+	Prices             []ProductPrice     `bson:"prices,omitempty"`
+	PriceVersions      []PriceVersion     `bson:"price_versions,omitempty"`
+	TaxCategory        string             `bson:"tax_category"`
+	TaxInclusive       bool               `bson:"tax_inclusive"`
+	ArchivedAt         *time.Time         `bson:"archived_at,omitempty"`
+}
+
+// ProductPrice represent price list entry of the product record
+type ProductPrice struct {
+	Country string `bson:"country,omitempty"`
+	Price   Money  `bson:"price"`
+}
+
+// PriceVersion represent price version entry of the product record
+type PriceVersion struct {
+	Id            primitive.ObjectID `bson:"id"`
+	EffectiveFrom time.Time          `bson:"effective_from"`
+	Price         Money              `bson:"price"`
+	Prices        []ProductPrice     `bson:"prices,omitempty"`
+}
+
+// createDomainPrices creates domain price list from db price list
+func createDomainPrices(p []ProductPrice) []domain.ProductPrice {
+	var prices []domain.ProductPrice
+	for _, v := range p {
+		prices = append(prices, domain.ProductPrice{
+			Country: v.Country,
+			Price:   createDomainMoney(v.Price),
+		})
+	}
+	return prices
+}
+
+// createDBPrices creates db price list from domain price list
+func createDBPrices(p []domain.ProductPrice) []ProductPrice {
+	var prices []ProductPrice
+	for _, v := range p {
+		prices = append(prices, ProductPrice{
+			Country: v.Country,
+			Price:   createDBMoney(v.Price),
+		})
+	}
+	return prices
 }
 
 // createDomainProductRecord creates domain product record from db product
 func createDomainProductRecord(p *Product) *domain.Product {
-	return &domain.Product{
+	product := &domain.Product{
 		ID:                 p.Id.Hex(),
 		Name:               p.Name,
 		SubscriptionPeriod: p.SubscriptionPeriod,
-		Price:              p.Price,
-		TaxPercentage:      p.TaxPercentage,
+		TrialDays:          p.TrialDays,
+		MaxPauseDays:       p.MaxPauseDays,
+		MaxPausesPerPeriod: p.MaxPausesPerPeriod,
+		Price:              createDomainMoney(p.Price),
+		TaxCategory:        p.TaxCategory,
+		TaxInclusive:       p.TaxInclusive,
+		ArchivedAt:         p.ArchivedAt,
+		Prices:             createDomainPrices(p.Prices),
+	}
+
+	for _, v := range p.PriceVersions {
+		product.PriceVersions = append(product.PriceVersions, domain.PriceVersion{
+			ID:            v.Id.Hex(),
+			EffectiveFrom: v.EffectiveFrom.UTC(),
+			Price:         createDomainMoney(v.Price),
+			Prices:        createDomainPrices(v.Prices),
+		})
+	}
+	return product
+}
+
+// createDBProductRecord creates db product record from domain product, price version without ID gets new ID
+func createDBProductRecord(p *domain.Product, id primitive.ObjectID) *Product {
+	product := &Product{
+		Id:                 id,
+		Name:               p.Name,
+		SubscriptionPeriod: p.SubscriptionPeriod,
+		TrialDays:          p.TrialDays,
+		MaxPauseDays:       p.MaxPauseDays,
+		MaxPausesPerPeriod: p.MaxPausesPerPeriod,
+		Price:              createDBMoney(p.Price),
+		TaxCategory:        p.TaxCategory,
+		TaxInclusive:       p.TaxInclusive,
+		ArchivedAt:         p.ArchivedAt,
+		Prices:             createDBPrices(p.Prices),
+	}
+
+	for _, v := range p.PriceVersions {
+		versionID, err := primitive.ObjectIDFromHex(v.ID)
+		if err != nil {
+			versionID = primitive.NewObjectID()
+		}
+		product.PriceVersions = append(product.PriceVersions, PriceVersion{
+			Id:            versionID,
+			EffectiveFrom: v.EffectiveFrom,
+			Price:         createDBMoney(v.Price),
+			Prices:        createDBPrices(v.Prices),
+		})
 	}
+	return product
 }
 
 // createDomainProductRecordSl create domain Product slice from input product slice
@@ -58,3 +152,169 @@ func (m *mongoDetails) GetProduct(ctx context.Context, id string) ([]domain.Prod
 
 	return createDomainProductRecordSl(productRecords), nil
 }
+
+// effectivePriceField is the field with default price of the price version effective at the filter time, see effectivePrice
+const effectivePriceField = "effective_price"
+
+// effectivePrice returns expression of the default price of the price version effective at given time,
+// which is the price of the last price version effective from or before the time or the product price if no version is effective yet
+func effectivePrice(at time.Time) primitive.M {
+	return primitive.M{"$let": primitive.M{
+		"vars": primitive.M{"version": primitive.M{"$arrayElemAt": primitive.A{
+			primitive.M{"$filter": primitive.M{
+				"input": primitive.M{"$ifNull": primitive.A{"$price_versions", primitive.A{}}},
+				"cond":  primitive.M{"$lte": primitive.A{"$$this.effective_from", at}},
+			}},
+			-1,
+		}}},
+		"in": primitive.M{"$ifNull": primitive.A{"$$version.price", "$price"}},
+	}}
+}
+
+// productQuery returns query of the product filter except the price range and the cursor
+func productQuery(filter *db.ProductFilter) primitive.M {
+	query := primitive.M{}
+	if filter.Name != "" {
+		query["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Name), Options: "i"}
+	}
+
+	if filter.SubscriptionPeriod != 0 {
+		query["subscription_period"] = filter.SubscriptionPeriod
+	}
+
+	if !filter.IncludeArchived {
+		query["archived_at"] = nil
+	}
+	return query
+}
+
+// productPipeline returns aggregation stages matching the product filter except the cursor,
+// the products get default price effective at the filter time, which is matched by the price range
+func productPipeline(filter *db.ProductFilter) primitive.A {
+	priceQuery := primitive.M{}
+	price := primitive.M{}
+	if filter.MinPrice != nil {
+		priceQuery[effectivePriceField+".currency"] = filter.MinPrice.Currency
+		price["$gte"] = filter.MinPrice.Amount
+	}
+
+	if filter.MaxPrice != nil {
+		priceQuery[effectivePriceField+".currency"] = filter.MaxPrice.Currency
+		price["$lte"] = filter.MaxPrice.Amount
+	}
+
+	if len(price) > 0 {
+		priceQuery[effectivePriceField+".amount"] = price
+	}
+
+	return primitive.A{
+		primitive.M{"$match": productQuery(filter)},
+		primitive.M{"$addFields": primitive.M{effectivePriceField: effectivePrice(filter.PriceAt)}},
+		primitive.M{"$match": priceQuery},
+	}
+}
+
+// FindProducts returns products matching the filter sorted by the sort field and ID
+func (m *mongoDetails) FindProducts(ctx context.Context, filter db.ProductFilter) ([]domain.Product, error) {
+	pipeline := productPipeline(&filter)
+
+	sortField, sortOrder, comparison := "name", 1, "$gt"
+	switch filter.SortBy {
+	case domain.ProductSortByPrice:
+		sortField = effectivePriceField + ".amount"
+	case domain.ProductSortBySubscriptionPeriod:
+		sortField = "subscription_period"
+	}
+	if filter.SortDesc {
+		sortOrder, comparison = -1, "$lt"
+	}
+
+	if filter.After != nil {
+		afterID, err := primitive.ObjectIDFromHex(filter.After.ID)
+		if err != nil {
+			return nil, fmt.Errorf("cursor id %w", db.InvalidArgErr)
+		}
+
+		var value interface{}
+		switch filter.SortBy {
+		case domain.ProductSortByPrice:
+			value = filter.After.PriceAmount
+		case domain.ProductSortBySubscriptionPeriod:
+			value = filter.After.SubscriptionPeriod
+		default:
+			value = filter.After.Name
+		}
+		pipeline = append(pipeline, primitive.M{"$match": primitive.M{"$or": primitive.A{
+			primitive.M{sortField: primitive.M{comparison: value}},
+			primitive.M{sortField: value, "_id": primitive.M{comparison: afterID}},
+		}}})
+	}
+
+	pipeline = append(pipeline, primitive.M{"$sort": primitive.D{{Key: sortField, Value: sortOrder}, {Key: "_id", Value: sortOrder}}})
+	if filter.Limit > 0 {
+		pipeline = append(pipeline, primitive.M{"$limit": filter.Limit})
+	}
+
+	cur, err := m.ProductCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	productRecords := []Product{}
+	err = cur.All(ctx, &productRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	return createDomainProductRecordSl(productRecords), nil
+}
+
+// SaveProduct inserts the product without ID, otherwise replaces the product
+func (m *mongoDetails) SaveProduct(ctx context.Context, p *domain.Product) (*domain.Product, error) {
+	if p == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	if p.ID == "" {
+		record := createDBProductRecord(p, primitive.NewObjectID())
+		_, err := m.ProductCollection.InsertOne(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		return createDomainProductRecord(record), nil
+	}
+
+	idHex, err := primitive.ObjectIDFromHex(p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	record := createDBProductRecord(p, idHex)
+	res, err := m.ProductCollection.ReplaceOne(ctx, primitive.M{"_id": idHex}, record)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.MatchedCount == 0 {
+		return nil, fmt.Errorf("product %v %w", p.ID, db.RecordNotFoundErr)
+	}
+	return createDomainProductRecord(record), nil
+}
+
+// CountProducts returns number of the products matching the filter
+func (m *mongoDetails) CountProducts(ctx context.Context, filter db.ProductFilter) (int64, error) {
+	pipeline := append(productPipeline(&filter), primitive.M{"$count": "count"})
+	cur, err := m.ProductCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	result := []struct {
+		Count int64 `bson:"count"`
+	}{}
+	err = cur.All(ctx, &result)
+	if err != nil || len(result) == 0 {
+		return 0, err
+	}
+	return result[0].Count, nil
+}
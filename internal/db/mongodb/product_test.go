@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +14,7 @@ import (
 
 func Test_createDomainProductRecord(t *testing.T) {
 	productIDHex := primitive.NewObjectID()
+	priceVersionIDHex := primitive.NewObjectID()
 	type args struct {
 		p *Product
 	}
@@ -28,16 +30,92 @@ func Test_createDomainProductRecord(t *testing.T) {
 					Id:                 productIDHex,
 					Name:               "test name",
 					SubscriptionPeriod: 1,
-					Price:              10,
-					TaxPercentage:      10,
+					TrialDays:          7,
+					MaxPauseDays:       30,
+					MaxPausesPerPeriod: 2,
+					Price:              Money{Amount: 1000, Currency: "EUR"},
+					TaxCategory:        "digital_service",
+					TaxInclusive:       true,
 				},
 			},
 			want: &domain.Product{
 				ID:                 productIDHex.Hex(),
 				Name:               "test name",
 				SubscriptionPeriod: 1,
-				Price:              10,
-				TaxPercentage:      10,
+				TrialDays:          7,
+				MaxPauseDays:       30,
+				MaxPausesPerPeriod: 2,
+				Price:              domain.NewMoney(1000, "EUR"),
+				TaxCategory:        "digital_service",
+				TaxInclusive:       true,
+			},
+		},
+		{
+			name: "should return domain Product record with price list for the DB record",
+			args: args{
+				p: &Product{
+					Id:                 productIDHex,
+					Name:               "test name",
+					SubscriptionPeriod: 1,
+					Price:              Money{Amount: 1000, Currency: "EUR"},
+					Prices: []ProductPrice{
+						{
+							Country: "CH",
+							Price:   Money{Amount: 1100, Currency: "CHF"},
+						},
+					},
+					TaxCategory:  "digital_service",
+					TaxInclusive: true,
+				},
+			},
+			want: &domain.Product{
+				ID:                 productIDHex.Hex(),
+				Name:               "test name",
+				SubscriptionPeriod: 1,
+				Price:              domain.NewMoney(1000, "EUR"),
+				Prices: []domain.ProductPrice{
+					{
+						Country: "CH",
+						Price:   domain.NewMoney(1100, "CHF"),
+					},
+				},
+				TaxCategory:  "digital_service",
+				TaxInclusive: true,
+			},
+		},
+		{
+			name: "should return domain Product record with price versions for the DB record",
+			args: args{
+				p: &Product{
+					Id:                 productIDHex,
+					Name:               "test name",
+					SubscriptionPeriod: 1,
+					Price:              Money{Amount: 1000, Currency: "EUR"},
+					PriceVersions: []PriceVersion{
+						{
+							Id:            priceVersionIDHex,
+							EffectiveFrom: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+							Price:         Money{Amount: 1200, Currency: "EUR"},
+						},
+					},
+					TaxCategory:  "digital_service",
+					TaxInclusive: true,
+				},
+			},
+			want: &domain.Product{
+				ID:                 productIDHex.Hex(),
+				Name:               "test name",
+				SubscriptionPeriod: 1,
+				Price:              domain.NewMoney(1000, "EUR"),
+				PriceVersions: []domain.PriceVersion{
+					{
+						ID:            priceVersionIDHex.Hex(),
+						EffectiveFrom: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+						Price:         domain.NewMoney(1200, "EUR"),
+					},
+				},
+				TaxCategory:  "digital_service",
+				TaxInclusive: true,
 			},
 		},
 	}
@@ -64,8 +142,9 @@ func (suite *MongoTestSuite) TestGetProduct() {
 		Id:                 productIDHex,
 		Name:               "test name",
 		SubscriptionPeriod: 1,
-		Price:              10,
-		TaxPercentage:      10,
+		Price:              Money{Amount: 1000, Currency: "EUR"},
+		TaxCategory:        "digital_service",
+		TaxInclusive:       true,
 	})
 
 	if err != nil {
@@ -106,8 +185,9 @@ func (suite *MongoTestSuite) TestGetProduct() {
 					ID:                 productIDHex.Hex(),
 					Name:               "test name",
 					SubscriptionPeriod: 1,
-					Price:              10,
-					TaxPercentage:      10,
+					Price:              domain.NewMoney(1000, "EUR"),
+					TaxCategory:        "digital_service",
+					TaxInclusive:       true,
 				},
 			},
 			wantErr: false,
@@ -159,8 +239,9 @@ func (suite *MongoTestSuite) TestGetProduct() {
 					ID:                 productIDHex.Hex(),
 					Name:               "test name",
 					SubscriptionPeriod: 1,
-					Price:              10,
-					TaxPercentage:      10,
+					Price:              domain.NewMoney(1000, "EUR"),
+					TaxCategory:        "digital_service",
+					TaxInclusive:       true,
 				},
 			},
 			wantErr: false,
@@ -204,8 +285,9 @@ func Test_createDomainProductRecordSl(t *testing.T) {
 						Id:                 productIDHex,
 						Name:               "test name",
 						SubscriptionPeriod: 1,
-						Price:              10,
-						TaxPercentage:      10,
+						Price:              Money{Amount: 1000, Currency: "EUR"},
+						TaxCategory:        "digital_service",
+						TaxInclusive:       true,
 					},
 				},
 			},
@@ -214,8 +296,9 @@ func Test_createDomainProductRecordSl(t *testing.T) {
 					ID:                 productIDHex.Hex(),
 					Name:               "test name",
 					SubscriptionPeriod: 1,
-					Price:              10,
-					TaxPercentage:      10,
+					Price:              domain.NewMoney(1000, "EUR"),
+					TaxCategory:        "digital_service",
+					TaxInclusive:       true,
 				},
 			},
 		},
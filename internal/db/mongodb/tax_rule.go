@@ -0,0 +1,48 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxRule represent mongodb record from tax_rule collection
+type TaxRule struct {
+	Id            primitive.ObjectID `bson:"_id"`
+	Country       string             `bson:"country"`
+	Category      string             `bson:"category"`
+	Rate          float64            `bson:"rate"`
+	EffectiveFrom time.Time          `bson:"effective_from"`
+	EffectiveTo   *time.Time         `bson:"effective_to,omitempty"`
+}
+
+// createDomainTaxRuleRecord creates domain tax rule record from db tax rule
+func createDomainTaxRuleRecord(r *TaxRule) *domain.TaxRule {
+	return &domain.TaxRule{
+		ID:            r.Id.Hex(),
+		Country:       r.Country,
+		Category:      r.Category,
+		Rate:          r.Rate,
+		EffectiveFrom: r.EffectiveFrom,
+		EffectiveTo:   r.EffectiveTo,
+	}
+}
+
+// GetTaxRules returns all the tax rules for given product tax category
+func (m *mongoDetails) GetTaxRules(ctx context.Context, category string) ([]domain.TaxRule, error) {
+	filter := primitive.M{"category": category}
+
+	taxRuleRecords := []TaxRule{}
+	err := m.getAllDocuments(ctx, m.TaxRuleCollection, filter, &taxRuleRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []domain.TaxRule{}
+	for i := range taxRuleRecords {
+		rules = append(rules, *createDomainTaxRuleRecord(&taxRuleRecords[i]))
+	}
+	return rules, nil
+}
@@ -0,0 +1,123 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_createDomainTaxRuleRecord(t *testing.T) {
+	idHex := primitive.NewObjectID()
+	timeNow := time.Now()
+	type args struct {
+		r *TaxRule
+	}
+	tests := []struct {
+		name string
+		args args
+		want *domain.TaxRule
+	}{
+		{
+			name: "should return domain TaxRule record for the DB record",
+			args: args{
+				r: &TaxRule{
+					Id:            idHex,
+					Country:       "DE",
+					Category:      "digital_service",
+					Rate:          19,
+					EffectiveFrom: timeNow,
+					EffectiveTo:   &timeNow,
+				},
+			},
+			want: &domain.TaxRule{
+				ID:            idHex.Hex(),
+				Country:       "DE",
+				Category:      "digital_service",
+				Rate:          19,
+				EffectiveFrom: timeNow,
+				EffectiveTo:   &timeNow,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createDomainTaxRuleRecord(tt.args.r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createDomainTaxRuleRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (suite *MongoTestSuite) TestGetTaxRules() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	client, err := connect(fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbName := "testdb"
+	effectiveFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	idHex := primitive.NewObjectID()
+	_, err = client.Database(dbName).Collection(taxRuleCollection).InsertOne(context.Background(), &TaxRule{
+		Id:            idHex,
+		Country:       "DE",
+		Category:      "digital_service",
+		Rate:          19,
+		EffectiveFrom: effectiveFrom,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mongoDetails{
+		client:            client,
+		dbName:            dbName,
+		TaxRuleCollection: client.Database(dbName).Collection(taxRuleCollection),
+	}
+
+	tests := []struct {
+		name     string
+		category string
+		want     []domain.TaxRule
+		wantErr  bool
+	}{
+		{
+			name:     "should return rules for given category",
+			category: "digital_service",
+			want: []domain.TaxRule{
+				{
+					ID:            idHex.Hex(),
+					Country:       "DE",
+					Category:      "digital_service",
+					Rate:          19,
+					EffectiveFrom: effectiveFrom,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "should return empty slice for category without rules",
+			category: "unknown",
+			want:     []domain.TaxRule{},
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.GetTaxRules(context.Background(), tt.category)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mongoDetails.GetTaxRules() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mongoDetails.GetTaxRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
@@ -13,19 +14,133 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// uniquenessKeyIndex is the name of the partial unique index of the user_subscription uniqueness_key
+const uniquenessKeyIndex = "uniqueness_key"
+
 // UserSubscription represent mongodb record from user_subscription collection
 type UserSubscription struct {
-	Id             primitive.ObjectID `bson:"_id,omitempty"`
-	CreatedAt      time.Time          `bson:"created_at"`
-	UpdatedAt      *time.Time         `bson:"updated_at,omitempty"`
-	Email          string             `bson:"email"`
-	ProductName    string             `bson:"product_name"`
-	StartDate      time.Time          `bson:"start_date"`
-	EndDate        time.Time          `bson:"end_date"`
-	Price          float64            `bson:"price"`
-	Tax            float64            `bson:"tax"`
-	Status         string             `bson:"status"`
-	PauseStartDate *time.Time         `bson:"pause_start_date,omitempty"`
+	Id                 primitive.ObjectID `bson:"_id,omitempty"`
+	Version            int64              `bson:"version"`
+	CreatedAt          time.Time          `bson:"created_at"`
+	UpdatedAt          *time.Time         `bson:"updated_at,omitempty"`
+	Email              string             `bson:"email"`
+	Country            string             `bson:"country,omitempty"`
+	ProductID          string             `bson:"product_id,omitempty"`
+	ProductName        string             `bson:"product_name"`
+	StartDate          time.Time          `bson:"start_date"`
+	EndDate            time.Time          `bson:"end_date"`
+	Price              Money              `bson:"price"`
+	NetPrice           Money              `bson:"net_price"`
+	Tax                Money              `bson:"tax"`
+	TaxRate            float64            `bson:"tax_rate"`
+	PriceVersionID     string             `bson:"price_version_id,omitempty"`
+	Status             string             `bson:"status"`
+	Pauses             []Pause            `bson:"pauses,omitempty"`
+	ResumeDate         *time.Time         `bson:"resume_date,omitempty"`
+	AutoRenew          bool               `bson:"auto_renew"`
+	Renewals           []Renewal          `bson:"renewals,omitempty"`
+	TrialEndDate       *time.Time         `bson:"trial_end_date,omitempty"`
+	CancelAtPeriodEnd  bool               `bson:"cancel_at_period_end,omitempty"`
+	PendingPlanChange  *PlanChange        `bson:"pending_plan_change,omitempty"`
+	PlanChanges        []PlanChange       `bson:"plan_changes,omitempty"`
+	UniquenessKey      string             `bson:"uniqueness_key,omitempty"`
+	PaymentToken       string             `bson:"payment_token,omitempty"`
+	Payments           []Payment          `bson:"payments,omitempty"`
+	PendingPayment     *Payment           `bson:"pending_payment,omitempty"`
+	NextPaymentRetry   *time.Time         `bson:"next_payment_retry,omitempty"`
+	PaymentAttempts    []PaymentAttempt   `bson:"payment_attempts,omitempty"`
+	PendingInvoices    []Invoice          `bson:"pending_invoices,omitempty"`
+	PendingAuditEvents []AuditEvent       `bson:"pending_audit_events,omitempty"`
+}
+
+// Renewal represent renewal entry of the user_subscription record
+type Renewal struct {
+	RenewedAt      time.Time `bson:"renewed_at"`
+	PeriodStart    time.Time `bson:"period_start"`
+	PeriodEnd      time.Time `bson:"period_end"`
+	Price          Money     `bson:"price"`
+	PriceVersionID string    `bson:"price_version_id,omitempty"`
+}
+
+// Payment represent payment entry of the user_subscription record
+type Payment struct {
+	AuthorizationID string    `bson:"authorization_id"`
+	Type            string    `bson:"type"`
+	Reason          string    `bson:"reason"`
+	Amount          Money     `bson:"amount"`
+	CreatedAt       time.Time `bson:"created_at"`
+}
+
+// PaymentAttempt represent renewal charge attempt entry of the user_subscription record
+type PaymentAttempt struct {
+	PeriodStart time.Time `bson:"period_start"`
+	AttemptedAt time.Time `bson:"attempted_at"`
+	Succeeded   bool      `bson:"succeeded"`
+	Error       string    `bson:"error,omitempty"`
+}
+
+// Pause represent pause entry of the user_subscription record
+type Pause struct {
+	StartDate time.Time  `bson:"start_date"`
+	EndDate   *time.Time `bson:"end_date,omitempty"`
+	Reason    string     `bson:"reason,omitempty"`
+	Actor     string     `bson:"actor,omitempty"`
+}
+
+// PlanChange represent plan change entry of the user_subscription record
+type PlanChange struct {
+	RequestedAt   time.Time `bson:"requested_at"`
+	EffectiveAt   time.Time `bson:"effective_at"`
+	FromProductID string    `bson:"from_product_id"`
+	ToProductID   string    `bson:"to_product_id"`
+	Price         Money     `bson:"price"`
+	Proration     Money     `bson:"proration"`
+}
+
+// createDBPlanChange creates db PlanChange from domain plan change
+func createDBPlanChange(pc domain.PlanChange) PlanChange {
+	return PlanChange{
+		RequestedAt:   pc.RequestedAt,
+		EffectiveAt:   pc.EffectiveAt,
+		FromProductID: pc.FromProductID,
+		ToProductID:   pc.ToProductID,
+		Price:         createDBMoney(pc.Price),
+		Proration:     createDBMoney(pc.Proration),
+	}
+}
+
+// createDomainPlanChange creates domain PlanChange from db plan change
+func createDomainPlanChange(pc PlanChange) domain.PlanChange {
+	return domain.PlanChange{
+		RequestedAt:   pc.RequestedAt,
+		EffectiveAt:   pc.EffectiveAt,
+		FromProductID: pc.FromProductID,
+		ToProductID:   pc.ToProductID,
+		Price:         createDomainMoney(pc.Price),
+		Proration:     createDomainMoney(pc.Proration),
+	}
+}
+
+// createDBPayment creates db Payment from domain payment
+func createDBPayment(p domain.Payment) Payment {
+	return Payment{
+		AuthorizationID: p.AuthorizationID,
+		Type:            string(p.Type),
+		Reason:          string(p.Reason),
+		Amount:          createDBMoney(p.Amount),
+		CreatedAt:       p.CreatedAt,
+	}
+}
+
+// createDomainPayment creates domain Payment from db payment
+func createDomainPayment(p Payment) domain.Payment {
+	return domain.Payment{
+		AuthorizationID: p.AuthorizationID,
+		Type:            domain.PaymentType(p.Type),
+		Reason:          domain.PaymentReason(p.Reason),
+		Amount:          createDomainMoney(p.Amount),
+		CreatedAt:       p.CreatedAt,
+	}
 }
 
 // createDomainProductRecord creates db UserSbuscription record from domain record
@@ -35,14 +150,36 @@ func createDBUserSubscriptionRecord(us *domain.UserSubscription) (*UserSubscript
 	}
 
 	userSubscription := &UserSubscription{
-		CreatedAt:   us.CreatedAt,
-		Email:       us.Email,
-		ProductName: us.ProductName,
-		StartDate:   us.StartDate,
-		EndDate:     us.EndDate,
-		Price:       us.Price,
-		Tax:         us.Tax,
-		Status:      string(us.Status),
+		Version:           us.Version,
+		CreatedAt:         us.CreatedAt,
+		Email:             us.Email,
+		Country:           us.Country,
+		ProductID:         us.ProductID,
+		ProductName:       us.ProductName,
+		StartDate:         us.StartDate,
+		EndDate:           us.EndDate,
+		Price:             createDBMoney(us.Price),
+		NetPrice:          createDBMoney(us.NetPrice),
+		Tax:               createDBMoney(us.Tax),
+		TaxRate:           us.TaxRate,
+		PriceVersionID:    us.PriceVersionID,
+		Status:            string(us.Status),
+		AutoRenew:         us.AutoRenew,
+		CancelAtPeriodEnd: us.CancelAtPeriodEnd,
+		ResumeDate:        us.ResumeDate,
+		NextPaymentRetry:  us.NextPaymentRetry,
+		UniquenessKey:     us.UniquenessKey,
+		PaymentToken:      us.PaymentToken,
+	}
+
+	for _, v := range us.Renewals {
+		userSubscription.Renewals = append(userSubscription.Renewals, Renewal{
+			RenewedAt:      v.RenewedAt,
+			PeriodStart:    v.PeriodStart,
+			PeriodEnd:      v.PeriodEnd,
+			Price:          createDBMoney(v.Price),
+			PriceVersionID: v.PriceVersionID,
+		})
 	}
 
 	if us.ID != "" {
@@ -57,8 +194,54 @@ func createDBUserSubscriptionRecord(us *domain.UserSubscription) (*UserSubscript
 		userSubscription.UpdatedAt = us.UpdatedAt
 	}
 
-	if us.PauseStartDate != nil {
-		userSubscription.PauseStartDate = us.PauseStartDate
+	for _, v := range us.Pauses {
+		userSubscription.Pauses = append(userSubscription.Pauses, Pause(v))
+	}
+
+	if us.TrialEndDate != nil {
+		userSubscription.TrialEndDate = us.TrialEndDate
+	}
+
+	if us.PendingPlanChange != nil {
+		planChange := createDBPlanChange(*us.PendingPlanChange)
+		userSubscription.PendingPlanChange = &planChange
+	}
+
+	for _, v := range us.PlanChanges {
+		userSubscription.PlanChanges = append(userSubscription.PlanChanges, createDBPlanChange(v))
+	}
+
+	for _, v := range us.Payments {
+		userSubscription.Payments = append(userSubscription.Payments, createDBPayment(v))
+	}
+
+	if us.PendingPayment != nil {
+		payment := createDBPayment(*us.PendingPayment)
+		userSubscription.PendingPayment = &payment
+	}
+
+	for _, v := range us.PaymentAttempts {
+		userSubscription.PaymentAttempts = append(userSubscription.PaymentAttempts, PaymentAttempt(v))
+	}
+
+	for i := range us.PendingInvoices {
+		invoice := createDBInvoiceRecord(&us.PendingInvoices[i])
+		id, err := primitive.ObjectIDFromHex(us.PendingInvoices[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("pending invoice id %w", db.InvalidArgErr)
+		}
+		invoice.Id = id
+		userSubscription.PendingInvoices = append(userSubscription.PendingInvoices, *invoice)
+	}
+
+	for i := range us.PendingAuditEvents {
+		event := createDBAuditEventRecord(&us.PendingAuditEvents[i])
+		id, err := primitive.ObjectIDFromHex(us.PendingAuditEvents[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("pending audit event id %w", db.InvalidArgErr)
+		}
+		event.Id = id
+		userSubscription.PendingAuditEvents = append(userSubscription.PendingAuditEvents, *event)
 	}
 	return userSubscription, nil
 }
@@ -70,53 +253,208 @@ func createDomainUserSubscriptionRecord(us *UserSubscription) (*domain.UserSubsc
 	}
 
 	userSubscription := &domain.UserSubscription{
-		ID:          us.Id.Hex(),
-		CreatedAt:   us.CreatedAt,
-		Email:       us.Email,
-		ProductName: us.ProductName,
-		StartDate:   us.StartDate,
-		EndDate:     us.EndDate,
-		Price:       us.Price,
-		Tax:         us.Tax,
-		Status:      domain.SubscriptionStatus(us.Status),
+		ID:                us.Id.Hex(),
+		Version:           us.Version,
+		CreatedAt:         us.CreatedAt,
+		Email:             us.Email,
+		Country:           us.Country,
+		ProductID:         us.ProductID,
+		ProductName:       us.ProductName,
+		StartDate:         us.StartDate,
+		EndDate:           us.EndDate,
+		Price:             createDomainMoney(us.Price),
+		NetPrice:          createDomainMoney(us.NetPrice),
+		Tax:               createDomainMoney(us.Tax),
+		TaxRate:           us.TaxRate,
+		PriceVersionID:    us.PriceVersionID,
+		Status:            domain.SubscriptionStatus(us.Status),
+		AutoRenew:         us.AutoRenew,
+		CancelAtPeriodEnd: us.CancelAtPeriodEnd,
+		ResumeDate:        us.ResumeDate,
+		NextPaymentRetry:  us.NextPaymentRetry,
+		UniquenessKey:     us.UniquenessKey,
+		PaymentToken:      us.PaymentToken,
+	}
+
+	for _, v := range us.Renewals {
+		userSubscription.Renewals = append(userSubscription.Renewals, domain.Renewal{
+			RenewedAt:      v.RenewedAt,
+			PeriodStart:    v.PeriodStart,
+			PeriodEnd:      v.PeriodEnd,
+			Price:          createDomainMoney(v.Price),
+			PriceVersionID: v.PriceVersionID,
+		})
 	}
 
 	if us.UpdatedAt != nil {
 		userSubscription.UpdatedAt = us.UpdatedAt
 	}
 
-	if us.PauseStartDate != nil {
-		userSubscription.PauseStartDate = us.PauseStartDate
+	for _, v := range us.Pauses {
+		userSubscription.Pauses = append(userSubscription.Pauses, domain.Pause(v))
+	}
+
+	if us.TrialEndDate != nil {
+		userSubscription.TrialEndDate = us.TrialEndDate
+	}
+
+	if us.PendingPlanChange != nil {
+		planChange := createDomainPlanChange(*us.PendingPlanChange)
+		userSubscription.PendingPlanChange = &planChange
+	}
+
+	for _, v := range us.PlanChanges {
+		userSubscription.PlanChanges = append(userSubscription.PlanChanges, createDomainPlanChange(v))
+	}
+
+	for _, v := range us.Payments {
+		userSubscription.Payments = append(userSubscription.Payments, createDomainPayment(v))
+	}
+
+	if us.PendingPayment != nil {
+		payment := createDomainPayment(*us.PendingPayment)
+		userSubscription.PendingPayment = &payment
+	}
+
+	for _, v := range us.PaymentAttempts {
+		userSubscription.PaymentAttempts = append(userSubscription.PaymentAttempts, domain.PaymentAttempt(v))
+	}
+
+	for i := range us.PendingInvoices {
+		userSubscription.PendingInvoices = append(userSubscription.PendingInvoices, *createDomainInvoiceRecord(&us.PendingInvoices[i]))
+	}
+
+	for i := range us.PendingAuditEvents {
+		userSubscription.PendingAuditEvents = append(userSubscription.PendingAuditEvents, *createDomainAuditEventRecord(&us.PendingAuditEvents[i]))
 	}
 
 	return userSubscription, nil
 }
 
-// SaveSubscription create new subscription if not present in the database otherwise update and return the subscription record
+// SaveSubscription create new subscription if its version is 0 otherwise update the subscription if its version is unchanged
+// and return the subscription record with incremented version
+// returns version conflict error if the subscription already exists or its version is changed
+// and duplicate record error if other subscription has the same uniqueness key
 func (m *mongoDetails) SaveSubscription(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+	if us == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	for i := range us.PendingInvoices {
+		if us.PendingInvoices[i].ID == "" {
+			us.PendingInvoices[i].ID = primitive.NewObjectID().Hex()
+		}
+	}
+
+	for i := range us.PendingAuditEvents {
+		if us.PendingAuditEvents[i].ID == "" {
+			us.PendingAuditEvents[i].ID = primitive.NewObjectID().Hex()
+		}
+	}
+
 	userSubscription, err := createDBUserSubscriptionRecord(us)
 	if err != nil {
 		return nil, err
 	}
 
-	var recordID primitive.ObjectID
-	if !userSubscription.Id.IsZero() {
-		recordID = userSubscription.Id
+	if userSubscription.Id.IsZero() {
+		userSubscription.Id = primitive.NewObjectID()
+	}
+	userSubscription.Version = us.Version + 1
+	for i := range userSubscription.PendingInvoices {
+		userSubscription.PendingInvoices[i].SubscriptionID = userSubscription.Id.Hex()
+		us.PendingInvoices[i].SubscriptionID = userSubscription.Id.Hex()
+	}
+	for i := range userSubscription.PendingAuditEvents {
+		userSubscription.PendingAuditEvents[i].SubscriptionID = userSubscription.Id.Hex()
+		us.PendingAuditEvents[i].SubscriptionID = userSubscription.Id.Hex()
+	}
+
+	if us.Version == 0 {
+		_, err = m.UserSubscriptionCollection.InsertOne(ctx, userSubscription)
+		if err != nil {
+			if isUniquenessKeyError(err) {
+				return nil, fmt.Errorf("subscription uniqueness key %v %w", us.UniquenessKey, db.DuplicateRecordErr)
+			}
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, fmt.Errorf("subscription %v %w", userSubscription.Id.Hex(), db.VersionConflictErr)
+			}
+			return nil, err
+		}
 	} else {
-		recordID = primitive.NewObjectID()
+		filter := primitive.M{
+			"_id":     userSubscription.Id,
+			"version": us.Version,
+		}
+		res, err := m.UserSubscriptionCollection.ReplaceOne(ctx, filter, userSubscription)
+		if err != nil {
+			if isUniquenessKeyError(err) {
+				return nil, fmt.Errorf("subscription uniqueness key %v %w", us.UniquenessKey, db.DuplicateRecordErr)
+			}
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, fmt.Errorf("subscription %v version %v %w", userSubscription.Id.Hex(), us.Version, db.VersionConflictErr)
+		}
 	}
-	us.ID = recordID.Hex()
-	filter := primitive.M{
-		"_id": recordID,
+
+	us.ID = userSubscription.Id.Hex()
+	us.Version = userSubscription.Version
+	return us, nil
+}
+
+// RemovePendingInvoices removes the pending invoices with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (m *mongoDetails) RemovePendingInvoices(ctx context.Context, subscriptionID string, invoiceIDs []string) error {
+	return m.removePendingEntries(ctx, "pending_invoices", subscriptionID, invoiceIDs)
+}
+
+// RemovePendingAuditEvents removes the pending audit events with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (m *mongoDetails) RemovePendingAuditEvents(ctx context.Context, subscriptionID string, eventIDs []string) error {
+	return m.removePendingEntries(ctx, "pending_audit_events", subscriptionID, eventIDs)
+}
+
+// removePendingEntries removes the entries with given ids from the array field of the subscription with given id
+// returns record not found error if the subscription does not exist
+func (m *mongoDetails) removePendingEntries(ctx context.Context, field string, subscriptionID string, entryIDs []string) error {
+	idHex, err := primitive.ObjectIDFromHex(subscriptionID)
+	if err != nil {
+		return fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	ids := primitive.A{}
+	for _, v := range entryIDs {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return fmt.Errorf("%v id %w", field, db.InvalidArgErr)
+		}
+		ids = append(ids, id)
 	}
 
-	opts := options.Replace().SetUpsert(true)
-	_, err = m.UserSubscriptionCollection.ReplaceOne(ctx, filter, userSubscription, opts)
+	// the field is unset once all the entries are removed, so the subscription is not matched by the filter
+	update := primitive.A{
+		primitive.M{"$set": primitive.M{field: primitive.M{"$filter": primitive.M{
+			"input": primitive.M{"$ifNull": primitive.A{"$" + field, primitive.A{}}},
+			"cond":  primitive.M{"$not": primitive.A{primitive.M{"$in": primitive.A{"$$this._id", ids}}}},
+		}}}},
+		primitive.M{"$set": primitive.M{field: primitive.M{"$cond": primitive.A{
+			primitive.M{"$eq": primitive.A{primitive.M{"$size": "$" + field}, 0}}, "$$REMOVE", "$" + field,
+		}}}},
+	}
+	res, err := m.UserSubscriptionCollection.UpdateOne(ctx, primitive.M{"_id": idHex}, update)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("subscription %v %w", subscriptionID, db.RecordNotFoundErr)
 	}
+	return nil
+}
 
-	return us, nil
+// isUniquenessKeyError returns true if the error is duplicate key error of the uniqueness_key index
+func isUniquenessKeyError(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), uniquenessKeyIndex)
 }
 
 // GetSubscriptionByID return subscription for given id
@@ -138,3 +476,114 @@ func (m *mongoDetails) GetSubscriptionByID(ctx context.Context, id string) (*dom
 	}
 	return createDomainUserSubscriptionRecord(&record)
 }
+
+// FindSubscriptions returns subscriptions matching the filter sorted by the sort field and ID
+func (m *mongoDetails) FindSubscriptions(ctx context.Context, filter db.SubscriptionFilter) ([]domain.UserSubscription, error) {
+	query := primitive.M{}
+	if filter.Email != "" {
+		query["email"] = filter.Email
+	}
+
+	if filter.ProductID != "" {
+		query["product_id"] = filter.ProductID
+	}
+
+	if filter.HadTrial {
+		query["trial_end_date"] = primitive.M{"$exists": true}
+	}
+
+	if filter.HasPendingInvoices {
+		query["pending_invoices"] = primitive.M{"$exists": true}
+	}
+
+	if filter.HasPendingAuditEvents {
+		query["pending_audit_events"] = primitive.M{"$exists": true}
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := primitive.A{}
+		for _, v := range filter.Statuses {
+			statuses = append(statuses, string(v))
+		}
+		query["status"] = primitive.M{"$in": statuses}
+	}
+
+	endDate := primitive.M{}
+	if filter.EndDateFrom != nil {
+		endDate["$gte"] = *filter.EndDateFrom
+	}
+
+	if filter.EndDateBefore != nil {
+		endDate["$lte"] = *filter.EndDateBefore
+	}
+
+	if len(endDate) > 0 {
+		query["end_date"] = endDate
+	}
+
+	createdAt := primitive.M{}
+	if filter.CreatedFrom != nil {
+		createdAt["$gte"] = *filter.CreatedFrom
+	}
+
+	if filter.CreatedTo != nil {
+		createdAt["$lte"] = *filter.CreatedTo
+	}
+
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	if filter.ResumeDateBefore != nil {
+		query["resume_date"] = primitive.M{"$lte": *filter.ResumeDateBefore}
+	}
+
+	if filter.PaymentRetryBefore != nil {
+		query["next_payment_retry"] = primitive.M{"$lte": *filter.PaymentRetryBefore}
+	}
+
+	sortField, sortOrder, comparison := "end_date", 1, "$gt"
+	if filter.SortBy.IsValid() {
+		sortField = string(filter.SortBy)
+	}
+	if filter.SortDesc {
+		sortOrder, comparison = -1, "$lt"
+	}
+
+	if filter.After != nil {
+		afterID, err := primitive.ObjectIDFromHex(filter.After.ID)
+		if err != nil {
+			return nil, fmt.Errorf("cursor id %w", db.InvalidArgErr)
+		}
+		query["$or"] = primitive.A{
+			primitive.M{sortField: primitive.M{comparison: filter.After.Value}},
+			primitive.M{sortField: filter.After.Value, "_id": primitive.M{comparison: afterID}},
+		}
+	}
+
+	opts := options.Find().SetSort(primitive.D{{Key: sortField, Value: sortOrder}, {Key: "_id", Value: sortOrder}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	cur, err := m.UserSubscriptionCollection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []UserSubscription{}
+	err = cur.All(ctx, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := []domain.UserSubscription{}
+	for i := range records {
+		subscription, err := createDomainUserSubscriptionRecord(&records[i])
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions, nil
+}
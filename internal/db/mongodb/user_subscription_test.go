@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,29 +36,157 @@ func Test_createDBUserSubscriptionRecord(t *testing.T) {
 			name: "should return record for valid input record",
 			args: args{
 				us: &domain.UserSubscription{
-					CreatedAt:      timeNow,
-					Email:          "test@gmail.com",
-					ProductName:    "test product",
-					Status:         domain.SubscriptionStatusActive,
-					StartDate:      timeNow,
-					UpdatedAt:      &timeNow,
-					EndDate:        timeNow,
-					Price:          10.0,
-					Tax:            10.0,
-					PauseStartDate: &timeNow,
+					Version:       2,
+					UniquenessKey: "test@gmail.com/62bb4ecdba3bbe275f8c7788",
+					CreatedAt:     timeNow,
+					Email:         "test@gmail.com",
+					ProductID:     "62bb4ecdba3bbe275f8c7788",
+					ProductName:   "test product",
+					Status:        domain.SubscriptionStatusActive,
+					StartDate:     timeNow,
+					UpdatedAt:     &timeNow,
+					EndDate:       timeNow,
+					Price:         domain.NewMoney(1000, "EUR"),
+					Tax:           domain.NewMoney(1000, "EUR"),
+					Pauses: []domain.Pause{
+						{
+							StartDate: timeNow,
+							EndDate:   &timeNow,
+							Reason:    "holiday",
+							Actor:     "user",
+						},
+					},
+					AutoRenew:         true,
+					CancelAtPeriodEnd: true,
+					TrialEndDate:      &timeNow,
+					PendingPlanChange: &domain.PlanChange{
+						RequestedAt:   timeNow,
+						EffectiveAt:   timeNow,
+						FromProductID: "62bb4ecdba3bbe275f8c7788",
+						ToProductID:   "62bb4ecdba3bbe275f8c7789",
+						Price:         domain.NewMoney(0, "EUR"),
+						Proration:     domain.NewMoney(0, "EUR"),
+					},
+					PlanChanges: []domain.PlanChange{
+						{
+							RequestedAt:   timeNow,
+							EffectiveAt:   timeNow,
+							FromProductID: "62bb4ecdba3bbe275f8c7787",
+							ToProductID:   "62bb4ecdba3bbe275f8c7788",
+							Price:         domain.NewMoney(1000, "EUR"),
+							Proration:     domain.NewMoney(-500, "EUR"),
+						},
+					},
+					Renewals: []domain.Renewal{
+						{
+							RenewedAt:   timeNow,
+							PeriodStart: timeNow,
+							PeriodEnd:   timeNow,
+							Price:       domain.NewMoney(1000, "EUR"),
+						},
+					},
+					PaymentToken: "tok_visa",
+					Payments: []domain.Payment{
+						{
+							AuthorizationID: "fake_auth_1",
+							Type:            domain.PaymentTypeCharge,
+							Reason:          domain.PaymentReasonPurchase,
+							Amount:          domain.NewMoney(1000, "EUR"),
+							CreatedAt:       timeNow,
+						},
+					},
+					PendingPayment: &domain.Payment{
+						AuthorizationID: "fake_auth_2",
+						Type:            domain.PaymentTypeCharge,
+						Reason:          domain.PaymentReasonPurchase,
+						Amount:          domain.NewMoney(1000, "EUR"),
+						CreatedAt:       timeNow,
+					},
+					NextPaymentRetry: &timeNow,
+					PaymentAttempts: []domain.PaymentAttempt{
+						{
+							PeriodStart: timeNow,
+							AttemptedAt: timeNow,
+							Error:       "card declined",
+						},
+					},
 				},
 			},
 			want: &UserSubscription{
-				CreatedAt:      timeNow,
-				Email:          "test@gmail.com",
-				ProductName:    "test product",
-				Status:         string(domain.SubscriptionStatusActive),
-				StartDate:      timeNow,
-				UpdatedAt:      &timeNow,
-				EndDate:        timeNow,
-				Price:          10.0,
-				Tax:            10.0,
-				PauseStartDate: &timeNow,
+				Version:       2,
+				UniquenessKey: "test@gmail.com/62bb4ecdba3bbe275f8c7788",
+				CreatedAt:     timeNow,
+				Email:         "test@gmail.com",
+				ProductID:     "62bb4ecdba3bbe275f8c7788",
+				ProductName:   "test product",
+				Status:        string(domain.SubscriptionStatusActive),
+				StartDate:     timeNow,
+				UpdatedAt:     &timeNow,
+				EndDate:       timeNow,
+				Price:         Money{Amount: 1000, Currency: "EUR"},
+				Tax:           Money{Amount: 1000, Currency: "EUR"},
+				Pauses: []Pause{
+					{
+						StartDate: timeNow,
+						EndDate:   &timeNow,
+						Reason:    "holiday",
+						Actor:     "user",
+					},
+				},
+				AutoRenew:         true,
+				CancelAtPeriodEnd: true,
+				TrialEndDate:      &timeNow,
+				PendingPlanChange: &PlanChange{
+					RequestedAt:   timeNow,
+					EffectiveAt:   timeNow,
+					FromProductID: "62bb4ecdba3bbe275f8c7788",
+					ToProductID:   "62bb4ecdba3bbe275f8c7789",
+					Price:         Money{Amount: 0, Currency: "EUR"},
+					Proration:     Money{Amount: 0, Currency: "EUR"},
+				},
+				PlanChanges: []PlanChange{
+					{
+						RequestedAt:   timeNow,
+						EffectiveAt:   timeNow,
+						FromProductID: "62bb4ecdba3bbe275f8c7787",
+						ToProductID:   "62bb4ecdba3bbe275f8c7788",
+						Price:         Money{Amount: 1000, Currency: "EUR"},
+						Proration:     Money{Amount: -500, Currency: "EUR"},
+					},
+				},
+				Renewals: []Renewal{
+					{
+						RenewedAt:   timeNow,
+						PeriodStart: timeNow,
+						PeriodEnd:   timeNow,
+						Price:       Money{Amount: 1000, Currency: "EUR"},
+					},
+				},
+				PaymentToken: "tok_visa",
+				Payments: []Payment{
+					{
+						AuthorizationID: "fake_auth_1",
+						Type:            "charge",
+						Reason:          "purchase",
+						Amount:          Money{Amount: 1000, Currency: "EUR"},
+						CreatedAt:       timeNow,
+					},
+				},
+				PendingPayment: &Payment{
+					AuthorizationID: "fake_auth_2",
+					Type:            "charge",
+					Reason:          "purchase",
+					Amount:          Money{Amount: 1000, Currency: "EUR"},
+					CreatedAt:       timeNow,
+				},
+				NextPaymentRetry: &timeNow,
+				PaymentAttempts: []PaymentAttempt{
+					{
+						PeriodStart: timeNow,
+						AttemptedAt: timeNow,
+						Error:       "card declined",
+					},
+				},
 			},
 			wantErr: false,
 		},
@@ -101,31 +230,109 @@ func Test_createDomainUserSubscriptionRecord(t *testing.T) {
 			name: "should return record for valid input record",
 			args: args{
 				us: &UserSubscription{
-					Id:             idHex,
-					CreatedAt:      timeNow,
-					Email:          "test@gmail.com",
-					ProductName:    "test product",
-					Status:         string(domain.SubscriptionStatusActive),
-					StartDate:      timeNow,
-					UpdatedAt:      &timeNow,
-					EndDate:        timeNow,
-					Price:          10.0,
-					Tax:            10.0,
-					PauseStartDate: &timeNow,
+					Id:            idHex,
+					Version:       2,
+					UniquenessKey: "test@gmail.com/62bb4ecdba3bbe275f8c7788",
+					CreatedAt:     timeNow,
+					Email:         "test@gmail.com",
+					ProductID:     "62bb4ecdba3bbe275f8c7788",
+					ProductName:   "test product",
+					Status:        string(domain.SubscriptionStatusActive),
+					StartDate:     timeNow,
+					UpdatedAt:     &timeNow,
+					EndDate:       timeNow,
+					Price:         Money{Amount: 1000, Currency: "EUR"},
+					Tax:           Money{Amount: 1000, Currency: "EUR"},
+					Pauses: []Pause{
+						{
+							StartDate: timeNow,
+							EndDate:   &timeNow,
+							Reason:    "holiday",
+							Actor:     "user",
+						},
+					},
+					AutoRenew:         true,
+					CancelAtPeriodEnd: true,
+					TrialEndDate:      &timeNow,
+					PendingPlanChange: &PlanChange{
+						RequestedAt:   timeNow,
+						EffectiveAt:   timeNow,
+						FromProductID: "62bb4ecdba3bbe275f8c7788",
+						ToProductID:   "62bb4ecdba3bbe275f8c7789",
+						Price:         Money{Amount: 0, Currency: "EUR"},
+						Proration:     Money{Amount: 0, Currency: "EUR"},
+					},
+					PlanChanges: []PlanChange{
+						{
+							RequestedAt:   timeNow,
+							EffectiveAt:   timeNow,
+							FromProductID: "62bb4ecdba3bbe275f8c7787",
+							ToProductID:   "62bb4ecdba3bbe275f8c7788",
+							Price:         Money{Amount: 1000, Currency: "EUR"},
+							Proration:     Money{Amount: -500, Currency: "EUR"},
+						},
+					},
+					Renewals: []Renewal{
+						{
+							RenewedAt:   timeNow,
+							PeriodStart: timeNow,
+							PeriodEnd:   timeNow,
+							Price:       Money{Amount: 1000, Currency: "EUR"},
+						},
+					},
 				},
 			},
 			want: &domain.UserSubscription{
-				ID:             idHex.Hex(),
-				CreatedAt:      timeNow,
-				Email:          "test@gmail.com",
-				ProductName:    "test product",
-				Status:         domain.SubscriptionStatusActive,
-				StartDate:      timeNow,
-				UpdatedAt:      &timeNow,
-				EndDate:        timeNow,
-				Price:          10.0,
-				Tax:            10.0,
-				PauseStartDate: &timeNow,
+				ID:            idHex.Hex(),
+				Version:       2,
+				UniquenessKey: "test@gmail.com/62bb4ecdba3bbe275f8c7788",
+				CreatedAt:     timeNow,
+				Email:         "test@gmail.com",
+				ProductID:     "62bb4ecdba3bbe275f8c7788",
+				ProductName:   "test product",
+				Status:        domain.SubscriptionStatusActive,
+				StartDate:     timeNow,
+				UpdatedAt:     &timeNow,
+				EndDate:       timeNow,
+				Price:         domain.NewMoney(1000, "EUR"),
+				Tax:           domain.NewMoney(1000, "EUR"),
+				Pauses: []domain.Pause{
+					{
+						StartDate: timeNow,
+						EndDate:   &timeNow,
+						Reason:    "holiday",
+						Actor:     "user",
+					},
+				},
+				AutoRenew:         true,
+				CancelAtPeriodEnd: true,
+				TrialEndDate:      &timeNow,
+				PendingPlanChange: &domain.PlanChange{
+					RequestedAt:   timeNow,
+					EffectiveAt:   timeNow,
+					FromProductID: "62bb4ecdba3bbe275f8c7788",
+					ToProductID:   "62bb4ecdba3bbe275f8c7789",
+					Price:         domain.NewMoney(0, "EUR"),
+					Proration:     domain.NewMoney(0, "EUR"),
+				},
+				PlanChanges: []domain.PlanChange{
+					{
+						RequestedAt:   timeNow,
+						EffectiveAt:   timeNow,
+						FromProductID: "62bb4ecdba3bbe275f8c7787",
+						ToProductID:   "62bb4ecdba3bbe275f8c7788",
+						Price:         domain.NewMoney(1000, "EUR"),
+						Proration:     domain.NewMoney(-500, "EUR"),
+					},
+				},
+				Renewals: []domain.Renewal{
+					{
+						RenewedAt:   timeNow,
+						PeriodStart: timeNow,
+						PeriodEnd:   timeNow,
+						Price:       domain.NewMoney(1000, "EUR"),
+					},
+				},
 			},
 			wantErr: false,
 		},
@@ -156,17 +363,24 @@ func (suite *MongoTestSuite) TestSaveSubscription() {
 	idHex := primitive.NewObjectID()
 
 	us := &domain.UserSubscription{
-		ID:             idHex.Hex(),
-		CreatedAt:      timeNow,
-		Email:          "test@gmail.com",
-		ProductName:    "test product",
-		Status:         domain.SubscriptionStatusActive,
-		StartDate:      timeNow,
-		UpdatedAt:      &timeNow,
-		EndDate:        timeNow,
-		Price:          10.0,
-		Tax:            10.0,
-		PauseStartDate: &timeNow,
+		ID:          idHex.Hex(),
+		CreatedAt:   timeNow,
+		Email:       "test@gmail.com",
+		ProductName: "test product",
+		Status:      domain.SubscriptionStatusActive,
+		StartDate:   timeNow,
+		UpdatedAt:   &timeNow,
+		EndDate:     timeNow,
+		Price:       domain.NewMoney(1000, "EUR"),
+		Tax:         domain.NewMoney(1000, "EUR"),
+		Pauses: []domain.Pause{
+			{
+				StartDate: timeNow,
+				EndDate:   &timeNow,
+				Reason:    "holiday",
+				Actor:     "user",
+			},
+		},
 	}
 
 	type fields struct {
@@ -249,17 +463,24 @@ func (suite *MongoTestSuite) TestGetSubscriptionByID() {
 	idHex := primitive.NewObjectID()
 
 	us := &domain.UserSubscription{
-		ID:             idHex.Hex(),
-		CreatedAt:      timeNow,
-		Email:          "test@gmail.com",
-		ProductName:    "test product",
-		Status:         domain.SubscriptionStatusActive,
-		StartDate:      timeNow,
-		UpdatedAt:      &timeNow,
-		EndDate:        timeNow,
-		Price:          10.0,
-		Tax:            10.0,
-		PauseStartDate: &timeNow,
+		ID:          idHex.Hex(),
+		CreatedAt:   timeNow,
+		Email:       "test@gmail.com",
+		ProductName: "test product",
+		Status:      domain.SubscriptionStatusActive,
+		StartDate:   timeNow,
+		UpdatedAt:   &timeNow,
+		EndDate:     timeNow,
+		Price:       domain.NewMoney(1000, "EUR"),
+		Tax:         domain.NewMoney(1000, "EUR"),
+		Pauses: []domain.Pause{
+			{
+				StartDate: timeNow,
+				EndDate:   &timeNow,
+				Reason:    "holiday",
+				Actor:     "user",
+			},
+		},
 	}
 
 	m := &mongoDetails{
@@ -337,3 +558,105 @@ func (suite *MongoTestSuite) TestGetSubscriptionByID() {
 		})
 	}
 }
+
+func (suite *MongoTestSuite) TestFindSubscriptions() {
+	mgoC := suite.TestContainer
+	t := suite.T()
+	client, err := connect(fmt.Sprintf("mongodb://%s:%s", mgoC.Ip, mgoC.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbName := "testdb"
+	timeNow := time.Now().UTC()
+
+	m := &mongoDetails{
+		client:                     client,
+		dbName:                     dbName,
+		UserSubscriptionCollection: client.Database(dbName).Collection(userSubscriptionCollection),
+	}
+
+	dueSubscription, err := m.SaveSubscription(context.Background(), &domain.UserSubscription{
+		Email:   "due@gmail.com",
+		Status:  domain.SubscriptionStatusActive,
+		EndDate: timeNow.Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.SaveSubscription(context.Background(), &domain.UserSubscription{
+		Email:   "notdue@gmail.com",
+		Status:  domain.SubscriptionStatusActive,
+		EndDate: timeNow.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.SaveSubscription(context.Background(), &domain.UserSubscription{
+		Email:   "cancelled@gmail.com",
+		Status:  domain.SubscriptionStatusCancelled,
+		EndDate: timeNow.Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumeDate := timeNow.Add(-time.Minute)
+	resumeSubscription, err := m.SaveSubscription(context.Background(), &domain.UserSubscription{
+		Email:      "resume@gmail.com",
+		Status:     domain.SubscriptionStatusPaused,
+		EndDate:    timeNow.Add(2 * time.Hour),
+		ResumeDate: &resumeDate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filter  db.SubscriptionFilter
+		wantIDs []string
+		wantErr bool
+	}{
+		{
+			name: "should return active subscriptions with end date before given time",
+			filter: db.SubscriptionFilter{
+				Statuses:      []domain.SubscriptionStatus{domain.SubscriptionStatusActive},
+				EndDateBefore: &timeNow,
+			},
+			wantIDs: []string{dueSubscription.ID},
+			wantErr: false,
+		},
+		{
+			name: "should return paused subscriptions with resume date before given time",
+			filter: db.SubscriptionFilter{
+				Statuses:         []domain.SubscriptionStatus{domain.SubscriptionStatusPaused},
+				ResumeDateBefore: &timeNow,
+			},
+			wantIDs: []string{resumeSubscription.ID},
+			wantErr: false,
+		},
+		{
+			name: "should return limited number of subscriptions",
+			filter: db.SubscriptionFilter{
+				Limit: 1,
+			},
+			wantIDs: []string{dueSubscription.ID},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.FindSubscriptions(context.Background(), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("mongoDetails.FindSubscriptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			gotIDs := []string{}
+			for _, v := range got {
+				gotIDs = append(gotIDs, v.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("mongoDetails.FindSubscriptions() = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/sqldb"
+	"github.com/lib/pq"
+)
+
+// NewPostgresDB created new postgres db instance, returns error if input is invalid or db is not reachable
+// the schema is created by the migration files in migration/postgres
+func NewPostgresDB(uri string) (db.DB, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("NewPostgresDB: empty uri %w", db.EmptyArgErr)
+	}
+
+	client, err := connect(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.New(client, postgresDialect{}), nil
+}
+
+// connect opens postgres db connection pool and checks the connection, returns error if fails
+func connect(uri string) (*sql.DB, error) {
+	client, err := sql.Open("postgres", uri)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = client.PingContext(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// postgresDialect is the sqldb dialect of postgres, times are stored as TIMESTAMPTZ and JSON columns as JSONB
+type postgresDialect struct{}
+
+// Rebind returns the query as it is, postgres uses numbered $1 placeholders
+func (postgresDialect) Rebind(query string) string {
+	return query
+}
+
+// TimeValue returns time column query argument
+func (postgresDialect) TimeValue(t time.Time) interface{} {
+	return t.UTC()
+}
+
+// TimeColumn returns the scanner of time column
+func (postgresDialect) TimeColumn(dest *time.Time) sql.Scanner {
+	return timeColumn{dest: dest}
+}
+
+// IsUniqueViolation returns true if the error is violation of unique constraint
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// EffectivePriceColumns returns effective price columns of the product row
+func (postgresDialect) EffectivePriceColumns(at string) string {
+	return `COALESCE((SELECT (v->'price'->>'amount')::BIGINT FROM jsonb_array_elements(price_versions) v
+		WHERE (v->>'effective_from')::TIMESTAMPTZ <= ` + at + ` ORDER BY (v->>'effective_from')::TIMESTAMPTZ DESC LIMIT 1), price_amount) AS effective_price_amount,
+	COALESCE((SELECT v->'price'->>'currency' FROM jsonb_array_elements(price_versions) v
+		WHERE (v->>'effective_from')::TIMESTAMPTZ <= ` + at + ` ORDER BY (v->>'effective_from')::TIMESTAMPTZ DESC LIMIT 1), price_currency) AS effective_price_currency`
+}
+
+// Contains returns the condition which is true if the text contains the substring ignoring the case
+func (postgresDialect) Contains(text string, substring string) string {
+	return `strpos(lower(` + text + `), lower(` + substring + `)) > 0`
+}
+
+// RemoveJSONEntries returns the JSON array of the column without the entries with the ids,
+// NULL once all of them are removed as aggregate of no rows is NULL
+func (postgresDialect) RemoveJSONEntries(column string, ids string) string {
+	return `(SELECT jsonb_agg(entry.value ORDER BY entry.position)
+		FROM jsonb_array_elements(` + column + `) WITH ORDINALITY AS entry(value, position)
+		WHERE entry.value->>'id' NOT IN (SELECT jsonb_array_elements_text(` + ids + `::jsonb)))`
+}
+
+// timeColumn scans TIMESTAMPTZ column into the time in UTC
+type timeColumn struct {
+	dest *time.Time
+}
+
+// Scan implements sql.Scanner
+func (c timeColumn) Scan(src interface{}) error {
+	t, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("unexpected time column value %v", src)
+	}
+	*c.dest = t.UTC()
+	return nil
+}
@@ -0,0 +1,107 @@
+package sqldb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// auditEventColumns are the columns of the subscription_audit record
+const auditEventColumns = `id, subscription_id, action, previous_status, new_status, actor, request_id, created_at`
+
+// PendingAuditEvent represent JSON pending audit event entry of the user_subscription record
+type PendingAuditEvent struct {
+	ID             string    `json:"id"`
+	Action         string    `json:"action"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	NewStatus      string    `json:"new_status"`
+	Actor          string    `json:"actor"`
+	RequestID      string    `json:"request_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// createDBPendingAuditEvent creates db pending audit event from domain audit event
+func createDBPendingAuditEvent(e domain.AuditEvent) PendingAuditEvent {
+	return PendingAuditEvent{
+		ID:             e.ID,
+		Action:         string(e.Action),
+		PreviousStatus: string(e.PreviousStatus),
+		NewStatus:      string(e.NewStatus),
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		CreatedAt:      e.CreatedAt,
+	}
+}
+
+// createDomainPendingAuditEvent creates domain audit event of the subscription with given id from db pending audit event
+func createDomainPendingAuditEvent(subscriptionID string, e PendingAuditEvent) domain.AuditEvent {
+	return domain.AuditEvent{
+		ID:             e.ID,
+		SubscriptionID: subscriptionID,
+		Action:         domain.AuditAction(e.Action),
+		PreviousStatus: domain.SubscriptionStatus(e.PreviousStatus),
+		NewStatus:      domain.SubscriptionStatus(e.NewStatus),
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		CreatedAt:      e.CreatedAt.UTC(),
+	}
+}
+
+// scanAuditEvent scans audit event record from the row with audit event columns
+func (s *sqlDetails) scanAuditEvent(row interface{ Scan(...interface{}) error }) (*domain.AuditEvent, error) {
+	event := domain.AuditEvent{}
+	err := row.Scan(&event.ID, &event.SubscriptionID, &event.Action, &event.PreviousStatus, &event.NewStatus,
+		&event.Actor, &event.RequestID, s.timeColumn(&event.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// SaveAuditEvent appends the audit event to the audit log and returns it with the record ID
+// audit events are never updated, the stored event is returned if the event with the ID is already inserted
+func (s *sqlDetails) SaveAuditEvent(ctx context.Context, event *domain.AuditEvent) (*domain.AuditEvent, error) {
+	id := event.ID
+	if id == "" {
+		id = newID()
+	}
+
+	res, err := s.exec(ctx, `INSERT INTO subscription_audit (`+auditEventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id) DO NOTHING`,
+		id, event.SubscriptionID, event.Action, event.PreviousStatus, event.NewStatus, event.Actor, event.RequestID, s.timeValue(event.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return s.scanAuditEvent(s.queryRow(ctx, `SELECT `+auditEventColumns+` FROM subscription_audit WHERE id = $1`, id))
+	}
+
+	event.ID = id
+	return event, nil
+}
+
+// GetAuditEvents returns audit events of the subscription with given id sorted by creation time
+func (s *sqlDetails) GetAuditEvents(ctx context.Context, subscriptionID string) ([]domain.AuditEvent, error) {
+	rows, err := s.query(ctx, `SELECT `+auditEventColumns+`
+		FROM subscription_audit WHERE subscription_id = $1 ORDER BY created_at, id`, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		event, err := s.scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,110 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// CreateIdempotencyRecord stores the record of the request in progress
+// returns duplicate record error if unexpired record with the key exists, expired record is replaced
+// expired records of the other keys are deleted
+func (s *sqlDetails) CreateIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil || record.Key == "" {
+		return db.InvalidArgErr
+	}
+
+	header, err := marshalJSON(record.Header, len(record.Header) == 0)
+	if err != nil {
+		return err
+	}
+
+	timeNow := s.timeValue(time.Now().UTC())
+	_, err = s.exec(ctx, `DELETE FROM idempotency_key WHERE expires_at <= $1 AND key <> $2`, timeNow, record.Key)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.exec(ctx, `INSERT INTO idempotency_key
+		(key, fingerprint, status_code, header, body, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE SET fingerprint = excluded.fingerprint, status_code = excluded.status_code,
+		header = excluded.header, body = excluded.body, created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_key.expires_at <= $8`,
+		record.Key, record.Fingerprint, record.StatusCode, jsonValue(header), nullBytesValue(record.Body),
+		s.timeValue(record.CreatedAt), s.timeValue(record.ExpiresAt), timeNow)
+	if err != nil {
+		return err
+	}
+
+	// unexpired record with the key exists, nothing is inserted or updated
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("idempotency key %v %w", record.Key, db.DuplicateRecordErr)
+	}
+	return nil
+}
+
+// GetIdempotencyRecord returns unexpired record with given key
+func (s *sqlDetails) GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	record := &domain.IdempotencyRecord{}
+	var header []byte
+	err := s.queryRow(ctx, `SELECT key, fingerprint, status_code, header, body, created_at, expires_at
+		FROM idempotency_key WHERE key = $1 AND expires_at > $2`, key, s.timeValue(time.Now().UTC())).Scan(
+		&record.Key, &record.Fingerprint, &record.StatusCode, &header, &record.Body,
+		s.timeColumn(&record.CreatedAt), s.timeColumn(&record.ExpiresAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.RecordNotFoundErr
+		}
+		return nil, err
+	}
+
+	err = unmarshalJSON(header, &record.Header)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyRecord stores the response and expiry of the in-progress record with the key
+// returns record not found error if there is no in-progress record with the key
+func (s *sqlDetails) CompleteIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	if record == nil {
+		return db.InvalidArgErr
+	}
+
+	header, err := marshalJSON(record.Header, len(record.Header) == 0)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.exec(ctx, `UPDATE idempotency_key SET status_code = $2, header = $3, body = $4, expires_at = $5
+		WHERE key = $1 AND status_code = 0`,
+		record.Key, record.StatusCode, jsonValue(header), nullBytesValue(record.Body), s.timeValue(record.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return db.RecordNotFoundErr
+	}
+	return nil
+}
+
+// DeleteIdempotencyRecord deletes the record with given key if it exists
+func (s *sqlDetails) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	_, err := s.exec(ctx, `DELETE FROM idempotency_key WHERE key = $1`, key)
+	return err
+}
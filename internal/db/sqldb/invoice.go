@@ -0,0 +1,226 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// invoiceNumberAttempts is number of attempts to insert the invoice with the next sequence of the year,
+// the sequence is taken by concurrent insert if the attempt fails
+const invoiceNumberAttempts = 5
+
+const invoiceColumns = `id, type, number, year, sequence, subscription_id, email, country, authorization_id, reason, lines,
+	net_amount, net_currency, tax_amount, tax_currency, total_amount, total_currency, issued_at`
+
+// InvoiceLine represent JSON line item entry of the invoice record
+type InvoiceLine struct {
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Net         Money     `json:"net"`
+	Tax         Money     `json:"tax"`
+	Gross       Money     `json:"gross"`
+	TaxRate     float64   `json:"tax_rate"`
+}
+
+// PendingInvoice represent JSON pending invoice entry of the user_subscription record
+type PendingInvoice struct {
+	ID              string        `json:"id"`
+	Type            string        `json:"type"`
+	Email           string        `json:"email"`
+	Country         string        `json:"country,omitempty"`
+	AuthorizationID string        `json:"authorization_id,omitempty"`
+	Reason          string        `json:"reason,omitempty"`
+	Lines           []InvoiceLine `json:"lines"`
+	Net             Money         `json:"net"`
+	Tax             Money         `json:"tax"`
+	Total           Money         `json:"total"`
+	IssuedAt        time.Time     `json:"issued_at"`
+}
+
+// createDBInvoiceLines creates db invoice lines from domain invoice lines
+func createDBInvoiceLines(lines []domain.InvoiceLine) []InvoiceLine {
+	lineRecords := []InvoiceLine{}
+	for _, v := range lines {
+		lineRecords = append(lineRecords, InvoiceLine{
+			ProductID:   v.ProductID,
+			ProductName: v.ProductName,
+			PeriodStart: v.PeriodStart,
+			PeriodEnd:   v.PeriodEnd,
+			Net:         createDBMoney(v.Net),
+			Tax:         createDBMoney(v.Tax),
+			Gross:       createDBMoney(v.Gross),
+			TaxRate:     v.TaxRate,
+		})
+	}
+	return lineRecords
+}
+
+// createDomainInvoiceLines creates domain invoice lines from db invoice lines
+func createDomainInvoiceLines(lineRecords []InvoiceLine) []domain.InvoiceLine {
+	var lines []domain.InvoiceLine
+	for _, v := range lineRecords {
+		lines = append(lines, domain.InvoiceLine{
+			ProductID:   v.ProductID,
+			ProductName: v.ProductName,
+			PeriodStart: v.PeriodStart.UTC(),
+			PeriodEnd:   v.PeriodEnd.UTC(),
+			Net:         createDomainMoney(v.Net),
+			Tax:         createDomainMoney(v.Tax),
+			Gross:       createDomainMoney(v.Gross),
+			TaxRate:     v.TaxRate,
+		})
+	}
+	return lines
+}
+
+// createDBPendingInvoice creates db PendingInvoice from domain invoice
+func createDBPendingInvoice(i domain.Invoice) PendingInvoice {
+	return PendingInvoice{
+		ID:              i.ID,
+		Type:            string(i.Type),
+		Email:           i.Email,
+		Country:         i.Country,
+		AuthorizationID: i.AuthorizationID,
+		Reason:          string(i.Reason),
+		Lines:           createDBInvoiceLines(i.Lines),
+		Net:             createDBMoney(i.Net),
+		Tax:             createDBMoney(i.Tax),
+		Total:           createDBMoney(i.Total),
+		IssuedAt:        i.IssuedAt,
+	}
+}
+
+// createDomainPendingInvoice creates domain invoice of the subscription with given id from db pending invoice
+func createDomainPendingInvoice(subscriptionID string, i PendingInvoice) domain.Invoice {
+	return domain.Invoice{
+		ID:              i.ID,
+		Type:            domain.InvoiceType(i.Type),
+		SubscriptionID:  subscriptionID,
+		Email:           i.Email,
+		Country:         i.Country,
+		AuthorizationID: i.AuthorizationID,
+		Reason:          domain.PaymentReason(i.Reason),
+		Lines:           createDomainInvoiceLines(i.Lines),
+		Net:             createDomainMoney(i.Net),
+		Tax:             createDomainMoney(i.Tax),
+		Total:           createDomainMoney(i.Total),
+		IssuedAt:        i.IssuedAt.UTC(),
+	}
+}
+
+// scanInvoice scans invoice record from the row with invoice columns
+func (s *sqlDetails) scanInvoice(row interface{ Scan(...interface{}) error }) (*domain.Invoice, error) {
+	invoice := domain.Invoice{}
+	var lines []byte
+	err := row.Scan(&invoice.ID, &invoice.Type, &invoice.Number, &invoice.Year, &invoice.Sequence, &invoice.SubscriptionID, &invoice.Email,
+		&invoice.Country, &invoice.AuthorizationID, &invoice.Reason, &lines, &invoice.Net.Amount, &invoice.Net.Currency,
+		&invoice.Tax.Amount, &invoice.Tax.Currency, &invoice.Total.Amount, &invoice.Total.Currency, s.timeColumn(&invoice.IssuedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	lineRecords := []InvoiceLine{}
+	err = unmarshalJSON(lines, &lineRecords)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Lines = createDomainInvoiceLines(lineRecords)
+	return &invoice, nil
+}
+
+// SaveInvoice inserts the invoice with the next sequence of the year it is issued in and returns it with the record ID
+// the insert is retried with the next sequence if concurrent insert took the sequence, invoices are never updated
+// the stored invoice is returned if the invoice with the ID is already inserted
+func (s *sqlDetails) SaveInvoice(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
+	if invoice == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	lines, err := marshalJSON(createDBInvoiceLines(invoice.Lines), false)
+	if err != nil {
+		return nil, err
+	}
+
+	id := invoice.ID
+	if id == "" {
+		id = newID()
+	}
+	year := invoice.IssuedAt.UTC().Year()
+	for i := 0; i < invoiceNumberAttempts; i++ {
+		// the invoice is inserted by the previous issue or concurrently
+		row := s.queryRow(ctx, `SELECT `+invoiceColumns+` FROM invoice WHERE id = $1`, id)
+		stored, err := s.scanInvoice(row)
+		if err == nil {
+			return stored, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		var sequence int64
+		err = s.queryRow(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM invoice WHERE year = $1`, year).Scan(&sequence)
+		if err != nil {
+			return nil, err
+		}
+
+		sequence++
+		_, err = s.exec(ctx, `INSERT INTO invoice (`+invoiceColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+			id, invoice.Type, domain.InvoiceNumber(year, sequence), year, sequence, invoice.SubscriptionID, invoice.Email, invoice.Country,
+			invoice.AuthorizationID, invoice.Reason, jsonValue(lines), invoice.Net.Amount, invoice.Net.Currency,
+			invoice.Tax.Amount, invoice.Tax.Currency, invoice.Total.Amount, invoice.Total.Currency, s.timeValue(invoice.IssuedAt))
+		if s.dialect.IsUniqueViolation(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.ID = id
+		invoice.SetNumber(sequence)
+		return invoice, nil
+	}
+	return nil, fmt.Errorf("invoice number of year %v %w", year, db.DuplicateRecordErr)
+}
+
+// GetInvoices returns invoices of the subscription with given id sorted by issue time
+func (s *sqlDetails) GetInvoices(ctx context.Context, subscriptionID string) ([]domain.Invoice, error) {
+	rows, err := s.query(ctx, `SELECT `+invoiceColumns+`
+		FROM invoice WHERE subscription_id = $1 ORDER BY issued_at, year, sequence`, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []domain.Invoice{}
+	for rows.Next() {
+		invoice, err := s.scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	return invoices, rows.Err()
+}
+
+// GetInvoiceByNumber returns invoice with given number, returns record not found error if it does not exist
+func (s *sqlDetails) GetInvoiceByNumber(ctx context.Context, number string) (*domain.Invoice, error) {
+	if number == "" {
+		return nil, db.InvalidArgErr
+	}
+
+	row := s.queryRow(ctx, `SELECT `+invoiceColumns+` FROM invoice WHERE number = $1`, number)
+	invoice, err := s.scanInvoice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("invoice %v %w", number, db.RecordNotFoundErr)
+	}
+	return invoice, err
+}
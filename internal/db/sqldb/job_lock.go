@@ -0,0 +1,31 @@
+package sqldb
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireLock acquires named lock for the owner until ttl expires
+// returns false if the lock is held by other owner
+func (s *sqlDetails) AcquireLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	timeNow := time.Now().UTC()
+	res, err := s.exec(ctx, `INSERT INTO job_lock (name, owner, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE job_lock.owner = excluded.owner OR job_lock.expires_at <= $4`, name, owner, s.timeValue(timeNow.Add(ttl)), s.timeValue(timeNow))
+	if err != nil {
+		return false, err
+	}
+
+	// lock is held by other owner, nothing is inserted or updated
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ReleaseLock releases named lock if it is held by the owner
+func (s *sqlDetails) ReleaseLock(ctx context.Context, name string, owner string) error {
+	_, err := s.exec(ctx, `DELETE FROM job_lock WHERE name = $1 AND owner = $2`, name, owner)
+	return err
+}
@@ -0,0 +1,316 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// Money represent JSON money value, amount is stored in minor units
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// ProductPrice represent price list entry of the product record
+type ProductPrice struct {
+	Country string `json:"country,omitempty"`
+	Price   Money  `json:"price"`
+}
+
+// PriceVersion represent JSON price version entry of the product record
+type PriceVersion struct {
+	ID            string         `json:"id"`
+	EffectiveFrom time.Time      `json:"effective_from"`
+	Price         Money          `json:"price"`
+	Prices        []ProductPrice `json:"prices,omitempty"`
+}
+
+// createDBMoney creates db money record from domain money
+func createDBMoney(m domain.Money) Money {
+	return Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+// createDomainMoney creates domain money from db money record
+func createDomainMoney(m Money) domain.Money {
+	return domain.NewMoney(m.Amount, m.Currency)
+}
+
+const productColumns = `id, name, subscription_period, trial_days, max_pause_days, max_pauses_per_period,
+	price_amount, price_currency, prices, tax_category, tax_inclusive, archived_at, price_versions`
+
+// scanProduct scans product row into domain product
+func (s *sqlDetails) scanProduct(row interface{ Scan(...interface{}) error }) (*domain.Product, error) {
+	product := &domain.Product{}
+	var prices, priceVersions []byte
+	err := row.Scan(&product.ID, &product.Name, &product.SubscriptionPeriod, &product.TrialDays, &product.MaxPauseDays,
+		&product.MaxPausesPerPeriod, &product.Price.Amount, &product.Price.Currency, &prices, &product.TaxCategory, &product.TaxInclusive,
+		s.nullTimeColumn(&product.ArchivedAt), &priceVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	priceRecords := []ProductPrice{}
+	err = unmarshalJSON(prices, &priceRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Prices = createDomainPrices(priceRecords)
+
+	versionRecords := []PriceVersion{}
+	err = unmarshalJSON(priceVersions, &versionRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versionRecords {
+		product.PriceVersions = append(product.PriceVersions, domain.PriceVersion{
+			ID:            v.ID,
+			EffectiveFrom: v.EffectiveFrom.UTC(),
+			Price:         createDomainMoney(v.Price),
+			Prices:        createDomainPrices(v.Prices),
+		})
+	}
+	return product, nil
+}
+
+// createDomainPrices creates domain price list from JSON price list
+func createDomainPrices(p []ProductPrice) []domain.ProductPrice {
+	var prices []domain.ProductPrice
+	for _, v := range p {
+		prices = append(prices, domain.ProductPrice{
+			Country: v.Country,
+			Price:   createDomainMoney(v.Price),
+		})
+	}
+	return prices
+}
+
+// createDBPrices creates JSON price list from domain price list
+func createDBPrices(p []domain.ProductPrice) []ProductPrice {
+	prices := []ProductPrice{}
+	for _, v := range p {
+		prices = append(prices, ProductPrice{
+			Country: v.Country,
+			Price:   createDBMoney(v.Price),
+		})
+	}
+	return prices
+}
+
+// GetProduct returns product for given id, if id is not given, will return all the products
+func (s *sqlDetails) GetProduct(ctx context.Context, id string) ([]domain.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM product`
+	args := []interface{}{}
+	if id != "" {
+		if !isValidID(id) {
+			return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+		}
+		query += ` WHERE id = $1`
+		args = append(args, id)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []domain.Product{}
+	for rows.Next() {
+		product, err := s.scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, rows.Err()
+}
+
+// productSource returns the product table with default price of the price version effective at $1 as effective price columns
+func (s *sqlDetails) productSource() string {
+	return `(SELECT product.*, ` + s.dialect.EffectivePriceColumns("$1") + ` FROM product) product`
+}
+
+// productConditions returns SQL conditions of the product source and their arguments for the product filter except the cursor
+func (s *sqlDetails) productConditions(filter *db.ProductFilter) ([]string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{s.timeValue(filter.PriceAt)}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Name != "" {
+		addCondition(s.dialect.Contains("name", "$%d"), filter.Name)
+	}
+
+	if filter.SubscriptionPeriod != 0 {
+		addCondition("subscription_period = $%d", filter.SubscriptionPeriod)
+	}
+
+	if filter.MinPrice != nil {
+		addCondition("effective_price_currency = $%d", filter.MinPrice.Currency)
+		addCondition("effective_price_amount >= $%d", filter.MinPrice.Amount)
+	}
+
+	if filter.MaxPrice != nil {
+		addCondition("effective_price_currency = $%d", filter.MaxPrice.Currency)
+		addCondition("effective_price_amount <= $%d", filter.MaxPrice.Amount)
+	}
+
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+	return conditions, args
+}
+
+// FindProducts returns products matching the filter sorted by the sort field and ID
+func (s *sqlDetails) FindProducts(ctx context.Context, filter db.ProductFilter) ([]domain.Product, error) {
+	conditions, args := s.productConditions(&filter)
+
+	sortColumn, sortOrder, comparison := "name", "", ">"
+	switch filter.SortBy {
+	case domain.ProductSortByPrice:
+		sortColumn = "effective_price_amount"
+	case domain.ProductSortBySubscriptionPeriod:
+		sortColumn = "subscription_period"
+	}
+	if filter.SortDesc {
+		sortOrder, comparison = " DESC", "<"
+	}
+
+	if filter.After != nil {
+		var value interface{}
+		switch filter.SortBy {
+		case domain.ProductSortByPrice:
+			value = filter.After.PriceAmount
+		case domain.ProductSortBySubscriptionPeriod:
+			value = filter.After.SubscriptionPeriod
+		default:
+			value = filter.After.Name
+		}
+		args = append(args, value, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%[1]v %[2]v $%[3]d OR (%[1]v = $%[3]d AND id %[2]v $%[4]d))",
+			sortColumn, comparison, len(args)-1, len(args)))
+	}
+
+	query := `SELECT ` + productColumns + ` FROM ` + s.productSource()
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY ` + sortColumn + sortOrder + `, id` + sortOrder
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []domain.Product{}
+	for rows.Next() {
+		product, err := s.scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, rows.Err()
+}
+
+// SaveProduct inserts the product without ID, otherwise updates the product, price version without ID gets new ID
+func (s *sqlDetails) SaveProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	if product == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	id := product.ID
+	if id == "" {
+		id = newID()
+	} else if !isValidID(id) {
+		return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	priceRecords := createDBPrices(product.Prices)
+	prices, err := marshalJSON(priceRecords, len(priceRecords) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	versionRecords := []PriceVersion{}
+	for i := range product.PriceVersions {
+		if product.PriceVersions[i].ID == "" {
+			product.PriceVersions[i].ID = newID()
+		}
+		v := product.PriceVersions[i]
+		versionRecords = append(versionRecords, PriceVersion{
+			ID:            v.ID,
+			EffectiveFrom: v.EffectiveFrom,
+			Price:         createDBMoney(v.Price),
+			Prices:        createDBPrices(v.Prices),
+		})
+	}
+	priceVersions, err := marshalJSON(versionRecords, len(versionRecords) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{
+		id, product.Name, product.SubscriptionPeriod, product.TrialDays, product.MaxPauseDays, product.MaxPausesPerPeriod,
+		product.Price.Amount, product.Price.Currency, jsonValue(prices), product.TaxCategory, product.TaxInclusive,
+		s.nullTimeValue(product.ArchivedAt), jsonValue(priceVersions),
+	}
+
+	if product.ID == "" {
+		_, err = s.exec(ctx, `INSERT INTO product (`+productColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`, args...)
+		if err != nil {
+			return nil, err
+		}
+		product.ID = id
+		return product, nil
+	}
+
+	res, err := s.exec(ctx, `UPDATE product SET name = $2, subscription_period = $3, trial_days = $4,
+		max_pause_days = $5, max_pauses_per_period = $6, price_amount = $7, price_currency = $8, prices = $9,
+		tax_category = $10, tax_inclusive = $11, archived_at = $12, price_versions = $13 WHERE id = $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, fmt.Errorf("product %v %w", id, db.RecordNotFoundErr)
+	}
+	return product, nil
+}
+
+// CountProducts returns number of the products matching the filter
+func (s *sqlDetails) CountProducts(ctx context.Context, filter db.ProductFilter) (int64, error) {
+	conditions, args := s.productConditions(&filter)
+
+	query := `SELECT COUNT(*) FROM ` + s.productSource()
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	var count int64
+	err := s.queryRow(ctx, query, args...).Scan(&count)
+	return count, err
+}
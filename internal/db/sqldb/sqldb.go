@@ -0,0 +1,175 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Dialect has the differences of the SQL database used by the shared db implementation
+// queries are written with numbered $1, $2... placeholders and the upsert syntax supported by PostgreSQL and SQLite
+type Dialect interface {
+	// Rebind returns the query with the placeholders of the database
+	Rebind(query string) string
+	// TimeValue returns time column query argument
+	TimeValue(t time.Time) interface{}
+	// TimeColumn returns the scanner of time column into the time in UTC
+	TimeColumn(dest *time.Time) sql.Scanner
+	// IsUniqueViolation returns true if the error is violation of unique constraint
+	IsUniqueViolation(err error) bool
+	// EffectivePriceColumns returns effective_price_amount and effective_price_currency columns of the product row,
+	// the default price of the last price version effective from or before the time of the placeholder
+	// or the product price if no version is effective yet
+	EffectivePriceColumns(at string) string
+	// Contains returns the condition which is true if the text contains the substring ignoring the case
+	Contains(text string, substring string) string
+	// RemoveJSONEntries returns the JSON array of the column without the entries whose id is in the JSON array of the placeholder,
+	// NULL once all of the entries are removed
+	RemoveJSONEntries(column string, ids string) string
+}
+
+type sqlDetails struct {
+	client  *sql.DB
+	dialect Dialect
+}
+
+// New creates new db instance for the SQL database connection pool with the dialect of the database
+// the schema is created by the migration files of the database
+func New(client *sql.DB, dialect Dialect) db.DB {
+	return &sqlDetails{
+		client:  client,
+		dialect: dialect,
+	}
+}
+
+// Disconnect closes db connection pool, otherwise returns error
+func (s *sqlDetails) Disconnect(ctx context.Context) error {
+	return s.client.Close()
+}
+
+// exec executes the query without returning rows
+func (s *sqlDetails) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.client.ExecContext(ctx, s.dialect.Rebind(query), args...)
+}
+
+// query executes the query returning rows
+func (s *sqlDetails) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.client.QueryContext(ctx, s.dialect.Rebind(query), args...)
+}
+
+// queryRow executes the query returning at most one row
+func (s *sqlDetails) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.client.QueryRowContext(ctx, s.dialect.Rebind(query), args...)
+}
+
+// timeValue returns time column query argument
+func (s *sqlDetails) timeValue(t time.Time) interface{} {
+	return s.dialect.TimeValue(t)
+}
+
+// nullTimeValue returns nullable time column query argument, nil time is passed as NULL
+func (s *sqlDetails) nullTimeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return s.dialect.TimeValue(*t)
+}
+
+// timeColumn returns the scanner of time column
+func (s *sqlDetails) timeColumn(dest *time.Time) sql.Scanner {
+	return s.dialect.TimeColumn(dest)
+}
+
+// nullTimeColumn returns the scanner of nullable time column, NULL is scanned as nil time
+func (s *sqlDetails) nullTimeColumn(dest **time.Time) sql.Scanner {
+	return nullTimeColumn{
+		dialect: s.dialect,
+		dest:    dest,
+	}
+}
+
+// nullTimeColumn scans nullable time column into the time with the time column scanner of the dialect
+type nullTimeColumn struct {
+	dialect Dialect
+	dest    **time.Time
+}
+
+// Scan implements sql.Scanner
+func (c nullTimeColumn) Scan(src interface{}) error {
+	if src == nil {
+		*c.dest = nil
+		return nil
+	}
+
+	t := time.Time{}
+	err := c.dialect.TimeColumn(&t).Scan(src)
+	if err != nil {
+		return err
+	}
+	*c.dest = &t
+	return nil
+}
+
+// newID returns new record ID, IDs have the same format as mongodb object IDs so they are interchangeable between the backends
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// isValidID returns true if the id has the record ID format
+func isValidID(id string) bool {
+	_, err := primitive.ObjectIDFromHex(id)
+	return err == nil
+}
+
+// marshalJSON returns JSON of the value for JSON column, returns nil (NULL) for empty value
+func marshalJSON(v interface{}, empty bool) ([]byte, error) {
+	if empty {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// jsonValue returns JSON query argument, nil JSON is passed as NULL
+func jsonValue(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+// unmarshalJSON decodes JSON column value into v, NULL value is ignored
+func unmarshalJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// nullStringValue returns nullable text column query argument, empty string is passed as NULL
+func nullStringValue(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullBytesValue returns nullable binary column query argument, nil bytes are passed as NULL
+func nullBytesValue(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// utcTime returns copy of the time in UTC, returns nil for nil time
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
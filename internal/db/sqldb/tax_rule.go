@@ -0,0 +1,29 @@
+package sqldb
+
+import (
+	"context"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// GetTaxRules returns all the tax rules for given product tax category
+func (s *sqlDetails) GetTaxRules(ctx context.Context, category string) ([]domain.TaxRule, error) {
+	rows, err := s.query(ctx, `SELECT id, country, category, rate, effective_from, effective_to
+		FROM tax_rule WHERE category = $1 ORDER BY id`, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []domain.TaxRule{}
+	for rows.Next() {
+		rule := domain.TaxRule{}
+		err := rows.Scan(&rule.ID, &rule.Country, &rule.Category, &rule.Rate, s.timeColumn(&rule.EffectiveFrom),
+			s.nullTimeColumn(&rule.EffectiveTo))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
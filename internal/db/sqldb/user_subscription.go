@@ -0,0 +1,583 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+// Renewal represent JSON renewal entry of the user_subscription record
+type Renewal struct {
+	RenewedAt      time.Time `json:"renewed_at"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	Price          Money     `json:"price"`
+	PriceVersionID string    `json:"price_version_id,omitempty"`
+}
+
+// Payment represent JSON payment entry of the user_subscription record
+type Payment struct {
+	AuthorizationID string    `json:"authorization_id"`
+	Type            string    `json:"type"`
+	Reason          string    `json:"reason"`
+	Amount          Money     `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PaymentAttempt represent JSON renewal charge attempt entry of the user_subscription record
+type PaymentAttempt struct {
+	PeriodStart time.Time `json:"period_start"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	Succeeded   bool      `json:"succeeded"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Pause represent JSON pause entry of the user_subscription record
+type Pause struct {
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Actor     string     `json:"actor,omitempty"`
+}
+
+// PlanChange represent JSON plan change entry of the user_subscription record
+type PlanChange struct {
+	RequestedAt   time.Time `json:"requested_at"`
+	EffectiveAt   time.Time `json:"effective_at"`
+	FromProductID string    `json:"from_product_id"`
+	ToProductID   string    `json:"to_product_id"`
+	Price         Money     `json:"price"`
+	Proration     Money     `json:"proration"`
+}
+
+// createDBPlanChange creates db PlanChange from domain plan change
+func createDBPlanChange(pc domain.PlanChange) PlanChange {
+	return PlanChange{
+		RequestedAt:   pc.RequestedAt,
+		EffectiveAt:   pc.EffectiveAt,
+		FromProductID: pc.FromProductID,
+		ToProductID:   pc.ToProductID,
+		Price:         createDBMoney(pc.Price),
+		Proration:     createDBMoney(pc.Proration),
+	}
+}
+
+// createDomainPlanChange creates domain PlanChange from db plan change
+func createDomainPlanChange(pc PlanChange) domain.PlanChange {
+	return domain.PlanChange{
+		RequestedAt:   pc.RequestedAt.UTC(),
+		EffectiveAt:   pc.EffectiveAt.UTC(),
+		FromProductID: pc.FromProductID,
+		ToProductID:   pc.ToProductID,
+		Price:         createDomainMoney(pc.Price),
+		Proration:     createDomainMoney(pc.Proration),
+	}
+}
+
+// createDBPayment creates db Payment from domain payment
+func createDBPayment(p domain.Payment) Payment {
+	return Payment{
+		AuthorizationID: p.AuthorizationID,
+		Type:            string(p.Type),
+		Reason:          string(p.Reason),
+		Amount:          createDBMoney(p.Amount),
+		CreatedAt:       p.CreatedAt,
+	}
+}
+
+// createDomainPayment creates domain Payment from db payment
+func createDomainPayment(p Payment) domain.Payment {
+	return domain.Payment{
+		AuthorizationID: p.AuthorizationID,
+		Type:            domain.PaymentType(p.Type),
+		Reason:          domain.PaymentReason(p.Reason),
+		Amount:          createDomainMoney(p.Amount),
+		CreatedAt:       p.CreatedAt.UTC(),
+	}
+}
+
+// subscriptionHistory holds JSON columns of the user_subscription record
+type subscriptionHistory struct {
+	Pauses             []byte
+	Renewals           []byte
+	PendingPlanChange  []byte
+	PlanChanges        []byte
+	Payments           []byte
+	PendingPayment     []byte
+	PaymentAttempts    []byte
+	PendingInvoices    []byte
+	PendingAuditEvents []byte
+}
+
+// createDBSubscriptionHistory creates JSON columns from the domain subscription
+func createDBSubscriptionHistory(us *domain.UserSubscription) (*subscriptionHistory, error) {
+	pauses := []Pause{}
+	for _, v := range us.Pauses {
+		pauses = append(pauses, Pause(v))
+	}
+
+	renewals := []Renewal{}
+	for _, v := range us.Renewals {
+		renewals = append(renewals, Renewal{
+			RenewedAt:      v.RenewedAt,
+			PeriodStart:    v.PeriodStart,
+			PeriodEnd:      v.PeriodEnd,
+			Price:          createDBMoney(v.Price),
+			PriceVersionID: v.PriceVersionID,
+		})
+	}
+
+	planChanges := []PlanChange{}
+	for _, v := range us.PlanChanges {
+		planChanges = append(planChanges, createDBPlanChange(v))
+	}
+
+	payments := []Payment{}
+	for _, v := range us.Payments {
+		payments = append(payments, createDBPayment(v))
+	}
+
+	var pendingPayment *Payment
+	if us.PendingPayment != nil {
+		payment := createDBPayment(*us.PendingPayment)
+		pendingPayment = &payment
+	}
+
+	var pendingPlanChange *PlanChange
+	if us.PendingPlanChange != nil {
+		planChange := createDBPlanChange(*us.PendingPlanChange)
+		pendingPlanChange = &planChange
+	}
+
+	var err error
+	history := &subscriptionHistory{}
+	history.Pauses, err = marshalJSON(pauses, len(pauses) == 0)
+	if err != nil {
+		return nil, err
+	}
+	history.Renewals, err = marshalJSON(renewals, len(renewals) == 0)
+	if err != nil {
+		return nil, err
+	}
+	history.PendingPlanChange, err = marshalJSON(pendingPlanChange, pendingPlanChange == nil)
+	if err != nil {
+		return nil, err
+	}
+	history.PlanChanges, err = marshalJSON(planChanges, len(planChanges) == 0)
+	if err != nil {
+		return nil, err
+	}
+	history.Payments, err = marshalJSON(payments, len(payments) == 0)
+	if err != nil {
+		return nil, err
+	}
+	history.PendingPayment, err = marshalJSON(pendingPayment, pendingPayment == nil)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentAttempts := []PaymentAttempt{}
+	for _, v := range us.PaymentAttempts {
+		paymentAttempts = append(paymentAttempts, PaymentAttempt(v))
+	}
+	history.PaymentAttempts, err = marshalJSON(paymentAttempts, len(paymentAttempts) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingInvoices := []PendingInvoice{}
+	for _, v := range us.PendingInvoices {
+		pendingInvoices = append(pendingInvoices, createDBPendingInvoice(v))
+	}
+	history.PendingInvoices, err = marshalJSON(pendingInvoices, len(pendingInvoices) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingAuditEvents := []PendingAuditEvent{}
+	for _, v := range us.PendingAuditEvents {
+		pendingAuditEvents = append(pendingAuditEvents, createDBPendingAuditEvent(v))
+	}
+	history.PendingAuditEvents, err = marshalJSON(pendingAuditEvents, len(pendingAuditEvents) == 0)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// setDomainSubscriptionHistory sets subscription history from JSON columns
+func setDomainSubscriptionHistory(us *domain.UserSubscription, history *subscriptionHistory) error {
+	pauses := []Pause{}
+	err := unmarshalJSON(history.Pauses, &pauses)
+	if err != nil {
+		return err
+	}
+	for _, v := range pauses {
+		us.Pauses = append(us.Pauses, domain.Pause{
+			StartDate: v.StartDate.UTC(),
+			EndDate:   utcTime(v.EndDate),
+			Reason:    v.Reason,
+			Actor:     v.Actor,
+		})
+	}
+
+	renewals := []Renewal{}
+	err = unmarshalJSON(history.Renewals, &renewals)
+	if err != nil {
+		return err
+	}
+	for _, v := range renewals {
+		us.Renewals = append(us.Renewals, domain.Renewal{
+			RenewedAt:      v.RenewedAt.UTC(),
+			PeriodStart:    v.PeriodStart.UTC(),
+			PeriodEnd:      v.PeriodEnd.UTC(),
+			Price:          createDomainMoney(v.Price),
+			PriceVersionID: v.PriceVersionID,
+		})
+	}
+
+	var pendingPlanChange *PlanChange
+	err = unmarshalJSON(history.PendingPlanChange, &pendingPlanChange)
+	if err != nil {
+		return err
+	}
+	if pendingPlanChange != nil {
+		planChange := createDomainPlanChange(*pendingPlanChange)
+		us.PendingPlanChange = &planChange
+	}
+
+	planChanges := []PlanChange{}
+	err = unmarshalJSON(history.PlanChanges, &planChanges)
+	if err != nil {
+		return err
+	}
+	for _, v := range planChanges {
+		us.PlanChanges = append(us.PlanChanges, createDomainPlanChange(v))
+	}
+
+	payments := []Payment{}
+	err = unmarshalJSON(history.Payments, &payments)
+	if err != nil {
+		return err
+	}
+	for _, v := range payments {
+		us.Payments = append(us.Payments, createDomainPayment(v))
+	}
+
+	var pendingPayment *Payment
+	err = unmarshalJSON(history.PendingPayment, &pendingPayment)
+	if err != nil {
+		return err
+	}
+	if pendingPayment != nil {
+		payment := createDomainPayment(*pendingPayment)
+		us.PendingPayment = &payment
+	}
+
+	paymentAttempts := []PaymentAttempt{}
+	err = unmarshalJSON(history.PaymentAttempts, &paymentAttempts)
+	if err != nil {
+		return err
+	}
+	for _, v := range paymentAttempts {
+		us.PaymentAttempts = append(us.PaymentAttempts, domain.PaymentAttempt{
+			PeriodStart: v.PeriodStart.UTC(),
+			AttemptedAt: v.AttemptedAt.UTC(),
+			Succeeded:   v.Succeeded,
+			Error:       v.Error,
+		})
+	}
+
+	pendingInvoices := []PendingInvoice{}
+	err = unmarshalJSON(history.PendingInvoices, &pendingInvoices)
+	if err != nil {
+		return err
+	}
+	for _, v := range pendingInvoices {
+		us.PendingInvoices = append(us.PendingInvoices, createDomainPendingInvoice(us.ID, v))
+	}
+
+	pendingAuditEvents := []PendingAuditEvent{}
+	err = unmarshalJSON(history.PendingAuditEvents, &pendingAuditEvents)
+	if err != nil {
+		return err
+	}
+	for _, v := range pendingAuditEvents {
+		us.PendingAuditEvents = append(us.PendingAuditEvents, createDomainPendingAuditEvent(us.ID, v))
+	}
+	return nil
+}
+
+const userSubscriptionColumns = `id, version, created_at, updated_at, email, country, product_id, product_name, start_date, end_date,
+	price_amount, price_currency, net_price_amount, net_price_currency, tax_amount, tax_currency, tax_rate, status,
+	pauses, resume_date, auto_renew, renewals, trial_end_date, cancel_at_period_end, pending_plan_change, plan_changes, uniqueness_key,
+	price_version_id, payment_token, payments, pending_payment, next_payment_retry, payment_attempts, pending_invoices,
+	pending_audit_events`
+
+// scanUserSubscription scans user_subscription row into domain subscription
+func (s *sqlDetails) scanUserSubscription(row interface{ Scan(...interface{}) error }) (*domain.UserSubscription, error) {
+	us := &domain.UserSubscription{}
+	history := &subscriptionHistory{}
+	uniquenessKey := sql.NullString{}
+	err := row.Scan(&us.ID, &us.Version, s.timeColumn(&us.CreatedAt), s.nullTimeColumn(&us.UpdatedAt), &us.Email, &us.Country,
+		&us.ProductID, &us.ProductName, s.timeColumn(&us.StartDate), s.timeColumn(&us.EndDate), &us.Price.Amount, &us.Price.Currency,
+		&us.NetPrice.Amount, &us.NetPrice.Currency, &us.Tax.Amount, &us.Tax.Currency, &us.TaxRate, &us.Status,
+		&history.Pauses, s.nullTimeColumn(&us.ResumeDate), &us.AutoRenew, &history.Renewals, s.nullTimeColumn(&us.TrialEndDate),
+		&us.CancelAtPeriodEnd, &history.PendingPlanChange, &history.PlanChanges, &uniquenessKey,
+		&us.PriceVersionID, &us.PaymentToken, &history.Payments, &history.PendingPayment,
+		s.nullTimeColumn(&us.NextPaymentRetry), &history.PaymentAttempts, &history.PendingInvoices, &history.PendingAuditEvents)
+	if err != nil {
+		return nil, err
+	}
+	us.UniquenessKey = uniquenessKey.String
+
+	err = setDomainSubscriptionHistory(us, history)
+	if err != nil {
+		return nil, err
+	}
+	return us, nil
+}
+
+// SaveSubscription create new subscription if its version is 0 otherwise update the subscription if its version is unchanged
+// and return the subscription record with incremented version
+// returns version conflict error if the subscription already exists or its version is changed
+// and duplicate record error if other subscription has the same uniqueness key
+func (s *sqlDetails) SaveSubscription(ctx context.Context, us *domain.UserSubscription) (*domain.UserSubscription, error) {
+	if us == nil {
+		return nil, db.InvalidArgErr
+	}
+
+	id := us.ID
+	if id == "" {
+		id = newID()
+	} else if !isValidID(id) {
+		return nil, db.InvalidArgErr
+	}
+
+	for i := range us.PendingInvoices {
+		if us.PendingInvoices[i].ID == "" {
+			us.PendingInvoices[i].ID = newID()
+		}
+		us.PendingInvoices[i].SubscriptionID = id
+	}
+
+	for i := range us.PendingAuditEvents {
+		if us.PendingAuditEvents[i].ID == "" {
+			us.PendingAuditEvents[i].ID = newID()
+		}
+		us.PendingAuditEvents[i].SubscriptionID = id
+	}
+
+	history, err := createDBSubscriptionHistory(us)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{
+		id, us.Version + 1, s.timeValue(us.CreatedAt), s.nullTimeValue(us.UpdatedAt), us.Email, us.Country, us.ProductID, us.ProductName,
+		s.timeValue(us.StartDate), s.timeValue(us.EndDate), us.Price.Amount, us.Price.Currency, us.NetPrice.Amount,
+		us.NetPrice.Currency, us.Tax.Amount, us.Tax.Currency, us.TaxRate, us.Status, jsonValue(history.Pauses),
+		s.nullTimeValue(us.ResumeDate), us.AutoRenew, jsonValue(history.Renewals), s.nullTimeValue(us.TrialEndDate),
+		us.CancelAtPeriodEnd, jsonValue(history.PendingPlanChange), jsonValue(history.PlanChanges),
+		nullStringValue(us.UniquenessKey), us.PriceVersionID, us.PaymentToken, jsonValue(history.Payments), jsonValue(history.PendingPayment),
+		s.nullTimeValue(us.NextPaymentRetry), jsonValue(history.PaymentAttempts), jsonValue(history.PendingInvoices),
+		jsonValue(history.PendingAuditEvents),
+	}
+
+	var res sql.Result
+	if us.Version == 0 {
+		res, err = s.exec(ctx, `INSERT INTO user_subscription (`+userSubscriptionColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35)
+			ON CONFLICT (id) DO NOTHING`, args...)
+	} else {
+		res, err = s.exec(ctx, `UPDATE user_subscription SET version = $2, created_at = $3, updated_at = $4,
+			email = $5, country = $6, product_id = $7, product_name = $8, start_date = $9, end_date = $10, price_amount = $11, price_currency = $12,
+			net_price_amount = $13, net_price_currency = $14, tax_amount = $15, tax_currency = $16,
+			tax_rate = $17, status = $18, pauses = $19, resume_date = $20, auto_renew = $21, renewals = $22,
+			trial_end_date = $23, cancel_at_period_end = $24, pending_plan_change = $25, plan_changes = $26,
+			uniqueness_key = $27, price_version_id = $28, payment_token = $29, payments = $30, pending_payment = $31,
+			next_payment_retry = $32, payment_attempts = $33, pending_invoices = $34,
+			pending_audit_events = $35 WHERE id = $1 AND version = $36`, append(args, us.Version)...)
+	}
+	if err != nil {
+		if s.dialect.IsUniqueViolation(err) {
+			return nil, fmt.Errorf("subscription uniqueness key %v %w", us.UniquenessKey, db.DuplicateRecordErr)
+		}
+		return nil, err
+	}
+
+	// subscription already exists or its version is changed, nothing is inserted or updated
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, fmt.Errorf("subscription %v version %v %w", id, us.Version, db.VersionConflictErr)
+	}
+
+	us.ID = id
+	us.Version++
+	return us, nil
+}
+
+// GetSubscriptionByID return subscription for given id
+func (s *sqlDetails) GetSubscriptionByID(ctx context.Context, id string) (*domain.UserSubscription, error) {
+	if !isValidID(id) {
+		return nil, fmt.Errorf("id %w", db.InvalidArgErr)
+	}
+
+	row := s.queryRow(ctx, `SELECT `+userSubscriptionColumns+` FROM user_subscription WHERE id = $1`, id)
+	subscription, err := s.scanUserSubscription(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.RecordNotFoundErr
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// FindSubscriptions returns subscriptions matching the filter sorted by the sort field and ID
+func (s *sqlDetails) FindSubscriptions(ctx context.Context, filter db.SubscriptionFilter) ([]domain.UserSubscription, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Email != "" {
+		addCondition("email = $%d", filter.Email)
+	}
+
+	if filter.ProductID != "" {
+		addCondition("product_id = $%d", filter.ProductID)
+	}
+
+	if filter.HadTrial {
+		conditions = append(conditions, "trial_end_date IS NOT NULL")
+	}
+
+	if filter.HasPendingInvoices {
+		conditions = append(conditions, "pending_invoices IS NOT NULL")
+	}
+
+	if filter.HasPendingAuditEvents {
+		conditions = append(conditions, "pending_audit_events IS NOT NULL")
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := []string{}
+		for _, v := range filter.Statuses {
+			args = append(args, string(v))
+			statuses = append(statuses, fmt.Sprintf("$%d", len(args)))
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(statuses, ", ")+")")
+	}
+
+	if filter.EndDateBefore != nil {
+		addCondition("end_date <= $%d", s.timeValue(*filter.EndDateBefore))
+	}
+
+	if filter.ResumeDateBefore != nil {
+		addCondition("resume_date <= $%d", s.timeValue(*filter.ResumeDateBefore))
+	}
+
+	if filter.PaymentRetryBefore != nil {
+		addCondition("next_payment_retry <= $%d", s.timeValue(*filter.PaymentRetryBefore))
+	}
+
+	if filter.CreatedFrom != nil {
+		addCondition("created_at >= $%d", s.timeValue(*filter.CreatedFrom))
+	}
+
+	if filter.CreatedTo != nil {
+		addCondition("created_at <= $%d", s.timeValue(*filter.CreatedTo))
+	}
+
+	if filter.EndDateFrom != nil {
+		addCondition("end_date >= $%d", s.timeValue(*filter.EndDateFrom))
+	}
+
+	sortColumn, sortOrder, comparison := "end_date", "", ">"
+	if filter.SortBy.IsValid() {
+		sortColumn = string(filter.SortBy)
+	}
+	if filter.SortDesc {
+		sortOrder, comparison = " DESC", "<"
+	}
+
+	if filter.After != nil {
+		args = append(args, s.timeValue(filter.After.Value), filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%[1]v %[2]v $%[3]d OR (%[1]v = $%[3]d AND id %[2]v $%[4]d))",
+			sortColumn, comparison, len(args)-1, len(args)))
+	}
+
+	query := `SELECT ` + userSubscriptionColumns + ` FROM user_subscription`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY ` + sortColumn + sortOrder + `, id` + sortOrder
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []domain.UserSubscription{}
+	for rows.Next() {
+		subscription, err := s.scanUserSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+// RemovePendingInvoices removes the pending invoices with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (s *sqlDetails) RemovePendingInvoices(ctx context.Context, subscriptionID string, invoiceIDs []string) error {
+	return s.removePendingEntries(ctx, "pending_invoices", subscriptionID, invoiceIDs)
+}
+
+// RemovePendingAuditEvents removes the pending audit events with given ids from the subscription with given id
+// the subscription version is kept, returns record not found error if the subscription does not exist
+func (s *sqlDetails) RemovePendingAuditEvents(ctx context.Context, subscriptionID string, eventIDs []string) error {
+	return s.removePendingEntries(ctx, "pending_audit_events", subscriptionID, eventIDs)
+}
+
+// removePendingEntries removes the entries with given ids from the JSON array column of the subscription with given id
+// returns record not found error if the subscription does not exist
+func (s *sqlDetails) removePendingEntries(ctx context.Context, column string, subscriptionID string, entryIDs []string) error {
+	ids, err := marshalJSON(entryIDs, false)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.exec(ctx, `UPDATE user_subscription SET `+column+` = `+s.dialect.RemoveJSONEntries("user_subscription."+column, "$2")+`
+		WHERE id = $1`, subscriptionID, jsonValue(ids))
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("subscription %v %w", subscriptionID, db.RecordNotFoundErr)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+)
+
+func Test_createDBSubscriptionHistory(t *testing.T) {
+	timeNow := time.Date(2022, 6, 1, 10, 30, 0, 0, time.UTC)
+	pauseEndDate := timeNow.AddDate(0, 0, 5)
+	tests := []struct {
+		name string
+		us   *domain.UserSubscription
+		want *subscriptionHistory
+	}{
+		{
+			name: "should return NULL columns for subscription without history",
+			us:   &domain.UserSubscription{},
+			want: &subscriptionHistory{},
+		},
+		{
+			name: "should return JSON columns for subscription history",
+			us: &domain.UserSubscription{
+				Pauses: []domain.Pause{
+					{
+						StartDate: timeNow,
+						EndDate:   &pauseEndDate,
+						Reason:    "holiday",
+					},
+				},
+				Renewals: []domain.Renewal{
+					{
+						RenewedAt:   timeNow,
+						PeriodStart: timeNow,
+						PeriodEnd:   timeNow,
+						Price:       domain.NewMoney(1000, "EUR"),
+					},
+				},
+				PendingPlanChange: &domain.PlanChange{
+					RequestedAt:   timeNow,
+					EffectiveAt:   timeNow,
+					FromProductID: "62bac24b0bf33af1c877d97f",
+					ToProductID:   "62bac25f83b5fcd9ddeb8170",
+				},
+			},
+			want: &subscriptionHistory{
+				Pauses:            []byte(`[{"start_date":"2022-06-01T10:30:00Z","end_date":"2022-06-06T10:30:00Z","reason":"holiday"}]`),
+				Renewals:          []byte(`[{"renewed_at":"2022-06-01T10:30:00Z","period_start":"2022-06-01T10:30:00Z","period_end":"2022-06-01T10:30:00Z","price":{"amount":1000,"currency":"EUR"}}]`),
+				PendingPlanChange: []byte(`{"requested_at":"2022-06-01T10:30:00Z","effective_at":"2022-06-01T10:30:00Z","from_product_id":"62bac24b0bf33af1c877d97f","to_product_id":"62bac25f83b5fcd9ddeb8170","price":{"amount":0,"currency":""},"proration":{"amount":0,"currency":""}}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createDBSubscriptionHistory(tt.us)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createDBSubscriptionHistory() = %s, want %s", got, tt.want)
+			}
+
+			// history is restored from the columns
+			restored := &domain.UserSubscription{}
+			err = setDomainSubscriptionHistory(restored, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(restored, tt.us) {
+				t.Errorf("setDomainSubscriptionHistory() = %v, want %v", restored, tt.us)
+			}
+		})
+	}
+}
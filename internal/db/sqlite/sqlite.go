@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/sqldb"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timeLayout is the layout of the time columns, times are stored as UTC text with fixed width
+// so that they are compared and sorted in chronological order
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// NewSqliteDB creates new sqlite db instance for the database file at given path, returns error if input is invalid
+// or db file cannot be opened
+// the schema is created by the migration files in migration/sqlite
+func NewSqliteDB(path string) (db.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("NewSqliteDB: empty path %w", db.EmptyArgErr)
+	}
+
+	client, err := connect(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.New(client, sqliteDialect{}), nil
+}
+
+// connect opens sqlite db file and checks the connection, returns error if fails
+func connect(path string) (*sql.DB, error) {
+	client, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows single writer, one connection serialises the writes instead of failing them with busy error
+	client.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = client.PingContext(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// sqliteDialect is the sqldb dialect of sqlite, times are stored as text and JSON columns are queried with the JSON1 functions
+type sqliteDialect struct{}
+
+// Rebind returns the query as it is, sqlite supports numbered $1 placeholders
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+// TimeValue returns time column query argument
+func (sqliteDialect) TimeValue(t time.Time) interface{} {
+	return t.UTC().Format(timeLayout)
+}
+
+// TimeColumn returns the scanner of time column
+func (sqliteDialect) TimeColumn(dest *time.Time) sql.Scanner {
+	return timeColumn{dest: dest}
+}
+
+// IsUniqueViolation returns true if the error is violation of unique constraint
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// EffectivePriceColumns returns effective price columns of the product row, the effective from times of the price versions
+// are RFC 3339 JSON times which are compared by julianday
+func (sqliteDialect) EffectivePriceColumns(at string) string {
+	return `COALESCE((SELECT json_extract(v.value, '$.price.amount') FROM json_each(price_versions) v
+		WHERE julianday(json_extract(v.value, '$.effective_from')) <= julianday(` + at + `)
+		ORDER BY julianday(json_extract(v.value, '$.effective_from')) DESC LIMIT 1), price_amount) AS effective_price_amount,
+	COALESCE((SELECT json_extract(v.value, '$.price.currency') FROM json_each(price_versions) v
+		WHERE julianday(json_extract(v.value, '$.effective_from')) <= julianday(` + at + `)
+		ORDER BY julianday(json_extract(v.value, '$.effective_from')) DESC LIMIT 1), price_currency) AS effective_price_currency`
+}
+
+// Contains returns the condition which is true if the text contains the substring ignoring the case
+func (sqliteDialect) Contains(text string, substring string) string {
+	return `instr(lower(` + text + `), lower(` + substring + `)) > 0`
+}
+
+// RemoveJSONEntries returns the JSON array of the column without the entries with the ids, NULL once all of them are removed
+func (sqliteDialect) RemoveJSONEntries(column string, ids string) string {
+	return `(SELECT NULLIF(json_group_array(json(value)), '[]') FROM json_each(` + column + `)
+		WHERE json_extract(value, '$.id') NOT IN (SELECT value FROM json_each(` + ids + `)))`
+}
+
+// timeColumn scans time column into the time
+type timeColumn struct {
+	dest *time.Time
+}
+
+// Scan implements sql.Scanner
+func (c timeColumn) Scan(src interface{}) error {
+	value, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unexpected time column value %v", src)
+	}
+
+	t, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return err
+	}
+	*c.dest = t
+	return nil
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/dbtest"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	migrationFilesPath = "file://../../../migration/sqlite"
+	seedFilesPath      = "file://../../../seed/sqlite"
+)
+
+// newMigratedDB creates new sqlite db file in the directory, migrates and seeds it
+func newMigratedDB(dir string) (db.DB, error) {
+	path := filepath.Join(dir, "gymondodb.sqlite")
+	err := migrateUp(migrationFilesPath, "sqlite://"+path)
+	if err != nil {
+		return nil, err
+	}
+
+	// seed version is kept in its own table
+	err = migrateUp(seedFilesPath, "sqlite://"+path+"?x-migrations-table=seed_migrations")
+	if err != nil {
+		return nil, err
+	}
+	return NewSqliteDB(path)
+}
+
+// migrateUp applies all up files from the source to the database
+func migrateUp(sourceURL string, databaseURL string) error {
+	m, err := migrate.New(sourceURL, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return m.Up()
+}
+
+func TestSqliteDBConformance(t *testing.T) {
+	suite.Run(t, &dbtest.Suite{
+		NewDB: func() (db.DB, error) {
+			return newMigratedDB(t.TempDir())
+		},
+	})
+}
+
+func TestNewSqliteDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{
+			name:    "should return error for empty path",
+			wantErr: true,
+		},
+		{
+			name:    "should return error if directory of the path does not exist",
+			path:    filepath.Join(t.TempDir(), "missing", "gymondodb.sqlite"),
+			wantErr: true,
+		},
+		{
+			name:    "should return success for valid path",
+			path:    filepath.Join(t.TempDir(), "gymondodb.sqlite"),
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database, err := NewSqliteDB(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSqliteDB() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if database != nil {
+				database.Disconnect(nil)
+			}
+		})
+	}
+}
+
+func TestDefaultTaxRuleWithoutSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gymondodb.sqlite")
+	err := migrateUp(migrationFilesPath, "sqlite://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database, err := NewSqliteDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Disconnect(nil)
+
+	// the default rate is created by the migrations, so products can be sold without the seed files
+	got, err := database.GetTaxRules(context.Background(), "digital_service")
+	if err != nil || len(got) != 1 || got[0].Country != "" || got[0].Rate != 10 {
+		t.Errorf("GetTaxRules() = %v, %v, want default rate 10", got, err)
+	}
+}
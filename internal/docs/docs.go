@@ -16,9 +16,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/product": {
-            "get": {
-                "description": "return feteched  products",
+        "/admin/product": {
+            "post": {
+                "description": "validate and create the product, subscription period must be positive, prices must not be negative and tax category must have tax rules",
                 "consumes": [
                     "application/json"
                 ],
@@ -26,14 +26,130 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "product-api"
+                    "product-admin-api"
+                ],
+                "summary": "create a product",
+                "parameters": [
+                    {
+                        "description": "product, prices are decimal strings e.g. 10.00",
+                        "name": "productRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.productRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin who makes the change",
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rest.getProductByIdResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/product/{id}": {
+            "put": {
+                "description": "validate and replace the product for input id, subscriptions of the product keep the name and price they were bought with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product-admin-api"
+                ],
+                "summary": "update a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "product, prices are decimal strings e.g. 10.00",
+                        "name": "productRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.productRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin who makes the change",
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "get all the products",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.getAllProductsResponse"
+                            "$ref": "#/definitions/rest.getProductByIdResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
                         }
                     },
                     "500": {
@@ -45,9 +161,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/product/{id}": {
-            "get": {
-                "description": "return feteched  product record for input id",
+        "/admin/product/{id}/archive": {
+            "post": {
+                "description": "archive the product for input id, archived product cannot be bought, existing subscriptions of the product are not changed",
                 "consumes": [
                     "application/json"
                 ],
@@ -55,9 +171,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "product-api"
+                    "product-admin-api"
                 ],
-                "summary": "get a product for given product id",
+                "summary": "archive a product",
                 "parameters": [
                     {
                         "type": "string",
@@ -65,6 +181,20 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin who makes the change",
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -80,6 +210,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/rest.errorRespose"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -95,9 +231,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription": {
+        "/admin/product/{id}/price_version": {
             "post": {
-                "description": "return created subscription record",
+                "description": "add a price version to the product for input id, the prices are effective from effective_from (now if not given) until the next price version",
                 "consumes": [
                     "application/json"
                 ],
@@ -105,25 +241,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription-api"
+                    "product-admin-api"
                 ],
-                "summary": "create a subscription for the user with given product",
+                "summary": "schedule a price change of a product",
                 "parameters": [
                     {
-                        "description": "create subscription request",
-                        "name": "buySubscriptionRequest",
+                        "type": "string",
+                        "description": "product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "price version, effective_from is RFC 3339 or YYYY-MM-DD and must be after the last price version",
+                        "name": "priceVersionRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/rest.buySubscriptionRequest"
+                            "$ref": "#/definitions/rest.priceVersionRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin who makes the change",
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/rest.buySubscriptionResponse"
+                            "$ref": "#/definitions/rest.getProductByIdResponse"
                         }
                     },
                     "400": {
@@ -132,6 +289,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/rest.errorRespose"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -141,9 +310,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/{id}": {
-            "get": {
-                "description": "return feteched  subscription record for input id",
+        "/admin/product/{id}/restore": {
+            "post": {
+                "description": "restore the archived product for input id, so it can be bought again",
                 "consumes": [
                     "application/json"
                 ],
@@ -151,23 +320,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription-api"
+                    "product-admin-api"
                 ],
-                "summary": "get a subscription for given subscription id",
+                "summary": "restore a product",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "subscription ID",
+                        "description": "product ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin who makes the change",
+                        "name": "X-Actor",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.getSubscriptionByIDResponse"
+                            "$ref": "#/definitions/rest.getProductByIdResponse"
                         }
                     },
                     "400": {
@@ -176,6 +359,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/rest.errorRespose"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -191,9 +380,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/{id}/changeStatus/{status}": {
-            "patch": {
-                "description": "update subscription with given status and returns updated subscription",
+        "/product": {
+            "get": {
+                "description": "return a page of the products matching the filter with total count of matching products, next_cursor is set if there are more products\nprice range is in the currency (default EUR) of the default product price and includes both min and max",
                 "consumes": [
                     "application/json"
                 ],
@@ -201,26 +390,120 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription-api"
+                    "product-api"
                 ],
-                "summary": "update subscription with given status",
+                "summary": "get all the products",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "part of the product name, case insensitive",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "subscription period in months",
+                        "name": "subscription_period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "min default price effective now e.g. 10.00",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "max default price effective now e.g. 20.00",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "currency of the price range (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list archived products too",
+                        "name": "include_archived",
+                        "in": "query"
                     },
                     {
                         "enum": [
-                            "active",
-                            "cancel",
-                            "pause"
+                            "name",
+                            "price",
+                            "subscription_period"
                         ],
                         "type": "string",
-                        "description": "status",
-                        "name": "status",
+                        "description": "sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 20 and max 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.getAllProductsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}": {
+            "get": {
+                "description": "return feteched  product record for input id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "product-api"
+                ],
+                "summary": "get a product for given product id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "product ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -229,7 +512,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/rest.updateSubscriptionByIDResponse"
+                            "$ref": "#/definitions/rest.getProductByIdResponse"
                         }
                     },
                     "400": {
@@ -252,129 +535,1514 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
+        },
+        "/subscription": {
+            "get": {
+                "description": "return a page of the subscriptions for input email matching the filter, next_cursor is set if there are more subscriptions\ndate ranges include both from and to, YYYY-MM-DD date of the to parameter includes the whole day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "list subscriptions of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "email of the user",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "subscription statuses, comma separated or repeated",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "product ID",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created at or before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end date at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "end_date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end date at or before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "end_date_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "end_date"
+                        ],
+                        "type": "string",
+                        "description": "sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, default 20 and max 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.listSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "return created subscription record",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "create a subscription for the user with given product",
+                "parameters": [
+                    {
+                        "description": "create subscription request",
+                        "name": "buySubscriptionRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.buySubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key get the response of the first request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/rest.buySubscriptionResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version"
+                            },
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the response of the earlier request with the same Idempotency-Key is replayed"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.duplicateSubscriptionResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}": {
+            "get": {
+                "description": "return feteched  subscription record for input id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "get a subscription for given subscription id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.getSubscriptionByIDResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/changePlan": {
+            "patch": {
+                "description": "change subscription product immediately with prorated charge/credit or at the end of the current period and returns updated subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "change product of the subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "change plan request, timing is immediate (default) or period_end",
+                        "name": "changePlanRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.changePlanRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the subscription version the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.updateSubscriptionByIDResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/changeStatus/{status}": {
+            "patch": {
+                "description": "update subscription with given status and returns updated subscription\ncancel with mode period_end keeps the subscription active until its end date, active status undoes it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "update subscription with given status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "cancel",
+                            "pause"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "immediate",
+                            "period_end"
+                        ],
+                        "type": "string",
+                        "description": "cancel mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "resume date of the paused subscription (RFC 3339 or YYYY-MM-DD)",
+                        "name": "resume_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "reason of the pause",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "who makes the change, anonymous if not set or the actor signing key is not configured",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of X-Actor with the actor signing key",
+                        "name": "X-Actor-Signature",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the subscription version the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key get the response of the first request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.updateSubscriptionByIDResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version"
+                            },
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "true if the response of the earlier request with the same Idempotency-Key is replayed"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/history": {
+            "get": {
+                "description": "return all the changes of the subscription for input id sorted by creation time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "get audit log of the subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.getSubscriptionHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/invoices": {
+            "get": {
+                "description": "return all the invoices and credit notes of the subscription for input id sorted by issue time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "get invoices of the subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.getSubscriptionInvoicesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/invoices/{number}": {
+            "get": {
+                "description": "return the invoice of the subscription for input id and invoice number e.g. 2022-000001",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "get invoice of the subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "invoice number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.invoiceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/invoices/{number}.pdf": {
+            "get": {
+                "description": "render the invoice of the subscription for input id and invoice number as PDF document",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "get invoice PDF of the subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "invoice number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/payment": {
+            "post": {
+                "description": "payment provider callback which activates the pending subscription when the payment succeeded or marks it payment_failed otherwise, returns updated subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription-api"
+                ],
+                "summary": "confirm the purchase payment of the pending subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "confirm payment request, status is succeeded or failed",
+                        "name": "confirmPaymentRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.confirmPaymentRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "hex HMAC-SHA256 of the request body with the payment callback key",
+                        "name": "X-Payment-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.updateSubscriptionByIDResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "subscription version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/rest.errorRespose"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "rest.auditEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "new_status": {
+                    "type": "string"
+                },
+                "previous_status": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
         "rest.buySubscriptionRequest": {
             "type": "object",
-            "required": [
-                "email_id",
-                "product_id"
-            ],
+            "required": [
+                "email_id",
+                "payment_token",
+                "product_id"
+            ],
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "email_id": {
+                    "type": "string"
+                },
+                "payment_token": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.buySubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "auto_renew": {
+                    "type": "boolean"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "net_price": {
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.paymentResponse"
+                    }
+                },
+                "pending_payment": {
+                    "$ref": "#/definitions/rest.paymentResponse"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "price_version_id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tax": {
+                    "type": "string"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
+                "trial_end_date": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.changePlanRequest": {
+            "type": "object",
+            "required": [
+                "product_id"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "string"
+                },
+                "timing": {
+                    "type": "string",
+                    "enum": [
+                        "immediate",
+                        "period_end"
+                    ]
+                }
+            }
+        },
+        "rest.confirmPaymentRequest": {
+            "type": "object",
+            "required": [
+                "authorization_id",
+                "status"
+            ],
+            "properties": {
+                "authorization_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "succeeded",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "rest.duplicateSubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "errorMessage": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.errorRespose": {
+            "type": "object",
+            "properties": {
+                "errorMessage": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.getAllProductsResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.getProductByIdResponse"
+                    }
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.getProductByIdResponse": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_pause_days": {
+                    "type": "integer"
+                },
+                "max_pauses_per_period": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "price_version_id": {
+                    "type": "string"
+                },
+                "price_versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.priceVersionResponse"
+                    }
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.productPriceResponse"
+                    }
+                },
+                "subscription_period": {
+                    "type": "integer"
+                },
+                "tax_category": {
+                    "type": "string"
+                },
+                "tax_inclusive": {
+                    "type": "boolean"
+                },
+                "trial_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.getSubscriptionByIDResponse": {
+            "type": "object",
+            "properties": {
+                "auto_renew": {
+                    "type": "boolean"
+                },
+                "cancel_at_period_end": {
+                    "type": "boolean"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "net_price": {
+                    "type": "string"
+                },
+                "next_payment_retry": {
+                    "type": "string"
+                },
+                "pause_start_date": {
+                    "type": "string"
+                },
+                "pauses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.pauseResponse"
+                    }
+                },
+                "payment_attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.paymentAttemptResponse"
+                    }
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.paymentResponse"
+                    }
+                },
+                "pending_payment": {
+                    "$ref": "#/definitions/rest.paymentResponse"
+                },
+                "pending_plan_change": {
+                    "$ref": "#/definitions/rest.planChangeResponse"
+                },
+                "plan_changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.planChangeResponse"
+                    }
+                },
+                "price": {
+                    "type": "string"
+                },
+                "price_version_id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "renewals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.renewalResponse"
+                    }
+                },
+                "resume_date": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tax": {
+                    "type": "string"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
+                "trial_end_date": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.getSubscriptionHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.auditEventResponse"
+                    }
+                }
+            }
+        },
+        "rest.getSubscriptionInvoicesResponse": {
+            "type": "object",
             "properties": {
-                "email_id": {
+                "invoices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.invoiceResponse"
+                    }
+                }
+            }
+        },
+        "rest.invoiceLineResponse": {
+            "type": "object",
+            "properties": {
+                "gross": {
+                    "type": "string"
+                },
+                "net": {
+                    "type": "string"
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
                     "type": "string"
                 },
                 "product_id": {
                     "type": "string"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "tax": {
+                    "type": "string"
+                },
+                "tax_rate": {
+                    "type": "number"
                 }
             }
         },
-        "rest.buySubscriptionResponse": {
+        "rest.invoiceResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "authorization_id": {
                     "type": "string"
                 },
-                "email": {
+                "country": {
                     "type": "string"
                 },
-                "end_date": {
+                "currency": {
+                    "type": "string"
+                },
+                "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "issued_at": {
+                    "type": "string"
                 },
-                "product_name": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.invoiceLineResponse"
+                    }
+                },
+                "net": {
                     "type": "string"
                 },
-                "start_date": {
+                "number": {
                     "type": "string"
                 },
-                "status": {
+                "reason": {
+                    "type": "string"
+                },
+                "subscription_id": {
                     "type": "string"
                 },
                 "tax": {
-                    "type": "number"
+                    "type": "string"
+                },
+                "total": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "rest.errorRespose": {
+        "rest.listSubscriptionsResponse": {
             "type": "object",
             "properties": {
-                "errorMessage": {
+                "next_cursor": {
                     "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.getSubscriptionByIDResponse"
+                    }
                 }
             }
         },
-        "rest.getAllProductsResponse": {
+        "rest.pauseResponse": {
             "type": "object",
             "properties": {
-                "products": {
+                "actor": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.paymentAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "succeeded": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "rest.paymentResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string"
+                },
+                "authorization_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.planChangeResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "effective_at": {
+                    "type": "string"
+                },
+                "from_product_id": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "proration": {
+                    "type": "string"
+                },
+                "requested_at": {
+                    "type": "string"
+                },
+                "to_product_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.priceVersionRequest": {
+            "type": "object",
+            "required": [
+                "currency",
+                "price"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string"
+                },
+                "prices": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/rest.getProductByIdResponse"
+                        "$ref": "#/definitions/rest.productPriceRequest"
                     }
                 }
             }
         },
-        "rest.getProductByIdResponse": {
+        "rest.priceVersionResponse": {
             "type": "object",
             "properties": {
-                "id": {
+                "currency": {
                     "type": "string"
                 },
-                "name": {
+                "effective_from": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "id": {
+                    "type": "string"
                 },
-                "subscription_period": {
-                    "type": "integer"
+                "price": {
+                    "type": "string"
                 },
-                "tax_percentage": {
-                    "type": "number"
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.productPriceResponse"
+                    }
                 }
             }
         },
-        "rest.getSubscriptionByIDResponse": {
+        "rest.productPriceRequest": {
             "type": "object",
+            "required": [
+                "currency",
+                "price"
+            ],
             "properties": {
-                "created_at": {
+                "country": {
                     "type": "string"
                 },
-                "email": {
+                "currency": {
                     "type": "string"
                 },
-                "end_date": {
+                "price": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.productPriceResponse": {
+            "type": "object",
+            "properties": {
+                "country": {
                     "type": "string"
                 },
-                "id": {
+                "currency": {
                     "type": "string"
                 },
-                "pause_start_date": {
+                "price": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.productRequest": {
+            "type": "object",
+            "required": [
+                "currency",
+                "name",
+                "price",
+                "subscription_period",
+                "tax_category"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "max_pause_days": {
+                    "type": "integer"
+                },
+                "max_pauses_per_period": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
                 },
                 "price": {
-                    "type": "number"
+                    "type": "string"
                 },
-                "product_name": {
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.productPriceRequest"
+                    }
+                },
+                "subscription_period": {
+                    "type": "integer"
+                },
+                "tax_category": {
                     "type": "string"
                 },
-                "start_date": {
+                "tax_inclusive": {
+                    "type": "boolean"
+                },
+                "trial_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest.renewalResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
                     "type": "string"
                 },
-                "status": {
+                "period_end": {
                     "type": "string"
                 },
-                "tax": {
-                    "type": "number"
+                "period_start": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "price": {
+                    "type": "string"
+                },
+                "price_version_id": {
+                    "type": "string"
+                },
+                "renewed_at": {
                     "type": "string"
                 }
             }
@@ -382,9 +2050,21 @@ const docTemplate = `{
         "rest.updateSubscriptionByIDResponse": {
             "type": "object",
             "properties": {
+                "auto_renew": {
+                    "type": "boolean"
+                },
+                "cancel_at_period_end": {
+                    "type": "boolean"
+                },
+                "country": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -394,15 +2074,66 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "net_price": {
+                    "type": "string"
+                },
+                "next_payment_retry": {
+                    "type": "string"
+                },
                 "pause_start_date": {
                     "type": "string"
                 },
+                "pauses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.pauseResponse"
+                    }
+                },
+                "payment_attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.paymentAttemptResponse"
+                    }
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.paymentResponse"
+                    }
+                },
+                "pending_payment": {
+                    "$ref": "#/definitions/rest.paymentResponse"
+                },
+                "pending_plan_change": {
+                    "$ref": "#/definitions/rest.planChangeResponse"
+                },
+                "plan_changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.planChangeResponse"
+                    }
+                },
                 "price": {
-                    "type": "number"
+                    "type": "string"
+                },
+                "price_version_id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
                 },
                 "product_name": {
                     "type": "string"
                 },
+                "renewals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest.renewalResponse"
+                    }
+                },
+                "resume_date": {
+                    "type": "string"
+                },
                 "start_date": {
                     "type": "string"
                 },
@@ -410,10 +2141,19 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "tax": {
+                    "type": "string"
+                },
+                "tax_rate": {
                     "type": "number"
                 },
+                "trial_end_date": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// AuditAction type to represent the change recorded in the audit log
+type AuditAction string
+
+const (
+	AuditActionCreated             AuditAction = "created"
+	AuditActionStatusChanged       AuditAction = "status_changed"
+	AuditActionRenewed             AuditAction = "renewed"
+	AuditActionCancelScheduled     AuditAction = "cancel_scheduled"
+	AuditActionCancelUndone        AuditAction = "cancel_undone"
+	AuditActionPlanChanged         AuditAction = "plan_changed"
+	AuditActionPlanChangeScheduled AuditAction = "plan_change_scheduled"
+	AuditActionPaymentConfirmed    AuditAction = "payment_confirmed"
+	AuditActionPaymentFailed       AuditAction = "payment_failed"
+
+	AuditActionRenewalPaymentFailed    AuditAction = "renewal_payment_failed"
+	AuditActionPaymentRetriesExhausted AuditAction = "payment_retries_exhausted"
+)
+
+// AuditEvent represents a change of the subscription in the audit log
+// PreviousStatus is empty for created subscription
+// Actor is who made the change and RequestID is ID of the API request which made the change if any
+type AuditEvent struct {
+	ID             string
+	SubscriptionID string
+	Action         AuditAction
+	PreviousStatus SubscriptionStatus
+	NewStatus      SubscriptionStatus
+	Actor          string
+	RequestID      string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// PaymentAttempt represents the renewal charge attempt of the period starting at PeriodStart
+// Error is the reason the payment provider declined the charge, it is empty for succeeded attempt
+type PaymentAttempt struct {
+	PeriodStart time.Time
+	AttemptedAt time.Time
+	Succeeded   bool
+	Error       string
+}
+
+// FailedPaymentAttempts returns the failed renewal charge attempts of the period starting at given time
+func (us *UserSubscription) FailedPaymentAttempts(periodStart time.Time) []PaymentAttempt {
+	var attempts []PaymentAttempt
+	for _, v := range us.PaymentAttempts {
+		if !v.Succeeded && v.PeriodStart.Equal(periodStart) {
+			attempts = append(attempts, v)
+		}
+	}
+	return attempts
+}
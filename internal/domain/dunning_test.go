@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserSubscription_FailedPaymentAttempts(t *testing.T) {
+	periodStart := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	previousPeriodStart := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		attempts []PaymentAttempt
+		want     int
+	}{
+		{
+			name: "should return nil if the period was never retried",
+		},
+		{
+			name: "should return failed attempts of the period only",
+			attempts: []PaymentAttempt{
+				{PeriodStart: previousPeriodStart, AttemptedAt: previousPeriodStart},
+				{PeriodStart: previousPeriodStart, AttemptedAt: previousPeriodStart.AddDate(0, 0, 1), Succeeded: true},
+				{PeriodStart: periodStart, AttemptedAt: periodStart},
+				{PeriodStart: periodStart, AttemptedAt: periodStart.AddDate(0, 0, 1)},
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserSubscription{PaymentAttempts: tt.attempts}
+			got := us.FailedPaymentAttempts(periodStart)
+			if len(got) != tt.want {
+				t.Errorf("UserSubscription.FailedPaymentAttempts() = %v, want %v attempts", got, tt.want)
+			}
+			for _, v := range got {
+				if v.Succeeded || !v.PeriodStart.Equal(periodStart) {
+					t.Errorf("UserSubscription.FailedPaymentAttempts() = %v, want failed attempts of %v", got, periodStart)
+				}
+			}
+		})
+	}
+}
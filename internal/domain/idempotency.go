@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord stores the response of the request made with the idempotency key,
+// retries of the request with the same key get the stored response instead of repeating the request
+// Fingerprint identifies the request the key was first used with
+// StatusCode is 0 while the request is in progress, the record is not used after ExpiresAt
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IsCompleted returns true if the response of the request is stored
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// InvoiceType type to represent if the invoice bills a charge or credits a refund
+type InvoiceType string
+
+const (
+	InvoiceTypeInvoice    InvoiceType = "invoice"
+	InvoiceTypeCreditNote InvoiceType = "credit_note"
+)
+
+// Invoice represents the bill of the subscription charge or the credit note of the refund of the charge
+// AuthorizationID is the provider authorization of the charge, credit note has the authorization of the refunded charge
+// Sequence is sequential without gaps within the Year the invoice is issued in and Number is formatted from both
+// Net, Tax and Total are the sums of the line items, they are negative for the credit note
+type Invoice struct {
+	ID              string
+	Type            InvoiceType
+	Number          string
+	Year            int
+	Sequence        int64
+	SubscriptionID  string
+	Email           string
+	Country         string
+	AuthorizationID string
+	Reason          PaymentReason
+	Lines           []InvoiceLine
+	Net             Money
+	Tax             Money
+	Total           Money
+	IssuedAt        time.Time
+}
+
+// InvoiceLine represents the product charged for the period from PeriodStart to PeriodEnd
+// Gross is the charged amount, Net and Tax is its breakdown for applied TaxRate
+type InvoiceLine struct {
+	ProductID   string
+	ProductName string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Net         Money
+	Tax         Money
+	Gross       Money
+	TaxRate     float64
+}
+
+// InvoiceNumber returns the invoice number of the sequence in the year e.g. 2022-000001
+func InvoiceNumber(year int, sequence int64) string {
+	return fmt.Sprintf("%04d-%06d", year, sequence)
+}
+
+// SetNumber sets the year, sequence and number of the invoice, the year is the year the invoice is issued in
+func (i *Invoice) SetNumber(sequence int64) {
+	i.Year = i.IssuedAt.UTC().Year()
+	i.Sequence = sequence
+	i.Number = InvoiceNumber(i.Year, sequence)
+}
+
+// NewInvoice creates invoice of the charge or credit note of the refund with given line items,
+// the totals are summed from the line items
+func NewInvoice(subscription *UserSubscription, charge *Payment, lines []InvoiceLine, issuedAt time.Time) Invoice {
+	invoiceType := InvoiceTypeInvoice
+	if charge.Type == PaymentTypeRefund {
+		invoiceType = InvoiceTypeCreditNote
+	}
+
+	invoice := Invoice{
+		Type:            invoiceType,
+		SubscriptionID:  subscription.ID,
+		Email:           subscription.Email,
+		Country:         subscription.Country,
+		AuthorizationID: charge.AuthorizationID,
+		Reason:          charge.Reason,
+		Lines:           lines,
+		Net:             NewMoney(0, charge.Amount.Currency),
+		Tax:             NewMoney(0, charge.Amount.Currency),
+		Total:           NewMoney(0, charge.Amount.Currency),
+		IssuedAt:        issuedAt,
+	}
+
+	for _, v := range lines {
+		invoice.Net.Amount += v.Net.Amount
+		invoice.Tax.Amount += v.Tax.Amount
+		invoice.Total.Amount += v.Gross.Amount
+	}
+	return invoice
+}
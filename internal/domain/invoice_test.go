@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvoice_SetNumber(t *testing.T) {
+	invoice := Invoice{
+		IssuedAt: time.Date(2022, 12, 31, 23, 30, 0, 0, time.FixedZone("UTC-1", -3600)),
+	}
+	invoice.SetNumber(42)
+
+	if invoice.Year != 2023 || invoice.Sequence != 42 || invoice.Number != "2023-000042" {
+		t.Errorf("Invoice.SetNumber() = %v, %v, %v, want %v, %v, %v", invoice.Year, invoice.Sequence, invoice.Number, 2023, 42, "2023-000042")
+	}
+}
+
+func TestNewInvoice(t *testing.T) {
+	issuedAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	subscription := &UserSubscription{
+		ID:      "62bc589278b49cee00f01421",
+		Email:   "user@test.com",
+		Country: "DE",
+	}
+	charge := &Payment{
+		AuthorizationID: "auth-1",
+		Type:            PaymentTypeCharge,
+		Reason:          PaymentReasonRenewal,
+		Amount:          NewMoney(1500, "EUR"),
+	}
+	lines := []InvoiceLine{
+		{Net: NewMoney(840, "EUR"), Tax: NewMoney(160, "EUR"), Gross: NewMoney(1000, "EUR")},
+		{Net: NewMoney(420, "EUR"), Tax: NewMoney(80, "EUR"), Gross: NewMoney(500, "EUR")},
+	}
+
+	got := NewInvoice(subscription, charge, lines, issuedAt)
+	if got.Type != InvoiceTypeInvoice || got.SubscriptionID != subscription.ID || got.Email != subscription.Email || got.AuthorizationID != charge.AuthorizationID ||
+		got.Reason != PaymentReasonRenewal || !got.IssuedAt.Equal(issuedAt) || len(got.Lines) != 2 {
+		t.Errorf("NewInvoice() = %v, want invoice of the charge", got)
+	}
+	if got.Net != NewMoney(1260, "EUR") || got.Tax != NewMoney(240, "EUR") || got.Total != NewMoney(1500, "EUR") {
+		t.Errorf("NewInvoice() totals = %v, %v, %v, want %v, %v, %v", got.Net, got.Tax, got.Total,
+			NewMoney(1260, "EUR"), NewMoney(240, "EUR"), NewMoney(1500, "EUR"))
+	}
+}
+
+func TestNewInvoice_CreditNote(t *testing.T) {
+	subscription := &UserSubscription{ID: "62bc589278b49cee00f01421", Email: "user@test.com"}
+	refund := &Payment{
+		AuthorizationID: "auth-1",
+		Type:            PaymentTypeRefund,
+		Reason:          PaymentReasonPlanChange,
+		Amount:          NewMoney(500, "EUR"),
+	}
+	lines := []InvoiceLine{
+		{Net: NewMoney(-420, "EUR"), Tax: NewMoney(-80, "EUR"), Gross: NewMoney(-500, "EUR")},
+	}
+
+	got := NewInvoice(subscription, refund, lines, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+	if got.Type != InvoiceTypeCreditNote || got.AuthorizationID != refund.AuthorizationID {
+		t.Errorf("NewInvoice() = %v, want credit note of the refund", got)
+	}
+	if got.Net != NewMoney(-420, "EUR") || got.Tax != NewMoney(-80, "EUR") || got.Total != NewMoney(-500, "EUR") {
+		t.Errorf("NewInvoice() totals = %v, %v, %v, want negative totals of the refund", got.Net, got.Tax, got.Total)
+	}
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency used when no currency is specified
+const DefaultCurrency = "EUR"
+
+var (
+	CurrencyMismatchErr = errors.New("currency mismatch")
+	InvalidAmountErr    = errors.New("invalid amount")
+)
+
+// currencyMinorUnits holds number of minor unit digits for currencies which
+// do not use 2 digits (e.g. cents)
+var currencyMinorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// Money represents an exact monetary amount
+// Amount is in minor units of the currency e.g. 10.50 EUR is 1050
+// Currency is ISO 4217 currency code
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney creates money for given amount in minor units and currency code
+func NewMoney(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+// ParseMoney parses decimal string e.g. "10.50" into money of given currency
+// returns error if value has more decimal digits than the currency allows
+func ParseMoney(value string, currency string) (Money, error) {
+	digits := MinorUnits(currency)
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	intPart, fracPart, _ := strings.Cut(value, ".")
+	if intPart == "" || len(fracPart) > digits {
+		return Money{}, fmt.Errorf("%v %w", value, InvalidAmountErr)
+	}
+	fracPart += strings.Repeat("0", digits-len(fracPart))
+
+	amount, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%v %w", value, InvalidAmountErr)
+	}
+	if negative {
+		amount = -amount
+	}
+	return NewMoney(amount, currency), nil
+}
+
+// MinorUnits returns number of decimal digits used by the currency
+func MinorUnits(currency string) int {
+	if digits, ok := currencyMinorUnits[strings.ToUpper(currency)]; ok {
+		return digits
+	}
+	return 2
+}
+
+// String returns exact decimal representation of the amount e.g. "10.50"
+func (m Money) String() string {
+	digits := MinorUnits(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	factor := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/factor, digits, amount%factor)
+}
+
+// IsZero returns true if amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns sum of the money values, returns error if currencies differ
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%v and %v %w", m.Currency, other.Currency, CurrencyMismatchErr)
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+// Sub returns difference of the money values, returns error if currencies differ
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%v and %v %w", m.Currency, other.Currency, CurrencyMismatchErr)
+	}
+	return NewMoney(m.Amount-other.Amount, m.Currency), nil
+}
+
+// Percentage returns given percent of the money rounded half away from zero
+// percent is rounded to 2 decimal places (basis points) so the calculation is done on integers
+func (m Money) Percentage(percent float64) Money {
+	return NewMoney(divRound(m.Amount*basisPoints(percent), 10000), m.Currency)
+}
+
+// basisPoints converts percent to basis points e.g. 7.7% is 770
+func basisPoints(percent float64) int64 {
+	return int64(math.Round(percent * 100))
+}
+
+// divRound divides n by d rounding half away from zero, d must be positive
+func divRound(n int64, d int64) int64 {
+	q := n / d
+	r := n % d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
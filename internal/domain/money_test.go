@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{
+			name:  "should return decimal string for cents",
+			money: NewMoney(1050, "EUR"),
+			want:  "10.50",
+		},
+		{
+			name:  "should pad minor units with zeros",
+			money: NewMoney(7, "EUR"),
+			want:  "0.07",
+		},
+		{
+			name:  "should return negative amount",
+			money: NewMoney(-1999, "CHF"),
+			want:  "-19.99",
+		},
+		{
+			name:  "should return amount without decimals for zero minor unit currency",
+			money: NewMoney(500, "JPY"),
+			want:  "500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("Money.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{
+			name:     "should parse decimal string",
+			value:    "10.5",
+			currency: "eur",
+			want:     NewMoney(1050, "EUR"),
+		},
+		{
+			name:     "should parse integer string",
+			value:    "20",
+			currency: "EUR",
+			want:     NewMoney(2000, "EUR"),
+		},
+		{
+			name:     "should parse negative value",
+			value:    "-0.99",
+			currency: "EUR",
+			want:     NewMoney(-99, "EUR"),
+		},
+		{
+			name:     "should return error for too many decimals",
+			value:    "10.505",
+			currency: "EUR",
+			wantErr:  true,
+		},
+		{
+			name:     "should return error for invalid value",
+			value:    "ten",
+			currency: "EUR",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMoney() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyPercentage(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		percent float64
+		want    Money
+	}{
+		{
+			name:    "should return exact percentage",
+			money:   NewMoney(1000, "EUR"),
+			percent: 10,
+			want:    NewMoney(100, "EUR"),
+		},
+		{
+			name:    "should round half away from zero",
+			money:   NewMoney(1995, "EUR"),
+			percent: 10,
+			want:    NewMoney(200, "EUR"),
+		},
+		{
+			name:    "should not drift for fractional percent",
+			money:   NewMoney(1999, "EUR"),
+			percent: 7.7,
+			want:    NewMoney(154, "EUR"),
+		},
+		{
+			name:    "should round negative amounts away from zero",
+			money:   NewMoney(-1995, "EUR"),
+			percent: 10,
+			want:    NewMoney(-200, "EUR"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.Percentage(tt.percent); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Money.Percentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		other   Money
+		want    Money
+		wantErr bool
+	}{
+		{
+			name:  "should add same currency amounts",
+			money: NewMoney(1000, "EUR"),
+			other: NewMoney(99, "EUR"),
+			want:  NewMoney(1099, "EUR"),
+		},
+		{
+			name:    "should return error for different currencies",
+			money:   NewMoney(1000, "EUR"),
+			other:   NewMoney(99, "CHF"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Add(tt.other)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Money.Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Money.Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// PaymentType type to represent if the money was charged or refunded
+type PaymentType string
+
+const (
+	PaymentTypeCharge PaymentType = "charge"
+	PaymentTypeRefund PaymentType = "refund"
+)
+
+// PaymentReason type to represent why the subscription was charged or refunded
+type PaymentReason string
+
+const (
+	PaymentReasonPurchase   PaymentReason = "purchase"
+	PaymentReasonRenewal    PaymentReason = "renewal"
+	PaymentReasonPlanChange PaymentReason = "plan_change"
+)
+
+// Payment represents money charged or refunded through the payment provider
+// AuthorizationID is the provider authorization of the charge, refund has the authorization of the refunded charge
+type Payment struct {
+	AuthorizationID string
+	Type            PaymentType
+	Reason          PaymentReason
+	Amount          Money
+	CreatedAt       time.Time
+}
+
+// RefundableAmount returns the amount of the charge which is not refunded yet by the refunds of the subscription
+func (us *UserSubscription) RefundableAmount(charge *Payment) Money {
+	amount := charge.Amount
+	for _, v := range us.Payments {
+		if v.Type == PaymentTypeRefund && v.AuthorizationID == charge.AuthorizationID {
+			amount.Amount -= v.Amount.Amount
+		}
+	}
+	if amount.Amount < 0 {
+		amount.Amount = 0
+	}
+	return amount
+}
+
+// LastCharge returns the last charge of the subscription, returns nil if the subscription was never charged
+func (us *UserSubscription) LastCharge() *Payment {
+	for i := len(us.Payments) - 1; i >= 0; i-- {
+		if us.Payments[i].Type == PaymentTypeCharge {
+			return &us.Payments[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestUserSubscription_LastCharge(t *testing.T) {
+	tests := []struct {
+		name     string
+		payments []Payment
+		want     string
+	}{
+		{
+			name: "should return nil if the subscription was never charged",
+		},
+		{
+			name: "should return last charge before the refund",
+			payments: []Payment{
+				{AuthorizationID: "auth-1", Type: PaymentTypeCharge},
+				{AuthorizationID: "auth-2", Type: PaymentTypeCharge},
+				{AuthorizationID: "auth-2", Type: PaymentTypeRefund},
+			},
+			want: "auth-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserSubscription{Payments: tt.payments}
+			got := us.LastCharge()
+			if (got == nil) != (tt.want == "") || (got != nil && got.AuthorizationID != tt.want) {
+				t.Errorf("UserSubscription.LastCharge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserSubscription_RefundableAmount(t *testing.T) {
+	charge := Payment{AuthorizationID: "auth-1", Type: PaymentTypeCharge, Amount: NewMoney(1000, "EUR")}
+	tests := []struct {
+		name     string
+		payments []Payment
+		want     Money
+	}{
+		{
+			name:     "should return charged amount if the charge was not refunded",
+			payments: []Payment{charge},
+			want:     NewMoney(1000, "EUR"),
+		},
+		{
+			name: "should subtract refunds of the charge",
+			payments: []Payment{
+				charge,
+				{AuthorizationID: "auth-1", Type: PaymentTypeRefund, Amount: NewMoney(300, "EUR")},
+				{AuthorizationID: "auth-2", Type: PaymentTypeRefund, Amount: NewMoney(500, "EUR")},
+			},
+			want: NewMoney(700, "EUR"),
+		},
+		{
+			name: "should return zero if the charge was fully refunded",
+			payments: []Payment{
+				charge,
+				{AuthorizationID: "auth-1", Type: PaymentTypeRefund, Amount: NewMoney(1000, "EUR")},
+				{AuthorizationID: "auth-1", Type: PaymentTypeRefund, Amount: NewMoney(100, "EUR")},
+			},
+			want: NewMoney(0, "EUR"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserSubscription{Payments: tt.payments}
+			if got := us.RefundableAmount(&charge); got != tt.want {
+				t.Errorf("UserSubscription.RefundableAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
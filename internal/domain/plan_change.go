@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+// PlanChangeTiming type to represent when the plan change takes effect
+type PlanChangeTiming string
+
+const (
+	PlanChangeImmediate   PlanChangeTiming = "immediate"
+	PlanChangeAtPeriodEnd PlanChangeTiming = "period_end"
+)
+
+// PlanChange represents change of the subscription product from FromProductID to ToProductID
+// Price is the gross price of the new product, it is set once the change is applied
+// Proration is the prorated amount for the remaining days of the period at the time of the change,
+// positive amount is charged and negative amount is credited to the user
+type PlanChange struct {
+	RequestedAt   time.Time
+	EffectiveAt   time.Time
+	FromProductID string
+	ToProductID   string
+	Price         Money
+	Proration     Money
+}
+
+// Proration represents prorated amount for the remaining days of the subscription period
+// Credit is the unused part of the current price and Charge is the price of the new plan for the remaining days
+// Amount is Charge minus Credit
+type Proration struct {
+	RemainingDays int64
+	Credit        Money
+	Charge        Money
+	Amount        Money
+}
+
+// CalculateProration calculates proration for switching from current price to new price at given time
+// current price is spread over the current period from periodStart to periodEnd and
+// new price is spread over newPeriodMonths starting at given time
+// returns error if the prices have different currencies
+func CalculateProration(currentPrice Money, periodStart, periodEnd time.Time, newPrice Money, newPeriodMonths uint, at time.Time) (Proration, error) {
+	currentPeriodDays := daysBetween(periodStart, periodEnd)
+	newPeriodDays := daysBetween(at, at.AddDate(0, int(newPeriodMonths), 0))
+	remainingDays := daysBetween(at, periodEnd)
+	if remainingDays > currentPeriodDays {
+		remainingDays = currentPeriodDays
+	}
+
+	credit := currentPrice.prorate(remainingDays, currentPeriodDays)
+	charge := newPrice.prorate(remainingDays, newPeriodDays)
+	amount, err := charge.Sub(credit)
+	if err != nil {
+		return Proration{}, err
+	}
+
+	return Proration{
+		RemainingDays: remainingDays,
+		Credit:        credit,
+		Charge:        charge,
+		Amount:        amount,
+	}, nil
+}
+
+// prorate returns part of the money for days out of totalDays rounded half away from zero
+func (m Money) prorate(days int64, totalDays int64) Money {
+	if totalDays <= 0 {
+		return NewMoney(0, m.Currency)
+	}
+	return NewMoney(divRound(m.Amount*days, totalDays), m.Currency)
+}
+
+// daysBetween returns number of whole days from start to end, returns 0 if end is before start
+func daysBetween(start time.Time, end time.Time) int64 {
+	if !end.After(start) {
+		return 0
+	}
+	return int64(end.Sub(start) / (24 * time.Hour))
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCalculateProration(t *testing.T) {
+	periodStart := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	type args struct {
+		currentPrice    Money
+		newPrice        Money
+		newPeriodMonths uint
+		at              time.Time
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Proration
+		wantErr bool
+	}{
+		{
+			name: "should charge difference for upgrade in the middle of the period",
+			args: args{
+				currentPrice:    NewMoney(1000, "EUR"),
+				newPrice:        NewMoney(2000, "EUR"),
+				newPeriodMonths: 1,
+				at:              time.Date(2022, 6, 16, 0, 0, 0, 0, time.UTC),
+			},
+			want: Proration{
+				RemainingDays: 15,
+				Credit:        NewMoney(500, "EUR"),
+				Charge:        NewMoney(1000, "EUR"),
+				Amount:        NewMoney(500, "EUR"),
+			},
+		},
+		{
+			name: "should credit difference for downgrade",
+			args: args{
+				currentPrice:    NewMoney(3000, "EUR"),
+				newPrice:        NewMoney(1000, "EUR"),
+				newPeriodMonths: 1,
+				at:              time.Date(2022, 6, 16, 0, 0, 0, 0, time.UTC),
+			},
+			want: Proration{
+				RemainingDays: 15,
+				Credit:        NewMoney(1500, "EUR"),
+				Charge:        NewMoney(500, "EUR"),
+				Amount:        NewMoney(-1000, "EUR"),
+			},
+		},
+		{
+			name: "should not prorate after the period end",
+			args: args{
+				currentPrice:    NewMoney(1000, "EUR"),
+				newPrice:        NewMoney(2000, "EUR"),
+				newPeriodMonths: 2,
+				at:              time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC),
+			},
+			want: Proration{
+				RemainingDays: 0,
+				Credit:        NewMoney(0, "EUR"),
+				Charge:        NewMoney(0, "EUR"),
+				Amount:        NewMoney(0, "EUR"),
+			},
+		},
+		{
+			name: "should return error for different currencies",
+			args: args{
+				currentPrice:    NewMoney(1000, "EUR"),
+				newPrice:        NewMoney(2000, "CHF"),
+				newPeriodMonths: 1,
+				at:              time.Date(2022, 6, 16, 0, 0, 0, 0, time.UTC),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalculateProration(tt.args.currentPrice, periodStart, periodEnd, tt.args.newPrice, tt.args.newPeriodMonths, tt.args.at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CalculateProration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateProration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
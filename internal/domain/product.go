@@ -1,15 +1,190 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidProductErr = errors.New("invalid product")
+)
+
 // Product represents the product details
 // ID unique product id
 // Name product name
 // SubscriptionPeriod is a period in terms of months
-// Price is total price including tax
-// TaxPercentage is % of tax applied on base price to get Price
+// Price is default price
+// Prices is a price list with prices for other currencies/countries
+// TaxCategory is used to find tax rule for the product
+// TaxInclusive is true if prices include tax otherwise tax is added on top of the prices
+// TrialDays is length of free trial in days, 0 means the product has no trial
+// MaxPauseDays is maximum length of a single pause in days, 0 means the pause length is not limited
+// MaxPausesPerPeriod is maximum number of pauses in a subscription period, 0 means the pauses are not limited
+// PriceVersions are scheduled price changes sorted by EffectiveFrom, Price and Prices apply before the first version
+// ArchivedAt is set when the product is archived, archived product cannot be bought
 type Product struct {
 	ID                 string
 	Name               string
 	SubscriptionPeriod uint
-	Price              float64
-	TaxPercentage      float64
+	TrialDays          uint
+	MaxPauseDays       uint
+	MaxPausesPerPeriod uint
+	Price              Money
+	Prices             []ProductPrice
+	PriceVersions      []PriceVersion
+	TaxCategory        string
+	TaxInclusive       bool
+	ArchivedAt         *time.Time
+}
+
+// ProductPrice represents product price for a currency and optionally a country
+// Country is ISO 3166-1 alpha-2 code, empty country means price applies to any country
+type ProductPrice struct {
+	Country string
+	Price   Money
+}
+
+// PriceVersion represents product prices effective from EffectiveFrom until the next price version
+// ID is empty for the base version made of the product Price and Prices
+type PriceVersion struct {
+	ID            string
+	EffectiveFrom time.Time
+	Price         Money
+	Prices        []ProductPrice
+}
+
+// PriceFor returns product price for given currency and country
+// price for the country takes precedence over the price for the currency,
+// default price is returned if currency and country are empty
+// returns false if no price matches
+func (v *PriceVersion) PriceFor(currency string, country string) (Money, bool) {
+	currency = strings.ToUpper(currency)
+	country = strings.ToUpper(country)
+
+	if country != "" {
+		for _, p := range v.Prices {
+			if p.Country == country && (currency == "" || p.Price.Currency == currency) {
+				return p.Price, true
+			}
+		}
+	}
+
+	if currency == "" || currency == v.Price.Currency {
+		return v.Price, true
+	}
+
+	for _, p := range v.Prices {
+		if p.Country == "" && p.Price.Currency == currency {
+			return p.Price, true
+		}
+	}
+	return Money{}, false
+}
+
+// PriceFor returns base price of the product for given currency and country, see PriceVersion.PriceFor
+func (p *Product) PriceFor(currency string, country string) (Money, bool) {
+	base := PriceVersion{Price: p.Price, Prices: p.Prices}
+	return base.PriceFor(currency, country)
+}
+
+// PriceVersionAt returns price version effective at given time, which is the last version effective from or before the time
+// returns the base version if no price version is effective yet
+func (p *Product) PriceVersionAt(at time.Time) PriceVersion {
+	version := PriceVersion{Price: p.Price, Prices: p.Prices}
+	for _, v := range p.PriceVersions {
+		if v.EffectiveFrom.After(at) {
+			break
+		}
+		version = v
+	}
+	return version
+}
+
+// IsArchived returns true if the product is archived
+func (p *Product) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+// Validate returns InvalidProductErr if the product cannot be sold
+// name and tax category are required, subscription period must be positive,
+// prices must not be negative and price list must not have two prices for the same currency and country
+// price versions must have effective from and be sorted by it
+func (p *Product) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("name is empty %w", InvalidProductErr)
+	}
+
+	if p.SubscriptionPeriod == 0 {
+		return fmt.Errorf("subscription period must be positive %w", InvalidProductErr)
+	}
+
+	if strings.TrimSpace(p.TaxCategory) == "" {
+		return fmt.Errorf("tax category is empty %w", InvalidProductErr)
+	}
+
+	err := validatePriceList(p.Price, p.Prices)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range p.PriceVersions {
+		if v.EffectiveFrom.IsZero() {
+			return fmt.Errorf("price version effective from is empty %w", InvalidProductErr)
+		}
+
+		if i > 0 && !v.EffectiveFrom.After(p.PriceVersions[i-1].EffectiveFrom) {
+			return fmt.Errorf("price versions are not sorted by effective from %w", InvalidProductErr)
+		}
+
+		err := validatePriceList(v.Price, v.Prices)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePriceList returns InvalidProductErr if the default price or a price of the price list is not valid
+// or the price list has two prices for the same currency and country
+func validatePriceList(price Money, prices []ProductPrice) error {
+	err := validatePrice(price)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{price.Currency: true}
+	for _, v := range prices {
+		err := validatePrice(v.Price)
+		if err != nil {
+			return err
+		}
+
+		if v.Country != "" && len(v.Country) != 2 {
+			return fmt.Errorf("price country %v %w", v.Country, InvalidProductErr)
+		}
+
+		key := v.Price.Currency + "/" + v.Country
+		if v.Country == "" {
+			key = v.Price.Currency
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate price %v %w", key, InvalidProductErr)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// validatePrice returns InvalidProductErr if the price is negative or has no valid currency code
+func validatePrice(price Money) error {
+	if len(price.Currency) != 3 {
+		return fmt.Errorf("price currency %v %w", price.Currency, InvalidProductErr)
+	}
+
+	if price.Amount < 0 {
+		return fmt.Errorf("price %v must not be negative %w", price, InvalidProductErr)
+	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package domain
+
+// ProductSortField type to represent the field the listed products are sorted by
+type ProductSortField string
+
+const (
+	ProductSortByName               ProductSortField = "name"
+	ProductSortByPrice              ProductSortField = "price"
+	ProductSortBySubscriptionPeriod ProductSortField = "subscription_period"
+)
+
+// IsValid returns true if the products can be sorted by the field
+func (f ProductSortField) IsValid() bool {
+	return f == ProductSortByName || f == ProductSortByPrice || f == ProductSortBySubscriptionPeriod
+}
+
+// ProductQuery represents request to list the products
+// Name matches products whose name contains it ignoring case
+// MinPrice and MaxPrice match products with default price in the same currency within the range including both
+// archived products are listed only if IncludeArchived is set
+// products with the same sort field value are sorted by ID
+// Cursor is NextCursor of the previous page, empty cursor returns the first page
+type ProductQuery struct {
+	Name               string
+	SubscriptionPeriod uint
+	MinPrice           *Money
+	MaxPrice           *Money
+	IncludeArchived    bool
+	SortBy             ProductSortField
+	SortDesc           bool
+	Limit              int64
+	Cursor             string
+}
+
+// ProductPage represents a page of the listed products
+// TotalCount is number of all the products matching the query, NextCursor is empty for the last page
+type ProductPage struct {
+	Products   []Product
+	TotalCount int64
+	NextCursor string
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "testing"
+
+func TestProductSortField_IsValid(t *testing.T) {
+	tests := []struct {
+		field ProductSortField
+		want  bool
+	}{
+		{field: ProductSortByName, want: true},
+		{field: ProductSortByPrice, want: true},
+		{field: ProductSortBySubscriptionPeriod, want: true},
+		{field: "trial_days", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			if got := tt.field.IsValid(); got != tt.want {
+				t.Errorf("ProductSortField.IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
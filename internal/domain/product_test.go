@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProductPriceFor(t *testing.T) {
+	product := &Product{
+		Price: NewMoney(1000, "EUR"),
+		Prices: []ProductPrice{
+			{
+				Price: NewMoney(1100, "CHF"),
+			},
+			{
+				Price: NewMoney(900, "GBP"),
+			},
+			{
+				Country: "AT",
+				Price:   NewMoney(950, "EUR"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		currency string
+		country  string
+		want     Money
+		wantOk   bool
+	}{
+		{
+			name:   "should return default price for empty currency and country",
+			want:   NewMoney(1000, "EUR"),
+			wantOk: true,
+		},
+		{
+			name:     "should return price for currency",
+			currency: "chf",
+			want:     NewMoney(1100, "CHF"),
+			wantOk:   true,
+		},
+		{
+			name:    "should return price for country",
+			country: "AT",
+			want:    NewMoney(950, "EUR"),
+			wantOk:  true,
+		},
+		{
+			name:     "should return currency price if country price has other currency",
+			currency: "GBP",
+			country:  "AT",
+			want:     NewMoney(900, "GBP"),
+			wantOk:   true,
+		},
+		{
+			name:    "should return default price for country without price",
+			country: "DE",
+			want:    NewMoney(1000, "EUR"),
+			wantOk:  true,
+		},
+		{
+			name:     "should return false for currency without price",
+			currency: "USD",
+			wantOk:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := product.PriceFor(tt.currency, tt.country)
+			if ok != tt.wantOk {
+				t.Errorf("Product.PriceFor() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Product.PriceFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProduct_Validate(t *testing.T) {
+	valid := Product{
+		Name:               "get in shape",
+		SubscriptionPeriod: 1,
+		Price:              NewMoney(1000, "EUR"),
+		Prices: []ProductPrice{
+			{Price: NewMoney(1100, "CHF")},
+			{Country: "AT", Price: NewMoney(950, "EUR")},
+		},
+		TaxCategory: "digital_service",
+	}
+
+	tests := []struct {
+		name    string
+		change  func(p *Product)
+		wantErr bool
+	}{
+		{
+			name:    "should accept valid product",
+			change:  func(p *Product) {},
+			wantErr: false,
+		},
+		{
+			name:    "should accept free product",
+			change:  func(p *Product) { p.Price = NewMoney(0, "EUR") },
+			wantErr: false,
+		},
+		{
+			name:    "should reject empty name",
+			change:  func(p *Product) { p.Name = " " },
+			wantErr: true,
+		},
+		{
+			name:    "should reject zero subscription period",
+			change:  func(p *Product) { p.SubscriptionPeriod = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "should reject empty tax category",
+			change:  func(p *Product) { p.TaxCategory = "" },
+			wantErr: true,
+		},
+		{
+			name:    "should reject negative price",
+			change:  func(p *Product) { p.Price = NewMoney(-1, "EUR") },
+			wantErr: true,
+		},
+		{
+			name:    "should reject invalid currency",
+			change:  func(p *Product) { p.Price = NewMoney(1000, "EURO") },
+			wantErr: true,
+		},
+		{
+			name:    "should reject negative price list price",
+			change:  func(p *Product) { p.Prices = []ProductPrice{{Price: NewMoney(-1, "CHF")}} },
+			wantErr: true,
+		},
+		{
+			name:    "should reject invalid price list country",
+			change:  func(p *Product) { p.Prices = []ProductPrice{{Country: "AUT", Price: NewMoney(950, "EUR")}} },
+			wantErr: true,
+		},
+		{
+			name:    "should reject duplicate price of the default currency",
+			change:  func(p *Product) { p.Prices = []ProductPrice{{Price: NewMoney(1100, "EUR")}} },
+			wantErr: true,
+		},
+		{
+			name: "should accept sorted price versions",
+			change: func(p *Product) {
+				p.PriceVersions = []PriceVersion{
+					{EffectiveFrom: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), Price: NewMoney(1200, "EUR")},
+					{EffectiveFrom: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), Price: NewMoney(1300, "EUR")},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "should reject price version without effective from",
+			change: func(p *Product) {
+				p.PriceVersions = []PriceVersion{{Price: NewMoney(1200, "EUR")}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "should reject unsorted price versions",
+			change: func(p *Product) {
+				p.PriceVersions = []PriceVersion{
+					{EffectiveFrom: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), Price: NewMoney(1200, "EUR")},
+					{EffectiveFrom: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), Price: NewMoney(1300, "EUR")},
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "should reject negative price version price",
+			change: func(p *Product) {
+				p.PriceVersions = []PriceVersion{
+					{EffectiveFrom: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), Price: NewMoney(-1, "EUR")},
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := valid
+			tt.change(&product)
+			err := product.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Product.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, InvalidProductErr) {
+				t.Errorf("Product.Validate() error = %v, want %v", err, InvalidProductErr)
+			}
+		})
+	}
+}
+
+func TestProductPriceVersionAt(t *testing.T) {
+	july := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	august := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	product := Product{
+		Price:  NewMoney(1000, "EUR"),
+		Prices: []ProductPrice{{Country: "CH", Price: NewMoney(1100, "CHF")}},
+		PriceVersions: []PriceVersion{
+			{ID: "july", EffectiveFrom: july, Price: NewMoney(1200, "EUR")},
+			{ID: "august", EffectiveFrom: august, Price: NewMoney(1300, "EUR")},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantID    string
+		wantPrice Money
+	}{
+		{
+			name:      "should return base version before the first version",
+			at:        july.Add(-time.Second),
+			wantID:    "",
+			wantPrice: NewMoney(1000, "EUR"),
+		},
+		{
+			name:      "should return version effective from the time",
+			at:        july,
+			wantID:    "july",
+			wantPrice: NewMoney(1200, "EUR"),
+		},
+		{
+			name:      "should return last effective version",
+			at:        august.AddDate(1, 0, 0),
+			wantID:    "august",
+			wantPrice: NewMoney(1300, "EUR"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := product.PriceVersionAt(tt.at)
+			if got.ID != tt.wantID || got.Price != tt.wantPrice {
+				t.Errorf("Product.PriceVersionAt() = %v, want %v %v", got, tt.wantID, tt.wantPrice)
+			}
+		})
+	}
+
+	// base version keeps the price list of the product
+	base := product.PriceVersionAt(july.Add(-time.Second))
+	price, ok := base.PriceFor("CHF", "CH")
+	if !ok || price != NewMoney(1100, "CHF") {
+		t.Errorf("PriceVersion.PriceFor() = %v %v, want %v", price, ok, NewMoney(1100, "CHF"))
+	}
+}
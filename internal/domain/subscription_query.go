@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// SubscriptionSortField type to represent the field the listed subscriptions are sorted by
+type SubscriptionSortField string
+
+const (
+	SubscriptionSortByCreatedAt SubscriptionSortField = "created_at"
+	SubscriptionSortByEndDate   SubscriptionSortField = "end_date"
+)
+
+// IsValid returns true if the subscriptions can be sorted by the field
+func (f SubscriptionSortField) IsValid() bool {
+	return f == SubscriptionSortByCreatedAt || f == SubscriptionSortByEndDate
+}
+
+// SortValue returns value of the sort field of the subscription, end date is returned for unknown field
+func (f SubscriptionSortField) SortValue(us *UserSubscription) time.Time {
+	if f == SubscriptionSortByCreatedAt {
+		return us.CreatedAt
+	}
+	return us.EndDate
+}
+
+// SubscriptionQuery represents request to list the subscriptions of the user
+// empty fields are not used in the filter, date ranges include both From and To
+// subscriptions with the same sort field value are sorted by ID
+// Cursor is NextCursor of the previous page, empty cursor returns the first page
+type SubscriptionQuery struct {
+	Email       string
+	ProductID   string
+	Statuses    []SubscriptionStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	EndDateFrom *time.Time
+	EndDateTo   *time.Time
+	SortBy      SubscriptionSortField
+	SortDesc    bool
+	Limit       int64
+	Cursor      string
+}
+
+// SubscriptionPage represents a page of the listed subscriptions, NextCursor is empty for the last page
+type SubscriptionPage struct {
+	Subscriptions []UserSubscription
+	NextCursor    string
+}
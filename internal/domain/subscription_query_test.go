@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscriptionSortField_SortValue(t *testing.T) {
+	createdAt := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	us := &UserSubscription{CreatedAt: createdAt, EndDate: endDate}
+
+	tests := []struct {
+		field     SubscriptionSortField
+		want      time.Time
+		wantValid bool
+	}{
+		{field: SubscriptionSortByCreatedAt, want: createdAt, wantValid: true},
+		{field: SubscriptionSortByEndDate, want: endDate, wantValid: true},
+		{field: "price", want: endDate, wantValid: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			if got := tt.field.SortValue(us); !got.Equal(tt.want) {
+				t.Errorf("SubscriptionSortField.SortValue() = %v, want %v", got, tt.want)
+			}
+			if got := tt.field.IsValid(); got != tt.wantValid {
+				t.Errorf("SubscriptionSortField.IsValid() = %v, want %v", got, tt.wantValid)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// TaxRule represents tax rate for the product category sold to the customer of the country
+// Country is ISO 3166-1 alpha-2 code, empty country is the default rule for any country
+// Rate is tax percentage e.g. 19 for 19%
+// rule is effective from EffectiveFrom until EffectiveTo (exclusive), nil EffectiveTo means no end
+type TaxRule struct {
+	ID            string
+	Country       string
+	Category      string
+	Rate          float64
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+}
+
+// IsEffective returns true if the rule is effective at given time
+func (r *TaxRule) IsEffective(at time.Time) bool {
+	if at.Before(r.EffectiveFrom) {
+		return false
+	}
+	return r.EffectiveTo == nil || at.Before(*r.EffectiveTo)
+}
+
+// TaxBreakdown represents net, tax and gross amount of the price for applied tax rate
+// Inclusive is true if the price the tax was calculated from included tax
+type TaxBreakdown struct {
+	Net       Money
+	Tax       Money
+	Gross     Money
+	Rate      float64
+	Inclusive bool
+}
+
+// CalculateTax calculates tax breakdown for the price with given tax rate
+// if inclusive is true then price is gross price otherwise net price
+func CalculateTax(price Money, rate float64, inclusive bool) TaxBreakdown {
+	breakdown := TaxBreakdown{
+		Rate:      rate,
+		Inclusive: inclusive,
+	}
+
+	if inclusive {
+		basisPoints := basisPoints(rate)
+		breakdown.Gross = price
+		breakdown.Net = NewMoney(divRound(price.Amount*10000, 10000+basisPoints), price.Currency)
+		breakdown.Tax = NewMoney(price.Amount-breakdown.Net.Amount, price.Currency)
+		return breakdown
+	}
+
+	breakdown.Net = price
+	breakdown.Tax = price.Percentage(rate)
+	breakdown.Gross = NewMoney(price.Amount+breakdown.Tax.Amount, price.Currency)
+	return breakdown
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculateTax(t *testing.T) {
+	type args struct {
+		price     Money
+		rate      float64
+		inclusive bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want TaxBreakdown
+	}{
+		{
+			name: "should add tax on top of tax exclusive price",
+			args: args{
+				price: NewMoney(1000, "EUR"),
+				rate:  19,
+			},
+			want: TaxBreakdown{
+				Net:   NewMoney(1000, "EUR"),
+				Tax:   NewMoney(190, "EUR"),
+				Gross: NewMoney(1190, "EUR"),
+				Rate:  19,
+			},
+		},
+		{
+			name: "should extract tax from tax inclusive price",
+			args: args{
+				price:     NewMoney(1190, "EUR"),
+				rate:      19,
+				inclusive: true,
+			},
+			want: TaxBreakdown{
+				Net:       NewMoney(1000, "EUR"),
+				Tax:       NewMoney(190, "EUR"),
+				Gross:     NewMoney(1190, "EUR"),
+				Rate:      19,
+				Inclusive: true,
+			},
+		},
+		{
+			name: "should keep gross equal to net plus tax after rounding",
+			args: args{
+				price:     NewMoney(1100, "CHF"),
+				rate:      8.1,
+				inclusive: true,
+			},
+			want: TaxBreakdown{
+				Net:       NewMoney(1018, "CHF"),
+				Tax:       NewMoney(82, "CHF"),
+				Gross:     NewMoney(1100, "CHF"),
+				Rate:      8.1,
+				Inclusive: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateTax(tt.args.price, tt.args.rate, tt.args.inclusive); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateTax() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,20 +9,165 @@ const (
 	SubscriptionStatusActive    SubscriptionStatus = "active"
 	SubscriptionStatusPaused    SubscriptionStatus = "paused"
 	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+	SubscriptionStatusExpired   SubscriptionStatus = "expired"
+	SubscriptionStatusTrialing  SubscriptionStatus = "trialing"
+
+	SubscriptionStatusPendingPayment SubscriptionStatus = "pending_payment"
+	SubscriptionStatusPaymentFailed  SubscriptionStatus = "payment_failed"
+	SubscriptionStatusPastDue        SubscriptionStatus = "past_due"
 )
 
+// SubscriptionStatuses are all the statuses of the subscription
+var SubscriptionStatuses = []SubscriptionStatus{
+	SubscriptionStatusPendingPayment,
+	SubscriptionStatusTrialing,
+	SubscriptionStatusActive,
+	SubscriptionStatusPaused,
+	SubscriptionStatusPastDue,
+	SubscriptionStatusCancelled,
+	SubscriptionStatusExpired,
+	SubscriptionStatusPaymentFailed,
+}
+
+// IsValid returns true if the status is one of the subscription statuses
+func (s SubscriptionStatus) IsValid() bool {
+	for _, v := range SubscriptionStatuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
 // UserSubscription represent unique subscription for the user
 // Note that the price is inclusive of tax amount
+// Price is the gross price for the Country/currency at the time of purchase,
+// NetPrice and Tax is the breakdown of Price for applied TaxRate
+// PriceVersionID is the product price version the Price is taken from, empty for the base price of the product
+// AutoRenew subscription is extended by product subscription period after EndDate otherwise it expires
+// Renewals holds all the renewals of the subscription
+// TrialEndDate is set if the subscription started with a free trial
+// Pauses holds all the pauses of the subscription, the last pause is open while the subscription is paused
+// ResumeDate is the date the paused subscription is resumed automatically
+// CancelAtPeriodEnd subscription stays active until EndDate and is cancelled instead of renewed
+// PendingPlanChange is the plan change scheduled for the period end and PlanChanges holds all the applied plan changes
+// Version is incremented by every save of the subscription, it is used to detect concurrent changes
+// UniquenessKey is set while the subscription is live, only one live subscription can have the same key
+// PaymentToken is the card token the subscription is charged with and Payments holds all the charges and refunds
+// PendingPayment is the authorized purchase charge of the subscription waiting for the payment confirmation
+// NextPaymentRetry is the date the declined renewal charge of the past due subscription is retried
+// and PaymentAttempts holds all the renewal charge attempts of the past due periods
+// PendingInvoices holds the invoices and credit notes of the payments which are not issued yet,
+// they are saved together with the payments and removed once they are issued
+// PendingAuditEvents holds the audit events of the changes which are not recorded in the audit log yet,
+// they are saved together with the changes and removed once they are recorded
 type UserSubscription struct {
-	ID             string
-	CreatedAt      time.Time
-	UpdatedAt      *time.Time
-	Email          string
-	ProductName    string
-	StartDate      time.Time
-	EndDate        time.Time
-	Price          float64
-	Tax            float64
-	Status         SubscriptionStatus
-	PauseStartDate *time.Time
+	ID                 string
+	Version            int64
+	CreatedAt          time.Time
+	UpdatedAt          *time.Time
+	Email              string
+	Country            string
+	ProductID          string
+	ProductName        string
+	StartDate          time.Time
+	EndDate            time.Time
+	Price              Money
+	NetPrice           Money
+	Tax                Money
+	TaxRate            float64
+	PriceVersionID     string
+	Status             SubscriptionStatus
+	Pauses             []Pause
+	ResumeDate         *time.Time
+	AutoRenew          bool
+	Renewals           []Renewal
+	TrialEndDate       *time.Time
+	CancelAtPeriodEnd  bool
+	PendingPlanChange  *PlanChange
+	PlanChanges        []PlanChange
+	UniquenessKey      string
+	PaymentToken       string
+	Payments           []Payment
+	PendingPayment     *Payment
+	NextPaymentRetry   *time.Time
+	PaymentAttempts    []PaymentAttempt
+	PendingInvoices    []Invoice
+	PendingAuditEvents []AuditEvent
+}
+
+// LiveSubscriptionStatuses are statuses of the subscription which is not ended yet
+var LiveSubscriptionStatuses = []SubscriptionStatus{
+	SubscriptionStatusPendingPayment,
+	SubscriptionStatusTrialing,
+	SubscriptionStatusActive,
+	SubscriptionStatusPaused,
+	SubscriptionStatusPastDue,
+}
+
+// IsLive returns true if the subscription is pending payment, trialing, active, paused or past due
+func (us *UserSubscription) IsLive() bool {
+	for _, v := range LiveSubscriptionStatuses {
+		if us.Status == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Pause represents pause of the subscription from StartDate to EndDate, EndDate is nil while the pause is open
+// Reason is optional reason of the pause and Actor is who paused the subscription
+type Pause struct {
+	StartDate time.Time
+	EndDate   *time.Time
+	Reason    string
+	Actor     string
+}
+
+// Duration returns length of the closed pause, returns 0 for open pause
+func (p *Pause) Duration() time.Duration {
+	if p.EndDate == nil {
+		return 0
+	}
+	return p.EndDate.Sub(p.StartDate)
+}
+
+// CurrentPause returns the open pause of the subscription, returns nil if the subscription is not paused
+func (us *UserSubscription) CurrentPause() *Pause {
+	if len(us.Pauses) == 0 || us.Pauses[len(us.Pauses)-1].EndDate != nil {
+		return nil
+	}
+	return &us.Pauses[len(us.Pauses)-1]
+}
+
+// PausesSince returns number of pauses started at or after given time
+func (us *UserSubscription) PausesSince(t time.Time) uint {
+	var count uint
+	for _, v := range us.Pauses {
+		if !v.StartDate.Before(t) {
+			count++
+		}
+	}
+	return count
+}
+
+// Renewal represents renewal of the subscription for the period from PeriodStart to PeriodEnd
+// Price is the price of the period taken from the product price version PriceVersionID
+type Renewal struct {
+	RenewedAt      time.Time
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Price          Money
+	PriceVersionID string
+}
+
+// Purchase represents user's request to buy a subscription for the product
+// Currency (ISO 4217) and Country (ISO 3166-1 alpha-2) are optional and used to select the product price
+// PaymentToken is the card token of the payment provider the subscription is charged with
+type Purchase struct {
+	ProductID    string
+	EmailID      string
+	Currency     string
+	Country      string
+	PaymentToken string
 }
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUserSubscription_CurrentPause(t *testing.T) {
+	start := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 5)
+
+	tests := []struct {
+		name   string
+		pauses []Pause
+		want   *Pause
+	}{
+		{
+			name: "should return nil if subscription was never paused",
+			want: nil,
+		},
+		{
+			name: "should return nil if the last pause is closed",
+			pauses: []Pause{
+				{StartDate: start, EndDate: &end},
+			},
+			want: nil,
+		},
+		{
+			name: "should return the open pause",
+			pauses: []Pause{
+				{StartDate: start, EndDate: &end},
+				{StartDate: end.AddDate(0, 0, 1), Reason: "holiday"},
+			},
+			want: &Pause{StartDate: end.AddDate(0, 0, 1), Reason: "holiday"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &UserSubscription{
+				Pauses: tt.pauses,
+			}
+			if got := us.CurrentPause(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserSubscription.CurrentPause() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserSubscription_PausesSince(t *testing.T) {
+	start := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 5)
+	us := &UserSubscription{
+		Pauses: []Pause{
+			{StartDate: start, EndDate: &end},
+			{StartDate: end.AddDate(0, 0, 1)},
+		},
+	}
+
+	if got := us.PausesSince(start); got != 2 {
+		t.Errorf("UserSubscription.PausesSince() = %v, want %v", got, 2)
+	}
+
+	if got := us.PausesSince(end); got != 1 {
+		t.Errorf("UserSubscription.PausesSince() = %v, want %v", got, 1)
+	}
+}
+
+func TestPause_Duration(t *testing.T) {
+	start := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 5)
+
+	closed := &Pause{StartDate: start, EndDate: &end}
+	if got := closed.Duration(); got != 5*24*time.Hour {
+		t.Errorf("Pause.Duration() = %v, want %v", got, 5*24*time.Hour)
+	}
+
+	open := &Pause{StartDate: start}
+	if got := open.Duration(); got != 0 {
+		t.Errorf("Pause.Duration() = %v, want %v", got, 0)
+	}
+}
+
+func TestUserSubscription_IsLive(t *testing.T) {
+	tests := []struct {
+		status SubscriptionStatus
+		want   bool
+	}{
+		{status: SubscriptionStatusTrialing, want: true},
+		{status: SubscriptionStatusActive, want: true},
+		{status: SubscriptionStatusPaused, want: true},
+		{status: SubscriptionStatusCancelled, want: false},
+		{status: SubscriptionStatusExpired, want: false},
+		{status: SubscriptionStatusPendingPayment, want: true},
+		{status: SubscriptionStatusPaymentFailed, want: false},
+		{status: SubscriptionStatusPastDue, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			us := &UserSubscription{Status: tt.status}
+			if got := us.IsLive(); got != tt.want {
+				t.Errorf("UserSubscription.IsLive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionStatus_IsValid(t *testing.T) {
+	if !SubscriptionStatusExpired.IsValid() {
+		t.Errorf("SubscriptionStatus.IsValid() = %v, want %v", false, true)
+	}
+
+	if SubscriptionStatus("cancel").IsValid() {
+		t.Errorf("SubscriptionStatus.IsValid() = %v, want %v", true, false)
+	}
+}
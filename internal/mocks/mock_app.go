@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 	gomock "github.com/golang/mock/gomock"
@@ -35,19 +36,108 @@ func (m *MockApp) EXPECT() *MockAppMockRecorder {
 	return m.recorder
 }
 
+// ArchiveProduct mocks base method.
+func (m *MockApp) ArchiveProduct(arg0 context.Context, arg1 string) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveProduct", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveProduct indicates an expected call of ArchiveProduct.
+func (mr *MockAppMockRecorder) ArchiveProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveProduct", reflect.TypeOf((*MockApp)(nil).ArchiveProduct), arg0, arg1)
+}
+
 // BuySubscription mocks base method.
-func (m *MockApp) BuySubscription(arg0 context.Context, arg1, arg2 string) (*domain.UserSubscription, error) {
+func (m *MockApp) BuySubscription(arg0 context.Context, arg1 domain.Purchase) (*domain.UserSubscription, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuySubscription", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "BuySubscription", arg0, arg1)
 	ret0, _ := ret[0].(*domain.UserSubscription)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BuySubscription indicates an expected call of BuySubscription.
-func (mr *MockAppMockRecorder) BuySubscription(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockAppMockRecorder) BuySubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuySubscription", reflect.TypeOf((*MockApp)(nil).BuySubscription), arg0, arg1)
+}
+
+// CancelSubscriptionAtPeriodEnd mocks base method.
+func (m *MockApp) CancelSubscriptionAtPeriodEnd(arg0 context.Context, arg1 string) (*domain.UserSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSubscriptionAtPeriodEnd", arg0, arg1)
+	ret0, _ := ret[0].(*domain.UserSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelSubscriptionAtPeriodEnd indicates an expected call of CancelSubscriptionAtPeriodEnd.
+func (mr *MockAppMockRecorder) CancelSubscriptionAtPeriodEnd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSubscriptionAtPeriodEnd", reflect.TypeOf((*MockApp)(nil).CancelSubscriptionAtPeriodEnd), arg0, arg1)
+}
+
+// ChangePlan mocks base method.
+func (m *MockApp) ChangePlan(arg0 context.Context, arg1, arg2 string, arg3 domain.PlanChangeTiming) (*domain.UserSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePlan", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.UserSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePlan indicates an expected call of ChangePlan.
+func (mr *MockAppMockRecorder) ChangePlan(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePlan", reflect.TypeOf((*MockApp)(nil).ChangePlan), arg0, arg1, arg2, arg3)
+}
+
+// ConfirmPayment mocks base method.
+func (m *MockApp) ConfirmPayment(arg0 context.Context, arg1, arg2 string, arg3 bool) (*domain.UserSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPayment", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.UserSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmPayment indicates an expected call of ConfirmPayment.
+func (mr *MockAppMockRecorder) ConfirmPayment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPayment", reflect.TypeOf((*MockApp)(nil).ConfirmPayment), arg0, arg1, arg2, arg3)
+}
+
+// CreateProduct mocks base method.
+func (m *MockApp) CreateProduct(arg0 context.Context, arg1 domain.Product) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProduct", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProduct indicates an expected call of CreateProduct.
+func (mr *MockAppMockRecorder) CreateProduct(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuySubscription", reflect.TypeOf((*MockApp)(nil).BuySubscription), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProduct", reflect.TypeOf((*MockApp)(nil).CreateProduct), arg0, arg1)
+}
+
+// FinishIdempotentRequest mocks base method.
+func (m *MockApp) FinishIdempotentRequest(arg0 context.Context, arg1 *domain.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishIdempotentRequest", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishIdempotentRequest indicates an expected call of FinishIdempotentRequest.
+func (mr *MockAppMockRecorder) FinishIdempotentRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishIdempotentRequest", reflect.TypeOf((*MockApp)(nil).FinishIdempotentRequest), arg0, arg1)
 }
 
 // GetProduct mocks base method.
@@ -80,6 +170,231 @@ func (mr *MockAppMockRecorder) GetSubscriptionByID(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionByID", reflect.TypeOf((*MockApp)(nil).GetSubscriptionByID), arg0, arg1)
 }
 
+// GetSubscriptionHistory mocks base method.
+func (m *MockApp) GetSubscriptionHistory(arg0 context.Context, arg1 string) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionHistory", arg0, arg1)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionHistory indicates an expected call of GetSubscriptionHistory.
+func (mr *MockAppMockRecorder) GetSubscriptionHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionHistory", reflect.TypeOf((*MockApp)(nil).GetSubscriptionHistory), arg0, arg1)
+}
+
+// GetSubscriptionInvoice mocks base method.
+func (m *MockApp) GetSubscriptionInvoice(arg0 context.Context, arg1, arg2 string) (*domain.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionInvoice", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionInvoice indicates an expected call of GetSubscriptionInvoice.
+func (mr *MockAppMockRecorder) GetSubscriptionInvoice(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionInvoice", reflect.TypeOf((*MockApp)(nil).GetSubscriptionInvoice), arg0, arg1, arg2)
+}
+
+// GetSubscriptionInvoices mocks base method.
+func (m *MockApp) GetSubscriptionInvoices(arg0 context.Context, arg1 string) ([]domain.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionInvoices", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionInvoices indicates an expected call of GetSubscriptionInvoices.
+func (mr *MockAppMockRecorder) GetSubscriptionInvoices(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionInvoices", reflect.TypeOf((*MockApp)(nil).GetSubscriptionInvoices), arg0, arg1)
+}
+
+// IssuePendingInvoices mocks base method.
+func (m *MockApp) IssuePendingInvoices(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssuePendingInvoices", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssuePendingInvoices indicates an expected call of IssuePendingInvoices.
+func (mr *MockAppMockRecorder) IssuePendingInvoices(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssuePendingInvoices", reflect.TypeOf((*MockApp)(nil).IssuePendingInvoices), arg0)
+}
+
+// ListProducts mocks base method.
+func (m *MockApp) ListProducts(arg0 context.Context, arg1 domain.ProductQuery) (*domain.ProductPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", arg0, arg1)
+	ret0, _ := ret[0].(*domain.ProductPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockAppMockRecorder) ListProducts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockApp)(nil).ListProducts), arg0, arg1)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockApp) ListSubscriptions(arg0 context.Context, arg1 domain.SubscriptionQuery) (*domain.SubscriptionPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(*domain.SubscriptionPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockAppMockRecorder) ListSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockApp)(nil).ListSubscriptions), arg0, arg1)
+}
+
+// PauseSubscriptionByID mocks base method.
+func (m *MockApp) PauseSubscriptionByID(arg0 context.Context, arg1 string, arg2 *time.Time, arg3 string) (*domain.UserSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseSubscriptionByID", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.UserSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PauseSubscriptionByID indicates an expected call of PauseSubscriptionByID.
+func (mr *MockAppMockRecorder) PauseSubscriptionByID(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseSubscriptionByID", reflect.TypeOf((*MockApp)(nil).PauseSubscriptionByID), arg0, arg1, arg2, arg3)
+}
+
+// RecordPendingAuditEvents mocks base method.
+func (m *MockApp) RecordPendingAuditEvents(arg0 context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPendingAuditEvents", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordPendingAuditEvents indicates an expected call of RecordPendingAuditEvents.
+func (mr *MockAppMockRecorder) RecordPendingAuditEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPendingAuditEvents", reflect.TypeOf((*MockApp)(nil).RecordPendingAuditEvents), arg0)
+}
+
+// RenewSubscriptions mocks base method.
+func (m *MockApp) RenewSubscriptions(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewSubscriptions indicates an expected call of RenewSubscriptions.
+func (mr *MockAppMockRecorder) RenewSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewSubscriptions", reflect.TypeOf((*MockApp)(nil).RenewSubscriptions), arg0, arg1)
+}
+
+// RestoreProduct mocks base method.
+func (m *MockApp) RestoreProduct(arg0 context.Context, arg1 string) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreProduct", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreProduct indicates an expected call of RestoreProduct.
+func (mr *MockAppMockRecorder) RestoreProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreProduct", reflect.TypeOf((*MockApp)(nil).RestoreProduct), arg0, arg1)
+}
+
+// ResumeSubscriptions mocks base method.
+func (m *MockApp) ResumeSubscriptions(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeSubscriptions indicates an expected call of ResumeSubscriptions.
+func (mr *MockAppMockRecorder) ResumeSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeSubscriptions", reflect.TypeOf((*MockApp)(nil).ResumeSubscriptions), arg0, arg1)
+}
+
+// RetryPayments mocks base method.
+func (m *MockApp) RetryPayments(arg0 context.Context, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryPayments", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryPayments indicates an expected call of RetryPayments.
+func (mr *MockAppMockRecorder) RetryPayments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryPayments", reflect.TypeOf((*MockApp)(nil).RetryPayments), arg0, arg1)
+}
+
+// SchedulePriceVersion mocks base method.
+func (m *MockApp) SchedulePriceVersion(arg0 context.Context, arg1 string, arg2 domain.PriceVersion) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchedulePriceVersion", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchedulePriceVersion indicates an expected call of SchedulePriceVersion.
+func (mr *MockAppMockRecorder) SchedulePriceVersion(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulePriceVersion", reflect.TypeOf((*MockApp)(nil).SchedulePriceVersion), arg0, arg1, arg2)
+}
+
+// StartIdempotentRequest mocks base method.
+func (m *MockApp) StartIdempotentRequest(arg0 context.Context, arg1, arg2 string) (*domain.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartIdempotentRequest", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartIdempotentRequest indicates an expected call of StartIdempotentRequest.
+func (mr *MockAppMockRecorder) StartIdempotentRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartIdempotentRequest", reflect.TypeOf((*MockApp)(nil).StartIdempotentRequest), arg0, arg1, arg2)
+}
+
+// UpdateProduct mocks base method.
+func (m *MockApp) UpdateProduct(arg0 context.Context, arg1 domain.Product) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProduct", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProduct indicates an expected call of UpdateProduct.
+func (mr *MockAppMockRecorder) UpdateProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockApp)(nil).UpdateProduct), arg0, arg1)
+}
+
 // UpdateSubscriptionStatusByID mocks base method.
 func (m *MockApp) UpdateSubscriptionStatusByID(arg0 context.Context, arg1 string, arg2 domain.SubscriptionStatus) (*domain.UserSubscription, error) {
 	m.ctrl.T.Helper()
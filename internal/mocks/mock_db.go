@@ -7,7 +7,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	db "github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
 	domain "github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +37,78 @@ func (m *MockDB) EXPECT() *MockDBMockRecorder {
 	return m.recorder
 }
 
+// AcquireLock mocks base method.
+func (m *MockDB) AcquireLock(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLock", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLock indicates an expected call of AcquireLock.
+func (mr *MockDBMockRecorder) AcquireLock(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLock", reflect.TypeOf((*MockDB)(nil).AcquireLock), arg0, arg1, arg2, arg3)
+}
+
+// CompleteIdempotencyRecord mocks base method.
+func (m *MockDB) CompleteIdempotencyRecord(arg0 context.Context, arg1 *domain.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyRecord", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotencyRecord indicates an expected call of CompleteIdempotencyRecord.
+func (mr *MockDBMockRecorder) CompleteIdempotencyRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyRecord", reflect.TypeOf((*MockDB)(nil).CompleteIdempotencyRecord), arg0, arg1)
+}
+
+// CountProducts mocks base method.
+func (m *MockDB) CountProducts(arg0 context.Context, arg1 db.ProductFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountProducts", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountProducts indicates an expected call of CountProducts.
+func (mr *MockDBMockRecorder) CountProducts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountProducts", reflect.TypeOf((*MockDB)(nil).CountProducts), arg0, arg1)
+}
+
+// CreateIdempotencyRecord mocks base method.
+func (m *MockDB) CreateIdempotencyRecord(arg0 context.Context, arg1 *domain.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyRecord", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdempotencyRecord indicates an expected call of CreateIdempotencyRecord.
+func (mr *MockDBMockRecorder) CreateIdempotencyRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyRecord", reflect.TypeOf((*MockDB)(nil).CreateIdempotencyRecord), arg0, arg1)
+}
+
+// DeleteIdempotencyRecord mocks base method.
+func (m *MockDB) DeleteIdempotencyRecord(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyRecord", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyRecord indicates an expected call of DeleteIdempotencyRecord.
+func (mr *MockDBMockRecorder) DeleteIdempotencyRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyRecord", reflect.TypeOf((*MockDB)(nil).DeleteIdempotencyRecord), arg0, arg1)
+}
+
 // Disconnect mocks base method.
 func (m *MockDB) Disconnect(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -49,6 +123,96 @@ func (mr *MockDBMockRecorder) Disconnect(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockDB)(nil).Disconnect), arg0)
 }
 
+// FindProducts mocks base method.
+func (m *MockDB) FindProducts(arg0 context.Context, arg1 db.ProductFilter) ([]domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProducts", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProducts indicates an expected call of FindProducts.
+func (mr *MockDBMockRecorder) FindProducts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProducts", reflect.TypeOf((*MockDB)(nil).FindProducts), arg0, arg1)
+}
+
+// FindSubscriptions mocks base method.
+func (m *MockDB) FindSubscriptions(arg0 context.Context, arg1 db.SubscriptionFilter) ([]domain.UserSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].([]domain.UserSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSubscriptions indicates an expected call of FindSubscriptions.
+func (mr *MockDBMockRecorder) FindSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSubscriptions", reflect.TypeOf((*MockDB)(nil).FindSubscriptions), arg0, arg1)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockDB) GetAuditEvents(arg0 context.Context, arg1 string) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", arg0, arg1)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockDBMockRecorder) GetAuditEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockDB)(nil).GetAuditEvents), arg0, arg1)
+}
+
+// GetIdempotencyRecord mocks base method.
+func (m *MockDB) GetIdempotencyRecord(arg0 context.Context, arg1 string) (*domain.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyRecord", arg0, arg1)
+	ret0, _ := ret[0].(*domain.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyRecord indicates an expected call of GetIdempotencyRecord.
+func (mr *MockDBMockRecorder) GetIdempotencyRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyRecord", reflect.TypeOf((*MockDB)(nil).GetIdempotencyRecord), arg0, arg1)
+}
+
+// GetInvoiceByNumber mocks base method.
+func (m *MockDB) GetInvoiceByNumber(arg0 context.Context, arg1 string) (*domain.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceByNumber", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceByNumber indicates an expected call of GetInvoiceByNumber.
+func (mr *MockDBMockRecorder) GetInvoiceByNumber(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceByNumber", reflect.TypeOf((*MockDB)(nil).GetInvoiceByNumber), arg0, arg1)
+}
+
+// GetInvoices mocks base method.
+func (m *MockDB) GetInvoices(arg0 context.Context, arg1 string) ([]domain.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoices", arg0, arg1)
+	ret0, _ := ret[0].([]domain.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoices indicates an expected call of GetInvoices.
+func (mr *MockDBMockRecorder) GetInvoices(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoices", reflect.TypeOf((*MockDB)(nil).GetInvoices), arg0, arg1)
+}
+
 // GetProduct mocks base method.
 func (m *MockDB) GetProduct(arg0 context.Context, arg1 string) ([]domain.Product, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +243,108 @@ func (mr *MockDBMockRecorder) GetSubscriptionByID(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionByID", reflect.TypeOf((*MockDB)(nil).GetSubscriptionByID), arg0, arg1)
 }
 
+// GetTaxRules mocks base method.
+func (m *MockDB) GetTaxRules(arg0 context.Context, arg1 string) ([]domain.TaxRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxRules", arg0, arg1)
+	ret0, _ := ret[0].([]domain.TaxRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxRules indicates an expected call of GetTaxRules.
+func (mr *MockDBMockRecorder) GetTaxRules(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxRules", reflect.TypeOf((*MockDB)(nil).GetTaxRules), arg0, arg1)
+}
+
+// ReleaseLock mocks base method.
+func (m *MockDB) ReleaseLock(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLock indicates an expected call of ReleaseLock.
+func (mr *MockDBMockRecorder) ReleaseLock(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLock", reflect.TypeOf((*MockDB)(nil).ReleaseLock), arg0, arg1, arg2)
+}
+
+// RemovePendingAuditEvents mocks base method.
+func (m *MockDB) RemovePendingAuditEvents(arg0 context.Context, arg1 string, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePendingAuditEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePendingAuditEvents indicates an expected call of RemovePendingAuditEvents.
+func (mr *MockDBMockRecorder) RemovePendingAuditEvents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePendingAuditEvents", reflect.TypeOf((*MockDB)(nil).RemovePendingAuditEvents), arg0, arg1, arg2)
+}
+
+// RemovePendingInvoices mocks base method.
+func (m *MockDB) RemovePendingInvoices(arg0 context.Context, arg1 string, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePendingInvoices", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePendingInvoices indicates an expected call of RemovePendingInvoices.
+func (mr *MockDBMockRecorder) RemovePendingInvoices(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePendingInvoices", reflect.TypeOf((*MockDB)(nil).RemovePendingInvoices), arg0, arg1, arg2)
+}
+
+// SaveAuditEvent mocks base method.
+func (m *MockDB) SaveAuditEvent(arg0 context.Context, arg1 *domain.AuditEvent) (*domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAuditEvent", arg0, arg1)
+	ret0, _ := ret[0].(*domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveAuditEvent indicates an expected call of SaveAuditEvent.
+func (mr *MockDBMockRecorder) SaveAuditEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAuditEvent", reflect.TypeOf((*MockDB)(nil).SaveAuditEvent), arg0, arg1)
+}
+
+// SaveInvoice mocks base method.
+func (m *MockDB) SaveInvoice(arg0 context.Context, arg1 *domain.Invoice) (*domain.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInvoice", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveInvoice indicates an expected call of SaveInvoice.
+func (mr *MockDBMockRecorder) SaveInvoice(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInvoice", reflect.TypeOf((*MockDB)(nil).SaveInvoice), arg0, arg1)
+}
+
+// SaveProduct mocks base method.
+func (m *MockDB) SaveProduct(arg0 context.Context, arg1 *domain.Product) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProduct", arg0, arg1)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveProduct indicates an expected call of SaveProduct.
+func (mr *MockDBMockRecorder) SaveProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProduct", reflect.TypeOf((*MockDB)(nil).SaveProduct), arg0, arg1)
+}
+
 // SaveSubscription mocks base method.
 func (m *MockDB) SaveSubscription(arg0 context.Context, arg1 *domain.UserSubscription) (*domain.UserSubscription, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+var (
+	NilArgErr     = errors.New("nil value not allowed")
+	InvalidArgErr = errors.New("invalid argument")
+)
+
+// Job is a function executed periodically by the worker
+type Job func(ctx context.Context) error
+
+// Locker provides named locks with expiry, used to run the job by a single instance of the service at a time
+type Locker interface {
+	AcquireLock(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, name string, owner string) error
+}
+
+// Worker runs the job periodically in background
+type Worker interface {
+	Start()
+	Stop()
+}
+
+type workerDetails struct {
+	name     string
+	interval time.Duration
+	locker   Locker
+	owner    string
+	job      Job
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewWorker creates new worker which runs the job every interval
+// the job is run only if the lock with worker name is acquired, so that
+// only one service instance runs the job at a time
+func NewWorker(name string, interval time.Duration, locker Locker, job Job) (Worker, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty name %w", InvalidArgErr)
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval %v %w", interval, InvalidArgErr)
+	}
+
+	if locker == nil {
+		return nil, fmt.Errorf("locker %w", NilArgErr)
+	}
+
+	if job == nil {
+		return nil, fmt.Errorf("job %w", NilArgErr)
+	}
+
+	return &workerDetails{
+		name:     name,
+		interval: interval,
+		locker:   locker,
+		owner:    newOwnerID(),
+		job:      job,
+	}, nil
+}
+
+// newOwnerID returns unique id of the service instance used as lock owner
+func newOwnerID() string {
+	hostname, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%v-%v-%v", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Start starts running the job in background every interval
+func (w *workerDetails) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.run(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the worker and waits for the running job to finish
+func (w *workerDetails) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	log.Printf("worker %v stopped", w.name)
+}
+
+// run runs the job once if the lock is acquired
+func (w *workerDetails) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	acquired, err := w.locker.AcquireLock(ctx, w.name, w.owner, w.interval)
+	if err != nil {
+		log.Printf("worker %v failed to acquire lock: %v", w.name, err)
+		return
+	}
+
+	if !acquired {
+		return
+	}
+
+	defer func() {
+		// use new context, the job context may be already done
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer releaseCancel()
+		if err := w.locker.ReleaseLock(releaseCtx, w.name, w.owner); err != nil {
+			log.Printf("worker %v failed to release lock: %v", w.name, err)
+		}
+	}()
+
+	if err := w.job(ctx); err != nil {
+		log.Printf("worker %v job failed: %v", w.name, err)
+	}
+}
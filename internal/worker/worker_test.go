@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewWorker(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	locker := mocks.NewMockDB(mockCtrl)
+	job := func(ctx context.Context) error { return nil }
+
+	type args struct {
+		name     string
+		interval time.Duration
+		locker   Locker
+		job      Job
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "should return worker for valid input",
+			args: args{
+				name:     "test",
+				interval: time.Minute,
+				locker:   locker,
+				job:      job,
+			},
+			wantErr: false,
+		},
+		{
+			name: "should return error for empty name",
+			args: args{
+				interval: time.Minute,
+				locker:   locker,
+				job:      job,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error for invalid interval",
+			args: args{
+				name:   "test",
+				locker: locker,
+				job:    job,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error for nil locker",
+			args: args{
+				name:     "test",
+				interval: time.Minute,
+				job:      job,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error for nil job",
+			args: args{
+				name:     "test",
+				interval: time.Minute,
+				locker:   locker,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWorker(tt.args.name, tt.args.interval, tt.args.locker, tt.args.job)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWorker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkerRun(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	locker := mocks.NewMockDB(mockCtrl)
+
+	jobRuns := 0
+	w := &workerDetails{
+		name:     "test",
+		interval: time.Minute,
+		locker:   locker,
+		owner:    "owner",
+		job: func(ctx context.Context) error {
+			jobRuns++
+			return nil
+		},
+	}
+
+	gomock.InOrder(
+		locker.EXPECT().AcquireLock(gomock.Any(), "test", "owner", time.Minute).Return(true, nil).Times(1),
+		locker.EXPECT().ReleaseLock(gomock.Any(), "test", "owner").Return(nil).Times(1),
+		locker.EXPECT().AcquireLock(gomock.Any(), "test", "owner", time.Minute).Return(false, nil).Times(1),
+	)
+
+	// lock acquired, job should run
+	w.run(context.Background())
+	if jobRuns != 1 {
+		t.Errorf("workerDetails.run() job runs = %v, want %v", jobRuns, 1)
+	}
+
+	// lock held by other instance, job should not run
+	w.run(context.Background())
+	if jobRuns != 1 {
+		t.Errorf("workerDetails.run() job runs = %v, want %v", jobRuns, 1)
+	}
+}
+
+func TestWorkerStartStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	locker := mocks.NewMockDB(mockCtrl)
+
+	jobDone := make(chan struct{}, 1)
+	w, err := NewWorker("test", 10*time.Millisecond, locker, func(ctx context.Context) error {
+		select {
+		case jobDone <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locker.EXPECT().AcquireLock(gomock.Any(), "test", gomock.Any(), gomock.Any()).Return(true, nil).MinTimes(1)
+	locker.EXPECT().ReleaseLock(gomock.Any(), "test", gomock.Any()).Return(nil).MinTimes(1)
+
+	w.Start()
+	select {
+	case <-jobDone:
+	case <-time.After(time.Second):
+		t.Error("job was not run by the worker")
+	}
+	w.Stop()
+}
@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/api/rest"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/app"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/config"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/memory"
 	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/mongodb"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/postgres"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/db/sqlite"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/domain"
+	"github.com/ganeshdipdumbare/gymondo-subscription/internal/worker"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
@@ -22,35 +33,137 @@ import (
 // @description A REST server to manage user subscriptions of the products
 // NewApi creates new api instance, otherwise returns error
 func main() {
-	// migrate reference data - product collection
-	m, err := migrate.New(
-		config.Get().MigrationFilesPath,
-		config.Get().MongoUri+"/"+config.Get().MongoDb)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	database, err := newDatabase()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := m.Up(); err != nil {
-		if err != migrate.ErrNoChange {
-			log.Fatal(err)
+	defer database.Disconnect(ctx)
+
+	taxCalculator, err := app.NewRuleTaxCalculator(database)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	idempotencyTTL, err := time.ParseDuration(config.Get().IdempotencyKeyTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	paymentProvider, err := newPaymentProvider()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	retryDays, err := app.ParseRetryDays(config.Get().PaymentRetryDays)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dunningPolicy := app.DunningPolicy{
+		RetryDays:   retryDays,
+		FinalStatus: domain.SubscriptionStatus(config.Get().PaymentRetryStatus),
+	}
+
+	subscriptionApp, err := app.NewApp(database, taxCalculator, app.UniquenessRule(config.Get().SubscriptionUniqueness), idempotencyTTL,
+		app.RenewalPricing(config.Get().RenewalPricing), paymentProvider, dunningPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	renewalInterval, err := time.ParseDuration(config.Get().RenewalInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	renewalWorker, err := worker.NewWorker("subscription_renewal", renewalInterval, database, func(ctx context.Context) error {
+		renewed, err := subscriptionApp.RenewSubscriptions(ctx, time.Now().UTC())
+		if renewed > 0 {
+			log.Printf("processed %v due subscriptions", renewed)
 		}
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
-	// complete migration
+	renewalWorker.Start()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	resumeInterval, err := time.ParseDuration(config.Get().ResumeInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	database, err := mongodb.NewMongoDB(config.Get().MongoUri, config.Get().MongoDb)
+	resumeWorker, err := worker.NewWorker("subscription_resume", resumeInterval, database, func(ctx context.Context) error {
+		resumed, err := subscriptionApp.ResumeSubscriptions(ctx, time.Now().UTC())
+		if resumed > 0 {
+			log.Printf("resumed %v paused subscriptions", resumed)
+		}
+		return err
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer database.Disconnect(ctx)
+	resumeWorker.Start()
 
-	subscriptionApp, err := app.NewApp(database)
+	paymentRetryInterval, err := time.ParseDuration(config.Get().PaymentRetryInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	paymentRetryWorker, err := worker.NewWorker("subscription_payment_retry", paymentRetryInterval, database, func(ctx context.Context) error {
+		retried, err := subscriptionApp.RetryPayments(ctx, time.Now().UTC())
+		if retried > 0 {
+			log.Printf("retried payments of %v past due subscriptions", retried)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	paymentRetryWorker.Start()
+
+	invoiceInterval, err := time.ParseDuration(config.Get().InvoiceInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	invoiceWorker, err := worker.NewWorker("subscription_invoice", invoiceInterval, database, func(ctx context.Context) error {
+		issued, err := subscriptionApp.IssuePendingInvoices(ctx)
+		if issued > 0 {
+			log.Printf("issued pending invoices of %v subscriptions", issued)
+		}
+		return err
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	invoiceWorker.Start()
 
-	restApi, err := rest.NewApi(subscriptionApp, config.Get().Port)
+	auditInterval, err := time.ParseDuration(config.Get().AuditInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	auditWorker, err := worker.NewWorker("subscription_audit", auditInterval, database, func(ctx context.Context) error {
+		recorded, err := subscriptionApp.RecordPendingAuditEvents(ctx)
+		if recorded > 0 {
+			log.Printf("recorded pending audit events of %v subscriptions", recorded)
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditWorker.Start()
+
+	invoiceTemplate, err := rest.LoadInvoiceTemplate(config.Get().InvoiceTemplatePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	restApi, err := rest.NewApi(subscriptionApp, config.Get().Port, invoiceTemplate, config.Get().ActorSigningKey, config.Get().PaymentCallbackKey)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -61,5 +174,131 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	renewalWorker.Stop()
+	resumeWorker.Stop()
+	paymentRetryWorker.Stop()
+	invoiceWorker.Stop()
+	auditWorker.Stop()
 	restApi.GracefulStopServer()
 }
+
+// newDatabase creates database for the configured driver
+// mongodb, postgres and sqlite are migrated before use, memory database is seeded with the reference data of the migration files
+func newDatabase() (db.DB, error) {
+	switch dbDriver() {
+	case "memory":
+		return memory.NewMemoryDB(config.Get().MigrationFilesPath, config.Get().SeedFilesPath)
+	case "mongodb":
+		// migrate reference data - product collection
+		err := migrateUp(config.Get().MigrationFilesPath, config.Get().MongoUri+"/"+config.Get().MongoDb)
+		if err != nil {
+			return nil, err
+		}
+
+		err = seedUp(config.Get().SeedFilesPath, config.Get().MongoUri+"/"+config.Get().MongoDb, "x-migrations-collection")
+		if err != nil {
+			return nil, err
+		}
+
+		return mongodb.NewMongoDB(config.Get().MongoUri, config.Get().MongoDb)
+	case "postgres":
+		// migrate schema and reference data
+		err := migrateUp(config.Get().MigrationFilesPath+"/postgres", config.Get().PostgresUri)
+		if err != nil {
+			return nil, err
+		}
+
+		err = seedUp(seedFilesPath("postgres"), config.Get().PostgresUri, "x-migrations-table")
+		if err != nil {
+			return nil, err
+		}
+
+		return postgres.NewPostgresDB(config.Get().PostgresUri)
+	case "sqlite":
+		if config.Get().SqlitePath == "" {
+			return nil, fmt.Errorf("sqlite db driver requires SQLITE_PATH")
+		}
+
+		// migrate schema and reference data
+		err := migrateUp(config.Get().MigrationFilesPath+"/sqlite", "sqlite://"+config.Get().SqlitePath)
+		if err != nil {
+			return nil, err
+		}
+
+		err = seedUp(seedFilesPath("sqlite"), "sqlite://"+config.Get().SqlitePath, "x-migrations-table")
+		if err != nil {
+			return nil, err
+		}
+
+		return sqlite.NewSqliteDB(config.Get().SqlitePath)
+	default:
+		return nil, fmt.Errorf("unknown db driver %v", config.Get().DbDriver)
+	}
+}
+
+// newPaymentProvider creates the configured payment provider, the provider has no default
+// so the service never starts with the fake provider by accident
+func newPaymentProvider() (app.PaymentProvider, error) {
+	switch config.Get().PaymentProvider {
+	case "":
+		return nil, errors.New("payment provider is required, set PAYMENT_PROVIDER")
+	case "fake":
+		return app.NewFakePaymentProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %v", config.Get().PaymentProvider)
+	}
+}
+
+// dbDriver returns the configured db driver, if the driver is not configured
+// sqlite is used when the sqlite file path is configured, otherwise mongodb
+func dbDriver() string {
+	if config.Get().DbDriver != "" {
+		return config.Get().DbDriver
+	}
+	if config.Get().SqlitePath != "" {
+		return "sqlite"
+	}
+	return "mongodb"
+}
+
+// migrateUp applies all up migrations from the source to the database
+func migrateUp(sourceURL string, databaseURL string) error {
+	m, err := migrate.New(sourceURL, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if err != migrate.ErrNoChange {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedVersionTable is the table or collection keeping the version of the applied seed files
+const seedVersionTable = "seed_migrations"
+
+// seedUp applies all up seed files from the source to the database if the source is configured,
+// the seed version is kept in its own table or collection given by the version parameter of the migrate driver,
+// so seeding is independent from the schema migrations
+func seedUp(sourceURL string, databaseURL string, versionParam string) error {
+	if sourceURL == "" {
+		return nil
+	}
+
+	separator := "?"
+	if strings.Contains(databaseURL, "?") {
+		separator = "&"
+	}
+	return migrateUp(sourceURL, databaseURL+separator+versionParam+"="+seedVersionTable)
+}
+
+// seedFilesPath returns the path of the seed files of the sql driver, empty if seeding is not configured
+func seedFilesPath(driver string) string {
+	if config.Get().SeedFilesPath == "" {
+		return ""
+	}
+	return config.Get().SeedFilesPath + "/" + driver
+}
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS user_subscription (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ,
+    email TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    product_id TEXT NOT NULL DEFAULT '',
+    product_name TEXT NOT NULL DEFAULT '',
+    start_date TIMESTAMPTZ NOT NULL,
+    end_date TIMESTAMPTZ NOT NULL,
+    price_amount BIGINT NOT NULL,
+    price_currency TEXT NOT NULL,
+    net_price_amount BIGINT NOT NULL,
+    net_price_currency TEXT NOT NULL,
+    tax_amount BIGINT NOT NULL,
+    tax_currency TEXT NOT NULL,
+    tax_rate DOUBLE PRECISION NOT NULL,
+    status TEXT NOT NULL,
+    pauses JSONB,
+    resume_date TIMESTAMPTZ,
+    auto_renew BOOLEAN NOT NULL,
+    renewals JSONB,
+    trial_end_date TIMESTAMPTZ,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_plan_change JSONB,
+    plan_changes JSONB
+);
+
+CREATE INDEX IF NOT EXISTS user_subscription_status_end_date ON user_subscription (status, end_date);
+CREATE INDEX IF NOT EXISTS user_subscription_status_resume_date ON user_subscription (status, resume_date);
+CREATE INDEX IF NOT EXISTS user_subscription_email_product_id ON user_subscription (email, product_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS subscription_audit (
+    id TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    previous_status TEXT NOT NULL DEFAULT '',
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS subscription_audit_subscription_id_created_at ON subscription_audit (subscription_id, created_at);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS job_lock (
+    name TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE user_subscription ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS user_subscription_email_created_at ON user_subscription (email, created_at, id);
+CREATE INDEX IF NOT EXISTS user_subscription_email_end_date ON user_subscription (email, end_date, id);
@@ -0,0 +1,2 @@
+ALTER TABLE product ADD COLUMN price_versions JSONB;
+ALTER TABLE user_subscription ADD COLUMN price_version_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE user_subscription DROP COLUMN payments;
+ALTER TABLE user_subscription DROP COLUMN payment_token;
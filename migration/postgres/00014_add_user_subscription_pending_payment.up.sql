@@ -0,0 +1 @@
+ALTER TABLE user_subscription ADD COLUMN pending_payment JSONB;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS user_subscription_status_next_payment_retry;
+ALTER TABLE user_subscription DROP COLUMN payment_attempts;
+ALTER TABLE user_subscription DROP COLUMN next_payment_retry;
@@ -0,0 +1,3 @@
+ALTER TABLE user_subscription ADD COLUMN next_payment_retry TIMESTAMPTZ;
+ALTER TABLE user_subscription ADD COLUMN payment_attempts JSONB;
+CREATE INDEX IF NOT EXISTS user_subscription_status_next_payment_retry ON user_subscription (status, next_payment_retry);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS user_subscription_pending_invoices;
+ALTER TABLE user_subscription DROP COLUMN pending_invoices;
+ALTER TABLE invoice DROP COLUMN type;
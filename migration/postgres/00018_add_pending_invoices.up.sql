@@ -0,0 +1,3 @@
+ALTER TABLE invoice ADD COLUMN type TEXT NOT NULL DEFAULT 'invoice';
+ALTER TABLE user_subscription ADD COLUMN pending_invoices JSONB;
+CREATE INDEX IF NOT EXISTS user_subscription_pending_invoices ON user_subscription (end_date, id) WHERE pending_invoices IS NOT NULL;
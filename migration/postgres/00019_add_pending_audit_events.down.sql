@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS user_subscription_pending_audit_events;
+ALTER TABLE user_subscription DROP COLUMN pending_audit_events;
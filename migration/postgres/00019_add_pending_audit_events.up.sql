@@ -0,0 +1,2 @@
+ALTER TABLE user_subscription ADD COLUMN pending_audit_events JSONB;
+CREATE INDEX IF NOT EXISTS user_subscription_pending_audit_events ON user_subscription (end_date, id) WHERE pending_audit_events IS NOT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS product (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    subscription_period INTEGER NOT NULL,
+    trial_days INTEGER NOT NULL DEFAULT 0,
+    max_pause_days INTEGER NOT NULL DEFAULT 0,
+    max_pauses_per_period INTEGER NOT NULL DEFAULT 0,
+    price_amount INTEGER NOT NULL,
+    price_currency TEXT NOT NULL,
+    prices TEXT,
+    tax_category TEXT NOT NULL,
+    tax_inclusive INTEGER NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tax_rule (
+    id TEXT PRIMARY KEY,
+    country TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL,
+    rate REAL NOT NULL,
+    effective_from TEXT NOT NULL,
+    effective_to TEXT
+);
+
+CREATE INDEX IF NOT EXISTS tax_rule_category_country ON tax_rule (category, country);
+
+-- default rate of the tax category, it replaces the flat tax percentage of the products
+INSERT INTO tax_rule (id, country, category, rate, effective_from, effective_to) VALUES
+    ('62c5a1f00bf33af1c877d001', '', 'digital_service', 10.0, '1970-01-01T00:00:00.000000000Z', NULL);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS user_subscription (
+    id TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    updated_at TEXT,
+    email TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    product_id TEXT NOT NULL DEFAULT '',
+    product_name TEXT NOT NULL DEFAULT '',
+    start_date TEXT NOT NULL,
+    end_date TEXT NOT NULL,
+    price_amount INTEGER NOT NULL,
+    price_currency TEXT NOT NULL,
+    net_price_amount INTEGER NOT NULL,
+    net_price_currency TEXT NOT NULL,
+    tax_amount INTEGER NOT NULL,
+    tax_currency TEXT NOT NULL,
+    tax_rate REAL NOT NULL,
+    status TEXT NOT NULL,
+    pauses TEXT,
+    resume_date TEXT,
+    auto_renew INTEGER NOT NULL,
+    renewals TEXT,
+    trial_end_date TEXT,
+    cancel_at_period_end INTEGER NOT NULL DEFAULT 0,
+    pending_plan_change TEXT,
+    plan_changes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS user_subscription_status_end_date ON user_subscription (status, end_date);
+CREATE INDEX IF NOT EXISTS user_subscription_status_resume_date ON user_subscription (status, resume_date);
+CREATE INDEX IF NOT EXISTS user_subscription_email_product_id ON user_subscription (email, product_id);
@@ -0,0 +1 @@
+ALTER TABLE user_subscription DROP COLUMN version;
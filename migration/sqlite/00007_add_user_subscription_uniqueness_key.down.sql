@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS user_subscription_uniqueness_key;
+ALTER TABLE user_subscription DROP COLUMN uniqueness_key;
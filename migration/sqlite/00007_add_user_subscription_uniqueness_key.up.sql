@@ -0,0 +1,2 @@
+ALTER TABLE user_subscription ADD COLUMN uniqueness_key TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS user_subscription_uniqueness_key ON user_subscription (uniqueness_key) WHERE uniqueness_key IS NOT NULL;
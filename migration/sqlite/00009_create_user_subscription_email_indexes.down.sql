@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS user_subscription_email_end_date;
+DROP INDEX IF EXISTS user_subscription_email_created_at;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS product_subscription_period;
+DROP INDEX IF EXISTS product_name;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS product_name ON product (name, id);
+CREATE INDEX IF NOT EXISTS product_subscription_period ON product (subscription_period, id);
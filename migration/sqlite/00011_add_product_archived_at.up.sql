@@ -0,0 +1 @@
+ALTER TABLE product ADD COLUMN archived_at TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE user_subscription DROP COLUMN price_version_id;
+ALTER TABLE product DROP COLUMN price_versions;
@@ -0,0 +1,2 @@
+ALTER TABLE user_subscription ADD COLUMN payment_token TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_subscription ADD COLUMN payments TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE user_subscription ADD COLUMN next_payment_retry TEXT;
+ALTER TABLE user_subscription ADD COLUMN payment_attempts TEXT;
+CREATE INDEX IF NOT EXISTS user_subscription_status_next_payment_retry ON user_subscription (status, next_payment_retry);
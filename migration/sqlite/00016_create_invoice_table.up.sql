@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS invoice (
+    id TEXT PRIMARY KEY,
+    number TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    sequence BIGINT NOT NULL,
+    subscription_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    authorization_id TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    lines TEXT NOT NULL,
+    net_amount BIGINT NOT NULL,
+    net_currency TEXT NOT NULL,
+    tax_amount BIGINT NOT NULL,
+    tax_currency TEXT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    total_currency TEXT NOT NULL,
+    issued_at TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS invoice_year_sequence ON invoice (year, sequence);
+CREATE UNIQUE INDEX IF NOT EXISTS invoice_number ON invoice (number);
+CREATE INDEX IF NOT EXISTS invoice_subscription_id_issued_at ON invoice (subscription_id, issued_at);
@@ -0,0 +1 @@
+DELETE FROM product WHERE id IN ('62bac24b0bf33af1c877d97f', '62bac25f83b5fcd9ddeb8170', '62bac26a69c9410f916fc262');
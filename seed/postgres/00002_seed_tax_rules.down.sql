@@ -0,0 +1 @@
+DELETE FROM tax_rule WHERE id IN ('62c5a1f00bf33af1c877d002', '62c5a1f00bf33af1c877d003', '62c5a1f00bf33af1c877d004', '62c5a1f00bf33af1c877d005', '62c5a1f00bf33af1c877d006');
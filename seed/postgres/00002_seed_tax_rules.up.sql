@@ -0,0 +1,7 @@
+INSERT INTO tax_rule (id, country, category, rate, effective_from, effective_to) VALUES
+    ('62c5a1f00bf33af1c877d002', 'DE', 'digital_service', 19.0, '1970-01-01T00:00:00Z', NULL),
+    ('62c5a1f00bf33af1c877d003', 'AT', 'digital_service', 20.0, '1970-01-01T00:00:00Z', NULL),
+    ('62c5a1f00bf33af1c877d004', 'GB', 'digital_service', 20.0, '1970-01-01T00:00:00Z', NULL),
+    ('62c5a1f00bf33af1c877d005', 'CH', 'digital_service', 7.7, '1970-01-01T00:00:00Z', '2024-01-01T00:00:00Z'),
+    ('62c5a1f00bf33af1c877d006', 'CH', 'digital_service', 8.1, '2024-01-01T00:00:00Z', NULL)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,5 @@
+INSERT INTO product (id, name, subscription_period, trial_days, max_pause_days, max_pauses_per_period, price_amount, price_currency, prices, tax_category, tax_inclusive) VALUES
+    ('62bac24b0bf33af1c877d97f', 'get in shape', 1, 7, 30, 2, 1000, 'EUR', '[{"price":{"amount":1100,"currency":"CHF"}},{"price":{"amount":900,"currency":"GBP"}}]', 'digital_service', 1),
+    ('62bac25f83b5fcd9ddeb8170', 'hiit extreme', 2, 14, 30, 2, 2000, 'EUR', '[{"price":{"amount":2200,"currency":"CHF"}},{"price":{"amount":1800,"currency":"GBP"}}]', 'digital_service', 1),
+    ('62bac26a69c9410f916fc262', 'hiphop cardio', 3, 0, 30, 2, 3000, 'EUR', '[{"price":{"amount":3300,"currency":"CHF"}},{"price":{"amount":2700,"currency":"GBP"}}]', 'digital_service', 1)
+ON CONFLICT (id) DO NOTHING;